@@ -0,0 +1,206 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/internal/services/admin"
+)
+
+var gs_Commands = map[string]command{
+	"tasks":    {"[-status s] [-type t] [-creator c] [-limit n]  list tasks", listTasks},
+	"task":     {"<task-id>  show the status of a task", getTask},
+	"subtasks": {"[-offset n] [-count n] <task-id>  show the unfinished subtasks of a task", listSubtasks},
+	"cancel":   {"<task-id>  cancel a running or paused task", cancelTask},
+	"pause":    {"<task-id>  pause a running task", pauseTask},
+	"resume":   {"<task-id>  resume a paused task", resumeTask},
+	"requeue":  {"<subtask-id>...  push stuck running subtasks to the executors again", requeueSubtasks},
+	"queues":   {"[-group g] [-count n]  show the quota groups and their scheduling queues", listQueues},
+	"retries":  {"[-count n]  show the subtasks waiting to be pushed to the executors again", listRetryQueue},
+	"types":    {"list the task types registered in the admin server process", listTaskTypes},
+}
+
+func listTasks(client *admin.Client, args []string) error {
+	flags := flag.NewFlagSet("tasks", flag.ExitOnError)
+	status := flags.String("status", "", "the task status: running, paused, cancelled, completed, exceptional")
+	taskType := flags.Uint("type", 0, "the task type")
+	creator := flags.String("creator", "", "the creator of the tasks")
+	limit := flags.Int("limit", admin.DefaultListCount, "the max count of the tasks")
+	flags.Parse(args)
+
+	req := admin.ListTasksRequest{
+		TaskType: uint32(*taskType),
+		Creator:  *creator,
+		Limit:    *limit,
+	}
+
+	if len(*status) > 0 {
+		var err error
+		req.TaskStatus, err = admin.ParseTaskStatus(*status)
+		if err != nil {
+			return fmt.Errorf("unknown task status %q", *status)
+		}
+	}
+
+	rsp := admin.ListTasksResponse{}
+	err := client.ListTasks(&req, &rsp)
+	if err != nil {
+		return err
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "ID\tNAME\tTYPE\tSTATUS\tCREATOR\tSTART TIME\tFINISH TIME")
+	for _, task := range rsp.Tasks {
+		fmt.Fprintf(writer, "%d\t%s\t%d\t%s\t%s\t%s\t%s\n",
+			task.Id, task.Name, task.TaskType,
+			admin.TaskStatusName(taskmodel.TaskStatusType(task.TaskStatus)),
+			task.Creator, task.StartTime, task.FinishTime)
+	}
+
+	return writer.Flush()
+}
+
+func getTask(client *admin.Client, args []string) error {
+	taskId, err := parseTaskId(args)
+	if err != nil {
+		return err
+	}
+
+	rsp := admin.GetTaskResponse{}
+	err = client.GetTask(&admin.TaskRequest{TaskId: taskId}, &rsp)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(rsp.Task)
+}
+
+func listSubtasks(client *admin.Client, args []string) error {
+	flags := flag.NewFlagSet("subtasks", flag.ExitOnError)
+	offset := flags.Int64("offset", 0, "the offset of the subtasks")
+	count := flags.Int64("count", admin.DefaultListCount, "the max count of the subtasks")
+	flags.Parse(args)
+
+	taskId, err := parseTaskId(flags.Args())
+	if err != nil {
+		return err
+	}
+
+	req := admin.ListSubtasksRequest{TaskId: taskId, Offset: *offset, Count: *count}
+	rsp := admin.ListSubtasksResponse{}
+	err = client.ListSubtasks(&req, &rsp)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(rsp)
+}
+
+func cancelTask(client *admin.Client, args []string) error {
+	taskId, err := parseTaskId(args)
+	if err != nil {
+		return err
+	}
+
+	return client.CancelTask(&admin.TaskRequest{TaskId: taskId})
+}
+
+func pauseTask(client *admin.Client, args []string) error {
+	taskId, err := parseTaskId(args)
+	if err != nil {
+		return err
+	}
+
+	return client.PauseTask(&admin.TaskRequest{TaskId: taskId})
+}
+
+func resumeTask(client *admin.Client, args []string) error {
+	taskId, err := parseTaskId(args)
+	if err != nil {
+		return err
+	}
+
+	return client.ResumeTask(&admin.TaskRequest{TaskId: taskId})
+}
+
+func requeueSubtasks(client *admin.Client, args []string) error {
+	if len(args) == 0 {
+		return errors.New("no subtask id")
+	}
+
+	req := admin.RequeueSubtasksRequest{}
+	for _, arg := range args {
+		id, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil || id == 0 {
+			return fmt.Errorf("invalid subtask id %q", arg)
+		}
+
+		req.SubtaskIds = append(req.SubtaskIds, taskmodel.SubtaskIdType(id))
+	}
+
+	rsp := admin.RequeueSubtasksResponse{}
+	err := client.RequeueSubtasks(&req, &rsp)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(rsp)
+}
+
+func listQueues(client *admin.Client, args []string) error {
+	flags := flag.NewFlagSet("queues", flag.ExitOnError)
+	group := flags.String("group", "", "the quota group name, all groups if empty")
+	count := flags.Int64("count", admin.DefaultListCount, "the max count of the tasks of each queue")
+	flags.Parse(args)
+
+	rsp := admin.ListQueuesResponse{}
+	err := client.ListQueues(&admin.ListQueuesRequest{GroupName: *group, Count: *count}, &rsp)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(rsp)
+}
+
+func listRetryQueue(client *admin.Client, args []string) error {
+	flags := flag.NewFlagSet("retries", flag.ExitOnError)
+	count := flags.Int64("count", admin.DefaultListCount, "the max count of the subtasks")
+	flags.Parse(args)
+
+	rsp := admin.ListRetryQueueResponse{}
+	err := client.ListRetryQueue(&admin.ListRetryQueueRequest{Count: *count}, &rsp)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(rsp)
+}
+
+func listTaskTypes(client *admin.Client, args []string) error {
+	rsp := admin.ListTaskTypesResponse{}
+	err := client.ListTaskTypes(&rsp)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(rsp.TaskTypes)
+}
+
+// parse the only task id argument
+func parseTaskId(args []string) (taskmodel.TaskIdType, error) {
+	if len(args) != 1 {
+		return 0, errors.New("need a task id")
+	}
+
+	id, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid task id %q", args[0])
+	}
+
+	return taskmodel.TaskIdType(id), nil
+}
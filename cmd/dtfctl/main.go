@@ -0,0 +1,85 @@
+// dtfctl operates a pterergate-dtf cluster through the admin API,
+// which is served by a service started with dtf.WithAdmin(port).
+//
+//	dtfctl -addr http://127.0.0.1:9200 -keypath ./key.conf tasks -status running
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/danenmao/pterergate-dtf/internal/msgsigner"
+	"github.com/danenmao/pterergate-dtf/internal/services/admin"
+)
+
+var (
+	gs_Address  = flag.String("addr", "http://127.0.0.1:9200", "the address of the admin server")
+	gs_UserName = flag.String("user", "dtfctl", "the user name in the authorization token")
+)
+
+// a sub command
+type command struct {
+	Usage string
+	Run   func(client *admin.Client, args []string) error
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := gs_Commands[flag.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "dtfctl: unknown command %q\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+
+	// the requests are signed with the private key in the key file
+	info, err := os.Stat(msgsigner.KeyPath)
+	if err != nil || info.IsDir() {
+		fmt.Fprintf(os.Stderr, "dtfctl: -keypath should be the key file of the cluster\n")
+		os.Exit(2)
+	}
+
+	client := admin.NewClient(*gs_Address, *gs_UserName)
+	err = cmd.Run(client, flag.Args()[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dtfctl %s: %v\n", flag.Arg(0), err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: dtfctl [flags] <command> [args]\n\ncommands:\n")
+
+	names := []string{}
+	for name := range gs_Commands {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, gs_Commands[name].Usage)
+	}
+
+	fmt.Fprintf(os.Stderr, "\nflags:\n")
+	flag.PrintDefaults()
+}
+
+// print the response as indented json
+func printJSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(data))
+	return nil
+}
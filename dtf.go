@@ -2,6 +2,7 @@ package dtf
 
 import (
 	"github.com/danenmao/pterergate-dtf/dtf/dtfdef"
+	"github.com/danenmao/pterergate-dtf/dtf/extconfig"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
 	"github.com/danenmao/pterergate-dtf/dtf/taskplugin"
 	"github.com/danenmao/pterergate-dtf/internal/servicectrl"
@@ -25,6 +26,26 @@ func StartService(role dtfdef.ServiceRole, opts ...ServiceOption) error {
 	return servicectrl.StartService(role, &cfg)
 }
 
+// start all service roles in one process, for development and testing.
+// state is kept in memory, MySQL and Redis are not required. task records and subtask results
+// are kept in memory too, or in a SQLite file set by WithMySQL with the sqlite dialect;
+// a MySQL server is rejected with ErrInvalidParameter.
+// the executor and collector are invoked through in-process channels
+func StartEmbedded(opts ...ServiceOption) error {
+	cfg := dtfdef.ServiceConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return servicectrl.StartEmbedded(&cfg)
+}
+
+// get the default runtime config,
+// modify it and pass it to WithRuntimeConfig to tune the services
+func DefaultRuntimeConfig() extconfig.RuntimeConfig {
+	return servicectrl.DefaultRuntimeConfig()
+}
+
 // notify to stop the service
 func NotifyStop() error {
 	return servicectrl.NotifyStop()
@@ -76,3 +97,20 @@ func CancelTask(taskId taskmodel.TaskIdType) error {
 func GetTaskStatus(taskId taskmodel.TaskIdType, status *taskmodel.TaskStatusData) error {
 	return taskmgmt.GetTaskStatus(taskId, status)
 }
+
+// list the tasks matching the query, page by page.
+// pass the NextCursor of a page as the Cursor of the query to get the next page
+func ListTasks(query *taskmodel.TaskQuery, list *taskmodel.TaskList) error {
+	return taskmgmt.ListTasks(query, list)
+}
+
+// list the saved subtask results of a task, page by page.
+// the results are saved only if a result store is set with WithResultStore
+func ListSubtaskResults(
+	taskId taskmodel.TaskIdType,
+	filter *taskmodel.SubtaskResultFilter,
+	page *taskmodel.PageParam,
+	list *taskmodel.SubtaskResultList,
+) error {
+	return taskmgmt.ListSubtaskResults(taskId, filter, page, list)
+}
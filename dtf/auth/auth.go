@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// 服务的接收方, 写入token的aud
+const (
+	Audience_Executor  = "executor"
+	Audience_Collector = "collector"
+	Audience_Admin     = "admin"
+)
+
+// 默认的签发者
+const DefaultIssuer = "SimpleInvoker"
+const Subject = "pterergate-service"
+const TokenExpireDuration time.Duration = 5 * time.Minute
+
+// 默认容忍的调用方与服务端的时钟偏差
+const DefaultClockSkew time.Duration = time.Minute
+
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// 认证通过的调用方
+type Identity struct {
+	Name      string    // 调用方的名称
+	Issuer    string    // token的签发者
+	BodyHash  string    // 请求体的哈希, 为空时不校验
+	Nonce     string    // 凭证的唯一ID, 用于防重放, 为空时使用请求ID
+	ExpiresAt time.Time // 凭证的过期时间, 为零值时按时钟偏差保留nonce
+}
+
+// 认证器
+// 调用方用Sign为请求附加凭证, 服务端用Authenticate校验请求
+type IAuthenticator interface {
+	Sign(req *http.Request, userName string, bodyHash string) error
+	Authenticate(req *http.Request) (*Identity, error)
+}
+
+// token中的消息
+type TokenMessage struct {
+	UserName string `json:"username"`
+	BodyHash string `json:"bodyhash"`
+}
+
+// 获取Authorization头中的Bearer token
+func bearerToken(req *http.Request) (string, error) {
+	authHeader := req.Header.Get("Authorization")
+	if authHeader == "" {
+		return "", errors.New("no Authorization")
+	}
+
+	parts := strings.SplitN(authHeader, " ", 2)
+	if !(len(parts) == 2 && parts[0] == "Bearer") {
+		return "", errors.New("invalid Authorization format")
+	}
+
+	return parts[1], nil
+}
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/danenmao/pterergate-dtf/internal/msgsigner"
+)
+
+func newRequest() *http.Request {
+	req, _ := http.NewRequest(http.MethodPost, "http://localhost/test", nil)
+	return req
+}
+
+func Test_JWTAuthenticator(t *testing.T) {
+	msgsigner.Setup()
+	defer msgsigner.Teardown()
+
+	Convey("authenticate with jwt", t, func() {
+		req := newRequest()
+		err := NewJWTAuthenticator(Audience_Executor).Sign(req, "tester", "hash")
+		So(err, ShouldBeNil)
+
+		Convey("should accept the token for the audience", func() {
+			identity, err := NewJWTAuthenticator(Audience_Executor).Authenticate(req)
+			So(err, ShouldBeNil)
+			So(identity.Name, ShouldEqual, "tester")
+			So(identity.Issuer, ShouldEqual, DefaultIssuer)
+			So(identity.BodyHash, ShouldEqual, "hash")
+		})
+
+		Convey("should reject the token for another audience", func() {
+			_, err := NewJWTAuthenticator(Audience_Collector).Authenticate(req)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("should reject the token of an unknown issuer", func() {
+			a := NewJWTAuthenticator(Audience_Executor)
+			a.Issuers = []string{"other"}
+			_, err := a.Authenticate(req)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("should reject a request without token", func() {
+			_, err := NewJWTAuthenticator(Audience_Executor).Authenticate(newRequest())
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func Test_StaticTokenAuthenticator(t *testing.T) {
+	Convey("authenticate with a static token", t, func() {
+		req := newRequest()
+		err := NewStaticTokenAuthenticator("secret").Sign(req, "tester", "hash")
+		So(err, ShouldBeNil)
+
+		_, err = NewStaticTokenAuthenticator("secret").Authenticate(req)
+		So(err, ShouldBeNil)
+
+		_, err = NewStaticTokenAuthenticator("other").Authenticate(req)
+		So(err, ShouldNotBeNil)
+
+		_, err = NewStaticTokenAuthenticator("").Authenticate(newRequest())
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func Test_MTLSAuthenticator(t *testing.T) {
+	Convey("authenticate with the client certificate", t, func() {
+		req := newRequest()
+		_, err := NewMTLSAuthenticator().Authenticate(req)
+		So(err, ShouldEqual, ErrUnauthenticated)
+
+		cert := &x509.Certificate{
+			Subject: pkix.Name{CommonName: "executor-1"},
+			Issuer:  pkix.Name{CommonName: "dtf-ca"},
+		}
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+
+		identity, err := NewMTLSAuthenticator().Authenticate(req)
+		So(err, ShouldBeNil)
+		So(identity.Name, ShouldEqual, "executor-1")
+		So(identity.Issuer, ShouldEqual, "dtf-ca")
+		So(identity.BodyHash, ShouldBeEmpty)
+
+		_, err = NewMTLSAuthenticator("collector-1").Authenticate(req)
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/danenmao/pterergate-dtf/internal/msgsigner"
+)
+
+// 使用-keypath中的RSA密钥签发和验证JWT
+// 签名时token的接收方为Audience; 验证时要求接收方包含Audience, 签发者在Issuers中.
+// Audience或Issuers为空时不做对应的校验.
+// 校验过期时间时容忍ClockSkew的时钟偏差
+type JWTAuthenticator struct {
+	Issuer    string
+	Audience  string
+	Issuers   []string
+	Expire    time.Duration
+	ClockSkew time.Duration
+	once      sync.Once
+	signer    *msgsigner.MsgSigner
+}
+
+func NewJWTAuthenticator(audience string) *JWTAuthenticator {
+	return &JWTAuthenticator{
+		Issuer:    DefaultIssuer,
+		Audience:  audience,
+		Issuers:   []string{DefaultIssuer},
+		Expire:    TokenExpireDuration,
+		ClockSkew: DefaultClockSkew,
+	}
+}
+
+func (a *JWTAuthenticator) getSigner() *msgsigner.MsgSigner {
+	a.once.Do(func() {
+		a.signer = msgsigner.NewMsgSigner()
+	})
+
+	return a.signer
+}
+
+func (a *JWTAuthenticator) Sign(req *http.Request, userName string, bodyHash string) error {
+	data, err := json.Marshal(TokenMessage{UserName: userName, BodyHash: bodyHash})
+	if err != nil {
+		return err
+	}
+
+	audience := []string{}
+	if len(a.Audience) > 0 {
+		audience = append(audience, a.Audience)
+	}
+
+	token, err := a.getSigner().Sign(a.Issuer, Subject, audience, string(data), a.Expire)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	return nil
+}
+
+func (a *JWTAuthenticator) Authenticate(req *http.Request) (*Identity, error) {
+	token, err := bearerToken(req)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := a.getSigner().VerifyClaims(token, a.Audience, a.Issuers, a.ClockSkew)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := TokenMessage{}
+	err = json.Unmarshal([]byte(claims.Message), &msg)
+	if err != nil {
+		return nil, err
+	}
+
+	// JWT只签名了请求头, 必须校验请求体
+	if len(msg.BodyHash) == 0 {
+		return nil, errors.New("no body hash in the token")
+	}
+
+	identity := &Identity{
+		Name:     msg.UserName,
+		Issuer:   claims.Issuer,
+		BodyHash: msg.BodyHash,
+		Nonce:    claims.ID,
+	}
+
+	if claims.ExpiresAt != nil {
+		identity.ExpiresAt = claims.ExpiresAt.Time.Add(a.ClockSkew)
+	}
+
+	return identity, nil
+}
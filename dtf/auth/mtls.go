@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// 使用mTLS的客户端证书认证调用方, 证书的CN为调用方的名称
+// AllowedNames不为空时只允许其中的调用方.
+// 连接已经由TLS保护, 不校验请求体哈希
+type MTLSAuthenticator struct {
+	AllowedNames []string
+}
+
+func NewMTLSAuthenticator(allowedNames ...string) *MTLSAuthenticator {
+	return &MTLSAuthenticator{AllowedNames: allowedNames}
+}
+
+// 客户端证书在TLS握手时发送, 不需要附加凭证
+func (a *MTLSAuthenticator) Sign(req *http.Request, userName string, bodyHash string) error {
+	return nil
+}
+
+func (a *MTLSAuthenticator) Authenticate(req *http.Request) (*Identity, error) {
+	if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 || len(req.TLS.VerifiedChains[0]) == 0 {
+		return nil, ErrUnauthenticated
+	}
+
+	cert := req.TLS.VerifiedChains[0][0]
+	name := cert.Subject.CommonName
+	if len(a.AllowedNames) > 0 && !contains(a.AllowedNames, name) {
+		return nil, fmt.Errorf("client %s is not allowed", name)
+	}
+
+	return &Identity{Name: name, Issuer: cert.Issuer.CommonName}, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
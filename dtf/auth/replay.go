@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
+)
+
+var ErrRequestExpired = errors.New("request timestamp out of the allowed clock skew")
+var ErrReplayedRequest = errors.New("replayed request")
+var ErrNonceCacheFull = errors.New("nonce cache is full")
+
+// 内存nonce缓存的默认容量
+const DefaultNonceCacheCapacity = 100000
+
+// nonce在状态存储中的key前缀
+const NonceKeyPrefix = "dtf.request.nonce."
+
+// 已使用的nonce
+type INonceCache interface {
+	// 记录nonce直到expireAt, nonce已存在时返回false
+	Add(ctx context.Context, nonce string, expireAt time.Time) (bool, error)
+}
+
+// 防重放检查
+// 拒绝时间戳与本地时钟相差超过ClockSkew的请求, 以及nonce已使用过的请求
+type ReplayGuard struct {
+	Nonces    INonceCache
+	ClockSkew time.Duration
+}
+
+func NewReplayGuard(nonces INonceCache, clockSkew time.Duration) *ReplayGuard {
+	return &ReplayGuard{
+		Nonces:    nonces,
+		ClockSkew: clockSkew,
+	}
+}
+
+// 使用内存nonce缓存的防重放检查, 只适用于单个服务实例
+func NewMemoryReplayGuard() *ReplayGuard {
+	return NewReplayGuard(NewMemoryNonceCache(DefaultNonceCacheCapacity), DefaultClockSkew)
+}
+
+// 检查请求的时间戳和nonce, 凭证没有唯一ID时使用请求ID作为nonce
+func (g *ReplayGuard) CheckRequest(
+	ctx context.Context, timestamp time.Time, requestId string, identity *Identity,
+) error {
+	err := g.CheckTimestamp(timestamp)
+	if err != nil {
+		return err
+	}
+
+	nonce, expireAt := requestId, time.Time{}
+	if identity != nil && len(identity.Nonce) > 0 {
+		nonce, expireAt = identity.Nonce, identity.ExpiresAt
+	}
+
+	return g.CheckNonce(ctx, nonce, expireAt)
+}
+
+// 检查请求的时间戳
+func (g *ReplayGuard) CheckTimestamp(timestamp time.Time) error {
+	skew := time.Since(timestamp)
+	if skew < 0 {
+		skew = -skew
+	}
+
+	if skew > g.ClockSkew {
+		return ErrRequestExpired
+	}
+
+	return nil
+}
+
+// 检查并记录nonce, expireAt为零值时保留到时间戳检查的窗口之后
+func (g *ReplayGuard) CheckNonce(ctx context.Context, nonce string, expireAt time.Time) error {
+	if g.Nonces == nil {
+		return nil
+	}
+
+	if len(nonce) == 0 {
+		return ErrReplayedRequest
+	}
+
+	if expireAt.IsZero() {
+		expireAt = time.Now().Add(2 * g.ClockSkew)
+	}
+
+	added, err := g.Nonces.Add(ctx, nonce, expireAt)
+	if err != nil {
+		return err
+	}
+
+	if !added {
+		return ErrReplayedRequest
+	}
+
+	return nil
+}
+
+// 内存中的nonce缓存
+// 最多保存Capacity个未过期的nonce, 已满时拒绝新的nonce
+type MemoryNonceCache struct {
+	Capacity int
+	lock     sync.Mutex
+	nonces   map[string]time.Time
+}
+
+func NewMemoryNonceCache(capacity int) *MemoryNonceCache {
+	return &MemoryNonceCache{
+		Capacity: capacity,
+		nonces:   map[string]time.Time{},
+	}
+}
+
+func (c *MemoryNonceCache) Add(ctx context.Context, nonce string, expireAt time.Time) (bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := time.Now()
+	if expire, ok := c.nonces[nonce]; ok && expire.After(now) {
+		return false, nil
+	}
+
+	if len(c.nonces) >= c.Capacity {
+		c.purge(now)
+		if len(c.nonces) >= c.Capacity {
+			return false, ErrNonceCacheFull
+		}
+	}
+
+	c.nonces[nonce] = expireAt
+	return true, nil
+}
+
+// 删除已过期的nonce
+func (c *MemoryNonceCache) purge(now time.Time) {
+	for nonce, expire := range c.nonces {
+		if !expire.After(now) {
+			delete(c.nonces, nonce)
+		}
+	}
+}
+
+// 保存在状态存储(Redis)中的nonce缓存, 多个服务实例共享
+type StoreNonceCache struct{}
+
+func NewStoreNonceCache() *StoreNonceCache {
+	return &StoreNonceCache{}
+}
+
+func (c *StoreNonceCache) Add(ctx context.Context, nonce string, expireAt time.Time) (bool, error) {
+	expire := time.Until(expireAt)
+	if expire <= 0 {
+		return false, nil
+	}
+
+	return statestore.Default().SetLockIfAbsent(ctx, NonceKeyPrefix+nonce, "1", expire)
+}
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
+)
+
+func Test_ReplayGuard_CheckTimestamp(t *testing.T) {
+	guard := NewReplayGuard(nil, time.Minute)
+
+	Convey("check the request timestamp", t, func() {
+		So(guard.CheckTimestamp(time.Now()), ShouldBeNil)
+		So(guard.CheckTimestamp(time.Now().Add(30*time.Second)), ShouldBeNil)
+		So(guard.CheckTimestamp(time.Now().Add(-2*time.Minute)), ShouldEqual, ErrRequestExpired)
+		So(guard.CheckTimestamp(time.Now().Add(2*time.Minute)), ShouldEqual, ErrRequestExpired)
+	})
+}
+
+func Test_ReplayGuard_CheckNonce(t *testing.T) {
+	guard := NewReplayGuard(NewMemoryNonceCache(2), time.Minute)
+	ctx := context.Background()
+
+	Convey("check the nonces", t, func() {
+		So(guard.CheckNonce(ctx, "a", time.Time{}), ShouldBeNil)
+		So(guard.CheckNonce(ctx, "a", time.Time{}), ShouldEqual, ErrReplayedRequest)
+		So(guard.CheckNonce(ctx, "", time.Time{}), ShouldEqual, ErrReplayedRequest)
+
+		Convey("should reuse the space of expired nonces", func() {
+			So(guard.CheckNonce(ctx, "b", time.Now().Add(-time.Second)), ShouldBeNil)
+			So(guard.CheckNonce(ctx, "c", time.Time{}), ShouldBeNil)
+			So(guard.CheckNonce(ctx, "d", time.Time{}), ShouldEqual, ErrNonceCacheFull)
+		})
+	})
+}
+
+func Test_StoreNonceCache(t *testing.T) {
+	statestore.SetDefault(statestore.NewMemoryStore())
+	defer statestore.SetDefault(statestore.NewRedisStore())
+
+	cache := NewStoreNonceCache()
+	ctx := context.Background()
+	first, err := cache.Add(ctx, "nonce", time.Now().Add(time.Minute))
+	second, _ := cache.Add(ctx, "nonce", time.Now().Add(time.Minute))
+	expired, _ := cache.Add(ctx, "other", time.Now().Add(-time.Second))
+
+	Convey("add nonces to the state store", t, func() {
+		So(err, ShouldBeNil)
+		So(first, ShouldBeTrue)
+		So(second, ShouldBeFalse)
+		So(expired, ShouldBeFalse)
+	})
+}
@@ -0,0 +1,21 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+)
+
+// 大对象存储接口, 用于保存超过阈值的子任务参数和结果.
+// key为以'/'分隔的路径, 如 "123/456.param". 实现需要是并发安全的
+type IBlobStore interface {
+	// 保存对象, 已存在的对象被覆盖
+	Put(ctx context.Context, key string, data []byte) error
+
+	// 读取对象, 不存在时返回errordef.ErrNotFound
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// 删除key以prefix开头的所有对象
+	DeletePrefix(ctx context.Context, prefix string) error
+}
+
+var ErrInvalidKey = errors.New("invalid blob key")
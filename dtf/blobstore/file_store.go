@@ -0,0 +1,111 @@
+package blobstore
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+)
+
+// 保存在本地目录中的大对象存储, 多个服务共享时需使用共享的文件系统
+type FileStore struct {
+	dir string
+}
+
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{dir: dir}
+}
+
+func (store *FileStore) Put(ctx context.Context, key string, data []byte) error {
+	path, err := store.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+
+	// 先写临时文件再改名, 读取方不会读到写了一半的对象
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return nil
+}
+
+func (store *FileStore) Get(ctx context.Context, key string) ([]byte, error) {
+	path, err := store.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, errordef.ErrNotFound
+	}
+
+	return data, err
+}
+
+func (store *FileStore) DeletePrefix(ctx context.Context, prefix string) error {
+	if strings.Contains(prefix, "..") {
+		return ErrInvalidKey
+	}
+
+	// 目录前缀直接删除目录
+	if strings.HasSuffix(prefix, "/") {
+		return os.RemoveAll(filepath.Join(store.dir, filepath.FromSlash(prefix)))
+	}
+
+	err := filepath.WalkDir(store.dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(store.dir, path)
+		if err != nil {
+			return err
+		}
+
+		if strings.HasPrefix(filepath.ToSlash(rel), prefix) {
+			return os.Remove(path)
+		}
+
+		return nil
+	})
+
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+// 对象的文件路径, 不允许访问目录之外的文件
+func (store *FileStore) path(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "..") {
+		return "", ErrInvalidKey
+	}
+
+	return filepath.Join(store.dir, filepath.FromSlash(key)), nil
+}
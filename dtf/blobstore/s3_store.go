@@ -0,0 +1,244 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/extconfig"
+)
+
+// 未指定区域时使用的区域
+const DefaultS3Region = "us-east-1"
+
+// S3兼容的大对象存储, 如AWS S3, MinIO.
+// 请求使用AWS Signature Version 4签名
+type S3Store struct {
+	address extconfig.S3Address
+	client  *http.Client
+}
+
+func NewS3Store(address *extconfig.S3Address) *S3Store {
+	store := &S3Store{
+		address: *address,
+		client:  &http.Client{Timeout: time.Minute},
+	}
+
+	store.address.Endpoint = strings.TrimSuffix(store.address.Endpoint, "/")
+	if store.address.Region == "" {
+		store.address.Region = DefaultS3Region
+	}
+
+	return store
+}
+
+func (store *S3Store) Put(ctx context.Context, key string, data []byte) error {
+	rsp, err := store.do(ctx, http.MethodPut, store.address.Prefix+key, nil, data)
+	if err != nil {
+		return err
+	}
+
+	rsp.Body.Close()
+	return nil
+}
+
+func (store *S3Store) Get(ctx context.Context, key string) ([]byte, error) {
+	rsp, err := store.do(ctx, http.MethodGet, store.address.Prefix+key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rsp.Body.Close()
+	return io.ReadAll(rsp.Body)
+}
+
+// ListObjectsV2的响应
+type s3ListResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func (store *S3Store) DeletePrefix(ctx context.Context, prefix string) error {
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {store.address.Prefix + prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		rsp, err := store.do(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return err
+		}
+
+		result := s3ListResult{}
+		err = xml.NewDecoder(rsp.Body).Decode(&result)
+		rsp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		for _, object := range result.Contents {
+			rsp, err := store.do(ctx, http.MethodDelete, object.Key, nil, nil)
+			if err != nil && err != errordef.ErrNotFound {
+				return err
+			}
+
+			if err == nil {
+				rsp.Body.Close()
+			}
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return nil
+		}
+
+		token = result.NextContinuationToken
+	}
+}
+
+// 发送签名的请求, key为空时访问bucket. 404时返回errordef.ErrNotFound
+func (store *S3Store) do(
+	ctx context.Context, method string, key string, query url.Values, body []byte,
+) (*http.Response, error) {
+	reqURL, err := url.Parse(store.address.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	reqURL.Path += "/" + store.address.Bucket
+	if key != "" {
+		reqURL.Path += "/" + key
+	}
+
+	// 发送的路径与签名的路径使用相同的编码
+	reqURL.RawPath = uriEncode(reqURL.Path, false)
+	reqURL.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	store.sign(req, body, time.Now().UTC())
+	rsp, err := store.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if rsp.StatusCode == http.StatusNotFound {
+		rsp.Body.Close()
+		return nil, errordef.ErrNotFound
+	}
+
+	if rsp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(rsp.Body, 1024))
+		rsp.Body.Close()
+		return nil, fmt.Errorf("s3 %s %s: HTTP status %d: %s", method, key, rsp.StatusCode, msg)
+	}
+
+	return rsp, nil
+}
+
+// 按AWS Signature Version 4签名请求
+func (store *S3Store) sign(req *http.Request, body []byte, now time.Time) {
+	payloadHash := sha256Hex(body)
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalPath(req.URL.Path),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host + "\n" +
+			"x-amz-content-sha256:" + payloadHash + "\n" +
+			"x-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + store.address.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+store.address.SecretKey), date)
+	key = hmacSHA256(key, store.address.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		store.address.AccessKey, scope, signedHeaders, signature))
+}
+
+// 按key排序并编码的查询串
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := []string{}
+	for _, key := range keys {
+		for _, val := range query[key] {
+			parts = append(parts, uriEncode(key, true)+"="+uriEncode(val, true))
+		}
+	}
+
+	return strings.Join(parts, "&")
+}
+
+func canonicalPath(path string) string {
+	if path == "" {
+		return "/"
+	}
+
+	return uriEncode(path, false)
+}
+
+// 编码除unreserved字符之外的字符, encodeSlash为false时保留'/'
+func uriEncode(s string, encodeSlash bool) string {
+	buf := strings.Builder{}
+	for _, b := range []byte(s) {
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9',
+			b == '-', b == '_', b == '.', b == '~':
+			buf.WriteByte(b)
+		case b == '/' && !encodeSlash:
+			buf.WriteByte(b)
+		default:
+			fmt.Fprintf(&buf, "%%%02X", b)
+		}
+	}
+
+	return buf.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
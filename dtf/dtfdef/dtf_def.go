@@ -3,8 +3,11 @@ package dtfdef
 import (
 	"time"
 
+	"github.com/danenmao/pterergate-dtf/dtf/blobstore"
 	"github.com/danenmao/pterergate-dtf/dtf/extconfig"
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/dtf/tracing"
 )
 
 // 服务角色，指定不同的服务类型
@@ -18,6 +21,33 @@ const (
 	ServiceRole_Collector ServiceRole = 5
 )
 
+// 子任务结果的存储类型
+type ResultStoreType uint32
+
+const (
+	ResultStore_None    ResultStoreType = 0 // 不保存子任务结果
+	ResultStore_MySQL   ResultStoreType = 1 // 保存到MySQL的tbl_subtask_result表
+	ResultStore_MongoDB ResultStoreType = 2 // 保存到MongoDB的subtask_result集合
+)
+
+// 任务和子任务ID的生成方式
+type IdGeneratorType uint32
+
+const (
+	IdGenerator_Redis     IdGeneratorType = 0 // 从Redis计数器按段分配
+	IdGenerator_MySQL     IdGeneratorType = 1 // 从SQL数据库的tbl_id_segment表按段分配
+	IdGenerator_Snowflake IdGeneratorType = 2 // 由时间戳、节点ID和序号生成, 不依赖外部服务
+)
+
+// 框架表的迁移模式
+type SchemaMigrationMode uint32
+
+const (
+	SchemaMigration_Apply    SchemaMigrationMode = 0 // 管理器启动时创建和升级框架表
+	SchemaMigration_DryRun   SchemaMigrationMode = 1 // 只在日志中输出需执行的语句, 不修改数据库
+	SchemaMigration_Disabled SchemaMigrationMode = 2 // 不检查框架表, 由用户自行维护
+)
+
 // 服务配置
 type ServiceConfig struct {
 	PrestopDuration          time.Duration
@@ -28,4 +58,17 @@ type ServiceConfig struct {
 	CollectorService         taskmodel.CollectorInvoker
 	ExecutorHandlerRegister  taskmodel.RegisterExecutorRequestHandler
 	CollectorHandlerRegister taskmodel.RegisterCollectorRequestHandler
+	KeyPrefix                string
+	RuntimeConfig            *extconfig.RuntimeConfig
+	ConfigFile               string
+	MetricsAddress           string
+	TraceExporter            tracing.IExporter
+	Logger                   logging.ILogger
+	AdminPort                uint16
+	ResultStore              ResultStoreType
+	SchemaMigration          SchemaMigrationMode
+	IdGenerator              IdGeneratorType
+	SnowflakeNodeId          uint16
+	BlobStore                blobstore.IBlobStore
+	BlobThreshold            int
 }
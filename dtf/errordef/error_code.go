@@ -14,6 +14,7 @@ const (
 	Error_Msg_OperationFailed     = "OperationFailed"
 	Error_Msg_AuthorizationFailed = "AuthorizationFailed"
 	Error_Msg_InternalError       = "InternalError"
+	Error_Msg_BodyTooLarge        = "BodyTooLarge"
 )
 
 // 错误码映射表
@@ -15,3 +15,5 @@ var ErrInvalidParameter = errors.New("invalid parameter")
 var ErrOperationFailed = errors.New("operation failed")
 var ErrInternalError = errors.New("internal error")
 var ErrAccessDenied = errors.New("access denied")
+var ErrInvalidStatus = errors.New("invalid status")
+var ErrBodyTooLarge = errors.New("body too large")
@@ -1,15 +1,36 @@
 package extconfig
 
+// Redis的部署模式
+const (
+	RedisMode_Single   = "single"
+	RedisMode_Sentinel = "sentinel"
+	RedisMode_Cluster  = "cluster"
+)
+
 // Redis地址
+// Sentinel和Cluster模式下, Address为逗号分隔的节点地址列表
 type RedisAddress struct {
-	Name     string `mapstructure:"name" json:"name"`
-	Type     string `mapstructure:"type" json:"type"`
-	Address  string `mapstructure:"address" json:"address"`
-	DB       string `mapstructure:"db" json:"db"`
-	Password string `mapstructure:"password" json:"password"`
+	Name       string `mapstructure:"name" json:"name"`
+	Type       string `mapstructure:"type" json:"type"`
+	Address    string `mapstructure:"address" json:"address"`
+	DB         string `mapstructure:"db" json:"db"`
+	Password   string `mapstructure:"password" json:"password"`
+	Mode       string `mapstructure:"mode" json:"mode"`               // 部署模式, 为空时表示single
+	MasterName string `mapstructure:"master-name" json:"master-name"` // Sentinel模式下的master名称
+	ShardCount uint   `mapstructure:"shard-count" json:"shard-count"` // 全局子任务集合的分片数, 为0时不分片
 }
 
-// MySQL地址
+// SQL数据库的方言
+const (
+	SQLDialect_MySQL      = "mysql"
+	SQLDialect_PostgreSQL = "postgres"
+	SQLDialect_SQLite     = "sqlite"
+)
+
+// MySQL地址, 也用于PostgreSQL和SQLite
+// Type为database/sql注册的驱动名, 为空时使用方言的默认驱动.
+// PostgreSQL的驱动需由使用者引入, 如 github.com/jackc/pgx/v5/stdlib; SQLite使用内置的github.com/mattn/go-sqlite3, 需要cgo.
+// SQLite的Address为数据库文件的路径
 type MySQLAddress struct {
 	Name     string `mapstructure:"name" json:"name"`
 	Type     string `mapstructure:"type" json:"type"`
@@ -18,6 +39,17 @@ type MySQLAddress struct {
 	DB       string `mapstructure:"db" json:"db"`
 	Username string `mapstructure:"username" json:"username"`
 	Password string `mapstructure:"password" json:"password"`
+	Dialect  string `mapstructure:"dialect" json:"dialect"` // 数据库方言, 为空时表示mysql
+	DSN      string `mapstructure:"dsn" json:"dsn"`         // 完整的连接串, 不为空时忽略地址和账号
+}
+
+// TLS配置
+// 服务端配置CAFile时要求并校验客户端证书(mTLS); 客户端配置CertFile时发送客户端证书
+type TLSConfig struct {
+	CertFile   string `mapstructure:"cert-file" json:"cert-file"`
+	KeyFile    string `mapstructure:"key-file" json:"key-file"`
+	CAFile     string `mapstructure:"ca-file" json:"ca-file"`
+	ServerName string `mapstructure:"server-name" json:"server-name"` // 客户端校验的服务端名称, 为空时使用连接的主机名
 }
 
 // Mongo地址
@@ -29,6 +61,18 @@ type MongoAddress struct {
 	ReplicaSet string `mapstructure:"replica-set" json:"replica-set"`
 }
 
+// S3兼容的对象存储地址, 也用于MinIO
+// Endpoint为不含bucket的服务地址, 如 https://s3.us-east-1.amazonaws.com, http://localhost:9000.
+// 对象以路径方式访问: Endpoint/Bucket/Prefix+key
+type S3Address struct {
+	Endpoint  string `mapstructure:"endpoint" json:"endpoint"`
+	Region    string `mapstructure:"region" json:"region"` // 为空时使用us-east-1
+	Bucket    string `mapstructure:"bucket" json:"bucket"`
+	AccessKey string `mapstructure:"access-key" json:"access-key"`
+	SecretKey string `mapstructure:"secret-key" json:"secret-key"`
+	Prefix    string `mapstructure:"prefix" json:"prefix"` // 对象key的前缀, 多个集群共享bucket时使用
+}
+
 // RPC service address
 type RPCServiceAddress struct {
 	Hostname string `json:"hostname"`
@@ -0,0 +1,93 @@
+package extconfig
+
+// 运行时配置, 控制各服务例程的并发数和执行间隔
+// 可以通过ServiceOption、配置文件和环境变量设置
+type RuntimeConfig struct {
+	Leader     LeaderConfig     `mapstructure:"leader" json:"leader"`
+	Manager    ManagerConfig    `mapstructure:"manager" json:"manager"`
+	Generator  GeneratorConfig  `mapstructure:"generator" json:"generator"`
+	Scheduler  SchedulerConfig  `mapstructure:"scheduler" json:"scheduler"`
+	Collector  CollectorConfig  `mapstructure:"collector" json:"collector"`
+	Scheduling SchedulingConfig `mapstructure:"scheduling" json:"scheduling"`
+}
+
+// 领导者选举的配置
+type LeaderConfig struct {
+	Lease int `mapstructure:"lease" json:"lease"` // 租约时长, 秒
+}
+
+// 任务管理服务的配置
+type ManagerConfig struct {
+	TaskCreationNextCheck           int  `mapstructure:"task-creation-next-check" json:"task-creation-next-check"`                     // 创建后检查任务记录的延迟, 秒
+	TaskCreatingTimeout             int  `mapstructure:"task-creating-timeout" json:"task-creating-timeout"`                           // 任务创建的超时时间, 秒
+	MonitorTaskTableConcurrency     uint `mapstructure:"monitor-task-table-concurrency" json:"monitor-task-table-concurrency"`         // 检查任务表的例程数
+	MonitorTaskTableInterval        int  `mapstructure:"monitor-task-table-interval" json:"monitor-task-table-interval"`               // 检查任务表的间隔, 秒
+	MonitorTaskTimeoutConcurrency   uint `mapstructure:"monitor-task-timeout-concurrency" json:"monitor-task-timeout-concurrency"`     // 检查任务超时的例程数
+	MonitorTaskTimeoutInterval      int  `mapstructure:"monitor-task-timeout-interval" json:"monitor-task-timeout-interval"`           // 检查任务超时的间隔, 秒
+	MonitorTaskCompletedConcurrency uint `mapstructure:"monitor-task-completed-concurrency" json:"monitor-task-completed-concurrency"` // 处理已完成任务的例程数
+	MonitorTaskCompletedInterval    int  `mapstructure:"monitor-task-completed-interval" json:"monitor-task-completed-interval"`       // 处理已完成任务的间隔, 秒
+}
+
+// 任务生成服务的配置
+type GeneratorConfig struct {
+	GenerateTaskConcurrency      uint `mapstructure:"generate-task-concurrency" json:"generate-task-concurrency"`           // 同时生成的任务数
+	GenerateTaskInterval         int  `mapstructure:"generate-task-interval" json:"generate-task-interval"`                 // 检查待生成任务的间隔, 秒
+	MonitorGenerationConcurrency uint `mapstructure:"monitor-generation-concurrency" json:"monitor-generation-concurrency"` // 检查生成过程的例程数
+	MonitorGenerationInterval    int  `mapstructure:"monitor-generation-interval" json:"monitor-generation-interval"`       // 检查生成过程的间隔, 秒
+	GenerationSlice              int  `mapstructure:"generation-slice" json:"generation-slice"`                             // 实例连续生成一个任务的时间, 到达后交由其他实例继续生成, 秒, 为0时不限制
+	GenerationMaxFailures        int  `mapstructure:"generation-max-failures" json:"generation-max-failures"`               // 任务生成失败的最大次数, 达到后任务被置为异常
+
+	// 生成队列的背压: 任务的待调度子任务数达到高水位时暂停生成, 降到低水位时恢复
+	QueueHighWatermark uint              `mapstructure:"queue-high-watermark" json:"queue-high-watermark"` // 默认的高水位
+	QueueLowWatermark  uint              `mapstructure:"queue-low-watermark" json:"queue-low-watermark"`   // 默认的低水位
+	QueueBufferTime    int               `mapstructure:"queue-buffer-time" json:"queue-buffer-time"`       // 按调度速度将高水位降到此时间内可调度的子任务数, 秒, 为0时使用固定水位
+	Watermarks         []WatermarkConfig `mapstructure:"watermarks" json:"watermarks"`                     // 任务类型的水位, 覆盖默认水位
+}
+
+// 任务类型的生成队列水位
+type WatermarkConfig struct {
+	TaskType uint32 `mapstructure:"task-type" json:"task-type"`
+	High     uint   `mapstructure:"high" json:"high"`
+	Low      uint   `mapstructure:"low" json:"low"`
+}
+
+// 任务调度服务的配置
+type SchedulerConfig struct {
+	ScheduleTaskConcurrency           uint `mapstructure:"schedule-task-concurrency" json:"schedule-task-concurrency"`                       // 调度例程数
+	ScheduleTaskInterval              int  `mapstructure:"schedule-task-interval" json:"schedule-task-interval"`                             // 调度间隔, 毫秒
+	RetryPushConcurrency              uint `mapstructure:"retry-push-concurrency" json:"retry-push-concurrency"`                             // 重试推送子任务的例程数
+	RetryPushInterval                 int  `mapstructure:"retry-push-interval" json:"retry-push-interval"`                                   // 重试推送子任务的间隔, 秒
+	MonitorSubtaskTimeoutConcurrency  uint `mapstructure:"monitor-subtask-timeout-concurrency" json:"monitor-subtask-timeout-concurrency"`   // 检查子任务超时的例程数
+	MonitorSubtaskTimeoutInterval     int  `mapstructure:"monitor-subtask-timeout-interval" json:"monitor-subtask-timeout-interval"`         // 检查子任务超时的间隔, 秒
+	MonitorSubtaskCompleteConcurrency uint `mapstructure:"monitor-subtask-complete-concurrency" json:"monitor-subtask-complete-concurrency"` // 处理已完成子任务的例程数
+	MonitorSubtaskCompleteInterval    int  `mapstructure:"monitor-subtask-complete-interval" json:"monitor-subtask-complete-interval"`       // 处理已完成子任务的间隔, 毫秒
+	MonitorTaskCompleteConcurrency    uint `mapstructure:"monitor-task-complete-concurrency" json:"monitor-task-complete-concurrency"`       // 检查任务完成的例程数
+	MonitorTaskCompleteInterval       int  `mapstructure:"monitor-task-complete-interval" json:"monitor-task-complete-interval"`             // 检查任务完成的间隔, 秒
+}
+
+// 调度策略的配置, 修改配置文件后调度器无需重启即可生效
+type SchedulingConfig struct {
+	QueueBaseTimeSlice      uint32        `mapstructure:"queue-base-time-slice" json:"queue-base-time-slice"`           // 优先级队列授予任务的基础时间片, 毫秒
+	QueueTimeSliceStep      uint32        `mapstructure:"queue-time-slice-step" json:"queue-time-slice-step"`           // 每级优先级队列增加的时间片, 毫秒
+	RRQueueTimeSlice        uint32        `mapstructure:"rr-queue-time-slice" json:"rr-queue-time-slice"`               // 低优先级队列的时间片, 毫秒
+	PriorityBonusLow        uint32        `mapstructure:"priority-bonus-low" json:"priority-bonus-low"`                 // 低优先级任务的时间片bonus系数
+	PriorityBonusMedium     uint32        `mapstructure:"priority-bonus-medium" json:"priority-bonus-medium"`           // 中优先级任务的时间片bonus系数
+	PriorityBonusHigh       uint32        `mapstructure:"priority-bonus-high" json:"priority-bonus-high"`               // 高优先级任务的时间片bonus系数
+	PriorityBoostInterval   uint32        `mapstructure:"priority-boost-interval" json:"priority-boost-interval"`       // 优先级队列执行Priority Boost的间隔, 秒
+	RRPriorityBoostInterval uint32        `mapstructure:"rr-priority-boost-interval" json:"rr-priority-boost-interval"` // 低优先级队列执行Priority Boost的间隔, 秒
+	ExecutorBatchSize       uint32        `mapstructure:"executor-batch-size" json:"executor-batch-size"`               // 批量推送给执行器的子任务数上限
+	Quotas                  []QuotaConfig `mapstructure:"quotas" json:"quotas"`                                         // 资源组的配额, 覆盖同名的内置资源组或新增资源组
+}
+
+// 资源组的配额
+type QuotaConfig struct {
+	Name  string  `mapstructure:"name" json:"name"`
+	Quota float32 `mapstructure:"quota" json:"quota"`
+}
+
+// 结果采集服务的配置
+type CollectorConfig struct {
+	CompleteSubtaskConcurrency uint `mapstructure:"complete-subtask-concurrency" json:"complete-subtask-concurrency"` // 处理子任务结果的例程数
+	CompleteSubtaskInterval    int  `mapstructure:"complete-subtask-interval" json:"complete-subtask-interval"`       // 处理子任务结果的间隔, 毫秒
+	SubtaskRoutineLimit        uint `mapstructure:"subtask-routine-limit" json:"subtask-routine-limit"`               // 同时处理子任务结果的协程数上限
+}
@@ -0,0 +1,63 @@
+package logging
+
+import "fmt"
+
+// 常用字段的名称
+const (
+	FieldKey_TaskId    = "task_id"
+	FieldKey_SubtaskId = "subtask_id"
+	FieldKey_TaskType  = "task_type"
+	FieldKey_Role      = "role"
+	FieldKey_RequestId = "request_id"
+	FieldKey_Error     = "error"
+)
+
+// 任务和子任务ID的类型, 包括taskmodel.TaskIdType, taskmodel.SubtaskIdType和uint64
+type idType interface {
+	~uint64
+}
+
+// 日志的结构化字段
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+func TaskId[T idType](taskId T) Field {
+	return Field{Key: FieldKey_TaskId, Value: uint64(taskId)}
+}
+
+func SubtaskId[T idType](subtaskId T) Field {
+	return Field{Key: FieldKey_SubtaskId, Value: uint64(subtaskId)}
+}
+
+func TaskType(taskType uint32) Field {
+	return Field{Key: FieldKey_TaskType, Value: taskType}
+}
+
+func Role(role string) Field {
+	return Field{Key: FieldKey_Role, Value: role}
+}
+
+func RequestId(requestId string) Field {
+	return Field{Key: FieldKey_RequestId, Value: requestId}
+}
+
+// 错误字段, err为nil时值为空
+func Err(err error) Field {
+	if err == nil {
+		return Field{Key: FieldKey_Error, Value: nil}
+	}
+
+	return Field{Key: FieldKey_Error, Value: err.Error()}
+}
+
+// 任意字段
+func Any(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// 以key=value的形式输出字段的值
+func (field Field) String() string {
+	return fmt.Sprintf("%s=%v", field.Key, field.Value)
+}
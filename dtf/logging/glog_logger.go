@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"runtime"
+	"strings"
+
+	"github.com/golang/glog"
+)
+
+// 输出到glog的日志对象, 字段以key=value的形式附加在消息后
+// Debug日志只在-v=1及以上时输出
+type GlogLogger struct {
+}
+
+func NewGlogLogger() GlogLogger {
+	return GlogLogger{}
+}
+
+func (logger GlogLogger) Enabled(level Level) bool {
+	if level == Level_Debug {
+		return bool(glog.V(1))
+	}
+
+	return true
+}
+
+func (logger GlogLogger) Log(level Level, msg string, fields []Field) {
+	line := formatLine(msg, fields)
+
+	// 跳过日志包内的调用层次, 以记录调用者的位置
+	depth := callerDepth()
+	switch level {
+	case Level_Debug, Level_Info:
+		glog.InfoDepth(depth, line)
+	case Level_Warning:
+		glog.WarningDepth(depth, line)
+	default:
+		glog.ErrorDepth(depth, line)
+	}
+}
+
+const packagePrefix = "github.com/danenmao/pterergate-dtf/dtf/logging."
+
+// 日志包外第一个调用者相对于GlogLogger.Log的层次
+func callerDepth() int {
+	pcs := make([]uintptr, 16)
+	count := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:count])
+
+	depth := 1
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, packagePrefix) || !more {
+			return depth
+		}
+
+		depth++
+	}
+}
+
+func formatLine(msg string, fields []Field) string {
+	if len(fields) == 0 {
+		return msg
+	}
+
+	builder := strings.Builder{}
+	builder.WriteString(msg)
+	for _, field := range fields {
+		builder.WriteByte(' ')
+		builder.WriteString(field.String())
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,128 @@
+package logging
+
+import "sync"
+
+// 日志级别
+type Level int32
+
+const (
+	Level_Debug   Level = 0
+	Level_Info    Level = 1
+	Level_Warning Level = 2
+	Level_Error   Level = 3
+)
+
+func (level Level) String() string {
+	switch level {
+	case Level_Debug:
+		return "debug"
+	case Level_Info:
+		return "info"
+	case Level_Warning:
+		return "warning"
+	case Level_Error:
+		return "error"
+	}
+
+	return "unknown"
+}
+
+// 日志接口, 每条日志由消息和结构化字段组成
+// 实现需要是并发安全的
+type ILogger interface {
+	Enabled(level Level) bool
+	Log(level Level, msg string, fields []Field)
+}
+
+// 默认输出到glog, 相同的Debug和Info日志每秒最多输出DefaultSampleFirst条
+var (
+	gs_Logger      ILogger = NewDefaultLogger()
+	gs_LoggerMutex sync.RWMutex
+)
+
+// 创建默认的日志对象
+func NewDefaultLogger() ILogger {
+	return NewSampledLogger(NewGlogLogger(), DefaultSampleTick, DefaultSampleFirst, DefaultSampleThereafter)
+}
+
+// 获取当前的日志对象
+func GetLogger() ILogger {
+	gs_LoggerMutex.RLock()
+	defer gs_LoggerMutex.RUnlock()
+	return gs_Logger
+}
+
+// 设置日志对象, 为nil时恢复为默认的日志对象
+func SetLogger(logger ILogger) {
+	if logger == nil {
+		logger = NewDefaultLogger()
+	}
+
+	gs_LoggerMutex.Lock()
+	defer gs_LoggerMutex.Unlock()
+	gs_Logger = logger
+}
+
+func Debug(msg string, fields ...Field) {
+	log(nil, Level_Debug, msg, fields)
+}
+
+func Info(msg string, fields ...Field) {
+	log(nil, Level_Info, msg, fields)
+}
+
+func Warning(msg string, fields ...Field) {
+	log(nil, Level_Warning, msg, fields)
+}
+
+func Error(msg string, fields ...Field) {
+	log(nil, Level_Error, msg, fields)
+}
+
+// 创建携带字段的日志入口, 通过它输出的日志都带有这些字段
+func With(fields ...Field) *Entry {
+	return &Entry{fields: fields}
+}
+
+// 携带字段的日志入口, 每次输出时使用当前的日志对象,
+// 所以可以在设置日志对象之前创建
+type Entry struct {
+	fields []Field
+}
+
+// 创建在当前字段上追加字段的日志入口
+func (entry *Entry) With(fields ...Field) *Entry {
+	merged := make([]Field, 0, len(entry.fields)+len(fields))
+	merged = append(merged, entry.fields...)
+	merged = append(merged, fields...)
+	return &Entry{fields: merged}
+}
+
+func (entry *Entry) Debug(msg string, fields ...Field) {
+	log(entry.fields, Level_Debug, msg, fields)
+}
+
+func (entry *Entry) Info(msg string, fields ...Field) {
+	log(entry.fields, Level_Info, msg, fields)
+}
+
+func (entry *Entry) Warning(msg string, fields ...Field) {
+	log(entry.fields, Level_Warning, msg, fields)
+}
+
+func (entry *Entry) Error(msg string, fields ...Field) {
+	log(entry.fields, Level_Error, msg, fields)
+}
+
+func log(base []Field, level Level, msg string, fields []Field) {
+	logger := GetLogger()
+	if !logger.Enabled(level) {
+		return
+	}
+
+	if len(base) > 0 {
+		fields = append(append(make([]Field, 0, len(base)+len(fields)), base...), fields...)
+	}
+
+	logger.Log(level, msg, fields)
+}
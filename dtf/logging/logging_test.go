@@ -0,0 +1,87 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// 记录日志的日志对象, 用于测试
+type recordLogger struct {
+	level   Level
+	records []string
+}
+
+func (logger *recordLogger) Enabled(level Level) bool {
+	return level >= logger.level
+}
+
+func (logger *recordLogger) Log(level Level, msg string, fields []Field) {
+	logger.records = append(logger.records, level.String()+" "+formatLine(msg, fields))
+}
+
+func Test_Entry_With(t *testing.T) {
+	logger := &recordLogger{level: Level_Info}
+	SetLogger(logger)
+	defer SetLogger(nil)
+
+	entry := With(Role("collector")).With(TaskId(uint64(12)))
+	entry.Info("processed", SubtaskId(uint64(34)), Err(errors.New("failed")))
+	entry.Debug("dropped")
+	Warning("no fields")
+
+	Convey("the fields of the entry are added to each log", t, func() {
+		So(logger.records, ShouldResemble, []string{
+			"info processed role=collector task_id=12 subtask_id=34 error=failed",
+			"warning no fields",
+		})
+	})
+}
+
+func Test_JSONLogger(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := NewJSONLogger(&buf, Level_Info)
+	logger.Log(Level_Debug, "dropped", nil)
+	logger.Log(Level_Warning, "failed to push subtask", []Field{TaskId(uint64(5)), RequestId("r1")})
+
+	record := map[string]interface{}{}
+	err := json.Unmarshal(buf.Bytes(), &record)
+
+	Convey("write the log and its fields as a JSON line", t, func() {
+		So(err, ShouldBeNil)
+		So(strings.Count(buf.String(), "\n"), ShouldEqual, 1)
+		So(record["level"], ShouldEqual, "WARN")
+		So(record["msg"], ShouldEqual, "failed to push subtask")
+		So(record["task_id"], ShouldEqual, 5)
+		So(record["request_id"], ShouldEqual, "r1")
+	})
+}
+
+func Test_SampledLogger(t *testing.T) {
+	inner := &recordLogger{level: Level_Debug}
+	logger := NewSampledLogger(inner, time.Hour, 2, 3)
+
+	for i := 0; i < 8; i++ {
+		logger.Log(Level_Info, "hot path", nil)
+		logger.Log(Level_Error, "error path", nil)
+	}
+	logger.Log(Level_Info, "another path", nil)
+
+	infoCount := 0
+	for _, record := range inner.records {
+		if record == "info hot path" {
+			infoCount++
+		}
+	}
+
+	Convey("the same info logs are sampled, the errors are not", t, func() {
+		// the 1st, 2nd, 5th and 8th logs are written
+		So(infoCount, ShouldEqual, 4)
+		So(len(inner.records), ShouldEqual, 4+8+1)
+	})
+}
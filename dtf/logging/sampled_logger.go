@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"sync"
+	"time"
+)
+
+// 默认的采样参数: 每秒内相同的日志输出前100条, 之后每100条输出1条
+const (
+	DefaultSampleTick       = time.Second
+	DefaultSampleFirst      = 100
+	DefaultSampleThereafter = 100
+)
+
+// 对Debug和Info日志采样的日志对象, 用于限制热点路径的日志量
+// 在每个tick内, 相同级别和消息的日志输出前first条, 之后每thereafter条输出1条
+// thereafter为0时丢弃其余的日志, Warning和Error日志不采样
+type SampledLogger struct {
+	logger     ILogger
+	tick       time.Duration
+	first      uint64
+	thereafter uint64
+
+	mutex    sync.Mutex
+	counters map[sampleKey]*sampleCounter
+}
+
+type sampleKey struct {
+	level Level
+	msg   string
+}
+
+type sampleCounter struct {
+	resetAt time.Time
+	count   uint64
+}
+
+func NewSampledLogger(logger ILogger, tick time.Duration, first uint64, thereafter uint64) *SampledLogger {
+	return &SampledLogger{
+		logger:     logger,
+		tick:       tick,
+		first:      first,
+		thereafter: thereafter,
+		counters:   make(map[sampleKey]*sampleCounter),
+	}
+}
+
+func (logger *SampledLogger) Enabled(level Level) bool {
+	return logger.logger.Enabled(level)
+}
+
+func (logger *SampledLogger) Log(level Level, msg string, fields []Field) {
+	if level > Level_Info || logger.sample(level, msg) {
+		logger.logger.Log(level, msg, fields)
+	}
+}
+
+// 检查日志是否需要输出
+func (logger *SampledLogger) sample(level Level, msg string) bool {
+	now := time.Now()
+
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+
+	key := sampleKey{level: level, msg: msg}
+	counter, ok := logger.counters[key]
+	if !ok || !now.Before(counter.resetAt) {
+		counter = &sampleCounter{resetAt: now.Add(logger.tick)}
+		logger.counters[key] = counter
+	}
+
+	counter.count++
+	if counter.count <= logger.first {
+		return true
+	}
+
+	return logger.thereafter > 0 && (counter.count-logger.first)%logger.thereafter == 0
+}
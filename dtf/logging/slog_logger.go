@@ -0,0 +1,48 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+)
+
+// 输出到slog的日志对象, 字段作为slog的属性
+type SlogLogger struct {
+	logger *slog.Logger
+}
+
+func NewSlogLogger(logger *slog.Logger) *SlogLogger {
+	return &SlogLogger{logger: logger}
+}
+
+// 创建以JSON行输出到writer的日志对象, 低于level的日志不输出
+func NewJSONLogger(writer io.Writer, level Level) *SlogLogger {
+	handler := slog.NewJSONHandler(writer, &slog.HandlerOptions{Level: slogLevel(level)})
+	return NewSlogLogger(slog.New(handler))
+}
+
+func (logger *SlogLogger) Enabled(level Level) bool {
+	return logger.logger.Enabled(context.Background(), slogLevel(level))
+}
+
+func (logger *SlogLogger) Log(level Level, msg string, fields []Field) {
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, field := range fields {
+		attrs = append(attrs, slog.Any(field.Key, field.Value))
+	}
+
+	logger.logger.LogAttrs(context.Background(), slogLevel(level), msg, attrs...)
+}
+
+func slogLevel(level Level) slog.Level {
+	switch level {
+	case Level_Debug:
+		return slog.LevelDebug
+	case Level_Info:
+		return slog.LevelInfo
+	case Level_Warning:
+		return slog.LevelWarn
+	}
+
+	return slog.LevelError
+}
@@ -0,0 +1,114 @@
+package serversupport
+
+import (
+	"sync"
+
+	"github.com/golang/glog"
+
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+)
+
+// 进程内channel的默认缓冲长度
+const DefaultChannelBufferSize = 1024
+
+// 进程内的执行器连接, 通过channel将子任务转交给执行器, 不经过HTTP
+// 用于在同一进程中运行调度器和执行器
+type ChannelExecutor struct {
+	requests chan []taskmodel.SubtaskBody
+	once     sync.Once
+}
+
+func NewChannelExecutor(bufferSize int) *ChannelExecutor {
+	if bufferSize <= 0 {
+		bufferSize = DefaultChannelBufferSize
+	}
+
+	return &ChannelExecutor{
+		requests: make(chan []taskmodel.SubtaskBody, bufferSize),
+	}
+}
+
+// return an invoker function
+// for scheduler to invoke the executor
+// channel已满时返回错误, 由调度器放入重试队列
+func (e *ChannelExecutor) GetInvoker() taskmodel.ExecutorInvoker {
+	return func(subtasks []taskmodel.SubtaskBody) error {
+		select {
+		case e.requests <- subtasks:
+			return nil
+		default:
+			glog.Warning("executor channel is full: ", len(subtasks))
+			return errordef.ErrOperationFailed
+		}
+	}
+}
+
+// for executor
+// to register a handler to process executor requests
+func (e *ChannelExecutor) GetRegister() taskmodel.RegisterExecutorRequestHandler {
+	return func(handler taskmodel.ExecutorRequestHandler) error {
+		e.once.Do(func() {
+			go func() {
+				for subtasks := range e.requests {
+					err := handler(subtasks)
+					if err != nil {
+						glog.Warning("failed to handle executor request: ", err)
+					}
+				}
+			}()
+		})
+
+		return nil
+	}
+}
+
+// 进程内的采集器连接, 通过channel将子任务结果转交给采集器, 不经过HTTP
+// 用于在同一进程中运行执行器和采集器
+type ChannelCollector struct {
+	results chan []taskmodel.SubtaskResult
+	once    sync.Once
+}
+
+func NewChannelCollector(bufferSize int) *ChannelCollector {
+	if bufferSize <= 0 {
+		bufferSize = DefaultChannelBufferSize
+	}
+
+	return &ChannelCollector{
+		results: make(chan []taskmodel.SubtaskResult, bufferSize),
+	}
+}
+
+// return an invoker function
+// for executor to invoke collector
+func (c *ChannelCollector) GetInvoker() taskmodel.CollectorInvoker {
+	return func(results []taskmodel.SubtaskResult) error {
+		select {
+		case c.results <- results:
+			return nil
+		default:
+			glog.Warning("collector channel is full: ", len(results))
+			return errordef.ErrOperationFailed
+		}
+	}
+}
+
+// for collector
+// to register a handler to process collector requests
+func (c *ChannelCollector) GetRegister() taskmodel.RegisterCollectorRequestHandler {
+	return func(handler taskmodel.CollectorRequestHandler) error {
+		c.once.Do(func() {
+			go func() {
+				for results := range c.results {
+					err := handler(results)
+					if err != nil {
+						glog.Warning("failed to handle collector request: ", err)
+					}
+				}
+			}()
+		})
+
+		return nil
+	}
+}
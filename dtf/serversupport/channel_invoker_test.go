@@ -0,0 +1,63 @@
+package serversupport
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+)
+
+func Test_ChannelExecutor_Success(t *testing.T) {
+	channel := NewChannelExecutor(1)
+	received := make(chan []taskmodel.SubtaskBody, 1)
+	channel.GetRegister()(func(subtasks []taskmodel.SubtaskBody) error {
+		received <- subtasks
+		return nil
+	})
+
+	err := channel.GetInvoker()([]taskmodel.SubtaskBody{{SubtaskId: 1}})
+
+	Convey("the handler receives the subtasks", t, func() {
+		So(err, ShouldBeNil)
+		select {
+		case subtasks := <-received:
+			So(subtasks[0].SubtaskId, ShouldEqual, 1)
+		case <-time.After(time.Second):
+			So("timeout", ShouldBeEmpty)
+		}
+	})
+}
+
+func Test_ChannelExecutor_Full(t *testing.T) {
+	channel := NewChannelExecutor(1)
+	invoker := channel.GetInvoker()
+
+	Convey("invoke fails when the channel is full", t, func() {
+		So(invoker([]taskmodel.SubtaskBody{}), ShouldBeNil)
+		So(invoker([]taskmodel.SubtaskBody{}), ShouldEqual, errordef.ErrOperationFailed)
+	})
+}
+
+func Test_ChannelCollector_Success(t *testing.T) {
+	channel := NewChannelCollector(1)
+	received := make(chan []taskmodel.SubtaskResult, 1)
+	channel.GetRegister()(func(results []taskmodel.SubtaskResult) error {
+		received <- results
+		return nil
+	})
+
+	err := channel.GetInvoker()([]taskmodel.SubtaskResult{{SubtaskId: 2}})
+
+	Convey("the handler receives the results", t, func() {
+		So(err, ShouldBeNil)
+		select {
+		case results := <-received:
+			So(results[0].SubtaskId, ShouldEqual, 2)
+		case <-time.After(time.Second):
+			So("timeout", ShouldBeEmpty)
+		}
+	})
+}
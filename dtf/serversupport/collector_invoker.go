@@ -3,6 +3,7 @@ package serversupport
 import (
 	"encoding/json"
 
+	"github.com/danenmao/pterergate-dtf/dtf/auth"
 	"github.com/danenmao/pterergate-dtf/dtf/errordef"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
 )
@@ -13,7 +14,7 @@ type CollectorInvoker struct {
 
 func NewCollectorInvoker(serverHost string, serverPort uint16, user string) *CollectorInvoker {
 	return &CollectorInvoker{
-		InvokerBase: NewInvokerBase(serverHost, serverPort, CollectorServerURI, user),
+		InvokerBase: newInvokerBase(serverHost, serverPort, CollectorServerURI, user, auth.Audience_Collector),
 	}
 }
 
@@ -35,6 +36,12 @@ func (c *CollectorInvoker) invoke(results []taskmodel.SubtaskResult) error {
 		return errordef.ErrOperationFailed
 	}
 
-	_, err = c.client.Post(c.url, c.UserName, string(data))
+	// send the trace context of the first result
+	traceParent := ""
+	if len(results) > 0 {
+		traceParent = results[0].TraceParent
+	}
+
+	_, err = c.client.PostWithTrace(c.url, c.UserName, string(data), traceParent)
 	return err
 }
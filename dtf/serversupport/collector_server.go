@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 
+	"github.com/danenmao/pterergate-dtf/dtf/auth"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
 	"github.com/danenmao/pterergate-dtf/internal/serverhelper"
 )
@@ -24,7 +25,7 @@ type CollectorServer struct {
 
 func NewCollectorServer(handler taskmodel.CollectorRequestHandler) *CollectorServer {
 	return &CollectorServer{
-		ServerBase: NewServerBase(),
+		ServerBase: newServerBase(auth.Audience_Collector),
 		handler:    handler,
 	}
 }
@@ -3,6 +3,7 @@ package serversupport
 import (
 	"encoding/json"
 
+	"github.com/danenmao/pterergate-dtf/dtf/auth"
 	"github.com/danenmao/pterergate-dtf/dtf/errordef"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
 )
@@ -13,7 +14,7 @@ type ExecutorInvoker struct {
 
 func NewExecutorInvoker(serverHost string, serverPort uint16, user string) *ExecutorInvoker {
 	return &ExecutorInvoker{
-		InvokerBase: NewInvokerBase(serverHost, serverPort, ExecutorServerURI, user),
+		InvokerBase: newInvokerBase(serverHost, serverPort, ExecutorServerURI, user, auth.Audience_Executor),
 	}
 }
 
@@ -36,6 +37,12 @@ func (e *ExecutorInvoker) invoke(subtasks []taskmodel.SubtaskBody) error {
 		return errordef.ErrOperationFailed
 	}
 
-	_, err = e.client.Post(e.url, e.UserName, string(data))
+	// the subtasks belong to the same task, send the trace context of the first one
+	traceParent := ""
+	if len(subtasks) > 0 {
+		traceParent = subtasks[0].TraceParent
+	}
+
+	_, err = e.client.PostWithTrace(e.url, e.UserName, string(data), traceParent)
 	return err
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 
+	"github.com/danenmao/pterergate-dtf/dtf/auth"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
 	"github.com/danenmao/pterergate-dtf/internal/serverhelper"
 )
@@ -21,7 +22,7 @@ type ExecutorServer struct {
 
 func NewExecutorServer(handler taskmodel.ExecutorRequestHandler) *ExecutorServer {
 	return &ExecutorServer{
-		ServerBase: NewServerBase(),
+		ServerBase: newServerBase(auth.Audience_Executor),
 		handler:    handler,
 	}
 }
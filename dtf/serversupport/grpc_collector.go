@@ -0,0 +1,158 @@
+package serversupport
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"google.golang.org/grpc"
+
+	"github.com/danenmao/pterergate-dtf/dtf/auth"
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/internal/rpcpb"
+)
+
+// gRPC的收集器服务, 接收执行器上报的子任务结果
+type GRPCCollectorServer struct {
+	*GRPCServerBase
+	rpcpb.UnimplementedCollectorServer
+	handler taskmodel.CollectorRequestHandler
+}
+
+func NewGRPCCollectorServer(handler taskmodel.CollectorRequestHandler) *GRPCCollectorServer {
+	return &GRPCCollectorServer{
+		GRPCServerBase: newGRPCServerBase(auth.Audience_Collector),
+		handler:        handler,
+	}
+}
+
+// for collector
+// to register a handler to process collector requests
+func (s *GRPCCollectorServer) GetRegister() taskmodel.RegisterCollectorRequestHandler {
+	return func(handler taskmodel.CollectorRequestHandler) error {
+		s.handler = handler
+		return nil
+	}
+}
+
+// start the collector server to receive requests
+func (s *GRPCCollectorServer) Serve(serverPort uint16) error {
+	return s.serve(serverPort, func(server *grpc.Server) {
+		rpcpb.RegisterCollectorServer(server, s)
+	})
+}
+
+func (s *GRPCCollectorServer) Report(ctx context.Context, req *rpcpb.ReportRequest) (*rpcpb.ReportResponse, error) {
+	return s.handleRequest(req), nil
+}
+
+func (s *GRPCCollectorServer) ReportStream(stream rpcpb.Collector_ReportStreamServer) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		err = stream.Send(s.handleRequest(req))
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *GRPCCollectorServer) handleRequest(req *rpcpb.ReportRequest) *rpcpb.ReportResponse {
+	rsp := &rpcpb.ReportResponse{BatchId: req.GetBatchId()}
+	if s.handler == nil {
+		rsp.Error = "no collector handler"
+		return rsp
+	}
+
+	err := s.handler(rpcpb.ToSubtaskResults(req.GetResults()))
+	if err != nil {
+		rsp.Error = err.Error()
+	}
+
+	return rsp
+}
+
+// gRPC的收集器调用方, 用于执行器上报子任务结果
+type GRPCCollectorInvoker struct {
+	*GRPCInvokerBase
+	stream *batchStream[*rpcpb.ReportRequest, *rpcpb.ReportResponse]
+}
+
+func NewGRPCCollectorInvoker(serverHost string, serverPort uint16, user string) *GRPCCollectorInvoker {
+	c := &GRPCCollectorInvoker{
+		GRPCInvokerBase: newGRPCInvokerBase(serverHost, serverPort, user, auth.Audience_Collector),
+	}
+
+	c.stream = newBatchStream(c.openStream)
+	return c
+}
+
+// return an invoker function
+// for executor to invoke collector
+func (c *GRPCCollectorInvoker) GetInvoker() taskmodel.CollectorInvoker {
+	return func(results []taskmodel.SubtaskResult) error {
+		return c.invoke(results)
+	}
+}
+
+// 关闭流和连接
+func (c *GRPCCollectorInvoker) Close() error {
+	c.stream.close()
+	return c.GRPCInvokerBase.Close()
+}
+
+func (c *GRPCCollectorInvoker) invoke(results []taskmodel.SubtaskResult) error {
+	pbResults := rpcpb.FromSubtaskResults(results)
+	if c.Streaming {
+		return c.stream.call(c.Timeout, func(batchId uint64) *rpcpb.ReportRequest {
+			return &rpcpb.ReportRequest{BatchId: batchId, Results: pbResults}
+		})
+	}
+
+	conn, err := c.getConn()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
+	defer cancel()
+
+	ctx, err = c.outgoingContext(ctx, rpcpb.Collector_Report_FullMethodName)
+	if err != nil {
+		return err
+	}
+
+	rsp, err := rpcpb.NewCollectorClient(conn).Report(ctx, &rpcpb.ReportRequest{Results: pbResults})
+	if err != nil {
+		return err
+	}
+
+	if len(rsp.GetError()) > 0 {
+		return errors.New(rsp.GetError())
+	}
+
+	return nil
+}
+
+func (c *GRPCCollectorInvoker) openStream(
+	ctx context.Context,
+) (batchClientStream[*rpcpb.ReportRequest, *rpcpb.ReportResponse], error) {
+	conn, err := c.getConn()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, err = c.outgoingContext(ctx, rpcpb.Collector_ReportStream_FullMethodName)
+	if err != nil {
+		return nil, err
+	}
+
+	return rpcpb.NewCollectorClient(conn).ReportStream(ctx)
+}
@@ -0,0 +1,158 @@
+package serversupport
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"google.golang.org/grpc"
+
+	"github.com/danenmao/pterergate-dtf/dtf/auth"
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/internal/rpcpb"
+)
+
+// gRPC的执行器服务, 接收调度器分发的子任务
+type GRPCExecutorServer struct {
+	*GRPCServerBase
+	rpcpb.UnimplementedExecutorServer
+	handler taskmodel.ExecutorRequestHandler
+}
+
+func NewGRPCExecutorServer(handler taskmodel.ExecutorRequestHandler) *GRPCExecutorServer {
+	return &GRPCExecutorServer{
+		GRPCServerBase: newGRPCServerBase(auth.Audience_Executor),
+		handler:        handler,
+	}
+}
+
+// for executor
+// to register a handler to process executor requests
+func (s *GRPCExecutorServer) GetRegister() taskmodel.RegisterExecutorRequestHandler {
+	return func(handler taskmodel.ExecutorRequestHandler) error {
+		s.handler = handler
+		return nil
+	}
+}
+
+// start the executor server to receive requests
+func (s *GRPCExecutorServer) Serve(serverPort uint16) error {
+	return s.serve(serverPort, func(server *grpc.Server) {
+		rpcpb.RegisterExecutorServer(server, s)
+	})
+}
+
+func (s *GRPCExecutorServer) Dispatch(ctx context.Context, req *rpcpb.DispatchRequest) (*rpcpb.DispatchResponse, error) {
+	return s.handleRequest(req), nil
+}
+
+func (s *GRPCExecutorServer) DispatchStream(stream rpcpb.Executor_DispatchStreamServer) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		err = stream.Send(s.handleRequest(req))
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *GRPCExecutorServer) handleRequest(req *rpcpb.DispatchRequest) *rpcpb.DispatchResponse {
+	rsp := &rpcpb.DispatchResponse{BatchId: req.GetBatchId()}
+	if s.handler == nil {
+		rsp.Error = "no executor handler"
+		return rsp
+	}
+
+	err := s.handler(rpcpb.ToSubtaskBodies(req.GetSubtasks()))
+	if err != nil {
+		rsp.Error = err.Error()
+	}
+
+	return rsp
+}
+
+// gRPC的执行器调用方, 用于调度器分发子任务
+type GRPCExecutorInvoker struct {
+	*GRPCInvokerBase
+	stream *batchStream[*rpcpb.DispatchRequest, *rpcpb.DispatchResponse]
+}
+
+func NewGRPCExecutorInvoker(serverHost string, serverPort uint16, user string) *GRPCExecutorInvoker {
+	e := &GRPCExecutorInvoker{
+		GRPCInvokerBase: newGRPCInvokerBase(serverHost, serverPort, user, auth.Audience_Executor),
+	}
+
+	e.stream = newBatchStream(e.openStream)
+	return e
+}
+
+// return an invoker function
+// for scheduler to invoke the executor
+func (e *GRPCExecutorInvoker) GetInvoker() taskmodel.ExecutorInvoker {
+	return func(subtasks []taskmodel.SubtaskBody) error {
+		return e.invoke(subtasks)
+	}
+}
+
+// 关闭流和连接
+func (e *GRPCExecutorInvoker) Close() error {
+	e.stream.close()
+	return e.GRPCInvokerBase.Close()
+}
+
+func (e *GRPCExecutorInvoker) invoke(subtasks []taskmodel.SubtaskBody) error {
+	bodies := rpcpb.FromSubtaskBodies(subtasks)
+	if e.Streaming {
+		return e.stream.call(e.Timeout, func(batchId uint64) *rpcpb.DispatchRequest {
+			return &rpcpb.DispatchRequest{BatchId: batchId, Subtasks: bodies}
+		})
+	}
+
+	conn, err := e.getConn()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.Timeout)
+	defer cancel()
+
+	ctx, err = e.outgoingContext(ctx, rpcpb.Executor_Dispatch_FullMethodName)
+	if err != nil {
+		return err
+	}
+
+	rsp, err := rpcpb.NewExecutorClient(conn).Dispatch(ctx, &rpcpb.DispatchRequest{Subtasks: bodies})
+	if err != nil {
+		return err
+	}
+
+	if len(rsp.GetError()) > 0 {
+		return errors.New(rsp.GetError())
+	}
+
+	return nil
+}
+
+func (e *GRPCExecutorInvoker) openStream(
+	ctx context.Context,
+) (batchClientStream[*rpcpb.DispatchRequest, *rpcpb.DispatchResponse], error) {
+	conn, err := e.getConn()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, err = e.outgoingContext(ctx, rpcpb.Executor_DispatchStream_FullMethodName)
+	if err != nil {
+		return nil, err
+	}
+
+	return rpcpb.NewExecutorClient(conn).DispatchStream(ctx)
+}
@@ -0,0 +1,116 @@
+package serversupport
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/danenmao/pterergate-dtf/dtf/auth"
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/internal/rpcpb"
+	"github.com/danenmao/pterergate-dtf/internal/serverhelper"
+)
+
+func Test_GRPCExecutor_Invoke(t *testing.T) {
+	var received int32
+	svr := NewGRPCExecutorServer(nil)
+	svr.GetRegister()(func(subtasks []taskmodel.SubtaskBody) error {
+		atomic.AddInt32(&received, int32(len(subtasks)))
+		return nil
+	})
+
+	go func() {
+		svr.Serve(8092)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	subtasks := []taskmodel.SubtaskBody{{SubtaskId: 1, TaskId: 2, TaskType: 3, TypeParam: "{}"}}
+	streamInvoker := NewGRPCExecutorInvoker("localhost", 8092, "test-invoker")
+	streamErr1 := streamInvoker.GetInvoker()(subtasks)
+	streamErr2 := streamInvoker.GetInvoker()(subtasks)
+	streamInvoker.Close()
+
+	unaryInvoker := NewGRPCExecutorInvoker("localhost", 8092, "test-invoker")
+	unaryInvoker.Streaming = false
+	unaryErr := unaryInvoker.GetInvoker()(subtasks)
+	unaryInvoker.Close()
+
+	// the collector token is not accepted by the executor
+	wrongInvoker := NewGRPCExecutorInvoker("localhost", 8092, "test-invoker")
+	wrongInvoker.SetAuthenticator(auth.NewJWTAuthenticator(auth.Audience_Collector))
+	wrongErr := wrongInvoker.GetInvoker()(subtasks)
+	wrongInvoker.Close()
+	svr.Shutdown()
+
+	Convey("send subtasks through grpc", t, func() {
+		Convey("should be received by the executor", func() {
+			So(streamErr1, ShouldBeNil)
+			So(streamErr2, ShouldBeNil)
+			So(unaryErr, ShouldBeNil)
+			So(wrongErr, ShouldNotBeNil)
+			So(atomic.LoadInt32(&received), ShouldEqual, 3)
+		})
+	})
+}
+
+func Test_GRPCServerBase_AuthenticateStream(t *testing.T) {
+	method := rpcpb.Executor_DispatchStream_FullMethodName
+	invoker := newGRPCInvokerBase("localhost", 8092, "test-invoker", auth.Audience_Executor)
+	ctx, signErr := invoker.outgoingContext(context.Background(), method)
+	md, _ := metadata.FromOutgoingContext(ctx)
+	incoming := metadata.NewIncomingContext(context.Background(), md)
+
+	svr := newGRPCServerBase(auth.Audience_Executor)
+	firstErr := svr.authenticate(incoming, method)
+	replayErr := svr.authenticate(incoming, method)
+
+	// 替换时间戳和请求ID后, 签名不再匹配
+	forged := md.Copy()
+	forged.Set(GRPCMetadata_Timestamp, serverhelper.FormatTimestamp(time.Now().Add(time.Second)))
+	forged.Set(GRPCMetadata_RequestId, "forged")
+	forgedErr := newGRPCServerBase(auth.Audience_Executor).authenticate(
+		metadata.NewIncomingContext(context.Background(), forged), method)
+
+	Convey("the stream credential is bound to its timestamp and request id", t, func() {
+		So(signErr, ShouldBeNil)
+		So(firstErr, ShouldBeNil)
+		So(replayErr, ShouldNotBeNil)
+		So(forgedErr, ShouldNotBeNil)
+	})
+}
+
+func Test_GRPCCollector_Invoke(t *testing.T) {
+	var results []taskmodel.SubtaskResult
+	svr := NewGRPCCollectorServer(nil)
+	svr.SetAuthenticator(auth.NewStaticTokenAuthenticator("token"))
+	svr.GetRegister()(func(r []taskmodel.SubtaskResult) error {
+		results = append(results, r...)
+		return nil
+	})
+
+	go func() {
+		svr.Serve(8092)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	invoker := NewGRPCCollectorInvoker("localhost", 8092, "test-invoker")
+	invoker.SetAuthenticator(auth.NewStaticTokenAuthenticator("token"))
+	err := invoker.GetInvoker()([]taskmodel.SubtaskResult{
+		{SubtaskId: 1, TaskId: 2, Result: taskmodel.SubtaskResult_Success, ResultMsg: "success"},
+	})
+	invoker.Close()
+	svr.Shutdown()
+
+	Convey("report results through grpc", t, func() {
+		Convey("should be received by the collector", func() {
+			So(err, ShouldBeNil)
+			So(len(results), ShouldEqual, 1)
+			So(results[0].SubtaskId, ShouldEqual, 1)
+			So(results[0].ResultMsg, ShouldEqual, "success")
+		})
+	})
+}
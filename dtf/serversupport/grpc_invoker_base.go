@@ -0,0 +1,283 @@
+package serversupport
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/danenmao/pterergate-dtf/dtf/auth"
+	"github.com/danenmao/pterergate-dtf/dtf/extconfig"
+	"github.com/danenmao/pterergate-dtf/internal/serverhelper"
+)
+
+// gRPC调用的默认超时
+const DefaultGRPCCallTimeout = 10 * time.Second
+
+// gRPC调用方的公共部分
+// Streaming为true时在一个长期的流上发送批次, 否则每批一次调用
+type GRPCInvokerBase struct {
+	ServerHost    string
+	ServerPort    uint16
+	UserName      string
+	Timeout       time.Duration // 每批的超时
+	Streaming     bool
+	Compression   string // 消息的压缩编码, 为空时不压缩
+	MaxBodySize   int64  // 发送和接收消息的大小上限
+	authenticator auth.IAuthenticator
+	tlsConfig     *tls.Config
+	lock          sync.Mutex
+	conn          *grpc.ClientConn
+}
+
+func newGRPCInvokerBase(serverHost string, serverPort uint16, user string, audience string) *GRPCInvokerBase {
+	return &GRPCInvokerBase{
+		ServerHost:    serverHost,
+		ServerPort:    serverPort,
+		UserName:      user,
+		Timeout:       DefaultGRPCCallTimeout,
+		Streaming:     true,
+		Compression:   gzip.Name,
+		MaxBodySize:   serverhelper.DefaultMaxBodySize,
+		authenticator: auth.NewJWTAuthenticator(audience),
+	}
+}
+
+// 设置认证器, 需与服务端一致
+func (i *GRPCInvokerBase) SetAuthenticator(authenticator auth.IAuthenticator) {
+	i.authenticator = authenticator
+}
+
+// 使用TLS连接服务, 配置了证书时用于mTLS. 需在第一次调用前设置
+func (i *GRPCInvokerBase) SetTLS(cfg *extconfig.TLSConfig) error {
+	tlsConfig, err := serverhelper.NewClientTLSConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	i.tlsConfig = tlsConfig
+	return nil
+}
+
+// 关闭连接
+func (i *GRPCInvokerBase) Close() error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if i.conn == nil {
+		return nil
+	}
+
+	err := i.conn.Close()
+	i.conn = nil
+	return err
+}
+
+// 获取连接, 第一次调用时建立
+func (i *GRPCInvokerBase) getConn() (*grpc.ClientConn, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if i.conn != nil {
+		return i.conn, nil
+	}
+
+	creds := insecure.NewCredentials()
+	if i.tlsConfig != nil {
+		creds = credentials.NewTLS(i.tlsConfig)
+	}
+
+	callOpts := []grpc.CallOption{
+		grpc.MaxCallRecvMsgSize(int(i.MaxBodySize)),
+		grpc.MaxCallSendMsgSize(int(i.MaxBodySize)),
+	}
+	if i.Compression != "" {
+		callOpts = append(callOpts, grpc.UseCompressor(i.Compression))
+	}
+
+	conn, err := grpc.Dial(fmt.Sprintf("%s:%d", i.ServerHost, i.ServerPort),
+		grpc.WithTransportCredentials(creds), grpc.WithDefaultCallOptions(callOpts...))
+	if err != nil {
+		return nil, err
+	}
+
+	i.conn = conn
+	return conn, nil
+}
+
+// 为调用或流附加凭证, 凭证签名方法名和每次不同的时间戳、请求ID
+func (i *GRPCInvokerBase) outgoingContext(ctx context.Context, method string) (context.Context, error) {
+	req, err := http.NewRequest(http.MethodPost, method, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp, requestId := serverhelper.FormatTimestamp(time.Now()), uuid.NewString()
+	err = i.authenticator.Sign(req, i.UserName, grpcCallHash(method, timestamp, requestId))
+	if err != nil {
+		return nil, err
+	}
+
+	md := metadata.Pairs(
+		GRPCMetadata_Timestamp, timestamp,
+		GRPCMetadata_RequestId, requestId,
+	)
+	for key, values := range req.Header {
+		md.Append(strings.ToLower(key), values...)
+	}
+
+	return metadata.NewOutgoingContext(ctx, md), nil
+}
+
+// 带批次ID的应答
+type batchResponse interface {
+	GetBatchId() uint64
+	GetError() string
+}
+
+// 双向流的客户端
+type batchClientStream[Req any, Rsp batchResponse] interface {
+	Send(Req) error
+	Recv() (Rsp, error)
+	CloseSend() error
+}
+
+// 在一个流上发送批次, 按批次ID匹配应答
+// 流断开时, 等待应答的批次都返回错误, 下一个批次重新建立流
+type batchStream[Req any, Rsp batchResponse] struct {
+	open    func(ctx context.Context) (batchClientStream[Req, Rsp], error)
+	lock    sync.Mutex
+	stream  batchClientStream[Req, Rsp]
+	cancel  context.CancelFunc
+	pending map[uint64]chan error
+	nextId  uint64
+}
+
+func newBatchStream[Req any, Rsp batchResponse](
+	open func(ctx context.Context) (batchClientStream[Req, Rsp], error),
+) *batchStream[Req, Rsp] {
+	return &batchStream[Req, Rsp]{
+		open:    open,
+		pending: map[uint64]chan error{},
+	}
+}
+
+// 发送一个批次并等待应答, build生成指定批次ID的请求
+func (s *batchStream[Req, Rsp]) call(timeout time.Duration, build func(batchId uint64) Req) error {
+	s.lock.Lock()
+	stream, err := s.getStream()
+	if err != nil {
+		s.lock.Unlock()
+		return err
+	}
+
+	s.nextId++
+	batchId := s.nextId
+	done := make(chan error, 1)
+	s.pending[batchId] = done
+
+	err = stream.Send(build(batchId))
+	if err != nil {
+		s.reset(stream, err)
+		s.lock.Unlock()
+		return err
+	}
+	s.lock.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case err = <-done:
+		return err
+	case <-timer.C:
+		s.lock.Lock()
+		delete(s.pending, batchId)
+		s.lock.Unlock()
+		return status.Error(codes.DeadlineExceeded, "batch timeout")
+	}
+}
+
+// 获取流, 需持有锁
+func (s *batchStream[Req, Rsp]) getStream() (batchClientStream[Req, Rsp], error) {
+	if s.stream != nil {
+		return s.stream, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := s.open(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	s.stream, s.cancel = stream, cancel
+	go s.receive(stream)
+	return stream, nil
+}
+
+// 接收应答, 通知等待的批次
+func (s *batchStream[Req, Rsp]) receive(stream batchClientStream[Req, Rsp]) {
+	for {
+		rsp, err := stream.Recv()
+		if err != nil {
+			s.lock.Lock()
+			s.reset(stream, err)
+			s.lock.Unlock()
+			return
+		}
+
+		s.lock.Lock()
+		done, ok := s.pending[rsp.GetBatchId()]
+		delete(s.pending, rsp.GetBatchId())
+		s.lock.Unlock()
+
+		if !ok {
+			continue
+		}
+
+		if len(rsp.GetError()) > 0 {
+			done <- errors.New(rsp.GetError())
+		} else {
+			done <- nil
+		}
+	}
+}
+
+// 关闭出错的流, 需持有锁
+func (s *batchStream[Req, Rsp]) reset(stream batchClientStream[Req, Rsp], err error) {
+	if s.stream != stream {
+		return
+	}
+
+	s.cancel()
+	s.stream, s.cancel = nil, nil
+	for batchId, done := range s.pending {
+		done <- err
+		delete(s.pending, batchId)
+	}
+}
+
+// 关闭流
+func (s *batchStream[Req, Rsp]) close() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.stream != nil {
+		s.stream.CloseSend()
+		s.reset(s.stream, errors.New("stream closed"))
+	}
+}
@@ -0,0 +1,202 @@
+package serversupport
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	_ "google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/danenmao/pterergate-dtf/dtf/auth"
+	"github.com/danenmao/pterergate-dtf/dtf/extconfig"
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
+	"github.com/danenmao/pterergate-dtf/internal/exitctrl"
+	"github.com/danenmao/pterergate-dtf/internal/metrics"
+	"github.com/danenmao/pterergate-dtf/internal/serverhelper"
+)
+
+// gRPC请求的元数据
+const (
+	GRPCMetadata_Timestamp = "x-dtf-timestamp"
+	GRPCMetadata_RequestId = "x-dtf-request-id"
+)
+
+// 连接的最长存活时间, 到期后客户端重新建立连接和流, 使用新的凭证
+const GRPCMaxConnectionAge = 30 * time.Minute
+
+// gRPC服务的公共部分
+// 每个调用或流在建立时认证一次, 之后流上的批次不再签名
+type GRPCServerBase struct {
+	server        *grpc.Server
+	authenticator auth.IAuthenticator
+	replayGuard   *auth.ReplayGuard
+	tlsConfig     *tls.Config
+	maxBodySize   int64
+}
+
+func newGRPCServerBase(audience string) *GRPCServerBase {
+	return &GRPCServerBase{
+		authenticator: auth.NewJWTAuthenticator(audience),
+		replayGuard:   auth.NewMemoryReplayGuard(),
+		maxBodySize:   serverhelper.DefaultMaxBodySize,
+	}
+}
+
+// 设置认证器, 默认使用JWT认证
+func (s *GRPCServerBase) SetAuthenticator(authenticator auth.IAuthenticator) {
+	s.authenticator = authenticator
+}
+
+// 设置防重放检查, 为nil时不检查
+func (s *GRPCServerBase) SetReplayGuard(replayGuard *auth.ReplayGuard) {
+	s.replayGuard = replayGuard
+}
+
+// 使用TLS, 配置了CA时要求客户端证书
+func (s *GRPCServerBase) SetTLS(cfg *extconfig.TLSConfig) error {
+	tlsConfig, err := serverhelper.NewServerTLSConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	s.tlsConfig = tlsConfig
+	return nil
+}
+
+// 设置接收消息的大小上限, 默认为serverhelper.DefaultMaxBodySize
+func (s *GRPCServerBase) SetMaxBodySize(maxBodySize int64) {
+	s.maxBodySize = maxBodySize
+}
+
+func (s *GRPCServerBase) serve(serverPort uint16, register func(server *grpc.Server)) error {
+	opts := []grpc.ServerOption{
+		grpc.UnaryInterceptor(s.unaryInterceptor),
+		grpc.StreamInterceptor(s.streamInterceptor),
+		grpc.KeepaliveParams(keepalive.ServerParameters{MaxConnectionAge: GRPCMaxConnectionAge}),
+		grpc.MaxRecvMsgSize(int(s.maxBodySize)),
+	}
+
+	if s.tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(s.tlsConfig)))
+	}
+
+	s.server = grpc.NewServer(opts...)
+	register(s.server)
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", serverPort))
+	if err != nil {
+		logging.Error("failed to listen", logging.Any("port", serverPort), logging.Err(err))
+		return err
+	}
+
+	exitctrl.AddExitRoutine(func() {
+		s.Shutdown()
+	})
+
+	err = s.server.Serve(listener)
+	if err != nil && err != grpc.ErrServerStopped {
+		logging.Error("failed to run the grpc server", logging.Err(err))
+		return err
+	}
+
+	return nil
+}
+
+func (s *GRPCServerBase) Shutdown() error {
+	if s.server != nil {
+		s.server.GracefulStop()
+	}
+
+	return nil
+}
+
+func (s *GRPCServerBase) unaryInterceptor(
+	ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (interface{}, error) {
+	err := s.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+func (s *GRPCServerBase) streamInterceptor(
+	srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+) error {
+	err := s.authenticate(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, stream)
+}
+
+// 用元数据和TLS连接状态构造HTTP请求, 复用HTTP服务的认证器
+func (s *GRPCServerBase) authenticate(ctx context.Context, method string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	req := &http.Request{
+		Method: http.MethodPost,
+		URL:    &url.URL{Path: method},
+		Header: http.Header{},
+	}
+
+	for key, values := range md {
+		req.Header[textproto.CanonicalMIMEHeaderKey(key)] = values
+	}
+
+	if p, ok := peer.FromContext(ctx); ok {
+		if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			req.TLS = &tlsInfo.State
+		}
+	}
+
+	identity, err := s.authenticator.Authenticate(req)
+	if err != nil {
+		logging.Warning("failed to authenticate the call", logging.Any("method", method), logging.Err(err))
+		metrics.RequestRejections.Inc(method, metrics.RejectReason_Unauthenticated)
+		return status.Error(codes.Unauthenticated, "invalid authorization")
+	}
+
+	// JWT签名的是方法名、时间戳和请求ID, 时间戳和请求ID由防重放检查
+	timestamp, requestId := firstValue(md, GRPCMetadata_Timestamp), firstValue(md, GRPCMetadata_RequestId)
+	if len(identity.BodyHash) > 0 && identity.BodyHash != grpcCallHash(method, timestamp, requestId) {
+		metrics.RequestRejections.Inc(method, metrics.RejectReason_BodyHash)
+		return status.Error(codes.Unauthenticated, "the token is not for this method")
+	}
+
+	if s.replayGuard != nil {
+		err = serverhelper.CheckReplay(ctx, s.replayGuard, method, timestamp, requestId, identity)
+		if err != nil {
+			return status.Error(codes.Unauthenticated, "replayed or expired request")
+		}
+	}
+
+	return nil
+}
+
+// 凭证签名的内容, 每个调用或流的时间戳和请求ID不同
+func grpcCallHash(method string, timestamp string, requestId string) string {
+	return serverhelper.CalcMsgHash(method + "\n" + timestamp + "\n" + requestId)
+}
+
+func firstValue(md metadata.MD, key string) string {
+	values := md.Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
@@ -3,6 +3,8 @@ package serversupport
 import (
 	"fmt"
 
+	"github.com/danenmao/pterergate-dtf/dtf/auth"
+	"github.com/danenmao/pterergate-dtf/dtf/extconfig"
 	"github.com/danenmao/pterergate-dtf/internal/serverhelper"
 )
 
@@ -27,3 +29,37 @@ func NewInvokerBase(serverHost string, serverPort uint16, uri string, user strin
 	i.url = fmt.Sprintf("http://%s:%d%s", i.ServerHost, i.ServerPort, i.URI)
 	return i
 }
+
+// token的接收方为audience的调用方
+func newInvokerBase(serverHost string, serverPort uint16, uri string, user string, audience string) *InvokerBase {
+	i := NewInvokerBase(serverHost, serverPort, uri, user)
+	i.client.SetAuthenticator(auth.NewJWTAuthenticator(audience))
+	return i
+}
+
+// 设置认证器, 需与服务端一致
+func (i *InvokerBase) SetAuthenticator(authenticator auth.IAuthenticator) {
+	i.client.SetAuthenticator(authenticator)
+}
+
+// 设置请求的压缩编码: gzip, zstd, 为空时不压缩. 默认不压缩
+func (i *InvokerBase) SetCompression(encoding string) error {
+	return i.client.SetCompression(encoding)
+}
+
+// 设置请求和响应体的大小上限, 默认为serverhelper.DefaultMaxBodySize
+func (i *InvokerBase) SetMaxBodySize(maxBodySize int64) {
+	i.client.SetMaxBodySize(maxBodySize)
+}
+
+// 使用HTTPS访问服务, 配置了证书时用于mTLS
+func (i *InvokerBase) SetTLS(cfg *extconfig.TLSConfig) error {
+	tlsConfig, err := serverhelper.NewClientTLSConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	i.client.SetTLSConfig(tlsConfig)
+	i.url = fmt.Sprintf("https://%s:%d%s", i.ServerHost, i.ServerPort, i.URI)
+	return nil
+}
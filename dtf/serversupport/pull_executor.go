@@ -0,0 +1,404 @@
+package serversupport
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/internal/redistool"
+	"github.com/danenmao/pterergate-dtf/internal/routine"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
+	"github.com/danenmao/pterergate-dtf/internal/tasktool"
+)
+
+// 拉取模式的状态存储key
+const (
+	// 待拉取的子任务队列, 每个分组一个, list, 分组名作为hash tag
+	PullSubtaskQueuePrefix = "dtf.pull.subtask.queue."
+
+	// 已租用的子任务数据, 每个分组一个, zset, 按租约的到期时间排序, 与分组队列位于同一slot
+	PullLeasedSubtaskZsetPrefix = "dtf.pull.leased.subtask.list."
+
+	// 子任务的租约, 值为持有租约的执行器
+	PullSubtaskLeasePrefix = "dtf.pull.subtask.lease."
+)
+
+// 默认的分组, 所有子任务使用同一个队列
+const DefaultPullGroup = "default"
+
+// 拉取模式的默认参数
+const (
+	DefaultPullBatchSize         = 10
+	DefaultPullMaxLeased         = 100
+	DefaultPullInterval          = 200 * time.Millisecond
+	DefaultPullVisibilityTimeout = time.Minute
+	DefaultPullQueueTimeout      = time.Hour
+)
+
+// 拉取模式的执行器连接
+// 调度器将子任务放入状态存储中的分组队列, 执行器主动拉取并租用子任务, 不需要调度器能访问执行器.
+// 执行器在子任务执行期间续租, 执行器退出后租约到期, 仍在执行中的子任务放回队列
+type PullExecutor struct {
+	GroupOf           func(subtask *taskmodel.SubtaskBody) string // 调度器使用, 子任务所属的分组
+	Groups            []string                                    // 执行器使用, 拉取的分组
+	BatchSize         int                                         // 每次拉取的子任务数
+	MaxLeased         int                                         // 执行器同时租用的子任务上限
+	PollInterval      time.Duration                               // 队列为空时的拉取间隔
+	VisibilityTimeout time.Duration                               // 租约的有效期, 执行器每1/3有效期续租一次
+	QueueTimeout      time.Duration                               // 子任务在队列中等待的超时
+
+	owner  string // 租约的持有者标识
+	lock   sync.Mutex
+	leases map[taskmodel.SubtaskIdType]*pullLease // 本执行器租用的子任务
+	once   sync.Once
+}
+
+// 本执行器持有的租约
+type pullLease struct {
+	group    string
+	val      string
+	deadline time.Time // 子任务执行的超时时间
+}
+
+// 队列中的子任务
+type pullSubtaskData struct {
+	taskmodel.SubtaskBody
+	Group string `json:"group"`
+}
+
+// 创建拉取模式的执行器连接, 执行器拉取groups中的分组, 为空时拉取默认分组
+func NewPullExecutor(groups ...string) *PullExecutor {
+	if len(groups) == 0 {
+		groups = []string{DefaultPullGroup}
+	}
+
+	return &PullExecutor{
+		GroupOf:           func(*taskmodel.SubtaskBody) string { return DefaultPullGroup },
+		Groups:            groups,
+		BatchSize:         DefaultPullBatchSize,
+		MaxLeased:         DefaultPullMaxLeased,
+		PollInterval:      DefaultPullInterval,
+		VisibilityTimeout: DefaultPullVisibilityTimeout,
+		QueueTimeout:      DefaultPullQueueTimeout,
+		owner:             uuid.NewString(),
+		leases:            map[taskmodel.SubtaskIdType]*pullLease{},
+	}
+}
+
+// 按任务类型分组, 用于GroupOf
+func PullGroupByTaskType(subtask *taskmodel.SubtaskBody) string {
+	return TaskTypePullGroup(subtask.TaskType)
+}
+
+// 任务类型对应的分组名
+func TaskTypePullGroup(taskType uint32) string {
+	return strconv.FormatUint(uint64(taskType), 10)
+}
+
+// return an invoker function
+// for scheduler to put subtasks into the queues
+func (e *PullExecutor) GetInvoker() taskmodel.ExecutorInvoker {
+	return func(subtasks []taskmodel.SubtaskBody) error {
+		return e.enqueue(subtasks)
+	}
+}
+
+// for executor
+// to register a handler to process the pulled subtasks
+func (e *PullExecutor) GetRegister() taskmodel.RegisterExecutorRequestHandler {
+	return func(handler taskmodel.ExecutorRequestHandler) error {
+		e.once.Do(func() {
+			go routine.ExecRoutineWithInterval("PullSubtasks", func() {
+				e.pull(handler)
+			}, e.PollInterval)
+			go routine.ExecRoutineWithInterval("RenewSubtaskLeases", e.renewLeases, e.VisibilityTimeout/3)
+			go routine.ExecRoutineWithInterval("ReapSubtaskLeases", e.reapLeases, e.VisibilityTimeout/3)
+		})
+
+		return nil
+	}
+}
+
+// 将子任务放入分组队列, 在队列中等待的子任务按QueueTimeout超时
+func (e *PullExecutor) enqueue(subtasks []taskmodel.SubtaskBody) error {
+	queues := map[string][]interface{}{}
+	for i := range subtasks {
+		data := pullSubtaskData{SubtaskBody: subtasks[i], Group: e.GroupOf(&subtasks[i])}
+		val, err := json.Marshal(&data)
+		if err != nil {
+			return errordef.ErrOperationFailed
+		}
+
+		keyName := pullQueueKey(data.Group)
+		queues[keyName] = append(queues[keyName], string(val))
+	}
+
+	// 入队前设置, 否则可能覆盖执行器租用时设置的超时时间
+	deadline := time.Now().Add(e.QueueTimeout)
+	for i := range subtasks {
+		tasktool.SetSubtaskRunningDeadline(subtasks[i].SubtaskId, deadline)
+	}
+
+	batch := statestore.Default().Batch()
+	for keyName, vals := range queues {
+		batch.RPush(keyName, vals...)
+	}
+
+	err := batch.Exec(context.Background())
+	if err != nil {
+		logging.Warning("failed to push subtasks to the pull queues", logging.Err(err))
+		return err
+	}
+
+	return nil
+}
+
+// 从各分组拉取子任务, 直到队列为空或达到租用上限
+// 子任务在弹出的同时加入分组的租用集合, 执行器在加锁前退出时, 由租约回收放回队列
+func (e *PullExecutor) pull(handler taskmodel.ExecutorRequestHandler) {
+	for _, group := range e.Groups {
+		for {
+			count := e.MaxLeased - e.leasedCount()
+			if count > e.BatchSize {
+				count = e.BatchSize
+			}
+
+			if count <= 0 {
+				return
+			}
+
+			score := float64(time.Now().Add(e.VisibilityTimeout).Unix())
+			vals, err := statestore.Default().LPopToZSet(context.Background(), pullQueueKey(group),
+				pullLeasedKey(group), count, score)
+			if err != nil || len(vals) == 0 {
+				break
+			}
+
+			subtasks, leasedVals := e.lease(group, vals)
+			if len(subtasks) == 0 {
+				continue
+			}
+
+			err = handler(subtasks)
+			if err != nil {
+				logging.Warning("failed to handle the pulled subtasks", logging.Any("group", group), logging.Err(err))
+				e.release(group, subtasks, leasedVals)
+			}
+		}
+	}
+}
+
+func (e *PullExecutor) leasedCount() int {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return len(e.leases)
+}
+
+// 获取拉取的子任务的租约, 子任务的执行超时从租用时开始计算
+func (e *PullExecutor) lease(group string, vals []string) ([]taskmodel.SubtaskBody, []string) {
+	ctx := context.Background()
+	now := time.Now()
+	subtasks := []taskmodel.SubtaskBody{}
+	leasedVals := []string{}
+	for _, val := range vals {
+		data := pullSubtaskData{}
+		err := json.Unmarshal([]byte(val), &data)
+		if err != nil {
+			logging.Warning("failed to unmarshal the pulled subtask", logging.Any("data", val), logging.Err(err))
+			statestore.Default().ZRem(ctx, pullLeasedKey(group), val)
+			continue
+		}
+
+		// 未获取到租约时, 由租约回收处理
+		ok, err := statestore.Default().SetLockIfAbsent(ctx, pullLeaseKey(data.SubtaskId), e.owner,
+			e.VisibilityTimeout)
+		if err != nil || !ok {
+			logging.Warning("failed to lease the pulled subtask", logging.SubtaskId(data.SubtaskId), logging.Err(err))
+			continue
+		}
+
+		timeout := time.Duration(tasktool.DefaultSubtaskTimeout) * time.Second
+		if data.Timeout != 0 {
+			timeout = time.Duration(data.Timeout) * time.Second
+		}
+
+		e.lock.Lock()
+		e.leases[data.SubtaskId] = &pullLease{group: group, val: val, deadline: now.Add(timeout)}
+		e.lock.Unlock()
+
+		tasktool.SetSubtaskRunningDeadline(data.SubtaskId, now.Add(timeout))
+		subtasks = append(subtasks, data.SubtaskBody)
+		leasedVals = append(leasedVals, val)
+	}
+
+	return subtasks, leasedVals
+}
+
+// 执行器无法处理时, 结束租约并立即将子任务放回队列
+func (e *PullExecutor) release(group string, subtasks []taskmodel.SubtaskBody, vals []string) {
+	ctx := context.Background()
+	for i := range subtasks {
+		e.forget(subtasks[i].SubtaskId)
+		statestore.Default().DeleteLockIfOwned(ctx, pullLeaseKey(subtasks[i].SubtaskId), e.owner)
+		e.requeue(group, subtasks[i].SubtaskId, vals[i])
+	}
+}
+
+// 为执行中的子任务续租, 子任务已完成时结束租约.
+// 只续期本执行器仍持有的租约, 租约已失效时不再跟踪子任务
+func (e *PullExecutor) renewLeases() {
+	e.lock.Lock()
+	leases := make(map[taskmodel.SubtaskIdType]pullLease, len(e.leases))
+	for subtaskId, lease := range e.leases {
+		leases[subtaskId] = *lease
+	}
+	e.lock.Unlock()
+
+	ctx := context.Background()
+	now := time.Now()
+	for subtaskId, lease := range leases {
+		running, err := tasktool.IsSubtaskInRunningList(subtaskId)
+		if err != nil {
+			continue
+		}
+
+		if !running {
+			statestore.Default().ZRem(ctx, pullLeasedKey(lease.group), lease.val)
+			statestore.Default().DeleteLockIfOwned(ctx, pullLeaseKey(subtaskId), e.owner)
+			e.forget(subtaskId)
+			continue
+		}
+
+		// 执行已超时, 由调度器的超时检查处理
+		if now.After(lease.deadline.Add(e.VisibilityTimeout)) {
+			e.forget(subtaskId)
+			continue
+		}
+
+		renewed, err := statestore.Default().ExpireLockIfOwned(ctx, pullLeaseKey(subtaskId), e.owner,
+			e.VisibilityTimeout)
+		if err != nil {
+			continue
+		}
+
+		if !renewed {
+			logging.Warning("the subtask lease is lost", logging.SubtaskId(subtaskId))
+			e.forget(subtaskId)
+			continue
+		}
+
+		statestore.Default().ZAdd(ctx, pullLeasedKey(lease.group), statestore.Z{
+			Score:  float64(now.Add(e.VisibilityTimeout).Unix()),
+			Member: lease.val,
+		})
+	}
+}
+
+// 回收到期的租约, 仍在执行中的子任务放回队列.
+// 租约仍被持有时延后检查
+func (e *PullExecutor) reapLeases() {
+	for _, group := range e.Groups {
+		e.reapGroupLeases(group)
+	}
+}
+
+func (e *PullExecutor) reapGroupLeases(group string) {
+	ctx := context.Background()
+	now := time.Now()
+	expired, err := statestore.Default().ZRangeByScore(ctx, pullLeasedKey(group), &statestore.RangeBy{
+		Min: "-inf", Max: strconv.FormatInt(now.Unix(), 10),
+		Offset: 0, Count: int64(e.BatchSize),
+	})
+	if err != nil || len(expired) == 0 {
+		return
+	}
+
+	members := []interface{}{}
+	subtaskIds := []taskmodel.SubtaskIdType{}
+	for _, val := range expired {
+		data := pullSubtaskData{}
+		err := json.Unmarshal([]byte(val), &data)
+		if err != nil {
+			logging.Warning("failed to unmarshal the leased subtask", logging.Any("data", val), logging.Err(err))
+			statestore.Default().ZRem(ctx, pullLeasedKey(group), val)
+			continue
+		}
+
+		_, err = statestore.Default().Get(ctx, pullLeaseKey(data.SubtaskId))
+		if err == nil {
+			statestore.Default().ZAdd(ctx, pullLeasedKey(group), statestore.Z{
+				Score:  float64(now.Add(e.VisibilityTimeout).Unix()),
+				Member: val,
+			})
+			continue
+		}
+
+		if err != errordef.ErrNotFound {
+			continue
+		}
+
+		members = append(members, val)
+		subtaskIds = append(subtaskIds, data.SubtaskId)
+	}
+
+	if len(members) == 0 {
+		return
+	}
+
+	// 删除成功的回收者负责放回队列
+	removed, err := statestore.Default().ZRemEach(ctx, pullLeasedKey(group), members)
+	if err != nil {
+		return
+	}
+
+	for idx, ok := range removed {
+		if !ok {
+			continue
+		}
+
+		running, err := tasktool.IsSubtaskInRunningList(subtaskIds[idx])
+		if err == nil && running {
+			logging.Info("the subtask lease expired, requeue it", logging.SubtaskId(subtaskIds[idx]))
+			e.requeue(group, subtaskIds[idx], members[idx].(string))
+		}
+	}
+}
+
+// 将子任务放回分组的队列, 并从租用集合中删除.
+// 超时时间在放回前设置, 与enqueue相同
+func (e *PullExecutor) requeue(group string, subtaskId taskmodel.SubtaskIdType, val string) {
+	tasktool.SetSubtaskRunningDeadline(subtaskId, time.Now().Add(e.QueueTimeout))
+
+	batch := statestore.Default().Batch()
+	batch.RPush(pullQueueKey(group), val)
+	batch.ZRem(pullLeasedKey(group), val)
+	err := batch.Exec(context.Background())
+	if err != nil {
+		logging.Warning("failed to requeue the subtask", logging.SubtaskId(subtaskId), logging.Err(err))
+	}
+}
+
+func (e *PullExecutor) forget(subtaskId taskmodel.SubtaskIdType) {
+	e.lock.Lock()
+	delete(e.leases, subtaskId)
+	e.lock.Unlock()
+}
+
+func pullQueueKey(group string) string {
+	return redistool.HashTagKey(PullSubtaskQueuePrefix, group)
+}
+
+func pullLeasedKey(group string) string {
+	return redistool.HashTagKey(PullLeasedSubtaskZsetPrefix, group)
+}
+
+func pullLeaseKey(subtaskId taskmodel.SubtaskIdType) string {
+	return PullSubtaskLeasePrefix + strconv.FormatUint(uint64(subtaskId), 10)
+}
@@ -0,0 +1,150 @@
+package serversupport
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/internal/config"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
+	"github.com/danenmao/pterergate-dtf/internal/tasktool"
+)
+
+func addRunningSubtask(subtaskId taskmodel.SubtaskIdType) {
+	keyName := tasktool.GetShardKey(config.RunningSubtaskZset, uint64(subtaskId))
+	statestore.Default().ZAdd(context.Background(), keyName, statestore.Z{
+		Score: float64(time.Now().Unix()), Member: uint64(subtaskId),
+	})
+}
+
+func getRunningDeadline(subtaskId taskmodel.SubtaskIdType) float64 {
+	keyName := tasktool.GetShardKey(config.RunningSubtaskZset, uint64(subtaskId))
+	score, _ := statestore.Default().ZScore(context.Background(), keyName, strconv.FormatUint(uint64(subtaskId), 10))
+	return score
+}
+
+func Test_PullExecutor(t *testing.T) {
+	statestore.SetDefault(statestore.NewMemoryStore())
+	defer statestore.SetDefault(statestore.NewRedisStore())
+
+	var subtaskId taskmodel.SubtaskIdType = 11
+	addRunningSubtask(subtaskId)
+
+	executor := NewPullExecutor(TaskTypePullGroup(3))
+	executor.GroupOf = PullGroupByTaskType
+	enqueueErr := executor.GetInvoker()([]taskmodel.SubtaskBody{
+		{SubtaskId: subtaskId, TaskId: 2, TaskType: 3, Timeout: 30},
+	})
+	queuedDeadline := getRunningDeadline(subtaskId)
+
+	received := []taskmodel.SubtaskBody{}
+	executor.pull(func(subtasks []taskmodel.SubtaskBody) error {
+		received = append(received, subtasks...)
+		return nil
+	})
+	leasedDeadline := getRunningDeadline(subtaskId)
+	ctx := context.Background()
+	group := TaskTypePullGroup(3)
+	leased, _ := statestore.Default().ZRangeByScore(ctx, pullLeasedKey(group), &statestore.RangeBy{
+		Min: "-inf", Max: "+inf",
+	})
+	leaseScore := float64(0)
+	if len(leased) > 0 {
+		leaseScore, _ = statestore.Default().ZScore(ctx, pullLeasedKey(group), leased[0])
+	}
+	leaseOwner, _ := statestore.Default().Get(ctx, pullLeaseKey(subtaskId))
+
+	// 未到期的租约不回收
+	executor.reapLeases()
+	notReaped, _ := statestore.Default().LLen(ctx, pullQueueKey(group))
+
+	// 租约被其他执行器持有时, 不再续租
+	statestore.Default().Set(ctx, pullLeaseKey(subtaskId), "other", time.Minute)
+	expiredScore := float64(time.Now().Add(-time.Second).Unix())
+	statestore.Default().ZAdd(ctx, pullLeasedKey(group), statestore.Z{Score: expiredScore, Member: leased[0]})
+	executor.renewLeases()
+	renewedScore, _ := statestore.Default().ZScore(ctx, pullLeasedKey(group), leased[0])
+	leasedCount := executor.leasedCount()
+
+	// 租约到期后放回队列
+	statestore.Default().Del(ctx, pullLeaseKey(subtaskId))
+	executor.reapLeases()
+	requeued, _ := statestore.Default().LPopN(ctx, pullQueueKey(group), 10)
+	remained, _ := statestore.Default().ZCard(ctx, pullLeasedKey(group))
+
+	Convey("pull subtasks from the queue", t, func() {
+		Convey("should lease the subtask", func() {
+			now := float64(time.Now().Unix())
+			So(enqueueErr, ShouldBeNil)
+			So(queuedDeadline, ShouldBeGreaterThan, now+DefaultPullQueueTimeout.Seconds()-5)
+			So(len(received), ShouldEqual, 1)
+			So(received[0].SubtaskId, ShouldEqual, subtaskId)
+			So(leasedDeadline, ShouldBeLessThanOrEqualTo, now+30)
+			So(len(leased), ShouldEqual, 1)
+			So(leaseScore, ShouldBeGreaterThan, now)
+			So(leaseOwner, ShouldEqual, executor.owner)
+			So(notReaped, ShouldEqual, 0)
+		})
+
+		Convey("should drop the lease owned by another executor", func() {
+			So(renewedScore, ShouldEqual, expiredScore)
+			So(leasedCount, ShouldEqual, 0)
+		})
+
+		Convey("should requeue the subtask after the lease expired", func() {
+			So(requeued, ShouldResemble, leased)
+			So(remained, ShouldEqual, 0)
+		})
+	})
+}
+
+// 批量写入后立即调用afterExec, 模拟执行器在入队后马上拉取
+type afterExecStore struct {
+	statestore.IStateStore
+	afterExec func()
+}
+
+type afterExecBatch struct {
+	statestore.IBatch
+	afterExec func()
+}
+
+func (store *afterExecStore) Batch() statestore.IBatch {
+	return &afterExecBatch{IBatch: store.IStateStore.Batch(), afterExec: store.afterExec}
+}
+
+func (batch *afterExecBatch) Exec(ctx context.Context) error {
+	err := batch.IBatch.Exec(ctx)
+	batch.afterExec()
+	return err
+}
+
+func Test_PullExecutor_LeaseBeforeEnqueueReturns(t *testing.T) {
+	store := statestore.NewMemoryStore()
+	statestore.SetDefault(store)
+	defer statestore.SetDefault(statestore.NewRedisStore())
+
+	var subtaskId taskmodel.SubtaskIdType = 12
+	addRunningSubtask(subtaskId)
+
+	executor := NewPullExecutor()
+	received := 0
+	statestore.SetDefault(&afterExecStore{IStateStore: store, afterExec: func() {
+		executor.pull(func(subtasks []taskmodel.SubtaskBody) error {
+			received += len(subtasks)
+			return nil
+		})
+	}})
+	enqueueErr := executor.GetInvoker()([]taskmodel.SubtaskBody{{SubtaskId: subtaskId, TaskId: 2, Timeout: 30}})
+	statestore.SetDefault(store)
+
+	Convey("the queue deadline doesn't replace the deadline set by the lease", t, func() {
+		So(enqueueErr, ShouldBeNil)
+		So(received, ShouldEqual, 1)
+		So(getRunningDeadline(subtaskId), ShouldBeLessThanOrEqualTo, float64(time.Now().Unix()+30))
+	})
+}
@@ -1,20 +1,61 @@
 package serversupport
 
 import (
+	"crypto/tls"
 	"errors"
 
+	"github.com/danenmao/pterergate-dtf/dtf/auth"
+	"github.com/danenmao/pterergate-dtf/dtf/extconfig"
 	"github.com/danenmao/pterergate-dtf/internal/exitctrl"
 	"github.com/danenmao/pterergate-dtf/internal/serverhelper"
 )
 
 type ServerBase struct {
-	server *serverhelper.SimpleServer
+	server        *serverhelper.SimpleServer
+	authenticator auth.IAuthenticator
+	replayGuard   *auth.ReplayGuard
+	tlsConfig     *tls.Config
+	maxBodySize   int64
 }
 
 func NewServerBase() *ServerBase {
 	return &ServerBase{}
 }
 
+// 校验token接收方的服务
+func newServerBase(audience string) *ServerBase {
+	return &ServerBase{
+		authenticator: auth.NewJWTAuthenticator(audience),
+	}
+}
+
+// 设置防重放检查, 默认使用内存nonce缓存.
+// 多个服务实例共享nonce时使用auth.NewStoreNonceCache()
+func (s *ServerBase) SetReplayGuard(replayGuard *auth.ReplayGuard) {
+	s.replayGuard = replayGuard
+}
+
+// 设置认证器, 默认使用JWT认证
+func (s *ServerBase) SetAuthenticator(authenticator auth.IAuthenticator) {
+	s.authenticator = authenticator
+}
+
+// 使用HTTPS服务, 配置了CA时要求客户端证书
+func (s *ServerBase) SetTLS(cfg *extconfig.TLSConfig) error {
+	tlsConfig, err := serverhelper.NewServerTLSConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	s.tlsConfig = tlsConfig
+	return nil
+}
+
+// 设置请求体的大小上限, 默认为serverhelper.DefaultMaxBodySize
+func (s *ServerBase) SetMaxBodySize(maxBodySize int64) {
+	s.maxBodySize = maxBodySize
+}
+
 func (s *ServerBase) serve(serverPort uint16, uri string, handler serverhelper.RequestHandler) error {
 	s.server = serverhelper.NewSimpleServer(
 		serverPort,
@@ -25,6 +66,17 @@ func (s *ServerBase) serve(serverPort uint16, uri string, handler serverhelper.R
 		return errors.New("failed to new a simple server")
 	}
 
+	if s.authenticator != nil {
+		s.server.SetAuthenticator(s.authenticator)
+	}
+	if s.replayGuard != nil {
+		s.server.SetReplayGuard(s.replayGuard)
+	}
+	s.server.SetTLSConfig(s.tlsConfig)
+	if s.maxBodySize > 0 {
+		s.server.SetMaxBodySize(s.maxBodySize)
+	}
+
 	exitctrl.AddExitRoutine(func() {
 		s.server.Shutdown()
 	})
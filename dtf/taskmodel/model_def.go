@@ -45,14 +45,15 @@ type TaskStatusData struct {
 
 // 任务的创建参数
 type TaskParam struct {
-	Creator       TaskCreator   `json:"creator"`        // 任务创建者
-	ResourceGroup string        `json:"resource_group"` // 任务所属的资源组名称
-	Priority      uint32        `json:"priority"`       // 任务的基础优先级
-	TaskName      string        `json:"task_name"`      // 任务名
-	Description   string        `json:"description"`    // 任务描述
-	TaskType      uint32        `json:"task_type"`      // 任务类型
-	Timeout       time.Duration `json:"timeout"`        // 任务的超时值
-	TypeParam     string        `json:"type_param"`     // 任务的自定义参数
+	Creator       TaskCreator   `json:"creator"`                // 任务创建者
+	ResourceGroup string        `json:"resource_group"`         // 任务所属的资源组名称
+	Priority      uint32        `json:"priority"`               // 任务的基础优先级
+	TaskName      string        `json:"task_name"`              // 任务名
+	Description   string        `json:"description"`            // 任务描述
+	TaskType      uint32        `json:"task_type"`              // 任务类型
+	Timeout       time.Duration `json:"timeout"`                // 任务的超时值
+	TypeParam     string        `json:"type_param"`             // 任务的自定义参数
+	TraceParent   string        `json:"trace_parent,omitempty"` // 调用者的跟踪上下文, 非空时任务的trace作为其子span
 }
 
 // 任务的执行结果
@@ -63,3 +64,48 @@ type TaskResult struct {
 	Reason     string         `json:"reason"`      // 结果描述
 	ResultData string         `json:"result_data"` // 与任务类型相关的结果数据
 }
+
+// 任务列表的排序字段
+type TaskSortField uint32
+
+const (
+	TaskSort_CreateTime TaskSortField = 0 // 按任务的创建顺序
+	TaskSort_StartTime  TaskSortField = 1 // 按任务的开始时间
+	TaskSort_FinishTime TaskSortField = 2 // 按任务的结束时间
+)
+
+// 查询任务列表的条件, 零值的条件不生效
+type TaskQuery struct {
+	CreatorUID    uint64         `json:"creator_uid"`     // 创建者的ID, 用于查询"我的任务"
+	TaskType      uint32         `json:"task_type"`       // 任务类型
+	TaskStatus    TaskStatusType `json:"task_status"`     // 任务的状态
+	StartTimeFrom time.Time      `json:"start_time_from"` // 开始时间不早于此时间
+	StartTimeTo   time.Time      `json:"start_time_to"`   // 开始时间早于此时间
+	ResourceGroup string         `json:"resource_group"`  // 任务所属的资源组名
+	NamePrefix    string         `json:"name_prefix"`     // 任务名的前缀
+	SortBy        TaskSortField  `json:"sort_by"`         // 排序字段
+	Ascending     bool           `json:"ascending"`       // 是否升序, 默认降序
+	Cursor        string         `json:"cursor"`          // 上一页返回的NextCursor, 为空时从头查询
+	Limit         int            `json:"limit"`           // 每页的最大记录数
+}
+
+// 任务列表中的任务
+type TaskSummary struct {
+	TaskId        TaskIdType     `json:"task_id"`        // 任务ID
+	TaskType      uint32         `json:"task_type"`      // 任务类型
+	TaskStatus    TaskStatusType `json:"task_status"`    // 任务的状态
+	Priority      uint32         `json:"priority"`       // 任务优先级
+	StartTime     time.Time      `json:"start_time"`     // 任务的开始时间
+	FinishTime    time.Time      `json:"finish_time"`    // 任务的结束时间, 未结束时为零值
+	TimeCost      uint32         `json:"time_cost"`      // 任务耗时, 单位为秒
+	ResourceGroup string         `json:"resource_group"` // 任务所属的资源组名
+	TaskName      string         `json:"task_name"`      // 任务名
+	Description   string         `json:"description"`    // 任务描述
+	Creator       TaskCreator    `json:"creator"`        // 任务创建者
+}
+
+// 任务列表的一页
+type TaskList struct {
+	Tasks      []TaskSummary `json:"tasks"`       // 本页的任务
+	NextCursor string        `json:"next_cursor"` // 下一页的游标, 为空时没有更多任务
+}
@@ -23,21 +23,57 @@ const (
 
 // 子任务的参数体
 type SubtaskBody struct {
-	SubtaskId    SubtaskIdType `json:"subtask_id"`    // 子任务ID
-	TaskId       TaskIdType    `json:"task_id"`       // 所属的任务ID
-	TaskType     uint32        `json:"task_type"`     // 任务类型
-	Timeout      uint32        `json:"timeout"`       // 子任务的超时值, 秒
-	TypeParam    string        `json:"type_param"`    // 子任务与类型相关的参数数, json
-	CreatedAt    time.Time     `json:"created_at"`    // 子任务创建的时间
-	TerminatedAt time.Time     `json:"terminated_at"` // 子任务结束的时间
+	SubtaskId    SubtaskIdType `json:"subtask_id"`             // 子任务ID
+	TaskId       TaskIdType    `json:"task_id"`                // 所属的任务ID
+	TaskType     uint32        `json:"task_type"`              // 任务类型
+	Timeout      uint32        `json:"timeout"`                // 子任务的超时值, 秒
+	TypeParam    string        `json:"type_param"`             // 子任务与类型相关的参数数, json
+	CreatedAt    time.Time     `json:"created_at"`             // 子任务创建的时间
+	TerminatedAt time.Time     `json:"terminated_at"`          // 子任务结束的时间
+	TraceParent  string        `json:"trace_parent,omitempty"` // 子任务的跟踪上下文, W3C traceparent格式
 }
 
 // 子任务执行的结果
 type SubtaskResult struct {
+	SubtaskId   SubtaskIdType     `json:"subtask_id"`             // 子任务ID
+	TaskId      TaskIdType        `json:"task_id"`                // 所属的任务ID
+	Result      SubtaskResultType `json:"result"`                 // 子任务的结果
+	ResultCode  uint32            `json:"result_code"`            // 子任务的结果码
+	ResultMsg   string            `json:"result_msg"`             // 原因
+	ResultBody  string            `json:"result_body"`            // 子任务与类型相关的结果数据
+	TraceParent string            `json:"trace_parent,omitempty"` // 子任务执行的跟踪上下文, W3C traceparent格式
+}
+
+// 子任务结果的查询条件, 零值的条件不生效
+type SubtaskResultFilter struct {
+	Result     SubtaskResultType `json:"result"`      // 子任务的结果
+	ResultCode uint32            `json:"result_code"` // 子任务的结果码
+}
+
+// 分页参数
+type PageParam struct {
+	Cursor string `json:"cursor"` // 上一页返回的NextCursor, 为空时从头查询
+	Limit  int    `json:"limit"`  // 每页的最大记录数
+}
+
+// 保存的子任务结果
+type SubtaskResultRecord struct {
 	SubtaskId  SubtaskIdType     `json:"subtask_id"`  // 子任务ID
 	TaskId     TaskIdType        `json:"task_id"`     // 所属的任务ID
+	TaskType   uint32            `json:"task_type"`   // 任务类型
 	Result     SubtaskResultType `json:"result"`      // 子任务的结果
 	ResultCode uint32            `json:"result_code"` // 子任务的结果码
 	ResultMsg  string            `json:"result_msg"`  // 原因
 	ResultBody string            `json:"result_body"` // 子任务与类型相关的结果数据
+	TypeParam  string            `json:"type_param"`  // 子任务的参数
+	StartTime  time.Time         `json:"start_time"`  // 子任务开始执行的时间
+	EndTime    time.Time         `json:"end_time"`    // 子任务结束的时间
+	TimeCost   uint32            `json:"time_cost"`   // 子任务耗时, 秒
+	Attempts   uint32            `json:"attempts"`    // 子任务被分发执行的次数
+}
+
+// 子任务结果的一页
+type SubtaskResultList struct {
+	Results    []SubtaskResultRecord `json:"results"`     // 本页的结果
+	NextCursor string                `json:"next_cursor"` // 下一页的游标, 为空时没有更多结果
 }
@@ -0,0 +1,96 @@
+package tracing
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// span导出接口, 结束的span通过Export导出
+// 实现需要是并发安全的
+type IExporter interface {
+	Export(span *SpanData)
+}
+
+// 丢弃所有span的导出器, 为默认的导出器
+type NoopExporter struct {
+}
+
+func (exporter NoopExporter) Export(span *SpanData) {
+}
+
+var (
+	gs_Exporter      IExporter = NoopExporter{}
+	gs_ExporterMutex sync.RWMutex
+)
+
+// 获取当前的导出器
+func GetExporter() IExporter {
+	gs_ExporterMutex.RLock()
+	defer gs_ExporterMutex.RUnlock()
+	return gs_Exporter
+}
+
+// 设置导出器, 为nil时丢弃所有span
+func SetExporter(exporter IExporter) {
+	if exporter == nil {
+		exporter = NoopExporter{}
+	}
+
+	gs_ExporterMutex.Lock()
+	defer gs_ExporterMutex.Unlock()
+	gs_Exporter = exporter
+}
+
+// 在内存中保存span的导出器, 用于测试
+type MemoryExporter struct {
+	mutex sync.Mutex
+	spans []SpanData
+}
+
+// 创建内存导出器
+func NewMemoryExporter() *MemoryExporter {
+	return &MemoryExporter{}
+}
+
+func (exporter *MemoryExporter) Export(span *SpanData) {
+	exporter.mutex.Lock()
+	defer exporter.mutex.Unlock()
+	exporter.spans = append(exporter.spans, *span)
+}
+
+// 获取已导出的span
+func (exporter *MemoryExporter) Spans() []SpanData {
+	exporter.mutex.Lock()
+	defer exporter.mutex.Unlock()
+	return append([]SpanData{}, exporter.spans...)
+}
+
+// 清空已导出的span
+func (exporter *MemoryExporter) Reset() {
+	exporter.mutex.Lock()
+	defer exporter.mutex.Unlock()
+	exporter.spans = nil
+}
+
+// 将span以JSON行写入writer的导出器, 如os.Stdout
+type WriterExporter struct {
+	mutex  sync.Mutex
+	writer io.Writer
+}
+
+// 创建写入writer的导出器
+func NewWriterExporter(writer io.Writer) *WriterExporter {
+	return &WriterExporter{writer: writer}
+}
+
+func (exporter *WriterExporter) Export(span *SpanData) {
+	data, err := json.Marshal(span)
+	if err != nil {
+		return
+	}
+
+	exporter.mutex.Lock()
+	defer exporter.mutex.Unlock()
+	exporter.writer.Write(append(data, '\n'))
+}
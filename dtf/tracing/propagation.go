@@ -0,0 +1,21 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+)
+
+// 将traceParent写入HTTP头, traceParent为空时不写入
+func Inject(header http.Header, traceParent string) {
+	if len(traceParent) == 0 {
+		return
+	}
+
+	header.Set(TraceParentHeader, traceParent)
+}
+
+// 从HTTP头中读取traceparent, 创建服务端的span, 并返回包含span的ctx
+func Extract(ctx context.Context, header http.Header, name string) (context.Context, *Span) {
+	span := StartSpan(header.Get(TraceParentHeader), name)
+	return ContextWithSpan(ctx, span), span
+}
@@ -0,0 +1,193 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 跨服务传递跟踪上下文的HTTP头, 格式遵循W3C Trace Context
+const TraceParentHeader = "traceparent"
+
+// 跟踪上下文
+type SpanContext struct {
+	TraceId string // 32位十六进制
+	SpanId  string // 16位十六进制
+}
+
+// 是否为有效的跟踪上下文
+func (sc SpanContext) IsValid() bool {
+	return len(sc.TraceId) == 32 && len(sc.SpanId) == 16
+}
+
+// 格式化为traceparent, 无效时返回空串
+func (sc SpanContext) TraceParent() string {
+	if !sc.IsValid() {
+		return ""
+	}
+
+	return fmt.Sprintf("00-%s-%s-01", sc.TraceId, sc.SpanId)
+}
+
+// 解析traceparent, 格式错误时返回无效的上下文
+func ParseTraceParent(traceParent string) SpanContext {
+	parts := strings.Split(strings.TrimSpace(traceParent), "-")
+	if len(parts) != 4 || len(parts[0]) != 2 {
+		return SpanContext{}
+	}
+
+	sc := SpanContext{TraceId: strings.ToLower(parts[1]), SpanId: strings.ToLower(parts[2])}
+	if !sc.IsValid() || !isHex(sc.TraceId) || !isHex(sc.SpanId) ||
+		sc.TraceId == strings.Repeat("0", 32) || sc.SpanId == strings.Repeat("0", 16) {
+		return SpanContext{}
+	}
+
+	return sc
+}
+
+// 导出的span数据
+type SpanData struct {
+	Name         string            `json:"name"`
+	TraceId      string            `json:"trace_id"`
+	SpanId       string            `json:"span_id"`
+	ParentSpanId string            `json:"parent_span_id,omitempty"`
+	StartTime    time.Time         `json:"start_time"`
+	EndTime      time.Time         `json:"end_time"`
+	Attributes   map[string]string `json:"attributes,omitempty"`
+	Error        string            `json:"error,omitempty"`
+}
+
+// 耗时
+func (data *SpanData) Duration() time.Duration {
+	return data.EndTime.Sub(data.StartTime)
+}
+
+// 一次操作的跟踪记录
+type Span struct {
+	mutex sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+// 以traceParent为父span创建span, traceParent无效时创建新的trace
+func StartSpan(traceParent string, name string) *Span {
+	return startSpan(ParseTraceParent(traceParent), name)
+}
+
+// 以ctx中的span为父span创建span, 并返回包含新span的ctx
+func StartSpanWithContext(ctx context.Context, name string) (context.Context, *Span) {
+	parent := SpanContext{}
+	if span := SpanFromContext(ctx); span != nil {
+		parent = span.Context()
+	}
+
+	span := startSpan(parent, name)
+	return ContextWithSpan(ctx, span), span
+}
+
+func startSpan(parent SpanContext, name string) *Span {
+	span := &Span{
+		data: SpanData{
+			Name:      name,
+			SpanId:    newId(8),
+			StartTime: time.Now(),
+		},
+	}
+
+	if parent.IsValid() {
+		span.data.TraceId = parent.TraceId
+		span.data.ParentSpanId = parent.SpanId
+	} else {
+		span.data.TraceId = newId(16)
+	}
+
+	return span
+}
+
+// 获取span的跟踪上下文
+func (span *Span) Context() SpanContext {
+	if span == nil {
+		return SpanContext{}
+	}
+
+	return SpanContext{TraceId: span.data.TraceId, SpanId: span.data.SpanId}
+}
+
+// 获取span的traceparent, 用于传递给下游
+func (span *Span) TraceParent() string {
+	return span.Context().TraceParent()
+}
+
+// 设置属性
+func (span *Span) SetAttribute(key string, value interface{}) {
+	if span == nil {
+		return
+	}
+
+	span.mutex.Lock()
+	defer span.mutex.Unlock()
+	if span.data.Attributes == nil {
+		span.data.Attributes = map[string]string{}
+	}
+
+	span.data.Attributes[key] = fmt.Sprint(value)
+}
+
+// 记录错误, err为空时忽略
+func (span *Span) SetError(err error) {
+	if span == nil || err == nil {
+		return
+	}
+
+	span.mutex.Lock()
+	defer span.mutex.Unlock()
+	span.data.Error = err.Error()
+}
+
+// 结束span并导出, 重复调用时只导出一次
+func (span *Span) End() {
+	if span == nil {
+		return
+	}
+
+	span.mutex.Lock()
+	if span.ended {
+		span.mutex.Unlock()
+		return
+	}
+
+	span.ended = true
+	span.data.EndTime = time.Now()
+	data := span.data
+	span.mutex.Unlock()
+
+	GetExporter().Export(&data)
+}
+
+type spanKey struct{}
+
+// 将span保存到ctx中
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// 从ctx中获取span, 不存在时返回nil
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+func newId(size int) string {
+	buf := make([]byte, size)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+func isHex(s string) bool {
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
@@ -0,0 +1,12 @@
+package tracing
+
+// 框架创建的span的名称
+const (
+	SpanName_CreateTask      = "dtf.create_task"    // 创建任务
+	SpanName_GenerateSubtask = "dtf.generate"       // 生成一个子任务
+	SpanName_DispatchSubtask = "dtf.dispatch"       // 调度一个子任务, 包含BeforeDispatch和AfterDispatch
+	SpanName_PushToExecutor  = "dtf.push_executor"  // 向执行器推送一批子任务
+	SpanName_ExecuteSubtask  = "dtf.execute"        // 执行一个子任务
+	SpanName_CollectResult   = "dtf.collect"        // 处理一个子任务结果
+	SpanName_ServerRequest   = "dtf.server_request" // 执行器和收集器服务处理一个HTTP请求
+)
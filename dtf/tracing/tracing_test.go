@@ -0,0 +1,91 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_ParseTraceParent(t *testing.T) {
+	valid := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	zero := ParseTraceParent("00-00000000000000000000000000000000-00f067aa0ba902b7-01")
+	malformed := ParseTraceParent("4bf92f3577b34da6a3ce929d0e0e4736")
+
+	Convey("parse the W3C traceparent", t, func() {
+		So(valid.IsValid(), ShouldBeTrue)
+		So(valid.TraceId, ShouldEqual, "4bf92f3577b34da6a3ce929d0e0e4736")
+		So(valid.SpanId, ShouldEqual, "00f067aa0ba902b7")
+		So(valid.TraceParent(), ShouldEqual, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		So(zero.IsValid(), ShouldBeFalse)
+		So(malformed.IsValid(), ShouldBeFalse)
+		So(malformed.TraceParent(), ShouldEqual, "")
+	})
+}
+
+func Test_Span_ParentAndExport(t *testing.T) {
+	exporter := NewMemoryExporter()
+	SetExporter(exporter)
+	defer SetExporter(nil)
+
+	root := StartSpan("", SpanName_CreateTask)
+	child := StartSpan(root.TraceParent(), SpanName_GenerateSubtask)
+	child.SetAttribute("subtask_id", 7)
+	child.SetError(errors.New("failed"))
+	child.End()
+	child.End()
+	root.End()
+
+	spans := exporter.Spans()
+
+	Convey("the child span belongs to the trace of the parent", t, func() {
+		So(len(spans), ShouldEqual, 2)
+		So(spans[0].Name, ShouldEqual, SpanName_GenerateSubtask)
+		So(spans[0].TraceId, ShouldEqual, root.Context().TraceId)
+		So(spans[0].ParentSpanId, ShouldEqual, root.Context().SpanId)
+		So(spans[0].Attributes["subtask_id"], ShouldEqual, "7")
+		So(spans[0].Error, ShouldEqual, "failed")
+		So(spans[1].ParentSpanId, ShouldEqual, "")
+	})
+}
+
+func Test_InjectExtract(t *testing.T) {
+	exporter := NewMemoryExporter()
+	SetExporter(exporter)
+	defer SetExporter(nil)
+
+	parent := StartSpan("", SpanName_PushToExecutor)
+	header := http.Header{}
+	Inject(header, parent.TraceParent())
+
+	ctx, span := Extract(context.Background(), header, SpanName_ServerRequest)
+	_, child := StartSpanWithContext(ctx, SpanName_ExecuteSubtask)
+
+	Convey("continue the trace from the HTTP header", t, func() {
+		So(header.Get(TraceParentHeader), ShouldEqual, parent.TraceParent())
+		So(SpanFromContext(ctx), ShouldEqual, span)
+		So(span.Context().TraceId, ShouldEqual, parent.Context().TraceId)
+		So(child.Context().TraceId, ShouldEqual, parent.Context().TraceId)
+	})
+}
+
+func Test_WriterExporter(t *testing.T) {
+	buf := bytes.Buffer{}
+	SetExporter(NewWriterExporter(&buf))
+	defer SetExporter(nil)
+
+	StartSpan("", SpanName_CollectResult).End()
+
+	data := SpanData{}
+	err := json.Unmarshal(buf.Bytes(), &data)
+
+	Convey("write the span as a JSON line", t, func() {
+		So(err, ShouldBeNil)
+		So(data.Name, ShouldEqual, SpanName_CollectResult)
+		So(len(data.TraceId), ShouldEqual, 32)
+	})
+}
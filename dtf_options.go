@@ -3,9 +3,12 @@ package dtf
 import (
 	"time"
 
+	"github.com/danenmao/pterergate-dtf/dtf/blobstore"
 	"github.com/danenmao/pterergate-dtf/dtf/dtfdef"
 	"github.com/danenmao/pterergate-dtf/dtf/extconfig"
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/dtf/tracing"
 )
 
 // 用于设置服务配置
@@ -35,6 +38,31 @@ func WithMongoDB(mongo *extconfig.MongoAddress) ServiceOption {
 	}
 }
 
+// save the subtask results to MySQL or MongoDB, so they can be listed with ListSubtaskResults
+// after the task ends. the MongoDB address is set with WithMongoDB
+func WithResultStore(store dtfdef.ResultStoreType) ServiceOption {
+	return func(config *dtfdef.ServiceConfig) {
+		config.ResultStore = store
+	}
+}
+
+// set how the task and subtask IDs are generated, all services of a cluster must use the same way.
+// with IdGenerator_Snowflake, each process needs a distinct node ID in [0, 1023]
+func WithIdGenerator(generator dtfdef.IdGeneratorType, snowflakeNodeId uint16) ServiceOption {
+	return func(config *dtfdef.ServiceConfig) {
+		config.IdGenerator = generator
+		config.SnowflakeNodeId = snowflakeNodeId
+	}
+}
+
+// set how the manager provisions the framework tables at startup:
+// apply the pending migrations (the default), only log them, or leave the tables to the user
+func WithSchemaMigration(mode dtfdef.SchemaMigrationMode) ServiceOption {
+	return func(config *dtfdef.ServiceConfig) {
+		config.SchemaMigration = mode
+	}
+}
+
 func WithExecutor(executor taskmodel.ExecutorInvoker) ServiceOption {
 	return func(config *dtfdef.ServiceConfig) {
 		config.ExecutorService = executor
@@ -47,6 +75,70 @@ func WithCollector(collector taskmodel.CollectorInvoker) ServiceOption {
 	}
 }
 
+// set the namespace prefix of all keys,
+// independent clusters sharing one Redis should use different prefixes, such as "prod:"
+func WithKeyPrefix(prefix string) ServiceOption {
+	return func(config *dtfdef.ServiceConfig) {
+		config.KeyPrefix = prefix
+	}
+}
+
+// set the runtime config, the values in the config file and environment variables override it
+func WithRuntimeConfig(cfg *extconfig.RuntimeConfig) ServiceOption {
+	return func(config *dtfdef.ServiceConfig) {
+		config.RuntimeConfig = cfg
+	}
+}
+
+// load the runtime config from a yaml or json file
+func WithConfigFile(path string) ServiceOption {
+	return func(config *dtfdef.ServiceConfig) {
+		config.ConfigFile = path
+	}
+}
+
+// serve the Prometheus metrics on the address, such as ":9100",
+// the metrics are exported on the path /metrics
+func WithMetrics(address string) ServiceOption {
+	return func(config *dtfdef.ServiceConfig) {
+		config.MetricsAddress = address
+	}
+}
+
+// serve the admin API on the port, which is used by dtfctl to operate the cluster.
+// the requests are authorized with the keys in -keypath, as the executor and collector servers
+func WithAdmin(port uint16) ServiceOption {
+	return func(config *dtfdef.ServiceConfig) {
+		config.AdminPort = port
+	}
+}
+
+// export the spans of the task pipeline to the exporter,
+// such as tracing.NewWriterExporter(os.Stdout)
+func WithTraceExporter(exporter tracing.IExporter) ServiceOption {
+	return func(config *dtfdef.ServiceConfig) {
+		config.TraceExporter = exporter
+	}
+}
+
+// output the logs of the services to the logger, such as logging.NewJSONLogger(os.Stdout, logging.Level_Info).
+// wrap it with logging.NewSampledLogger to limit the logs of the hot paths
+func WithLogger(logger logging.ILogger) ServiceOption {
+	return func(config *dtfdef.ServiceConfig) {
+		config.Logger = logger
+	}
+}
+
+// save the subtask params and results larger than threshold bytes to the blob store,
+// such as blobstore.NewS3Store(...). the default threshold is 64 KB when threshold is 0.
+// the executors and collectors need the same store to read the payloads
+func WithBlobStore(store blobstore.IBlobStore, threshold int) ServiceOption {
+	return func(config *dtfdef.ServiceConfig) {
+		config.BlobStore = store
+		config.BlobThreshold = threshold
+	}
+}
+
 func WithRegisterExecutorHandler(register taskmodel.RegisterExecutorRequestHandler) ServiceOption {
 	return func(config *dtfdef.ServiceConfig) {
 		config.ExecutorHandlerRegister = register
@@ -0,0 +1,224 @@
+package dtf
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/danenmao/pterergate-dtf/dtf/blobstore"
+	"github.com/danenmao/pterergate-dtf/dtf/dtfdef"
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/extconfig"
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/dtf/taskplugin"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
+)
+
+const (
+	embeddedTestTaskType     = 9001
+	embeddedTestSubtaskCount = 3
+	embeddedTestBlobLimit    = 64
+	embeddedTestKeyPrefix    = "embedded-test:"
+)
+
+// 生成固定数量子任务的测试插件, 执行时返回子任务的参数
+type embeddedTestPlugin struct {
+	generated int
+}
+
+func (p *embeddedTestPlugin) GetPluginConf(conf *taskmodel.PluginConf) error {
+	conf.IterationMode = taskmodel.IterationMode_No
+	conf.TaskTypeTimeout = time.Minute
+	return nil
+}
+
+func (p *embeddedTestPlugin) GetPluginBody(body *taskmodel.PluginBody) error {
+	body.Generator = p
+	body.SchedulerCallback = p
+	body.Executor = &embeddedTestExecutor{}
+	body.CollectorCallback = p
+	return nil
+}
+
+func (p *embeddedTestPlugin) Begin(taskId taskmodel.TaskIdType, taskType uint32, taskData *taskmodel.TaskParam,
+	oldStatus string) error {
+	fmt.Sscan(oldStatus, &p.generated)
+	return nil
+}
+
+func (p *embeddedTestPlugin) End(taskId taskmodel.TaskIdType) error    { return nil }
+func (p *embeddedTestPlugin) Cancel(taskId taskmodel.TaskIdType) error { return nil }
+
+func (p *embeddedTestPlugin) SaveStatus(taskId taskmodel.TaskIdType) (string, error) {
+	return fmt.Sprint(p.generated), nil
+}
+
+func (p *embeddedTestPlugin) QueryProgress(taskId taskmodel.TaskIdType) (float32, error) {
+	return float32(p.generated) / embeddedTestSubtaskCount, nil
+}
+
+func (p *embeddedTestPlugin) GetSubtask(taskId taskmodel.TaskIdType, subtaskData *taskmodel.SubtaskBody,
+	finished *bool) error {
+	if p.generated >= embeddedTestSubtaskCount {
+		*finished = true
+		return errordef.ErrNotFound
+	}
+
+	p.generated++
+	subtaskData.TypeParam = embeddedTestParam(p.generated)
+	*finished = p.generated >= embeddedTestSubtaskCount
+	return nil
+}
+
+func (p *embeddedTestPlugin) BeforeDispatch(subtaskId taskmodel.SubtaskIdType,
+	subtaskData *taskmodel.SubtaskBody) (bool, error) {
+	return true, nil
+}
+
+func (p *embeddedTestPlugin) AfterDispatch(subtaskId taskmodel.SubtaskIdType) error { return nil }
+
+type embeddedTestExecutor struct{}
+
+func (e *embeddedTestExecutor) Execute(subtaskData *taskmodel.SubtaskBody, result *taskmodel.SubtaskResult) error {
+	result.Result = taskmodel.SubtaskResult_Success
+	result.ResultBody = subtaskData.TypeParam
+	return nil
+}
+
+func (e *embeddedTestExecutor) Cancel() error { return nil }
+
+func (p *embeddedTestPlugin) AfterExecution(subtaskResult *taskmodel.SubtaskResult,
+	support taskmodel.ITaskCollectorSupport) (bool, error) {
+	return true, nil
+}
+
+func (p *embeddedTestPlugin) AfterTaskCompleted(taskId taskmodel.TaskIdType) (int, error) {
+	return 0, nil
+}
+
+// 最后一个子任务的参数超过大对象阈值
+func embeddedTestParam(index int) string {
+	param := fmt.Sprintf("param-%d", index)
+	if index == embeddedTestSubtaskCount {
+		param += strings.Repeat("x", embeddedTestBlobLimit)
+	}
+
+	return param
+}
+
+// 记录写入次数的大对象存储
+type embeddedTestBlobStore struct {
+	*blobstore.FileStore
+	puts int32
+}
+
+func (store *embeddedTestBlobStore) Put(ctx context.Context, key string, data []byte) error {
+	atomic.AddInt32(&store.puts, 1)
+	return store.FileStore.Put(ctx, key, data)
+}
+
+// 等待任务完成, 每次检查时调用onPoll
+func waitForTask(taskId taskmodel.TaskIdType, timeout time.Duration, onPoll func()) taskmodel.TaskStatusType {
+	status := taskmodel.TaskStatusData{}
+	for deadline := time.Now().Add(timeout); time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+		onPoll()
+		if GetTaskStatus(taskId, &status) == nil && status.TaskStatus == taskmodel.TaskStatus_Completed {
+			break
+		}
+	}
+
+	return status.TaskStatus
+}
+
+// 记录内存状态存储中出现过的key
+func collectStateKeys(keys map[string]bool) {
+	prefixStore, ok := statestore.Default().(*statestore.PrefixStore)
+	if !ok {
+		return
+	}
+
+	for _, key := range prefixStore.Store.(*statestore.MemoryStore).Keys() {
+		keys[key] = true
+	}
+}
+
+func Test_StartEmbedded(t *testing.T) {
+	RegisterTaskType(&taskplugin.TaskPluginRegistration{
+		TaskType: embeddedTestTaskType,
+		Name:     "embedded_test",
+		PluginFactoryFn: func(plugin *taskplugin.ITaskPlugin) error {
+			*plugin = &embeddedTestPlugin{}
+			return nil
+		},
+	})
+
+	blobs := &embeddedTestBlobStore{FileStore: blobstore.NewFileStore(t.TempDir())}
+	err := StartEmbedded(
+		WithMySQL(&extconfig.MySQLAddress{
+			Dialect: extconfig.SQLDialect_SQLite,
+			Address: filepath.Join(t.TempDir(), "dtf.db"),
+		}),
+		WithResultStore(dtfdef.ResultStore_MySQL),
+		WithBlobStore(blobs, embeddedTestBlobLimit),
+		WithKeyPrefix(embeddedTestKeyPrefix),
+	)
+	defer NotifyStop()
+
+	taskId, createErr := CreateTask(embeddedTestTaskType, &taskmodel.TaskParam{
+		TaskName:      "embedded",
+		TaskType:      embeddedTestTaskType,
+		ResourceGroup: "2",
+		Timeout:       time.Minute,
+	})
+	keys := map[string]bool{}
+	taskStatus := waitForTask(taskId, time.Minute, func() { collectStateKeys(keys) })
+
+	// 任务记录和子任务结果保存在SQLite文件中
+	tasks := taskmodel.TaskList{}
+	listErr := ListTasks(&taskmodel.TaskQuery{TaskType: embeddedTestTaskType}, &tasks)
+	results := taskmodel.SubtaskResultList{}
+	resultErr := ListSubtaskResults(taskId, &taskmodel.SubtaskResultFilter{}, &taskmodel.PageParam{Limit: 10},
+		&results)
+
+	Convey("run a task in the embedded services with a SQLite file", t, func() {
+		So(err, ShouldBeNil)
+		So(createErr, ShouldBeNil)
+		So(taskStatus, ShouldEqual, taskmodel.TaskStatus_Completed)
+
+		So(listErr, ShouldBeNil)
+		So(len(tasks.Tasks), ShouldEqual, 1)
+		So(tasks.Tasks[0].TaskId, ShouldEqual, taskId)
+
+		So(resultErr, ShouldBeNil)
+		So(len(results.Results), ShouldEqual, embeddedTestSubtaskCount)
+		for i, result := range results.Results {
+			So(result.Result, ShouldEqual, taskmodel.SubtaskResult_Success)
+			So(result.TypeParam, ShouldEqual, embeddedTestParam(i+1))
+			So(result.ResultBody, ShouldEqual, result.TypeParam)
+		}
+	})
+
+	Convey("all keys in the state store have the prefix", t, func() {
+		So(len(keys), ShouldBeGreaterThan, 0)
+		for key := range keys {
+			So(strings.HasPrefix(key, embeddedTestKeyPrefix), ShouldBeTrue)
+		}
+	})
+
+	Convey("the large param and result are passed through the blob store", t, func() {
+		So(atomic.LoadInt32(&blobs.puts), ShouldEqual, 2)
+	})
+}
+
+func Test_StartEmbedded_MySQL(t *testing.T) {
+	Convey("a MySQL server is rejected in embedded mode", t, func() {
+		err := StartEmbedded(WithMySQL(&extconfig.MySQLAddress{Address: "127.0.0.1:3306", DB: "dtf"}))
+		So(err, ShouldEqual, errordef.ErrInvalidParameter)
+	})
+}
@@ -0,0 +1,141 @@
+package blobtool
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/danenmao/pterergate-dtf/dtf/blobstore"
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+)
+
+// 引用的前缀, 参数和结果以此开头时表示保存在大对象存储中
+const RefPrefix = "dtf-blob://"
+
+// 默认的阈值, 超过此大小的参数和结果保存到大对象存储
+const DefaultThreshold = 64 * 1024
+
+var (
+	gs_Store     blobstore.IBlobStore
+	gs_Threshold = DefaultThreshold
+)
+
+// 设置大对象存储和阈值, 需在服务启动前调用. store为nil时不转存
+func SetDefault(store blobstore.IBlobStore, threshold int) {
+	if threshold <= 0 {
+		threshold = DefaultThreshold
+	}
+
+	gs_Store = store
+	gs_Threshold = threshold
+}
+
+// 是否配置了大对象存储
+func Enabled() bool {
+	return gs_Store != nil
+}
+
+// 值是否为大对象的引用
+func IsRef(val string) bool {
+	return strings.HasPrefix(val, RefPrefix)
+}
+
+// 将超过阈值的子任务参数转存, 参数替换为引用
+func OffloadParam(subtask *taskmodel.SubtaskBody) error {
+	ref, err := offload(subtaskKey(subtask.TaskId, subtask.SubtaskId, "param"), subtask.TypeParam)
+	if err != nil {
+		return err
+	}
+
+	subtask.TypeParam = ref
+	return nil
+}
+
+// 将超过阈值的子任务结果转存, 结果替换为引用
+func OffloadResult(result *taskmodel.SubtaskResult) error {
+	ref, err := offload(subtaskKey(result.TaskId, result.SubtaskId, "result"), result.ResultBody)
+	if err != nil {
+		return err
+	}
+
+	result.ResultBody = ref
+	return nil
+}
+
+// 读取引用的子任务参数
+func ResolveParam(subtask *taskmodel.SubtaskBody) error {
+	val, err := Resolve(context.Background(), subtask.TypeParam)
+	if err != nil {
+		return err
+	}
+
+	subtask.TypeParam = val
+	return nil
+}
+
+// 读取引用的子任务结果
+func ResolveResult(result *taskmodel.SubtaskResult) error {
+	val, err := Resolve(context.Background(), result.ResultBody)
+	if err != nil {
+		return err
+	}
+
+	result.ResultBody = val
+	return nil
+}
+
+// 值为引用时从大对象存储中读取, 否则返回原值
+func Resolve(ctx context.Context, val string) (string, error) {
+	if !IsRef(val) {
+		return val, nil
+	}
+
+	if gs_Store == nil {
+		return "", errordef.ErrUninitialized
+	}
+
+	key := strings.TrimPrefix(val, RefPrefix)
+	data, err := gs_Store.Get(ctx, key)
+	if err != nil {
+		logging.Warning("failed to read the blob", logging.Any("key", key), logging.Err(err))
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// 删除任务的所有大对象, 在任务结束时调用
+func DeleteTaskBlobs(taskId taskmodel.TaskIdType) error {
+	if gs_Store == nil {
+		return nil
+	}
+
+	err := gs_Store.DeletePrefix(context.Background(), fmt.Sprintf("%d/", taskId))
+	if err != nil {
+		logging.Warning("failed to delete the task blobs", logging.TaskId(taskId), logging.Err(err))
+		return err
+	}
+
+	return nil
+}
+
+func offload(key string, val string) (string, error) {
+	if gs_Store == nil || len(val) <= gs_Threshold || IsRef(val) {
+		return val, nil
+	}
+
+	err := gs_Store.Put(context.Background(), key, []byte(val))
+	if err != nil {
+		logging.Warning("failed to write the blob", logging.Any("key", key), logging.Any("size", len(val)),
+			logging.Err(err))
+		return val, err
+	}
+
+	return RefPrefix + key, nil
+}
+
+func subtaskKey(taskId taskmodel.TaskIdType, subtaskId taskmodel.SubtaskIdType, kind string) string {
+	return fmt.Sprintf("%d/%d.%s", taskId, subtaskId, kind)
+}
@@ -0,0 +1,62 @@
+package blobtool
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/danenmao/pterergate-dtf/dtf/blobstore"
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+)
+
+func Test_Offload(t *testing.T) {
+	SetDefault(blobstore.NewFileStore(t.TempDir()), 16)
+	defer SetDefault(nil, 0)
+
+	large := strings.Repeat("x", 32)
+	subtask := taskmodel.SubtaskBody{SubtaskId: 10, TaskId: 1, TypeParam: large}
+	small := taskmodel.SubtaskBody{SubtaskId: 11, TaskId: 1, TypeParam: "{}"}
+	result := taskmodel.SubtaskResult{SubtaskId: 10, TaskId: 1, ResultBody: large}
+
+	Convey("offload the large params and results", t, func() {
+		So(OffloadParam(&subtask), ShouldBeNil)
+		So(OffloadParam(&small), ShouldBeNil)
+		So(OffloadResult(&result), ShouldBeNil)
+
+		Convey("should replace the large payloads with references", func() {
+			So(subtask.TypeParam, ShouldEqual, RefPrefix+"1/10.param")
+			So(small.TypeParam, ShouldEqual, "{}")
+			So(result.ResultBody, ShouldEqual, RefPrefix+"1/10.result")
+		})
+
+		Convey("should resolve the references", func() {
+			resolved := subtask
+			So(ResolveParam(&resolved), ShouldBeNil)
+			So(resolved.TypeParam, ShouldEqual, large)
+
+			resolvedResult := result
+			So(ResolveResult(&resolvedResult), ShouldBeNil)
+			So(resolvedResult.ResultBody, ShouldEqual, large)
+		})
+
+		Convey("should delete the blobs of the task", func() {
+			So(DeleteTaskBlobs(1), ShouldBeNil)
+			_, err := Resolve(context.Background(), subtask.TypeParam)
+			So(err, ShouldEqual, errordef.ErrNotFound)
+		})
+	})
+}
+
+func Test_Resolve_NoStore(t *testing.T) {
+	val, err := Resolve(context.Background(), "{}")
+	_, refErr := Resolve(context.Background(), RefPrefix+"1/10.param")
+
+	Convey("resolve without a blob store", t, func() {
+		So(err, ShouldBeNil)
+		So(val, ShouldEqual, "{}")
+		So(refErr, ShouldEqual, errordef.ErrUninitialized)
+	})
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/golang/glog"
+)
+
+// 配置文件变化后, 等待写入完成的时间
+const ConfigReloadDelay = 500 * time.Millisecond
+
+// 监视配置文件, 文件变化时调用onChange, 返回停止监视的函数
+// 监视文件所在的目录, 以便处理编辑器和ConfigMap通过重命名替换文件的情况
+func WatchConfigFile(path string, onChange func()) (func(), error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		glog.Warning("failed to create config watcher: ", err)
+		return nil, err
+	}
+
+	path = filepath.Clean(path)
+	err = watcher.Add(filepath.Dir(path))
+	if err != nil {
+		glog.Warning("failed to watch config file: ", path, ", ", err)
+		watcher.Close()
+		return nil, err
+	}
+
+	go func() {
+		// 合并短时间内的多次变化
+		var timer *time.Timer
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				if filepath.Clean(event.Name) != path ||
+					!event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Rename) {
+					continue
+				}
+
+				glog.Info("config file changed: ", event)
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(ConfigReloadDelay, onChange)
+
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+
+				glog.Warning("config watcher error: ", err)
+			}
+		}
+	}()
+
+	return func() {
+		watcher.Close()
+	}, nil
+}
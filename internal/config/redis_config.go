@@ -6,3 +6,6 @@ import (
 
 // Redis数据库的连接配置
 var DefaultRedisServer = extconfig.RedisAddress{}
+
+// 所有key的命名空间前缀, 为空时不添加前缀
+var KeyPrefix = ""
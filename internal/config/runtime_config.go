@@ -0,0 +1,296 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/spf13/viper"
+
+	"github.com/danenmao/pterergate-dtf/dtf/extconfig"
+)
+
+// 运行时配置的环境变量前缀, 如 DTF_SCHEDULER_SCHEDULE_TASK_CONCURRENCY
+const RuntimeConfigEnvPrefix = "DTF"
+
+// 兼容的子任务结果处理协程数上限的环境变量
+const SubtaskRoutineLimitEnvName = "SUBTASK_ROUTINE_LIMIT"
+
+// 获取当前生效的运行时配置
+func GetRuntimeConfig() extconfig.RuntimeConfig {
+	return extconfig.RuntimeConfig{
+		Leader: extconfig.LeaderConfig{
+			Lease: EnvLeaderLease,
+		},
+		Manager: extconfig.ManagerConfig{
+			TaskCreationNextCheck:           EnvTaskCreationNextCheck,
+			TaskCreatingTimeout:             EnvTaskCreatingTimeout,
+			MonitorTaskTableConcurrency:     EnvMonitorTaskTblCountLimit,
+			MonitorTaskTableInterval:        EnvMonitorTaskTblInterval,
+			MonitorTaskTimeoutConcurrency:   EnvMonitorTaskTimeoutCountLimit,
+			MonitorTaskTimeoutInterval:      EnvMonitorTaskTimeoutInterval,
+			MonitorTaskCompletedConcurrency: EnvMonitorTaskCompletedCountLimit,
+			MonitorTaskCompletedInterval:    EnvMonitorTaskCompletedInterval,
+		},
+		Generator: extconfig.GeneratorConfig{
+			GenerateTaskConcurrency:      EnvGenerateTaskConcurrencyLimit,
+			GenerateTaskInterval:         EnvGenerateTaskCheckInterval,
+			MonitorGenerationConcurrency: EnvMonitorTaskGenerationConcurrencyLimit,
+			MonitorGenerationInterval:    EnvMonitorTaskGenerationInterval,
+			GenerationSlice:              EnvTaskGenerationSlice,
+			GenerationMaxFailures:        EnvTaskGenerationMaxFailures,
+			QueueHighWatermark:           EnvGenerationQueueHighWatermark,
+			QueueLowWatermark:            EnvGenerationQueueLowWatermark,
+			QueueBufferTime:              EnvGenerationQueueBufferTime,
+			Watermarks:                   append([]extconfig.WatermarkConfig{}, EnvGenerationQueueWatermarks...),
+		},
+		Scheduler: extconfig.SchedulerConfig{
+			ScheduleTaskConcurrency:           EnvScheduleTaskConcurrencyLimit,
+			ScheduleTaskInterval:              EnvScheduleTaskInterval,
+			RetryPushConcurrency:              EnvRetryPushSubtaskConcurrencyLimit,
+			RetryPushInterval:                 EnvRetryPushSubtaskInterval,
+			MonitorSubtaskTimeoutConcurrency:  EnvMonitorSubtaskTimeoutConcurrencyLimit,
+			MonitorSubtaskTimeoutInterval:     EnvMonitorSubtaskTimeoutInterval,
+			MonitorSubtaskCompleteConcurrency: EnvMonitorSubtaskCompleteConcurrencyLimit,
+			MonitorSubtaskCompleteInterval:    EnvMonitorSubtaskCompleteInterval,
+			MonitorTaskCompleteConcurrency:    EnvMonitorTaskCompleteConcurrencyLimit,
+			MonitorTaskCompleteInterval:       EnvMonitorTaskCompleteInterval,
+		},
+		Collector: extconfig.CollectorConfig{
+			CompleteSubtaskConcurrency: EnvCompleteSubtaskConcurrencyLimit,
+			CompleteSubtaskInterval:    EnvCompleteSubtaskInterval,
+			SubtaskRoutineLimit:        EnvSubtaskRoutineLimit,
+		},
+		Scheduling: GetSchedulingConfig(),
+	}
+}
+
+// 检查并设置运行时配置
+func SetRuntimeConfig(cfg *extconfig.RuntimeConfig) error {
+	err := ValidateRuntimeConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	EnvLeaderLease = cfg.Leader.Lease
+
+	EnvTaskCreationNextCheck = cfg.Manager.TaskCreationNextCheck
+	EnvTaskCreatingTimeout = cfg.Manager.TaskCreatingTimeout
+	EnvMonitorTaskTblCountLimit = cfg.Manager.MonitorTaskTableConcurrency
+	EnvMonitorTaskTblInterval = cfg.Manager.MonitorTaskTableInterval
+	EnvMonitorTaskTimeoutCountLimit = cfg.Manager.MonitorTaskTimeoutConcurrency
+	EnvMonitorTaskTimeoutInterval = cfg.Manager.MonitorTaskTimeoutInterval
+	EnvMonitorTaskCompletedCountLimit = cfg.Manager.MonitorTaskCompletedConcurrency
+	EnvMonitorTaskCompletedInterval = cfg.Manager.MonitorTaskCompletedInterval
+
+	EnvGenerateTaskConcurrencyLimit = cfg.Generator.GenerateTaskConcurrency
+	EnvGenerateTaskCheckInterval = cfg.Generator.GenerateTaskInterval
+	EnvMonitorTaskGenerationConcurrencyLimit = cfg.Generator.MonitorGenerationConcurrency
+	EnvMonitorTaskGenerationInterval = cfg.Generator.MonitorGenerationInterval
+	EnvTaskGenerationSlice = cfg.Generator.GenerationSlice
+	EnvTaskGenerationMaxFailures = cfg.Generator.GenerationMaxFailures
+	EnvGenerationQueueHighWatermark = cfg.Generator.QueueHighWatermark
+	EnvGenerationQueueLowWatermark = cfg.Generator.QueueLowWatermark
+	EnvGenerationQueueBufferTime = cfg.Generator.QueueBufferTime
+	EnvGenerationQueueWatermarks = append([]extconfig.WatermarkConfig{}, cfg.Generator.Watermarks...)
+
+	EnvScheduleTaskConcurrencyLimit = cfg.Scheduler.ScheduleTaskConcurrency
+	EnvScheduleTaskInterval = cfg.Scheduler.ScheduleTaskInterval
+	EnvRetryPushSubtaskConcurrencyLimit = cfg.Scheduler.RetryPushConcurrency
+	EnvRetryPushSubtaskInterval = cfg.Scheduler.RetryPushInterval
+	EnvMonitorSubtaskTimeoutConcurrencyLimit = cfg.Scheduler.MonitorSubtaskTimeoutConcurrency
+	EnvMonitorSubtaskTimeoutInterval = cfg.Scheduler.MonitorSubtaskTimeoutInterval
+	EnvMonitorSubtaskCompleteConcurrencyLimit = cfg.Scheduler.MonitorSubtaskCompleteConcurrency
+	EnvMonitorSubtaskCompleteInterval = cfg.Scheduler.MonitorSubtaskCompleteInterval
+	EnvMonitorTaskCompleteConcurrencyLimit = cfg.Scheduler.MonitorTaskCompleteConcurrency
+	EnvMonitorTaskCompleteInterval = cfg.Scheduler.MonitorTaskCompleteInterval
+
+	EnvCompleteSubtaskConcurrencyLimit = cfg.Collector.CompleteSubtaskConcurrency
+	EnvCompleteSubtaskInterval = cfg.Collector.CompleteSubtaskInterval
+	EnvSubtaskRoutineLimit = cfg.Collector.SubtaskRoutineLimit
+
+	setSchedulingConfig(&cfg.Scheduling)
+
+	return nil
+}
+
+// 检查运行时配置, 所有并发数和间隔都必须为正数
+func ValidateRuntimeConfig(cfg *extconfig.RuntimeConfig) error {
+	positives := []struct {
+		name  string
+		value int64
+	}{
+		{"leader.lease", int64(cfg.Leader.Lease)},
+
+		{"manager.task-creation-next-check", int64(cfg.Manager.TaskCreationNextCheck)},
+		{"manager.task-creating-timeout", int64(cfg.Manager.TaskCreatingTimeout)},
+		{"manager.monitor-task-table-concurrency", int64(cfg.Manager.MonitorTaskTableConcurrency)},
+		{"manager.monitor-task-table-interval", int64(cfg.Manager.MonitorTaskTableInterval)},
+		{"manager.monitor-task-timeout-concurrency", int64(cfg.Manager.MonitorTaskTimeoutConcurrency)},
+		{"manager.monitor-task-timeout-interval", int64(cfg.Manager.MonitorTaskTimeoutInterval)},
+		{"manager.monitor-task-completed-concurrency", int64(cfg.Manager.MonitorTaskCompletedConcurrency)},
+		{"manager.monitor-task-completed-interval", int64(cfg.Manager.MonitorTaskCompletedInterval)},
+
+		{"generator.generate-task-concurrency", int64(cfg.Generator.GenerateTaskConcurrency)},
+		{"generator.generate-task-interval", int64(cfg.Generator.GenerateTaskInterval)},
+		{"generator.monitor-generation-concurrency", int64(cfg.Generator.MonitorGenerationConcurrency)},
+		{"generator.monitor-generation-interval", int64(cfg.Generator.MonitorGenerationInterval)},
+		{"generator.generation-max-failures", int64(cfg.Generator.GenerationMaxFailures)},
+		{"generator.queue-high-watermark", int64(cfg.Generator.QueueHighWatermark)},
+
+		{"scheduler.schedule-task-concurrency", int64(cfg.Scheduler.ScheduleTaskConcurrency)},
+		{"scheduler.schedule-task-interval", int64(cfg.Scheduler.ScheduleTaskInterval)},
+		{"scheduler.retry-push-concurrency", int64(cfg.Scheduler.RetryPushConcurrency)},
+		{"scheduler.retry-push-interval", int64(cfg.Scheduler.RetryPushInterval)},
+		{"scheduler.monitor-subtask-timeout-concurrency", int64(cfg.Scheduler.MonitorSubtaskTimeoutConcurrency)},
+		{"scheduler.monitor-subtask-timeout-interval", int64(cfg.Scheduler.MonitorSubtaskTimeoutInterval)},
+		{"scheduler.monitor-subtask-complete-concurrency", int64(cfg.Scheduler.MonitorSubtaskCompleteConcurrency)},
+		{"scheduler.monitor-subtask-complete-interval", int64(cfg.Scheduler.MonitorSubtaskCompleteInterval)},
+		{"scheduler.monitor-task-complete-concurrency", int64(cfg.Scheduler.MonitorTaskCompleteConcurrency)},
+		{"scheduler.monitor-task-complete-interval", int64(cfg.Scheduler.MonitorTaskCompleteInterval)},
+
+		{"collector.complete-subtask-concurrency", int64(cfg.Collector.CompleteSubtaskConcurrency)},
+		{"collector.complete-subtask-interval", int64(cfg.Collector.CompleteSubtaskInterval)},
+		{"collector.subtask-routine-limit", int64(cfg.Collector.SubtaskRoutineLimit)},
+
+		{"scheduling.queue-base-time-slice", int64(cfg.Scheduling.QueueBaseTimeSlice)},
+		{"scheduling.rr-queue-time-slice", int64(cfg.Scheduling.RRQueueTimeSlice)},
+		{"scheduling.priority-bonus-low", int64(cfg.Scheduling.PriorityBonusLow)},
+		{"scheduling.priority-bonus-medium", int64(cfg.Scheduling.PriorityBonusMedium)},
+		{"scheduling.priority-bonus-high", int64(cfg.Scheduling.PriorityBonusHigh)},
+		{"scheduling.priority-boost-interval", int64(cfg.Scheduling.PriorityBoostInterval)},
+		{"scheduling.rr-priority-boost-interval", int64(cfg.Scheduling.RRPriorityBoostInterval)},
+		{"scheduling.executor-batch-size", int64(cfg.Scheduling.ExecutorBatchSize)},
+	}
+
+	for _, item := range positives {
+		if item.value <= 0 {
+			return fmt.Errorf("invalid runtime config %s: %d, must be positive", item.name, item.value)
+		}
+	}
+
+	if cfg.Generator.QueueLowWatermark >= cfg.Generator.QueueHighWatermark {
+		return fmt.Errorf("invalid runtime config generator.queue-low-watermark: %d, must be below the high watermark %d",
+			cfg.Generator.QueueLowWatermark, cfg.Generator.QueueHighWatermark)
+	}
+
+	if cfg.Generator.GenerationSlice < 0 {
+		return fmt.Errorf("invalid runtime config generator.generation-slice: %d, must not be negative",
+			cfg.Generator.GenerationSlice)
+	}
+
+	if cfg.Generator.QueueBufferTime < 0 {
+		return fmt.Errorf("invalid runtime config generator.queue-buffer-time: %d, must not be negative",
+			cfg.Generator.QueueBufferTime)
+	}
+
+	for _, watermark := range cfg.Generator.Watermarks {
+		if watermark.High == 0 || watermark.Low >= watermark.High {
+			return fmt.Errorf("invalid runtime config generator.watermarks: task type %d, %d, %d",
+				watermark.TaskType, watermark.High, watermark.Low)
+		}
+	}
+
+	for _, quota := range cfg.Scheduling.Quotas {
+		if len(quota.Name) == 0 || quota.Quota <= 0 {
+			return fmt.Errorf("invalid runtime config scheduling.quotas: %s, %v", quota.Name, quota.Quota)
+		}
+	}
+
+	return nil
+}
+
+// 从配置文件和环境变量加载运行时配置, cfg中的值作为默认值
+// path为空时只读取环境变量, 配置文件的格式由扩展名决定, 支持yaml和json
+// 环境变量的名称为 DTF_<节>_<键>, 如 DTF_SCHEDULER_SCHEDULE_TASK_CONCURRENCY, 优先级高于配置文件
+func LoadRuntimeConfig(path string, cfg *extconfig.RuntimeConfig) error {
+	v := viper.New()
+
+	// 以cfg中的值作为默认值, 同时使viper可以从环境变量读取所有的键
+	defaults := map[string]interface{}{}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(data, &defaults)
+	if err != nil {
+		return err
+	}
+
+	for section, values := range defaults {
+		for key, value := range values.(map[string]interface{}) {
+			v.SetDefault(section+"."+key, value)
+		}
+	}
+
+	v.SetEnvPrefix(RuntimeConfigEnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
+	v.AutomaticEnv()
+	v.BindEnv("collector.subtask-routine-limit",
+		RuntimeConfigEnvPrefix+"_COLLECTOR_SUBTASK_ROUTINE_LIMIT", SubtaskRoutineLimitEnvName)
+
+	if len(path) > 0 {
+		v.SetConfigFile(path)
+		err = v.ReadInConfig()
+		if err != nil {
+			glog.Warning("failed to read runtime config file: ", path, ", ", err)
+			return err
+		}
+	}
+
+	err = v.Unmarshal(cfg)
+	if err != nil {
+		glog.Warning("failed to parse runtime config: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// 比较两个运行时配置, 返回变化的配置项, 格式为 "<节>.<键>: <修改前> -> <修改后>"
+func DiffRuntimeConfig(before *extconfig.RuntimeConfig, after *extconfig.RuntimeConfig) []string {
+	beforeMap := flattenRuntimeConfig(before)
+	afterMap := flattenRuntimeConfig(after)
+
+	changes := []string{}
+	for key, afterVal := range afterMap {
+		beforeVal := beforeMap[key]
+		if beforeVal != afterVal {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", key, beforeVal, afterVal))
+		}
+	}
+
+	sort.Strings(changes)
+	return changes
+}
+
+// 将运行时配置展开为 <节>.<键> 到值的JSON文本的映射
+func flattenRuntimeConfig(cfg *extconfig.RuntimeConfig) map[string]string {
+	sections := map[string]map[string]json.RawMessage{}
+	data, _ := json.Marshal(cfg)
+	json.Unmarshal(data, &sections)
+
+	flattened := map[string]string{}
+	for section, values := range sections {
+		for key, value := range values {
+			flattened[section+"."+key] = string(value)
+		}
+	}
+
+	return flattened
+}
+
+// 输出当前生效的运行时配置
+func DumpRuntimeConfig() {
+	cfg := GetRuntimeConfig()
+	data, err := json.Marshal(&cfg)
+	if err != nil {
+		glog.Warning("failed to marshal runtime config: ", err)
+		return
+	}
+
+	glog.Info("effective runtime config: ", string(data))
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/danenmao/pterergate-dtf/dtf/extconfig"
+)
+
+func Test_LoadRuntimeConfig_FileAndEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dtf.yaml")
+	os.WriteFile(path, []byte("scheduler:\n  schedule-task-concurrency: 20\n  schedule-task-interval: 500\n"), 0600)
+
+	os.Setenv("DTF_SCHEDULER_SCHEDULE_TASK_INTERVAL", "300")
+	os.Setenv(SubtaskRoutineLimitEnvName, "50")
+	defer os.Unsetenv("DTF_SCHEDULER_SCHEDULE_TASK_INTERVAL")
+	defer os.Unsetenv(SubtaskRoutineLimitEnvName)
+
+	cfg := GetRuntimeConfig()
+	err := LoadRuntimeConfig(path, &cfg)
+
+	Convey("the file and environment variables override the defaults", t, func() {
+		So(err, ShouldBeNil)
+		So(cfg.Scheduler.ScheduleTaskConcurrency, ShouldEqual, 20)
+		So(cfg.Scheduler.ScheduleTaskInterval, ShouldEqual, 300)
+		So(cfg.Collector.SubtaskRoutineLimit, ShouldEqual, 50)
+		So(cfg.Manager.MonitorTaskTableInterval, ShouldEqual, EnvMonitorTaskTblInterval)
+	})
+}
+
+func Test_LoadRuntimeConfig_NoFile(t *testing.T) {
+	cfg := GetRuntimeConfig()
+	err := LoadRuntimeConfig(filepath.Join(t.TempDir(), "none.yaml"), &cfg)
+
+	Convey("fails when the config file does not exist", t, func() {
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func Test_SetRuntimeConfig_Invalid(t *testing.T) {
+	cfg := GetRuntimeConfig()
+	cfg.Scheduler.ScheduleTaskConcurrency = 0
+	err := SetRuntimeConfig(&cfg)
+
+	Convey("rejects a zero concurrency", t, func() {
+		So(err, ShouldNotBeNil)
+		So(EnvScheduleTaskConcurrencyLimit, ShouldNotEqual, 0)
+	})
+}
+
+func Test_LoadRuntimeConfig_Scheduling(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dtf.yaml")
+	os.WriteFile(path, []byte("scheduling:\n  rr-queue-time-slice: 8000\n"+
+		"  quotas:\n    - name: batch\n      quota: 0.2\n"), 0600)
+
+	cfg := GetRuntimeConfig()
+	err := LoadRuntimeConfig(path, &cfg)
+
+	Convey("the scheduling section and quotas are loaded", t, func() {
+		So(err, ShouldBeNil)
+		So(cfg.Scheduling.RRQueueTimeSlice, ShouldEqual, 8000)
+		So(cfg.Scheduling.QueueBaseTimeSlice, ShouldEqual, GetSchedulingConfig().QueueBaseTimeSlice)
+		So(len(cfg.Scheduling.Quotas), ShouldEqual, 1)
+		So(cfg.Scheduling.Quotas[0].Name, ShouldEqual, "batch")
+	})
+}
+
+func Test_LoadRuntimeConfig_Watermarks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dtf.yaml")
+	os.WriteFile(path, []byte("generator:\n  queue-high-watermark: 2000\n  queue-low-watermark: 1000\n"+
+		"  watermarks:\n    - task-type: 7\n      high: 200\n      low: 50\n"), 0600)
+
+	before := GetRuntimeConfig()
+	defer SetRuntimeConfig(&before)
+
+	cfg := GetRuntimeConfig()
+	err := LoadRuntimeConfig(path, &cfg)
+	setErr := SetRuntimeConfig(&cfg)
+	typeHigh, typeLow := GetGenerationWatermark(7)
+	defaultHigh, defaultLow := GetGenerationWatermark(8)
+
+	invalid := GetRuntimeConfig()
+	invalid.Generator.Watermarks = []extconfig.WatermarkConfig{{TaskType: 7, High: 100, Low: 100}}
+	invalidErr := ValidateRuntimeConfig(&invalid)
+
+	Convey("the generation watermarks of the task types are loaded", t, func() {
+		So(err, ShouldBeNil)
+		So(setErr, ShouldBeNil)
+		So(typeHigh, ShouldEqual, 200)
+		So(typeLow, ShouldEqual, 50)
+		So(defaultHigh, ShouldEqual, 2000)
+		So(defaultLow, ShouldEqual, 1000)
+		So(invalidErr, ShouldNotBeNil)
+	})
+}
+
+func Test_DiffRuntimeConfig(t *testing.T) {
+	before := GetRuntimeConfig()
+	after := before
+	after.Scheduling.ExecutorBatchSize = before.Scheduling.ExecutorBatchSize + 5
+	after.Scheduler.ScheduleTaskInterval = before.Scheduler.ScheduleTaskInterval + 1
+
+	changes := DiffRuntimeConfig(&before, &after)
+
+	Convey("reports the changed keys with before and after values", t, func() {
+		So(len(changes), ShouldEqual, 2)
+		So(changes[0], ShouldStartWith, "scheduler.schedule-task-interval: ")
+		So(changes[1], ShouldEqual, fmt.Sprintf("scheduling.executor-batch-size: %d -> %d",
+			before.Scheduling.ExecutorBatchSize, after.Scheduling.ExecutorBatchSize))
+		So(DiffRuntimeConfig(&before, &before), ShouldBeEmpty)
+	})
+}
+
+func Test_WatchConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dtf.yaml")
+	os.WriteFile(path, []byte("scheduling: {}\n"), 0600)
+
+	changed := make(chan struct{}, 1)
+	stop, err := WatchConfigFile(path, func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+
+	if err == nil {
+		defer stop()
+		os.WriteFile(path, []byte("scheduling:\n  executor-batch-size: 20\n"), 0600)
+	}
+
+	notified := false
+	select {
+	case <-changed:
+		notified = true
+	case <-time.After(ConfigReloadDelay * 6):
+	}
+
+	Convey("notifies when the config file changes", t, func() {
+		So(err, ShouldBeNil)
+		So(notified, ShouldBeTrue)
+	})
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"sync"
+
+	"github.com/danenmao/pterergate-dtf/dtf/extconfig"
+)
+
+// scheduling settings
+// 调度策略的配置可以在运行时修改, 读写需要加锁
+var (
+	gs_SchedulingConfig = extconfig.SchedulingConfig{
+		QueueBaseTimeSlice:      1000,
+		QueueTimeSliceStep:      1000,
+		RRQueueTimeSlice:        5000,
+		PriorityBonusLow:        1,
+		PriorityBonusMedium:     8,
+		PriorityBonusHigh:       256,
+		PriorityBoostInterval:   120,
+		RRPriorityBoostInterval: 240,
+		ExecutorBatchSize:       10,
+		Quotas:                  []extconfig.QuotaConfig{},
+	}
+
+	gs_SchedulingConfigLock sync.RWMutex
+)
+
+// 获取当前的调度策略配置
+func GetSchedulingConfig() extconfig.SchedulingConfig {
+	gs_SchedulingConfigLock.RLock()
+	defer gs_SchedulingConfigLock.RUnlock()
+
+	cfg := gs_SchedulingConfig
+	cfg.Quotas = append([]extconfig.QuotaConfig{}, gs_SchedulingConfig.Quotas...)
+	return cfg
+}
+
+func setSchedulingConfig(cfg *extconfig.SchedulingConfig) {
+	gs_SchedulingConfigLock.Lock()
+	defer gs_SchedulingConfigLock.Unlock()
+
+	gs_SchedulingConfig = *cfg
+	gs_SchedulingConfig.Quotas = append([]extconfig.QuotaConfig{}, cfg.Quotas...)
+}
@@ -1,9 +1,20 @@
 package config
 
 import (
-	"time"
+	"github.com/danenmao/pterergate-dtf/dtf/extconfig"
+)
 
-	"github.com/danenmao/pterergate-dtf/internal/basedef"
+// leader election settings
+var (
+	// 领导者租约时长, 秒
+	EnvLeaderLease int = 15
+)
+
+// redis key settings
+var (
+	// 执行中和已完成子任务集合的分片数, 为1时不分片
+	// Cluster模式下, 分片可以将全局集合分散到多个节点
+	EnvSubtaskSetShardCount uint = 1
 )
 
 // manager settings
@@ -11,29 +22,20 @@ var (
 	//
 	// task_creation的设置
 	//
-	EnvTaskNextCheckTimeMax  string = time.Now().AddDate(3000, 1, 1).Format(basedef.GoTimeFormatStr)
-	EnvTaskCreationNextCheck int    = 120
-	EnvTaskCreatingTimeout   int    = 100
+	EnvTaskCreationNextCheck int = 120
+	EnvTaskCreatingTimeout   int = 100
 
 	//
 	// monitor_task_tbl的设置
 	//
-	EnvMonitorTaskTblCountLimit   uint = 2
-	EnvMonitorTaskTblInterval     int  = 120
-	EnvMonitorTaskTbleRecordLimit uint = 1000
-
-	//
-	// monitor_task_creation的设置
-	//
-	EnvMonitorTaskCreationCountLimit uint = 2
-	EnvMonitorTaskCreationInterval   int  = 60
+	EnvMonitorTaskTblCountLimit uint = 2
+	EnvMonitorTaskTblInterval   int  = 120
 
 	//
 	// monitor_task_timeout的设置
 	//
 	EnvMonitorTaskTimeoutCountLimit uint = 2
 	EnvMonitorTaskTimeoutInterval   int  = 30
-	EnvTaskTimeout                  int  = 1800
 
 	//
 	// monitor_completed_task的设置
@@ -52,8 +54,31 @@ var (
 	// monitor_task_generation
 	EnvMonitorTaskGenerationConcurrencyLimit uint = 2
 	EnvMonitorTaskGenerationInterval         int  = 30
+
+	// generation slice, 0 means no limit
+	EnvTaskGenerationSlice int = 0
+
+	// the task is set exceptional after its generation fails so many times
+	EnvTaskGenerationMaxFailures int = 5
+
+	// generation backpressure
+	EnvGenerationQueueHighWatermark uint = 10000
+	EnvGenerationQueueLowWatermark  uint = 5000
+	EnvGenerationQueueBufferTime    int  = 300
+	EnvGenerationQueueWatermarks         = []extconfig.WatermarkConfig{}
 )
 
+// 获取任务类型的生成队列水位, 未单独配置时使用默认水位
+func GetGenerationWatermark(taskType uint32) (high uint, low uint) {
+	for _, watermark := range EnvGenerationQueueWatermarks {
+		if watermark.TaskType == taskType {
+			return watermark.High, watermark.Low
+		}
+	}
+
+	return EnvGenerationQueueHighWatermark, EnvGenerationQueueLowWatermark
+}
+
 // scheduler settings
 var (
 
@@ -67,7 +92,7 @@ var (
 
 	// monitor_subtask_timeout
 	EnvMonitorSubtaskTimeoutConcurrencyLimit uint = 5
-	EnvMonitorSubtaskTimeoutInterval              = 2
+	EnvMonitorSubtaskTimeoutInterval         int  = 2
 
 	// monitor_subtask_complete
 	EnvMonitorSubtaskCompleteConcurrencyLimit uint = 10
@@ -85,4 +110,5 @@ var (
 	//
 	EnvCompleteSubtaskConcurrencyLimit uint = 2
 	EnvCompleteSubtaskInterval         int  = 100
+	EnvSubtaskRoutineLimit             uint = 300
 )
@@ -14,6 +14,14 @@ const (
 	SubtaskIdKey = "dtf.subtask.id.counter"
 )
 
+const (
+	// 管理器的领导者租约
+	ManagerLeaderKey = "dtf.manager.leader"
+
+	// 调度器的领导者租约
+	SchedulerLeaderKey = "dtf.scheduler.leader"
+)
+
 const (
 
 	// task_zset
@@ -55,13 +63,15 @@ const (
 	TaskInfo_TimeoutSubtaskCountField   = "timeout_subtask_count"
 	TaskInfo_CancelledSubtaskCountField = "cancelled_subtask_count"
 	TaskInfo_GenerationCompletedField   = "generation_completed"
+	TaskInfo_GenerationFailuresField    = "generation_failures" // 任务生成失败的次数
 	TaskInfo_ResourceCostField          = "resource_cost"
 	TaskInfo_TaskTypeField              = "task_type"
 	TaskInfo_Progess                    = "progress"
 	TaskInfo_TypeParam                  = "type_param"
 	TaskInfo_InitTaskRecord             = "init_task_record"
 	TaskInfo_CheckUIDMapField           = "check_uid_map"
-	TaskInfo_StatusField                = "status" // 任务的运行状态: 1:运行中; 2:已完成; 3:已取消;
+	TaskInfo_StatusField                = "status"       // 任务的运行状态: 1:运行中; 2:已完成; 3:已取消;
+	TaskInfo_TraceParentField           = "trace_parent" // 任务的跟踪上下文
 
 	// 每个任务的锁
 	TaskInfoLockPrefix = "dtf.task.lock."
@@ -107,6 +117,7 @@ const (
 	SubtaskInfo_TaskTypeField     = "task_type"        // 子任务的任务类型
 	SubtaskInfo_TimeoutField      = "timeout"          // 子任务的超时时间
 	SubtaskInfo_TimeoutCountField = "timeout_count"    // 子任务超时的次数
+	SubtaskInfo_AttemptCountField = "attempt_count"    // 子任务被分发执行的次数
 	SubtaskInfo_PriorityField     = "subtask_priority" // 子任务的优先级
 	SubtaskInfo_StartTimeField    = "start_time"       // 子任务执行的开始时间
 	SubtaskInfo_EndTimeField      = "end_time"         // 子任务执行的结束时间
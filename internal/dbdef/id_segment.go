@@ -0,0 +1,64 @@
+package dbdef
+
+import (
+	"fmt"
+
+	"github.com/danenmao/pterergate-dtf/internal/dbdialect"
+)
+
+// ID号段表的定义, 每个键一行, 记录已分配的最大ID
+const (
+	IdSegmentTableName        = "tbl_id_segment"
+	IdSegmentTable_KeyName    = "key_name"
+	IdSegmentTable_MaxId      = "max_id"
+	IdSegmentTable_UpdateTime = "update_time"
+)
+
+// ID号段表, 由各方言生成建表语句
+var IdSegmentTable = dbdialect.TableDef{
+	Name:    IdSegmentTableName,
+	Comment: "ID号段表",
+	Columns: []dbdialect.ColumnDef{
+		{Name: IdSegmentTable_KeyName, Type: dbdialect.Column_String, Size: 100, Comment: "ID的键名"},
+		{Name: IdSegmentTable_MaxId, Type: dbdialect.Column_Uint64, Default: "0", Comment: "已分配的最大ID"},
+		{Name: IdSegmentTable_UpdateTime, Type: dbdialect.Column_DateTime, Comment: "最近分配号段的时间"},
+	},
+	PrimaryKey: []string{IdSegmentTable_KeyName},
+}
+
+// 分配一个号段, 在事务中执行, 行锁保证并发分配的号段不重叠
+var SQL_IdSegmentTable_Increase string = fmt.Sprintf(
+	"UPDATE `%s` SET `%s`=`%s`+?,`%s`=? where `%s`=?",
+	IdSegmentTableName,
+	IdSegmentTable_MaxId,
+	IdSegmentTable_MaxId,
+	IdSegmentTable_UpdateTime,
+	IdSegmentTable_KeyName,
+)
+
+// 将已分配的最大ID提高到指定值
+var SQL_IdSegmentTable_Raise string = fmt.Sprintf(
+	"UPDATE `%s` SET `%s`=?,`%s`=? where `%s`=? and `%s`<?",
+	IdSegmentTableName,
+	IdSegmentTable_MaxId,
+	IdSegmentTable_UpdateTime,
+	IdSegmentTable_KeyName,
+	IdSegmentTable_MaxId,
+)
+
+// 添加键
+var SQL_IdSegmentTable_InsertKey string = fmt.Sprintf(
+	"INSERT INTO `%s` (`%s`,`%s`,`%s`) VALUES (?,?,?)",
+	IdSegmentTableName,
+	IdSegmentTable_KeyName,
+	IdSegmentTable_MaxId,
+	IdSegmentTable_UpdateTime,
+)
+
+// 查询已分配的最大ID
+var SQL_IdSegmentTable_QueryMaxId string = fmt.Sprintf(
+	"select `%s` from `%s` where `%s`=?",
+	IdSegmentTable_MaxId,
+	IdSegmentTableName,
+	IdSegmentTable_KeyName,
+)
@@ -0,0 +1,53 @@
+package dbdef
+
+import (
+	"fmt"
+
+	"github.com/danenmao/pterergate-dtf/internal/dbdialect"
+)
+
+// 已执行的迁移
+type DBSchemaMigration struct {
+	Version   uint32 `db:"version" json:"version"`
+	Name      string `db:"name" json:"name"`
+	AppliedAt string `db:"applied_at" json:"applied_at"`
+}
+
+// 迁移表的定义
+const (
+	SchemaMigrationTableName       = "tbl_schema_migration"
+	SchemaMigrationTable_Version   = "version"
+	SchemaMigrationTable_Name      = "name"
+	SchemaMigrationTable_AppliedAt = "applied_at"
+)
+
+// 迁移表, 由各方言生成建表语句
+var SchemaMigrationTable = dbdialect.TableDef{
+	Name:    SchemaMigrationTableName,
+	Comment: "框架表的迁移记录",
+	Columns: []dbdialect.ColumnDef{
+		{Name: SchemaMigrationTable_Version, Type: dbdialect.Column_Uint32, Comment: "迁移的版本"},
+		{Name: SchemaMigrationTable_Name, Type: dbdialect.Column_String, Size: 255, Comment: "迁移的名称"},
+		{Name: SchemaMigrationTable_AppliedAt, Type: dbdialect.Column_DateTime, Comment: "执行迁移的时间"},
+	},
+	PrimaryKey: []string{SchemaMigrationTable_Version},
+}
+
+// 查询已执行的迁移版本
+var SQL_SchemaMigrationTable_QueryVersion string = fmt.Sprintf(
+	"select `%s` from `%s`",
+	SchemaMigrationTable_Version,
+	SchemaMigrationTableName,
+)
+
+// 记录已执行的迁移
+var SQL_SchemaMigrationTable_InsertVersion string = fmt.Sprintf(
+	"INSERT INTO `%s` (`%s`,`%s`,`%s`) VALUES (?,?,?)",
+	SchemaMigrationTableName,
+	SchemaMigrationTable_Version,
+	SchemaMigrationTable_Name,
+	SchemaMigrationTable_AppliedAt,
+)
+
+// 迁移使用的命名锁
+const SchemaMigrationLockName = "dtf.schema.migration"
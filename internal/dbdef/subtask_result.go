@@ -0,0 +1,78 @@
+package dbdef
+
+import (
+	"fmt"
+
+	"github.com/danenmao/pterergate-dtf/internal/dbdialect"
+)
+
+// 子任务结果结构, 同时用于MySQL和MongoDB
+type DBSubtaskResult struct {
+	SubtaskId  uint64 `db:"subtask_id" bson:"_id" json:"subtask_id"`
+	TaskId     uint64 `db:"task_id" bson:"task_id" json:"task_id"`
+	TaskType   uint32 `db:"task_type" bson:"task_type" json:"task_type"`
+	Result     uint32 `db:"result" bson:"result" json:"result"`
+	ResultCode uint32 `db:"result_code" bson:"result_code" json:"result_code"`
+	ResultMsg  string `db:"result_msg" bson:"result_msg" json:"result_msg"`
+	ResultBody string `db:"result_body" bson:"result_body" json:"result_body"`
+	TypeParam  string `db:"type_param" bson:"type_param" json:"type_param"`
+	StartTime  string `db:"start_time" bson:"start_time" json:"start_time"`
+	EndTime    string `db:"end_time" bson:"end_time" json:"end_time"`
+	TimeCost   uint32 `db:"time_cost" bson:"time_cost" json:"time_cost"`
+	Attempts   uint32 `db:"attempts" bson:"attempts" json:"attempts"`
+}
+
+// 子任务结果表的定义
+const (
+	SubtaskResultTableName        = "tbl_subtask_result"
+	SubtaskResultTable_SubtaskId  = "subtask_id"
+	SubtaskResultTable_TaskId     = "task_id"
+	SubtaskResultTable_TaskType   = "task_type"
+	SubtaskResultTable_Result     = "result"
+	SubtaskResultTable_ResultCode = "result_code"
+	SubtaskResultTable_ResultMsg  = "result_msg"
+	SubtaskResultTable_ResultBody = "result_body"
+	SubtaskResultTable_TypeParam  = "type_param"
+	SubtaskResultTable_StartTime  = "start_time"
+	SubtaskResultTable_EndTime    = "end_time"
+	SubtaskResultTable_TimeCost   = "time_cost"
+	SubtaskResultTable_Attempts   = "attempts"
+)
+
+// 子任务结果表, 由各方言生成建表语句.
+// 子任务被重新执行时, 按主键覆盖之前的结果
+var SubtaskResultTable = dbdialect.TableDef{
+	Name:    SubtaskResultTableName,
+	Comment: "子任务结果表",
+	Columns: []dbdialect.ColumnDef{
+		{Name: SubtaskResultTable_SubtaskId, Type: dbdialect.Column_Uint64},
+		{Name: SubtaskResultTable_TaskId, Type: dbdialect.Column_Uint64, Comment: "所属的任务ID"},
+		{Name: SubtaskResultTable_TaskType, Type: dbdialect.Column_Uint32, Comment: "任务类型"},
+		{Name: SubtaskResultTable_Result, Type: dbdialect.Column_Uint32, Comment: "子任务的结果"},
+		{Name: SubtaskResultTable_ResultCode, Type: dbdialect.Column_Uint32, Default: "0", Comment: "子任务的结果码"},
+		{Name: SubtaskResultTable_ResultMsg, Type: dbdialect.Column_String, Size: 1024, Default: "''", Comment: "结果的原因"},
+		{Name: SubtaskResultTable_ResultBody, Type: dbdialect.Column_Text, Comment: "与任务类型相关的结果数据"},
+		{Name: SubtaskResultTable_TypeParam, Type: dbdialect.Column_Text, Comment: "子任务的执行参数"},
+		{Name: SubtaskResultTable_StartTime, Type: dbdialect.Column_DateTime, Comment: "子任务开始执行的时间"},
+		{Name: SubtaskResultTable_EndTime, Type: dbdialect.Column_DateTime, Comment: "子任务结束的时间"},
+		{Name: SubtaskResultTable_TimeCost, Type: dbdialect.Column_Uint32, Default: "0", Comment: "子任务耗时,单位为秒"},
+		{Name: SubtaskResultTable_Attempts, Type: dbdialect.Column_Uint32, Default: "1", Comment: "子任务被分发执行的次数"},
+	},
+	PrimaryKey: []string{SubtaskResultTable_SubtaskId},
+	Indexes: []dbdialect.IndexDef{
+		{Name: "key_task_id", Columns: []string{SubtaskResultTable_TaskId, SubtaskResultTable_Result, SubtaskResultTable_SubtaskId}},
+	},
+}
+
+// 查询最大的子任务ID, 表为空时为0
+var SQL_SubtaskResultTable_QueryMaxId string = fmt.Sprintf(
+	"select coalesce(max(`%s`), 0) from `%s`",
+	SubtaskResultTable_SubtaskId,
+	SubtaskResultTableName,
+)
+
+// 查询子任务结果, 由调用者拼接查询条件
+var SQL_SubtaskResultTable_QueryResult string = fmt.Sprintf(
+	"select * from `%s`",
+	SubtaskResultTableName,
+)
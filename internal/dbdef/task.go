@@ -1,6 +1,10 @@
 package dbdef
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/danenmao/pterergate-dtf/internal/dbdialect"
+)
 
 // 任务结构
 type DBTaskRecord struct {
@@ -15,6 +19,8 @@ type DBTaskRecord struct {
 	TaskType      uint32 `db:"task_type" json:"task_type"`
 	TimeCost      uint32 `db:"time_cost" json:"time_cost"`
 	TaskStatus    uint8  `db:"task_status" json:"task_status"`
+	ResourceGroup string `db:"resource_group" json:"resource_group"`
+	Priority      uint32 `db:"priority" json:"priority"`
 }
 
 // 任务表的定义
@@ -31,39 +37,45 @@ const (
 	TaskTable_TaskType      = "task_type"
 	TaskTable_TimeCost      = "time_cost"
 	TaskTable_TaskStatus    = "task_status"
+	TaskTable_ResourceGroup = "resource_group"
+	TaskTable_Priority      = "priority"
 )
 
-// 创建任务表的语句
-var SQL_CreateTaskTable string = fmt.Sprintf(
-	"CREATE TABLE IF NOT EXISTS `%s` ("+
-		"`id` bigint UNSIGNED NOT NULL,"+
-		"`name` varchar(100) NOT NULL COMMENT '任务名称',"+
-		"`description` varchar(255) NOT NULL DEFAULT '' COMMENT '任务的描述',"+
-		"`uid` bigint UNSIGNED NOT NULL COMMENT '所有者id',"+
-		"`creator` varchar(100) NOT NULL COMMENT '创建者的账号',"+
-		"`start_time` datetime NOT NULL COMMENT '创建任务的时间',"+
-		"`finish_time` datetime NOT NULL COMMENT '任务结束的时间',"+
-		"`next_check_time` datetime NOT NULL COMMENT '下次检查任务状态的时间',"+
-		"`task_type` int UNSIGNED NOT NULL COMMENT '任务类型',"+
-		"`time_cost` int UNSIGNED NOT NULL DEFAULT 0 COMMENT '任务耗时,单位为秒',"+
-		"`task_status` tinyint UNSIGNED NOT NULL COMMENT '任务的状态',"+
-
-		"PRIMARY KEY (`id`),"+
-		"KEY `key_uid` (`uid`,`asset_type`,`risk_level`),"+
-		"KEY `key_start_time` (`start_time`, `finish_time`)"+
-		")"+
-		"ENGINE = InnoDB "+
-		"AUTO_INCREMENT = 1 "+
-		"DEFAULT CHARSET = utf8mb4 "+
-		"COMMENT='任务表'",
-
-	TaskTableName,
-)
+// 任务表, 由各方言生成建表语句
+var TaskTable = dbdialect.TableDef{
+	Name:    TaskTableName,
+	Comment: "任务表",
+	Columns: []dbdialect.ColumnDef{
+		{Name: TaskTable_Id, Type: dbdialect.Column_Uint64},
+		{Name: TaskTable_Name, Type: dbdialect.Column_String, Size: 100, Comment: "任务名称"},
+		{Name: TaskTable_Description, Type: dbdialect.Column_String, Size: 255, Default: "''", Comment: "任务的描述"},
+		{Name: TaskTable_UID, Type: dbdialect.Column_Uint64, Comment: "所有者id"},
+		{Name: TaskTable_Creator, Type: dbdialect.Column_String, Size: 100, Comment: "创建者的账号"},
+		{Name: TaskTable_StartTime, Type: dbdialect.Column_DateTime, Comment: "创建任务的时间"},
+		{Name: TaskTable_FinishTime, Type: dbdialect.Column_DateTime, Comment: "任务结束的时间"},
+		{Name: TaskTable_NextCheckTime, Type: dbdialect.Column_DateTime, Comment: "下次检查任务状态的时间"},
+		{Name: TaskTable_TaskType, Type: dbdialect.Column_Uint32, Comment: "任务类型"},
+		{Name: TaskTable_TimeCost, Type: dbdialect.Column_Uint32, Default: "0", Comment: "任务耗时,单位为秒"},
+		{Name: TaskTable_TaskStatus, Type: dbdialect.Column_Uint8, Comment: "任务的状态"},
+		{Name: TaskTable_ResourceGroup, Type: dbdialect.Column_String, Size: 100, Default: "''", Comment: "任务所属的资源组名"},
+		{Name: TaskTable_Priority, Type: dbdialect.Column_Uint32, Default: "0", Comment: "任务的基础优先级"},
+	},
+	PrimaryKey: []string{TaskTable_Id},
+	Indexes: []dbdialect.IndexDef{
+		{Name: "key_uid", Columns: []string{TaskTable_UID, TaskTable_Id}},
+		{Name: "key_task_type", Columns: []string{TaskTable_TaskType, TaskTable_Id}},
+		{Name: "key_task_status", Columns: []string{TaskTable_TaskStatus, TaskTable_Id}},
+		{Name: "key_resource_group", Columns: []string{TaskTable_ResourceGroup, TaskTable_Id}},
+		{Name: "key_name", Columns: []string{TaskTable_Name}},
+		{Name: "key_start_time", Columns: []string{TaskTable_StartTime, TaskTable_FinishTime}},
+		{Name: "key_finish_time", Columns: []string{TaskTable_FinishTime}},
+	},
+}
 
 // 添加任务记录
 var SQL_TaskTable_InsertTask string = fmt.Sprintf(
-	"INSERT INTO `%s` (`%s`,`%s`,`%s`,`%s`,`%s`,`%s`,`%s`,`%s`,`%s`,`%s`,"+
-		") VALUES (:%s,:%s,:%s,:%s,:%s,:%s,:%s,:%s,:%s)",
+	"INSERT INTO `%s` (`%s`,`%s`,`%s`,`%s`,`%s`,`%s`,`%s`,`%s`,`%s`,`%s`,`%s`,`%s`,`%s`"+
+		") VALUES (:%s,:%s,:%s,:%s,:%s,:%s,:%s,:%s,:%s,:%s,:%s,:%s,:%s)",
 
 	TaskTableName,
 
@@ -77,6 +89,9 @@ var SQL_TaskTable_InsertTask string = fmt.Sprintf(
 	TaskTable_NextCheckTime,
 	TaskTable_TaskType,
 	TaskTable_TimeCost,
+	TaskTable_TaskStatus,
+	TaskTable_ResourceGroup,
+	TaskTable_Priority,
 
 	TaskTable_Id,
 	TaskTable_Name,
@@ -87,6 +102,10 @@ var SQL_TaskTable_InsertTask string = fmt.Sprintf(
 	TaskTable_FinishTime,
 	TaskTable_NextCheckTime,
 	TaskTable_TaskType,
+	TaskTable_TimeCost,
+	TaskTable_TaskStatus,
+	TaskTable_ResourceGroup,
+	TaskTable_Priority,
 )
 
 // 任务完成中更新任务记录
@@ -113,8 +132,29 @@ var SQL_TaskTable_UpdateNextCheckTime string = fmt.Sprintf(
 
 // 获取任务表中创建异常的任务列表
 var SQL_TaskTable_QueryExceptionalCreationTask string = fmt.Sprintf(
-	"select `%s` from `%s` where `%s` < ? limit ?, ? ",
+	"select `%s` from `%s` where `%s` < ? limit ? offset ? ",
 	TaskTable_Id,
 	TaskTableName,
 	TaskTable_NextCheckTime,
 )
+
+// 更新任务的状态
+var SQL_TaskTable_UpdateTaskStatus string = fmt.Sprintf(
+	"UPDATE `%s` SET `%s`=? where `%s`=?",
+	TaskTableName,
+	TaskTable_TaskStatus,
+	TaskTable_Id,
+)
+
+// 查询最大的任务ID, 表为空时为0
+var SQL_TaskTable_QueryMaxId string = fmt.Sprintf(
+	"select coalesce(max(`%s`), 0) from `%s`",
+	TaskTable_Id,
+	TaskTableName,
+)
+
+// 查询任务记录, 由调用者拼接查询条件
+var SQL_TaskTable_QueryTask string = fmt.Sprintf(
+	"select * from `%s`",
+	TaskTableName,
+)
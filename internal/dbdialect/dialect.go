@@ -0,0 +1,116 @@
+package dbdialect
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/extconfig"
+)
+
+// 未能在超时时间内获取命名锁
+var ErrLockNotAcquired = errors.New("failed to acquire the database lock")
+
+// SQL数据库的方言
+// 框架的语句以MySQL的语法编写, 使用反引号引用标识符和?占位符,
+// 执行前由SQL转换为当前方言的语法. 建表、升级和覆盖写入等语法差异较大的语句由方言生成
+type IDialect interface {
+	// 方言的名称, 即 extconfig.SQLDialect_*
+	Name() string
+
+	// 默认的驱动名, 未指定 MySQLAddress.Type 时使用
+	DriverName() string
+
+	// 占位符的类型, 即 sqlx.QUESTION 或 sqlx.DOLLAR
+	BindType() int
+
+	// 生成连接串, setting为附加的连接参数
+	DSN(addr *extconfig.MySQLAddress, setting string) string
+
+	// 将语句中的反引号转换为方言的标识符引号
+	Quote(query string) string
+
+	// 生成建表语句, 包括索引, 表已存在时不报错
+	CreateTable(table *TableDef) []string
+
+	// 生成向已有的表添加列和索引的语句
+	AddColumn(table *TableDef, column string) string
+	AddIndex(table *TableDef, index string) string
+
+	// 生成按主键插入或覆盖记录的语句, 使用命名参数
+	Upsert(table *TableDef) string
+
+	// like语句的转义子句, 转义字符为反斜杠
+	LikeEscape() string
+
+	// 检查当前数据库中的表、列和索引是否存在
+	TableExists(ctx context.Context, conn *sqlx.Conn, table string) (bool, error)
+	ColumnExists(ctx context.Context, conn *sqlx.Conn, table string, column string) (bool, error)
+	IndexExists(ctx context.Context, conn *sqlx.Conn, table string, index string) (bool, error)
+
+	// 在连接上获取和释放命名锁, 用于多个进程互斥地修改表结构
+	Lock(ctx context.Context, conn *sqlx.Conn, name string, timeoutSec int) error
+	Unlock(ctx context.Context, conn *sqlx.Conn, name string) error
+}
+
+// 支持的方言
+var gs_Dialects = map[string]IDialect{
+	extconfig.SQLDialect_MySQL:      &MySQLDialect{},
+	extconfig.SQLDialect_PostgreSQL: &PostgreSQLDialect{},
+	extconfig.SQLDialect_SQLite:     &SQLiteDialect{},
+}
+
+// 获取方言, 名称为空时为MySQL
+func Get(name string) (IDialect, error) {
+	if len(name) == 0 {
+		name = extconfig.SQLDialect_MySQL
+	}
+
+	dialect, ok := gs_Dialects[name]
+	if !ok {
+		return nil, errordef.ErrInvalidParameter
+	}
+
+	return dialect, nil
+}
+
+// 默认连接使用的方言
+var gs_DefaultDialect IDialect = &MySQLDialect{}
+
+func Default() IDialect {
+	return gs_DefaultDialect
+}
+
+// 设置默认连接使用的方言, 需在连接默认数据库时调用
+func SetDefault(dialect IDialect) {
+	gs_DefaultDialect = dialect
+}
+
+// 将MySQL语法的语句转换为默认方言的语法
+func SQL(query string) string {
+	return sqlx.Rebind(gs_DefaultDialect.BindType(), gs_DefaultDialect.Quote(query))
+}
+
+// 查询count(*)的结果是否大于0
+func countExists(ctx context.Context, conn *sqlx.Conn, bindType int, query string, args ...interface{}) (bool, error) {
+	var count int
+	err := conn.GetContext(ctx, &count, sqlx.Rebind(bindType, query), args...)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// 用逗号连接引用后的标识符
+func joinQuoted(names []string, quote string) string {
+	quoted := []string{}
+	for _, name := range names {
+		quoted = append(quoted, quote+name+quote)
+	}
+
+	return strings.Join(quoted, ",")
+}
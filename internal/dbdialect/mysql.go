@@ -0,0 +1,154 @@
+package dbdialect
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/danenmao/pterergate-dtf/dtf/extconfig"
+)
+
+// MySQL方言, 框架语句的原始语法
+type MySQLDialect struct{}
+
+func (d *MySQLDialect) Name() string {
+	return extconfig.SQLDialect_MySQL
+}
+
+func (d *MySQLDialect) DriverName() string {
+	return "mysql"
+}
+
+func (d *MySQLDialect) BindType() int {
+	return sqlx.QUESTION
+}
+
+func (d *MySQLDialect) DSN(addr *extconfig.MySQLAddress, setting string) string {
+	if len(setting) > 0 {
+		setting = fmt.Sprintf("&%s", setting)
+	}
+
+	return fmt.Sprintf("%s:%s@%s(%s)/%s?charset=utf8mb4%s",
+		addr.Username,
+		addr.Password,
+		addr.Protocol,
+		addr.Address,
+		addr.DB,
+		setting,
+	)
+}
+
+func (d *MySQLDialect) Quote(query string) string {
+	return query
+}
+
+func (d *MySQLDialect) columnType(column *ColumnDef) string {
+	switch column.Type {
+	case Column_Uint64:
+		return "bigint UNSIGNED"
+	case Column_Uint32:
+		return "int UNSIGNED"
+	case Column_Uint8:
+		return "tinyint UNSIGNED"
+	case Column_String:
+		return fmt.Sprintf("varchar(%d)", column.Size)
+	case Column_Text:
+		return "mediumtext"
+	case Column_DateTime:
+		return "datetime"
+	}
+
+	return "varchar(255)"
+}
+
+func (d *MySQLDialect) column(column *ColumnDef) string {
+	sql := fmt.Sprintf("`%s` %s NOT NULL", column.Name, d.columnType(column))
+	if len(column.Default) > 0 {
+		sql += " DEFAULT " + column.Default
+	}
+
+	if len(column.Comment) > 0 {
+		sql += fmt.Sprintf(" COMMENT '%s'", column.Comment)
+	}
+
+	return sql
+}
+
+func (d *MySQLDialect) CreateTable(table *TableDef) []string {
+	defs := []string{}
+	for idx := range table.Columns {
+		defs = append(defs, d.column(&table.Columns[idx]))
+	}
+
+	defs = append(defs, fmt.Sprintf("PRIMARY KEY (%s)", joinQuoted(table.PrimaryKey, "`")))
+	for _, index := range table.Indexes {
+		defs = append(defs, fmt.Sprintf("KEY `%s` (%s)", index.Name, joinQuoted(index.Columns, "`")))
+	}
+
+	return []string{fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS `%s` (%s) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COMMENT='%s'",
+		table.Name, strings.Join(defs, ","), table.Comment,
+	)}
+}
+
+func (d *MySQLDialect) AddColumn(table *TableDef, column string) string {
+	return fmt.Sprintf("ALTER TABLE `%s` ADD COLUMN %s", table.Name, d.column(table.Column(column)))
+}
+
+func (d *MySQLDialect) AddIndex(table *TableDef, index string) string {
+	def := table.Index(index)
+	return fmt.Sprintf("ALTER TABLE `%s` ADD KEY `%s` (%s)", table.Name, def.Name, joinQuoted(def.Columns, "`"))
+}
+
+func (d *MySQLDialect) Upsert(table *TableDef) string {
+	names := table.ColumnNames()
+	return fmt.Sprintf("REPLACE INTO `%s` (%s) VALUES (:%s)",
+		table.Name, joinQuoted(names, "`"), strings.Join(names, ",:"))
+}
+
+func (d *MySQLDialect) LikeEscape() string {
+	return ""
+}
+
+func (d *MySQLDialect) TableExists(ctx context.Context, conn *sqlx.Conn, table string) (bool, error) {
+	return countExists(ctx, conn, sqlx.QUESTION,
+		"select count(*) from information_schema.tables where table_schema=database() and table_name=?",
+		table)
+}
+
+func (d *MySQLDialect) ColumnExists(ctx context.Context, conn *sqlx.Conn, table string, column string) (bool, error) {
+	return countExists(ctx, conn, sqlx.QUESTION,
+		"select count(*) from information_schema.columns "+
+			"where table_schema=database() and table_name=? and column_name=?",
+		table, column)
+}
+
+func (d *MySQLDialect) IndexExists(ctx context.Context, conn *sqlx.Conn, table string, index string) (bool, error) {
+	return countExists(ctx, conn, sqlx.QUESTION,
+		"select count(*) from information_schema.statistics "+
+			"where table_schema=database() and table_name=? and index_name=?",
+		table, index)
+}
+
+// 使用MySQL命名锁, 连接断开时自动释放
+func (d *MySQLDialect) Lock(ctx context.Context, conn *sqlx.Conn, name string, timeoutSec int) error {
+	var locked sql.NullInt64
+	err := conn.GetContext(ctx, &locked, "select GET_LOCK(?, ?)", name, timeoutSec)
+	if err != nil {
+		return err
+	}
+
+	if !locked.Valid || locked.Int64 != 1 {
+		return ErrLockNotAcquired
+	}
+
+	return nil
+}
+
+func (d *MySQLDialect) Unlock(ctx context.Context, conn *sqlx.Conn, name string) error {
+	var released sql.NullInt64
+	return conn.GetContext(ctx, &released, "select RELEASE_LOCK(?)", name)
+}
@@ -0,0 +1,192 @@
+package dbdialect
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/danenmao/pterergate-dtf/dtf/extconfig"
+)
+
+// PostgreSQL方言
+// 无符号整数使用更宽的有符号类型, 时间列使用定长字符串, 与MySQL的datetime保持相同的比较和读取方式.
+// 索引名在schema内唯一, 因此加上表名前缀
+type PostgreSQLDialect struct{}
+
+// 获取锁的轮询间隔
+const lockPollInterval = 500 * time.Millisecond
+
+func (d *PostgreSQLDialect) Name() string {
+	return extconfig.SQLDialect_PostgreSQL
+}
+
+func (d *PostgreSQLDialect) DriverName() string {
+	return "pgx"
+}
+
+func (d *PostgreSQLDialect) BindType() int {
+	return sqlx.DOLLAR
+}
+
+func (d *PostgreSQLDialect) DSN(addr *extconfig.MySQLAddress, setting string) string {
+	dsn := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(addr.Username, addr.Password),
+		Host:   addr.Address,
+		Path:   "/" + addr.DB,
+	}
+
+	dsn.RawQuery = setting
+	return dsn.String()
+}
+
+func (d *PostgreSQLDialect) Quote(query string) string {
+	return strings.ReplaceAll(query, "`", `"`)
+}
+
+func (d *PostgreSQLDialect) columnType(column *ColumnDef) string {
+	switch column.Type {
+	case Column_Uint64:
+		return "bigint"
+	case Column_Uint32:
+		return "bigint"
+	case Column_Uint8:
+		return "smallint"
+	case Column_String:
+		return fmt.Sprintf("varchar(%d)", column.Size)
+	case Column_Text:
+		return "text"
+	case Column_DateTime:
+		return "varchar(19)"
+	}
+
+	return "varchar(255)"
+}
+
+func (d *PostgreSQLDialect) column(column *ColumnDef) string {
+	sql := fmt.Sprintf(`"%s" %s NOT NULL`, column.Name, d.columnType(column))
+	if len(column.Default) > 0 {
+		sql += " DEFAULT " + column.Default
+	}
+
+	return sql
+}
+
+func (d *PostgreSQLDialect) CreateTable(table *TableDef) []string {
+	return createTable(table, d.column)
+}
+
+func (d *PostgreSQLDialect) AddColumn(table *TableDef, column string) string {
+	return fmt.Sprintf(`ALTER TABLE "%s" ADD COLUMN IF NOT EXISTS %s`, table.Name, d.column(table.Column(column)))
+}
+
+func (d *PostgreSQLDialect) AddIndex(table *TableDef, index string) string {
+	return createIndex(table, table.Index(index))
+}
+
+func (d *PostgreSQLDialect) Upsert(table *TableDef) string {
+	names := table.ColumnNames()
+	updates := []string{}
+	for _, name := range names {
+		updates = append(updates, fmt.Sprintf(`"%s"=EXCLUDED."%s"`, name, name))
+	}
+
+	return fmt.Sprintf(`INSERT INTO "%s" (%s) VALUES (:%s) ON CONFLICT (%s) DO UPDATE SET %s`,
+		table.Name, joinQuoted(names, `"`), strings.Join(names, ",:"),
+		joinQuoted(table.PrimaryKey, `"`), strings.Join(updates, ","))
+}
+
+func (d *PostgreSQLDialect) LikeEscape() string {
+	return ""
+}
+
+func (d *PostgreSQLDialect) TableExists(ctx context.Context, conn *sqlx.Conn, table string) (bool, error) {
+	return countExists(ctx, conn, sqlx.DOLLAR,
+		"select count(*) from information_schema.tables where table_schema=current_schema() and table_name=?",
+		table)
+}
+
+func (d *PostgreSQLDialect) ColumnExists(ctx context.Context, conn *sqlx.Conn, table string, column string) (bool, error) {
+	return countExists(ctx, conn, sqlx.DOLLAR,
+		"select count(*) from information_schema.columns "+
+			"where table_schema=current_schema() and table_name=? and column_name=?",
+		table, column)
+}
+
+func (d *PostgreSQLDialect) IndexExists(ctx context.Context, conn *sqlx.Conn, table string, index string) (bool, error) {
+	return countExists(ctx, conn, sqlx.DOLLAR,
+		"select count(*) from pg_indexes where schemaname=current_schema() and tablename=? and indexname=?",
+		table, indexName(table, index))
+}
+
+// 使用会话级的advisory lock, 连接断开时自动释放
+func (d *PostgreSQLDialect) Lock(ctx context.Context, conn *sqlx.Conn, name string, timeoutSec int) error {
+	deadline := time.Now().Add(time.Duration(timeoutSec) * time.Second)
+	for {
+		var locked bool
+		err := conn.GetContext(ctx, &locked, "select pg_try_advisory_lock($1)", lockKey(name))
+		if err != nil {
+			return err
+		}
+
+		if locked {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return ErrLockNotAcquired
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+func (d *PostgreSQLDialect) Unlock(ctx context.Context, conn *sqlx.Conn, name string) error {
+	var released bool
+	return conn.GetContext(ctx, &released, "select pg_advisory_unlock($1)", lockKey(name))
+}
+
+// advisory lock的键为整数, 由锁名计算
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(h.Sum64())
+}
+
+// 加上表名前缀的索引名
+func indexName(table string, index string) string {
+	return table + "_" + index
+}
+
+// 生成建表和建索引的语句, 用于没有列注释和内联索引的方言
+func createTable(table *TableDef, column func(*ColumnDef) string) []string {
+	defs := []string{}
+	for idx := range table.Columns {
+		defs = append(defs, column(&table.Columns[idx]))
+	}
+
+	defs = append(defs, fmt.Sprintf("PRIMARY KEY (%s)", joinQuoted(table.PrimaryKey, `"`)))
+	statements := []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS "%s" (%s)`, table.Name, strings.Join(defs, ",")),
+	}
+
+	for idx := range table.Indexes {
+		statements = append(statements, createIndex(table, &table.Indexes[idx]))
+	}
+
+	return statements
+}
+
+func createIndex(table *TableDef, index *IndexDef) string {
+	return fmt.Sprintf(`CREATE INDEX IF NOT EXISTS "%s" ON "%s" (%s)`,
+		indexName(table.Name, index.Name), table.Name, joinQuoted(index.Columns, `"`))
+}
@@ -0,0 +1,69 @@
+package dbdialect
+
+// 列的类型, 由各方言转换为数据库的类型
+type ColumnType uint32
+
+const (
+	Column_Uint64   ColumnType = 1
+	Column_Uint32   ColumnType = 2
+	Column_Uint8    ColumnType = 3
+	Column_String   ColumnType = 4 // 变长字符串, 长度为Size
+	Column_Text     ColumnType = 5 // 长文本
+	Column_DateTime ColumnType = 6 // 格式为 2006-01-02 15:04:05 的时间
+)
+
+// 列的定义, 所有的列都是NOT NULL
+type ColumnDef struct {
+	Name    string
+	Type    ColumnType
+	Size    int    // 字符串的长度
+	Default string // 默认值的SQL字面量, 如 '' 或 0, 为空时没有默认值
+	Comment string
+}
+
+// 索引的定义
+type IndexDef struct {
+	Name    string
+	Columns []string
+}
+
+// 表的定义, 由各方言生成建表语句
+type TableDef struct {
+	Name       string
+	Comment    string
+	Columns    []ColumnDef
+	PrimaryKey []string
+	Indexes    []IndexDef
+}
+
+// 获取列的定义, 不存在时返回nil
+func (table *TableDef) Column(name string) *ColumnDef {
+	for idx := range table.Columns {
+		if table.Columns[idx].Name == name {
+			return &table.Columns[idx]
+		}
+	}
+
+	return nil
+}
+
+// 获取索引的定义, 不存在时返回nil
+func (table *TableDef) Index(name string) *IndexDef {
+	for idx := range table.Indexes {
+		if table.Indexes[idx].Name == name {
+			return &table.Indexes[idx]
+		}
+	}
+
+	return nil
+}
+
+// 所有列的名称
+func (table *TableDef) ColumnNames() []string {
+	names := []string{}
+	for _, column := range table.Columns {
+		names = append(names, column.Name)
+	}
+
+	return names
+}
@@ -0,0 +1,125 @@
+package dbdialect
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/danenmao/pterergate-dtf/dtf/extconfig"
+)
+
+// SQLite方言, 用于本地开发和嵌入式部署
+// 数据库只允许一个写者, 迁移期间持有写事务代替命名锁
+type SQLiteDialect struct{}
+
+func (d *SQLiteDialect) Name() string {
+	return extconfig.SQLDialect_SQLite
+}
+
+func (d *SQLiteDialect) DriverName() string {
+	return "sqlite3"
+}
+
+func (d *SQLiteDialect) BindType() int {
+	return sqlx.QUESTION
+}
+
+func (d *SQLiteDialect) DSN(addr *extconfig.MySQLAddress, setting string) string {
+	if len(setting) > 0 {
+		return addr.Address + "?" + setting
+	}
+
+	return addr.Address
+}
+
+func (d *SQLiteDialect) Quote(query string) string {
+	return strings.ReplaceAll(query, "`", `"`)
+}
+
+func (d *SQLiteDialect) columnType(column *ColumnDef) string {
+	switch column.Type {
+	case Column_Uint64, Column_Uint32, Column_Uint8:
+		return "INTEGER"
+	case Column_String:
+		return fmt.Sprintf("varchar(%d)", column.Size)
+	}
+
+	return "TEXT"
+}
+
+func (d *SQLiteDialect) column(column *ColumnDef) string {
+	sql := fmt.Sprintf(`"%s" %s NOT NULL`, column.Name, d.columnType(column))
+	if len(column.Default) > 0 {
+		sql += " DEFAULT " + column.Default
+	}
+
+	return sql
+}
+
+func (d *SQLiteDialect) CreateTable(table *TableDef) []string {
+	return createTable(table, d.column)
+}
+
+func (d *SQLiteDialect) AddColumn(table *TableDef, column string) string {
+	return fmt.Sprintf(`ALTER TABLE "%s" ADD COLUMN %s`, table.Name, d.column(table.Column(column)))
+}
+
+func (d *SQLiteDialect) AddIndex(table *TableDef, index string) string {
+	return createIndex(table, table.Index(index))
+}
+
+func (d *SQLiteDialect) Upsert(table *TableDef) string {
+	names := table.ColumnNames()
+	return fmt.Sprintf(`INSERT OR REPLACE INTO "%s" (%s) VALUES (:%s)`,
+		table.Name, joinQuoted(names, `"`), strings.Join(names, ",:"))
+}
+
+// SQLite的like没有默认的转义字符
+func (d *SQLiteDialect) LikeEscape() string {
+	return ` escape '\'`
+}
+
+func (d *SQLiteDialect) TableExists(ctx context.Context, conn *sqlx.Conn, table string) (bool, error) {
+	return countExists(ctx, conn, sqlx.QUESTION,
+		"select count(*) from sqlite_master where type='table' and name=?", table)
+}
+
+func (d *SQLiteDialect) ColumnExists(ctx context.Context, conn *sqlx.Conn, table string, column string) (bool, error) {
+	return countExists(ctx, conn, sqlx.QUESTION,
+		"select count(*) from pragma_table_info(?) where name=?", table, column)
+}
+
+func (d *SQLiteDialect) IndexExists(ctx context.Context, conn *sqlx.Conn, table string, index string) (bool, error) {
+	return countExists(ctx, conn, sqlx.QUESTION,
+		"select count(*) from sqlite_master where type='index' and tbl_name=? and name=?",
+		table, indexName(table, index))
+}
+
+// 开启写事务, 其他进程的写事务在提交前无法开启
+func (d *SQLiteDialect) Lock(ctx context.Context, conn *sqlx.Conn, name string, timeoutSec int) error {
+	deadline := time.Now().Add(time.Duration(timeoutSec) * time.Second)
+	for {
+		_, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE")
+		if err == nil {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return ErrLockNotAcquired
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+func (d *SQLiteDialect) Unlock(ctx context.Context, conn *sqlx.Conn, name string) error {
+	_, err := conn.ExecContext(ctx, "COMMIT")
+	return err
+}
@@ -0,0 +1,107 @@
+package idtool
+
+import (
+	"context"
+	"sync"
+
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+)
+
+// ID生成器, 一个进程内可同时为多个键生成ID
+type IIdGenerator interface {
+	// 初始化键, 获取此键的ID前调用, 重复调用无影响
+	Init(keyName string) error
+
+	// 获取键的下一个ID
+	GetId(keyName string) (uint64, error)
+
+	// 保证之后生成的ID大于minId, 用于启动时跳过已持久化的ID
+	EnsureIdAbove(ctx context.Context, keyName string, minId uint64) error
+}
+
+// 默认从Redis按段分配ID
+var gs_DefaultGenerator IIdGenerator = NewSegmentGenerator(NewRedisSegmentSource())
+
+// 获取默认的ID生成器
+func Default() IIdGenerator {
+	return gs_DefaultGenerator
+}
+
+// 设置默认的ID生成器, 需在服务启动前调用
+func SetDefault(generator IIdGenerator) {
+	gs_DefaultGenerator = generator
+}
+
+func Init(keyName string) error {
+	return gs_DefaultGenerator.Init(keyName)
+}
+
+func GetId(keyName string) (uint64, error) {
+	return gs_DefaultGenerator.GetId(keyName)
+}
+
+func EnsureIdAbove(ctx context.Context, keyName string, minId uint64) error {
+	return gs_DefaultGenerator.EnsureIdAbove(ctx, keyName, minId)
+}
+
+// 按号段分配ID的生成器, 每个键使用一个IdKeeper缓存号段
+type SegmentGenerator struct {
+	source  ISegmentSource
+	lock    sync.Mutex
+	keepers map[string]*IdKeeper
+}
+
+func NewSegmentGenerator(source ISegmentSource) *SegmentGenerator {
+	return &SegmentGenerator{
+		source:  source,
+		keepers: map[string]*IdKeeper{},
+	}
+}
+
+func (generator *SegmentGenerator) Init(keyName string) error {
+	generator.lock.Lock()
+	defer generator.lock.Unlock()
+
+	if _, ok := generator.keepers[keyName]; ok {
+		return nil
+	}
+
+	keeper := &IdKeeper{Source: generator.source}
+	err := keeper.Init(keyName)
+	if err != nil {
+		return err
+	}
+
+	generator.keepers[keyName] = keeper
+	return nil
+}
+
+func (generator *SegmentGenerator) GetId(keyName string) (uint64, error) {
+	keeper := generator.keeper(keyName)
+	if keeper == nil {
+		return 0, errordef.ErrUninitialized
+	}
+
+	return keeper.GetId(keyName)
+}
+
+func (generator *SegmentGenerator) EnsureIdAbove(ctx context.Context, keyName string, minId uint64) error {
+	err := generator.source.EnsureAbove(ctx, keyName, minId)
+	if err != nil {
+		return err
+	}
+
+	// 已缓存的号段可能在提高计数器之前分配
+	keeper := generator.keeper(keyName)
+	if keeper != nil {
+		keeper.discardBelow(minId)
+	}
+
+	return nil
+}
+
+func (generator *SegmentGenerator) keeper(keyName string) *IdKeeper {
+	generator.lock.Lock()
+	defer generator.lock.Unlock()
+	return generator.keepers[keyName]
+}
@@ -0,0 +1,116 @@
+package idtool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/internal/dbdef"
+	"github.com/danenmao/pterergate-dtf/internal/exitctrl"
+	"github.com/danenmao/pterergate-dtf/internal/mysqltool"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
+)
+
+func Test_SegmentGenerator(t *testing.T) {
+	exitctrl.Register()
+	statestore.SetDefault(statestore.NewMemoryStore())
+	defer statestore.SetDefault(statestore.NewRedisStore())
+
+	generator := NewSegmentGenerator(NewRedisSegmentSource())
+	generator.Init("task")
+	generator.Init("subtask")
+
+	Convey("generate ids for several keys", t, func() {
+		id, err := generator.GetId("task")
+		So(err, ShouldBeNil)
+		So(id, ShouldEqual, 1)
+
+		id, err = generator.GetId("subtask")
+		So(err, ShouldBeNil)
+		So(id, ShouldEqual, 1)
+
+		_, err = generator.GetId("unknown")
+		So(err, ShouldEqual, errordef.ErrUninitialized)
+	})
+
+	Convey("skip the persisted ids", t, func() {
+		So(generator.EnsureIdAbove(context.Background(), "task", 1000), ShouldBeNil)
+
+		id, err := generator.GetId("task")
+		So(err, ShouldBeNil)
+		So(id, ShouldBeGreaterThan, 1000)
+
+		// 计数器已大于此值时不变
+		So(generator.EnsureIdAbove(context.Background(), "task", 10), ShouldBeNil)
+		next, _ := generator.GetId("task")
+		So(next, ShouldEqual, id+1)
+	})
+
+	exitctrl.NotifyToExit()
+	exitctrl.WaitForSignal(200 * time.Millisecond)
+}
+
+func Test_SQLSegmentSource(t *testing.T) {
+	Convey("add the key and alloc a segment", t, func() {
+		mysqltool.Setup()
+		defer mysqltool.Teardown()
+
+		mysqltool.DBMock.ExpectBegin()
+		mysqltool.DBMock.ExpectExec(dbdef.SQL_IdSegmentTable_Increase).
+			WithArgs(ReallocStep, sqlmock.AnyArg(), "task").WillReturnResult(sqlmock.NewResult(0, 0))
+		mysqltool.DBMock.ExpectRollback()
+
+		mysqltool.DBMock.ExpectQuery(dbdef.SQL_IdSegmentTable_QueryMaxId).
+			WithArgs("task").WillReturnRows(sqlmock.NewRows([]string{"max_id"}))
+		mysqltool.DBMock.ExpectExec(dbdef.SQL_IdSegmentTable_InsertKey).
+			WithArgs("task", 0, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		mysqltool.DBMock.ExpectBegin()
+		mysqltool.DBMock.ExpectExec(dbdef.SQL_IdSegmentTable_Increase).
+			WithArgs(ReallocStep, sqlmock.AnyArg(), "task").WillReturnResult(sqlmock.NewResult(0, 1))
+		mysqltool.DBMock.ExpectQuery(dbdef.SQL_IdSegmentTable_QueryMaxId).
+			WithArgs("task").WillReturnRows(sqlmock.NewRows([]string{"max_id"}).AddRow(ReallocStep))
+		mysqltool.DBMock.ExpectCommit()
+
+		maxId, err := NewSQLSegmentSource().Alloc(context.Background(), "task", ReallocStep)
+		So(err, ShouldBeNil)
+		So(maxId, ShouldEqual, ReallocStep)
+		So(mysqltool.DBMock.ExpectationsWereMet(), ShouldBeNil)
+	})
+}
+
+func Test_SnowflakeGenerator(t *testing.T) {
+	_, err := NewSnowflakeGenerator(SnowflakeMaxNodeId + 1)
+	generator, _ := NewSnowflakeGenerator(3)
+	var now int64 = 1000
+	generator.now = func() int64 { return now }
+
+	Convey("reject an invalid node id", t, func() {
+		So(err, ShouldEqual, errordef.ErrInvalidParameter)
+	})
+
+	Convey("generate increasing ids", t, func() {
+		first, _ := generator.GetId("task")
+		So(first, ShouldEqual, uint64(1000)<<22|3<<12)
+
+		second, _ := generator.GetId("subtask")
+		So(second, ShouldEqual, first+1)
+
+		// 时钟回拨时沿用上次的时间戳
+		now = 900
+		third, _ := generator.GetId("task")
+		So(third, ShouldEqual, second+1)
+	})
+
+	Convey("skip the persisted ids ahead of the clock", t, func() {
+		minId := uint64(5000) << 22
+		So(generator.EnsureIdAbove(context.Background(), "task", minId), ShouldBeNil)
+
+		id, _ := generator.GetId("task")
+		So(id, ShouldBeGreaterThan, minId)
+	})
+}
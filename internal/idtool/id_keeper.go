@@ -9,7 +9,7 @@ import (
 
 	"github.com/golang/glog"
 
-	"github.com/danenmao/pterergate-dtf/internal/redistool"
+	"github.com/danenmao/pterergate-dtf/internal/metrics"
 	"github.com/danenmao/pterergate-dtf/internal/routine"
 )
 
@@ -19,10 +19,12 @@ const (
 	ReallocCheckInterval = 5
 )
 
+// keep the id ranges of a key, allocated from the segment source.
 // id range:
 // [Start, FormerEnd),[NewStart, End)
 type IdKeeper struct {
 	KeyName   string
+	Source    ISegmentSource // the redis counter if nil
 	Lock      sync.Mutex
 	Step      uint32
 	Count     uint32
@@ -32,20 +34,6 @@ type IdKeeper struct {
 	End       uint64
 }
 
-var gs_IdKeeper = IdKeeper{}
-
-func GetIdKeeper() *IdKeeper {
-	return &gs_IdKeeper
-}
-
-func Init(keyName string) error {
-	return GetIdKeeper().Init(keyName)
-}
-
-func GetId(keyName string) (uint64, error) {
-	return GetIdKeeper().GetId(keyName)
-}
-
 func (keeper *IdKeeper) Init(keyName string) error {
 	if len(keyName) <= 0 {
 		return errors.New("empty key name")
@@ -53,8 +41,9 @@ func (keeper *IdKeeper) Init(keyName string) error {
 
 	keeper.KeyName = keyName
 	keeper.Step = ReallocStep
-
-	// TODO: sync data to MySQL
+	if keeper.Source == nil {
+		keeper.Source = NewRedisSegmentSource()
+	}
 
 	// start to maintain the id range
 	go func() {
@@ -150,14 +139,14 @@ func (keeper *IdKeeper) realloc() {
 	}
 
 	// extend the id range
-	cmd := redistool.DefaultRedis().IncrBy(context.Background(), keeper.KeyName, ReallocStep)
-	val, err := cmd.Result()
+	val, err := keeper.Source.Alloc(context.Background(), keeper.KeyName, ReallocStep)
 	if err != nil {
-		glog.Warning("failed to incby ID step: ", err.Error())
+		glog.Warning("failed to alloc ID segment: ", err.Error())
 		return
 	}
 
-	glog.Info("redis incrby id return: ", val)
+	glog.Info("alloc id segment return: ", val)
+	metrics.IdAllocatorRefills.Inc()
 
 	keeper.Count += uint32(keeper.Step)
 
@@ -178,3 +167,21 @@ func (keeper *IdKeeper) realloc() {
 		keeper.Start, keeper.FormerEnd,
 		keeper.NewStart, keeper.End, keeper.Count))
 }
+
+// drop the kept ranges if they have ids not above minId,
+// the next id is taken from a new range
+func (keeper *IdKeeper) discardBelow(minId uint64) {
+	keeper.Lock.Lock()
+	defer keeper.Lock.Unlock()
+
+	if keeper.Count <= 0 || keeper.Start > minId {
+		return
+	}
+
+	glog.Warning("discard id range below ", minId, ": [", keeper.Start, ",", keeper.End, ")")
+	keeper.Count = 0
+	keeper.Start = 0
+	keeper.FormerEnd = 0
+	keeper.NewStart = 0
+	keeper.End = 0
+}
@@ -0,0 +1,151 @@
+package idtool
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/golang/glog"
+
+	"github.com/danenmao/pterergate-dtf/internal/dbdef"
+	"github.com/danenmao/pterergate-dtf/internal/dbdialect"
+	"github.com/danenmao/pterergate-dtf/internal/mysqltool"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
+)
+
+// ID号段的来源, 保存每个键已分配的最大ID
+type ISegmentSource interface {
+	// 分配step个ID, 返回分配后的最大ID, 即号段为 (max-step, max]
+	Alloc(ctx context.Context, keyName string, step uint32) (uint64, error)
+
+	// 保证之后分配的ID大于minId
+	EnsureAbove(ctx context.Context, keyName string, minId uint64) error
+}
+
+// 基于Redis计数器的号段来源
+type RedisSegmentSource struct {
+}
+
+func NewRedisSegmentSource() *RedisSegmentSource {
+	return &RedisSegmentSource{}
+}
+
+func (source *RedisSegmentSource) Alloc(ctx context.Context, keyName string, step uint32) (uint64, error) {
+	val, err := statestore.Default().IncrBy(ctx, keyName, int64(step))
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(val), nil
+}
+
+func (source *RedisSegmentSource) EnsureAbove(ctx context.Context, keyName string, minId uint64) error {
+	val, err := statestore.Default().IncrBy(ctx, keyName, 0)
+	if err != nil {
+		return err
+	}
+
+	// 并发分配只会使计数器更大
+	if uint64(val) < minId {
+		glog.Warning("raise the id counter ", keyName, " from ", val, " to ", minId)
+		_, err = statestore.Default().IncrBy(ctx, keyName, int64(minId)-val)
+	}
+
+	return err
+}
+
+// 基于SQL数据库tbl_id_segment表的号段来源, 使用mysqltool的默认连接
+// 计数器与任务记录保存在同一个数据库中, Redis的数据丢失后ID不会重复
+type SQLSegmentSource struct {
+}
+
+// 键不存在
+var errNoSegmentKey = errors.New("no id segment key")
+
+func NewSQLSegmentSource() *SQLSegmentSource {
+	return &SQLSegmentSource{}
+}
+
+func (source *SQLSegmentSource) Alloc(ctx context.Context, keyName string, step uint32) (uint64, error) {
+	maxId, err := source.increase(ctx, keyName, step)
+	if err != errNoSegmentKey {
+		return maxId, err
+	}
+
+	err = source.addKey(ctx, keyName)
+	if err != nil {
+		return 0, err
+	}
+
+	return source.increase(ctx, keyName, step)
+}
+
+func (source *SQLSegmentSource) EnsureAbove(ctx context.Context, keyName string, minId uint64) error {
+	err := source.addKey(ctx, keyName)
+	if err != nil {
+		return err
+	}
+
+	result, err := mysqltool.DefaultMySQL().ExecContext(ctx, dbdialect.SQL(dbdef.SQL_IdSegmentTable_Raise),
+		minId, time.Now().Format(dbdef.GoTimeFormatStr), keyName, minId)
+	if err != nil {
+		return err
+	}
+
+	lines, _ := result.RowsAffected()
+	if lines > 0 {
+		glog.Warning("raised the id segment ", keyName, " to ", minId)
+	}
+
+	return nil
+}
+
+// 在事务中增加最大ID并读取, 更新持有的行锁使并发的分配依次执行
+func (source *SQLSegmentSource) increase(ctx context.Context, keyName string, step uint32) (uint64, error) {
+	tx, err := mysqltool.DefaultMySQL().BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, dbdialect.SQL(dbdef.SQL_IdSegmentTable_Increase),
+		step, time.Now().Format(dbdef.GoTimeFormatStr), keyName)
+	if err != nil {
+		return 0, err
+	}
+
+	lines, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if lines == 0 {
+		return 0, errNoSegmentKey
+	}
+
+	var maxId uint64 = 0
+	err = tx.GetContext(ctx, &maxId, dbdialect.SQL(dbdef.SQL_IdSegmentTable_QueryMaxId), keyName)
+	if err != nil {
+		return 0, err
+	}
+
+	return maxId, tx.Commit()
+}
+
+// 添加不存在的键, 其他进程同时添加时忽略插入失败
+func (source *SQLSegmentSource) addKey(ctx context.Context, keyName string) error {
+	var maxId uint64 = 0
+	err := mysqltool.DefaultMySQL().GetContext(ctx, &maxId, dbdialect.SQL(dbdef.SQL_IdSegmentTable_QueryMaxId), keyName)
+	if err != sql.ErrNoRows {
+		return err
+	}
+
+	_, err = mysqltool.DefaultMySQL().ExecContext(ctx, dbdialect.SQL(dbdef.SQL_IdSegmentTable_InsertKey),
+		keyName, 0, time.Now().Format(dbdef.GoTimeFormatStr))
+	if err != nil {
+		glog.Warning("failed to add id segment key: ", keyName, ", ", err)
+	}
+
+	return nil
+}
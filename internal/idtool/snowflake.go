@@ -0,0 +1,85 @@
+package idtool
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+)
+
+// Snowflake ID的组成: 41位毫秒时间戳, 10位节点ID, 12位序号
+const (
+	SnowflakeEpoch        = 1672531200000 // 2023-01-01 00:00:00 UTC, 毫秒
+	SnowflakeNodeBits     = 10
+	SnowflakeSequenceBits = 12
+	SnowflakeMaxNodeId    = 1<<SnowflakeNodeBits - 1
+	snowflakeSequenceMask = 1<<SnowflakeSequenceBits - 1
+	snowflakeTimeShift    = SnowflakeNodeBits + SnowflakeSequenceBits
+)
+
+// Snowflake风格的ID生成器, 不依赖外部服务
+// 每个进程需使用不同的节点ID, 所有键共享同一个序列.
+// 时钟回拨或序号用尽时沿用上次的时间戳并向后借用, 保证ID递增
+type SnowflakeGenerator struct {
+	lock     sync.Mutex
+	nodeId   uint64
+	lastTime int64
+	sequence uint64
+	now      func() int64
+}
+
+func NewSnowflakeGenerator(nodeId uint16) (*SnowflakeGenerator, error) {
+	if nodeId > SnowflakeMaxNodeId {
+		glog.Warning("invalid snowflake node id: ", nodeId)
+		return nil, errordef.ErrInvalidParameter
+	}
+
+	return &SnowflakeGenerator{
+		nodeId: uint64(nodeId),
+		now: func() int64 {
+			return time.Now().UnixMilli() - SnowflakeEpoch
+		},
+	}, nil
+}
+
+func (generator *SnowflakeGenerator) Init(keyName string) error {
+	return nil
+}
+
+func (generator *SnowflakeGenerator) GetId(keyName string) (uint64, error) {
+	generator.lock.Lock()
+	defer generator.lock.Unlock()
+
+	current := generator.now()
+	if current > generator.lastTime {
+		generator.lastTime = current
+		generator.sequence = 0
+	} else {
+		generator.sequence = (generator.sequence + 1) & snowflakeSequenceMask
+		if generator.sequence == 0 {
+			generator.lastTime++
+		}
+	}
+
+	return uint64(generator.lastTime)<<snowflakeTimeShift | generator.nodeId<<SnowflakeSequenceBits | generator.sequence, nil
+}
+
+// 已持久化的ID来自更晚的时钟时, 从其时间戳之后开始生成
+func (generator *SnowflakeGenerator) EnsureIdAbove(ctx context.Context, keyName string, minId uint64) error {
+	generator.lock.Lock()
+	defer generator.lock.Unlock()
+
+	minTime := int64(minId>>snowflakeTimeShift) + 1
+	if generator.lastTime >= minTime || generator.now() >= minTime {
+		return nil
+	}
+
+	glog.Warning("the persisted id ", minId, " of ", keyName, " is ahead of the clock, ",
+		"generate ids from its timestamp")
+	generator.lastTime = minTime
+	generator.sequence = 0
+	return nil
+}
@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+)
+
+// 子任务事件, 作为SubtaskEvents的event标签
+const (
+	SubtaskEvent_Generated  = "generated"
+	SubtaskEvent_Dispatched = "dispatched"
+	SubtaskEvent_Completed  = "completed"
+	SubtaskEvent_Failed     = "failed"
+	SubtaskEvent_Timeout    = "timeout"
+	SubtaskEvent_Duplicate  = "duplicate"
+)
+
+// 拒绝RPC请求的原因, 作为RequestRejections的reason标签
+const (
+	RejectReason_Unauthenticated = "unauthenticated"
+	RejectReason_BodyHash        = "body_hash"
+	RejectReason_ClockSkew       = "clock_skew"
+	RejectReason_Replayed        = "replayed"
+	RejectReason_BodyTooLarge    = "body_too_large"
+)
+
+// 存储类型, 作为StoreCallDuration的store标签
+const (
+	Store_Redis = "redis"
+	Store_MySQL = "mysql"
+	Store_Mongo = "mongodb"
+)
+
+// 框架的指标
+var (
+	// 服务信息, 每个启动的角色一个样本
+	ServiceInfo = NewGaugeVec("dtf_service_info",
+		"The service roles running in this process.", "role")
+
+	// 调度队列中的任务数
+	SchedulingQueueDepth = NewGaugeVec("dtf_scheduling_queue_depth",
+		"Number of tasks in each scheduling queue.", "quota_group", "queue")
+
+	// 子任务的生成、分发、完成、失败和超时数
+	SubtaskEvents = NewCounterVec("dtf_subtasks_total",
+		"Number of subtasks by task type and event.", "task_type", "event")
+
+	// 向执行器推送一批子任务的耗时
+	ExecutorBatchDuration = NewHistogramVec("dtf_executor_batch_duration_seconds",
+		"Latency of pushing a batch of subtasks to the executor.", nil)
+
+	// 向执行器推送子任务失败的批次数
+	ExecutorBatchErrors = NewCounterVec("dtf_executor_batch_errors_total",
+		"Number of subtask batches failed to push to the executor.")
+
+	// 收集器中待处理的子任务结果数
+	CollectorBacklog = NewGaugeVec("dtf_collector_backlog",
+		"Number of subtask results waiting to be processed by the collector.")
+
+	// 任务的一次生成循环的耗时
+	GenerationLoopDuration = NewHistogramVec("dtf_generation_loop_duration_seconds",
+		"Duration of a task generation loop.",
+		[]float64{1, 5, 15, 30, 60, 300, 900, 1800, 3600}, "task_type")
+
+	// ID分配器重新申请ID区间的次数
+	IdAllocatorRefills = NewCounterVec("dtf_id_allocator_refills_total",
+		"Number of ID range refills of the ID allocator.")
+
+	// 工作例程的单次执行耗时
+	RoutineDuration = NewHistogramVec("dtf_routine_duration_seconds",
+		"Duration of a single run of the working routines.", nil, "routine")
+
+	// 被拒绝的RPC请求数
+	RequestRejections = NewCounterVec("dtf_rpc_rejected_total",
+		"Number of RPC requests rejected by authentication or replay checks.", "uri", "reason")
+
+	// Redis和MySQL的调用耗时
+	StoreCallDuration = NewHistogramVec("dtf_store_call_duration_seconds",
+		"Latency of Redis and MySQL calls.", nil, "store", "operation")
+
+	// Redis和MySQL的调用失败数
+	StoreCallErrors = NewCounterVec("dtf_store_call_errors_total",
+		"Number of failed Redis and MySQL calls.", "store", "operation")
+)
+
+// 记录子任务事件
+func AddSubtaskEvent(taskType uint32, event string, count int) {
+	SubtaskEvents.Add(float64(count), strconv.FormatUint(uint64(taskType), 10), event)
+}
+
+// 记录一次存储调用的耗时和结果
+func ObserveStoreCall(store string, operation string, start time.Time, err error) {
+	StoreCallDuration.ObserveSince(start, store, operation)
+	if err != nil {
+		StoreCallErrors.Inc(store, operation)
+	}
+}
@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 默认的耗时分桶, 秒
+var DefaultDurationBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// 直方图, 统计样本的分布
+type HistogramVec struct {
+	metricVec
+	buckets []float64
+	mutex   sync.Mutex
+	values  map[string]*histogramValue
+}
+
+type histogramValue struct {
+	counts []uint64 // 各分桶的计数, 非累计
+	count  uint64
+	sum    float64
+}
+
+// 创建直方图并注册到默认注册表, buckets为空时使用DefaultDurationBuckets
+func NewHistogramVec(name string, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefaultDurationBuckets
+	}
+
+	buckets = append([]float64{}, buckets...)
+	sort.Float64s(buckets)
+
+	histogram := &HistogramVec{
+		metricVec: metricVec{name: name, help: help, labels: labels},
+		buckets:   buckets,
+		values:    map[string]*histogramValue{},
+	}
+
+	return Default().Register(histogram).(*HistogramVec)
+}
+
+// 记录一个样本
+func (histogram *HistogramVec) Observe(value float64, labelValues ...string) {
+	key := histogram.labelKey(labelValues)
+	histogram.mutex.Lock()
+	defer histogram.mutex.Unlock()
+
+	hv, ok := histogram.values[key]
+	if !ok {
+		hv = &histogramValue{counts: make([]uint64, len(histogram.buckets))}
+		histogram.values[key] = hv
+	}
+
+	idx := sort.SearchFloat64s(histogram.buckets, value)
+	if idx < len(hv.counts) {
+		hv.counts[idx]++
+	}
+
+	hv.count++
+	hv.sum += value
+}
+
+// 记录从start开始到现在的耗时, 单位为秒
+func (histogram *HistogramVec) ObserveSince(start time.Time, labelValues ...string) {
+	histogram.Observe(time.Since(start).Seconds(), labelValues...)
+}
+
+// 读取标签组合的样本数
+func (histogram *HistogramVec) Count(labelValues ...string) uint64 {
+	key := histogram.labelKey(labelValues)
+	histogram.mutex.Lock()
+	defer histogram.mutex.Unlock()
+
+	hv, ok := histogram.values[key]
+	if !ok {
+		return 0
+	}
+
+	return hv.count
+}
+
+func (histogram *HistogramVec) writeText(w io.Writer) {
+	histogram.mutex.Lock()
+	defer histogram.mutex.Unlock()
+
+	histogram.writeHeader(w, "histogram")
+	keys := make([]string, 0, len(histogram.values))
+	for key := range histogram.values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		hv := histogram.values[key]
+		labelValues := splitLabelKey(key, len(histogram.labels))
+
+		cumulative := uint64(0)
+		for i, bound := range histogram.buckets {
+			cumulative += hv.counts[i]
+			histogram.writeSample(w, histogram.name+"_bucket", labelValues,
+				"le", formatValue(bound), float64(cumulative))
+		}
+
+		histogram.writeSample(w, histogram.name+"_bucket", labelValues, "le", "+Inf", float64(hv.count))
+		histogram.writeSample(w, histogram.name+"_sum", labelValues, "", "", hv.sum)
+		histogram.writeSample(w, histogram.name+"_count", labelValues, "", "", float64(hv.count))
+	}
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_CounterVec_WriteText(t *testing.T) {
+	counter := NewCounterVec("test_counter_total", "A test counter.", "type", "event")
+	counter.Inc("1", "generated")
+	counter.Add(2, "1", "generated")
+	counter.Add(-1, "1", "generated")
+	counter.Inc("2", "fa\"iled")
+
+	buf := bytes.Buffer{}
+	err := Default().WriteText(&buf)
+	text := buf.String()
+
+	Convey("write the counter in the text format", t, func() {
+		So(err, ShouldBeNil)
+		So(counter.Value("1", "generated"), ShouldEqual, 3)
+		So(text, ShouldContainSubstring, "# TYPE test_counter_total counter\n")
+		So(text, ShouldContainSubstring, "test_counter_total{type=\"1\",event=\"generated\"} 3\n")
+		So(text, ShouldContainSubstring, "test_counter_total{type=\"2\",event=\"fa\\\"iled\"} 1\n")
+	})
+}
+
+func Test_HistogramVec_WriteText(t *testing.T) {
+	histogram := NewHistogramVec("test_duration_seconds", "A test histogram.", []float64{1, 0.1}, "op")
+	histogram.Observe(0.05, "get")
+	histogram.Observe(0.5, "get")
+	histogram.Observe(2, "get")
+
+	buf := bytes.Buffer{}
+	Default().WriteText(&buf)
+	text := buf.String()
+
+	Convey("write cumulative buckets, sum and count", t, func() {
+		So(histogram.Count("get"), ShouldEqual, 3)
+		So(text, ShouldContainSubstring, "test_duration_seconds_bucket{op=\"get\",le=\"0.1\"} 1\n")
+		So(text, ShouldContainSubstring, "test_duration_seconds_bucket{op=\"get\",le=\"1\"} 2\n")
+		So(text, ShouldContainSubstring, "test_duration_seconds_bucket{op=\"get\",le=\"+Inf\"} 3\n")
+		So(text, ShouldContainSubstring, "test_duration_seconds_sum{op=\"get\"} 2.55\n")
+		So(text, ShouldContainSubstring, "test_duration_seconds_count{op=\"get\"} 3\n")
+	})
+}
+
+func Test_Handler_Collector(t *testing.T) {
+	gauge := NewGaugeVec("test_backlog", "A test gauge.")
+	AddCollector(func() {
+		gauge.Set(42)
+	})
+
+	recorder := httptest.NewRecorder()
+	Handler().ServeHTTP(recorder, httptest.NewRequest("GET", MetricsPath, nil))
+
+	Convey("the collectors run before the metrics are written", t, func() {
+		So(recorder.Code, ShouldEqual, 200)
+		So(strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/plain"), ShouldBeTrue)
+		So(recorder.Body.String(), ShouldContainSubstring, "test_backlog 42\n")
+	})
+}
@@ -0,0 +1,157 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 指标的接口, 以Prometheus文本格式输出
+type IMetric interface {
+	Name() string
+	writeText(w io.Writer)
+}
+
+// 采集函数, 在输出指标前调用, 用于更新需要实时读取的指标, 如队列长度
+type CollectFn func()
+
+// 指标注册表
+type Registry struct {
+	mutex      sync.Mutex
+	metrics    map[string]IMetric
+	collectors []CollectFn
+}
+
+// 创建指标注册表
+func NewRegistry() *Registry {
+	return &Registry{
+		metrics: map[string]IMetric{},
+	}
+}
+
+// 默认的指标注册表
+var gs_DefaultRegistry = NewRegistry()
+
+// 获取默认的指标注册表
+func Default() *Registry {
+	return gs_DefaultRegistry
+}
+
+// 注册指标, 同名的指标只保留第一个
+func (registry *Registry) Register(metric IMetric) IMetric {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	if existing, ok := registry.metrics[metric.Name()]; ok {
+		return existing
+	}
+
+	registry.metrics[metric.Name()] = metric
+	return metric
+}
+
+// 添加采集函数
+func (registry *Registry) AddCollector(fn CollectFn) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	registry.collectors = append(registry.collectors, fn)
+}
+
+// 以Prometheus文本格式输出所有指标, 按指标名排序
+func (registry *Registry) WriteText(w io.Writer) error {
+	registry.mutex.Lock()
+	collectors := append([]CollectFn{}, registry.collectors...)
+	metrics := make([]IMetric, 0, len(registry.metrics))
+	for _, metric := range registry.metrics {
+		metrics = append(metrics, metric)
+	}
+	registry.mutex.Unlock()
+
+	for _, collect := range collectors {
+		collect()
+	}
+
+	sort.Slice(metrics, func(i, j int) bool {
+		return metrics[i].Name() < metrics[j].Name()
+	})
+
+	buf := bufio.NewWriter(w)
+	for _, metric := range metrics {
+		metric.writeText(buf)
+	}
+
+	return buf.Flush()
+}
+
+// 添加采集函数到默认注册表
+func AddCollector(fn CollectFn) {
+	Default().AddCollector(fn)
+}
+
+// 带标签的指标的公共部分
+type metricVec struct {
+	name   string
+	help   string
+	labels []string
+}
+
+func (vec *metricVec) Name() string {
+	return vec.name
+}
+
+// 标签值组合的键
+func (vec *metricVec) labelKey(labelValues []string) string {
+	if len(labelValues) != len(vec.labels) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d",
+			vec.name, len(vec.labels), len(labelValues)))
+	}
+
+	return strings.Join(labelValues, "\xff")
+}
+
+func (vec *metricVec) writeHeader(w io.Writer, metricType string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", vec.name, vec.help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", vec.name, metricType)
+}
+
+// 输出一个样本, extra为附加的标签, 如直方图的le
+func (vec *metricVec) writeSample(w io.Writer, name string, labelValues []string,
+	extraName string, extraValue string, value float64) {
+
+	pairs := []string{}
+	for i, label := range vec.labels {
+		pairs = append(pairs, label+"=\""+escapeLabelValue(labelValues[i])+"\"")
+	}
+
+	if len(extraName) > 0 {
+		pairs = append(pairs, extraName+"=\""+extraValue+"\"")
+	}
+
+	if len(pairs) > 0 {
+		fmt.Fprintf(w, "%s{%s} %s\n", name, strings.Join(pairs, ","), formatValue(value))
+	} else {
+		fmt.Fprintf(w, "%s %s\n", name, formatValue(value))
+	}
+}
+
+func splitLabelKey(key string, count int) []string {
+	if count == 0 {
+		return []string{}
+	}
+
+	return strings.SplitN(key, "\xff", count)
+}
+
+func escapeLabelValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, "\n", `\n`)
+	return strings.ReplaceAll(value, `"`, `\"`)
+}
+
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
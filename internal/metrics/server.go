@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// 指标的HTTP路径
+const MetricsPath = "/metrics"
+
+// 输出默认注册表中指标的HTTP处理函数
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		err := Default().WriteText(w)
+		if err != nil {
+			glog.Warning("failed to write metrics: ", err)
+		}
+	})
+}
+
+// 指标服务
+type Server struct {
+	Address string
+	server  *http.Server
+}
+
+// 创建指标服务, address的格式为 host:port
+func NewServer(address string) *Server {
+	mux := http.NewServeMux()
+	mux.Handle(MetricsPath, Handler())
+
+	return &Server{
+		Address: address,
+		server: &http.Server{
+			Addr:              address,
+			Handler:           mux,
+			ReadHeaderTimeout: 5 * time.Second,
+		},
+	}
+}
+
+// 启动指标服务, 阻塞直到服务关闭
+func (s *Server) Serve() error {
+	glog.Info("metrics server listening on: ", s.Address)
+	err := s.server.ListenAndServe()
+	if err != nil && err != http.ErrServerClosed {
+		glog.Error("failed to run the metrics server: ", err)
+		return err
+	}
+
+	return nil
+}
+
+// 关闭指标服务
+func (s *Server) Shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.server.Shutdown(ctx)
+}
@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"io"
+	"sort"
+	"sync"
+)
+
+// 计数器, 只增不减
+type CounterVec struct {
+	valueVec
+}
+
+// 仪表, 可增可减
+type GaugeVec struct {
+	valueVec
+}
+
+// 创建计数器并注册到默认注册表
+func NewCounterVec(name string, help string, labels ...string) *CounterVec {
+	counter := &CounterVec{valueVec: newValueVec(name, help, "counter", labels)}
+	return Default().Register(counter).(*CounterVec)
+}
+
+// 创建仪表并注册到默认注册表
+func NewGaugeVec(name string, help string, labels ...string) *GaugeVec {
+	gauge := &GaugeVec{valueVec: newValueVec(name, help, "gauge", labels)}
+	return Default().Register(gauge).(*GaugeVec)
+}
+
+// 计数加1
+func (counter *CounterVec) Inc(labelValues ...string) {
+	counter.add(1, labelValues)
+}
+
+// 计数增加delta, delta不能为负数
+func (counter *CounterVec) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		return
+	}
+
+	counter.add(delta, labelValues)
+}
+
+// 设置仪表的值
+func (gauge *GaugeVec) Set(value float64, labelValues ...string) {
+	key := gauge.labelKey(labelValues)
+	gauge.mutex.Lock()
+	defer gauge.mutex.Unlock()
+	gauge.values[key] = value
+}
+
+// 仪表的值增加delta
+func (gauge *GaugeVec) Add(delta float64, labelValues ...string) {
+	gauge.add(delta, labelValues)
+}
+
+// 清除仪表的所有值, 用于重新采集时移除已不存在的标签组合
+func (gauge *GaugeVec) Reset() {
+	gauge.mutex.Lock()
+	defer gauge.mutex.Unlock()
+	gauge.values = map[string]float64{}
+}
+
+// 计数器和仪表的公共实现
+type valueVec struct {
+	metricVec
+	metricType string
+	mutex      sync.Mutex
+	values     map[string]float64
+}
+
+func newValueVec(name string, help string, metricType string, labels []string) valueVec {
+	return valueVec{
+		metricVec:  metricVec{name: name, help: help, labels: labels},
+		metricType: metricType,
+		values:     map[string]float64{},
+	}
+}
+
+// 读取标签组合的当前值
+func (vec *valueVec) Value(labelValues ...string) float64 {
+	key := vec.labelKey(labelValues)
+	vec.mutex.Lock()
+	defer vec.mutex.Unlock()
+	return vec.values[key]
+}
+
+func (vec *valueVec) add(delta float64, labelValues []string) {
+	key := vec.labelKey(labelValues)
+	vec.mutex.Lock()
+	defer vec.mutex.Unlock()
+	vec.values[key] += delta
+}
+
+func (vec *valueVec) writeText(w io.Writer) {
+	vec.mutex.Lock()
+	defer vec.mutex.Unlock()
+
+	vec.writeHeader(w, vec.metricType)
+	keys := make([]string, 0, len(vec.values))
+	for key := range vec.values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		vec.writeSample(w, vec.name, splitLabelKey(key, len(vec.labels)), "", "", vec.values[key])
+	}
+}
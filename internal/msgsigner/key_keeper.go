@@ -14,14 +14,34 @@ import (
 
 var KeyPath string
 
+// 验证token的公钥
+type VerifyKey struct {
+	PublicKey *rsa.PublicKey
+	Issuer    string // 只验证此签发者的token, 为空时不限制
+}
+
 type KeyKeeper struct {
 	privateKey *rsa.PrivateKey
 	publicKey  *rsa.PublicKey
+	keyId      string
+	verifyKeys map[string]*VerifyKey
 }
 
+// 密钥文件
+// 签名使用privatekey, 其ID为keyid, 对应的公钥为publickey.
+// 轮换密钥时, 在publickeys中保留仍然有效的旧公钥, 或加入其他节点的公钥
 type KeyConf struct {
-	PublicKey  string `mapstructure:"publickey" json:"publickey"`
-	PrivateKey string `mapstructure:"privatekey" json:"privatekey"`
+	KeyId      string          `mapstructure:"keyid" json:"keyid"`
+	PublicKey  string          `mapstructure:"publickey" json:"publickey"`
+	PrivateKey string          `mapstructure:"privatekey" json:"privatekey"`
+	PublicKeys []PublicKeyConf `mapstructure:"publickeys" json:"publickeys"`
+}
+
+// 其他有效的公钥
+type PublicKeyConf struct {
+	KeyId     string `mapstructure:"keyid" json:"keyid"`
+	PublicKey string `mapstructure:"publickey" json:"publickey"`
+	Issuer    string `mapstructure:"issuer" json:"issuer"`
 }
 
 func init() {
@@ -44,6 +64,21 @@ func (k *KeyKeeper) GetPublicKey() (*rsa.PublicKey, error) {
 	return k.publicKey, nil
 }
 
+// 签名私钥的ID, 写入token的kid头
+func (k *KeyKeeper) GetKeyId() string {
+	return k.keyId
+}
+
+// 获取验证token的公钥, kid为空时为签名私钥对应的公钥
+func (k *KeyKeeper) GetVerifyKey(kid string) (*VerifyKey, error) {
+	key, ok := k.verifyKeys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id: %s", kid)
+	}
+
+	return key, nil
+}
+
 func (k *KeyKeeper) Load() error {
 	v := viper.New()
 	v.SetConfigFile(KeyPath)
@@ -71,6 +106,27 @@ func (k *KeyKeeper) Load() error {
 		return err
 	}
 
+	k.keyId = keyConf.KeyId
+	k.verifyKeys = map[string]*VerifyKey{
+		"":            {PublicKey: k.publicKey},
+		keyConf.KeyId: {PublicKey: k.publicKey},
+	}
+
+	for _, conf := range keyConf.PublicKeys {
+		if len(conf.KeyId) == 0 {
+			glog.Warning("public key without key id")
+			return errors.New("empty key id")
+		}
+
+		key, err := ParsePublicKey([]byte(conf.PublicKey))
+		if err != nil {
+			glog.Warning("failed to parse the public key: ", conf.KeyId, ", ", err)
+			return err
+		}
+
+		k.verifyKeys[conf.KeyId] = &VerifyKey{PublicKey: key, Issuer: conf.Issuer}
+	}
+
 	return nil
 }
 
@@ -3,6 +3,7 @@ package msgsigner
 import (
 	"crypto/rsa"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -62,26 +63,90 @@ func (s *MsgSigner) Sign(
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	if len(s.keeper.GetKeyId()) > 0 {
+		token.Header["kid"] = s.keeper.GetKeyId()
+	}
+
 	return token.SignedString(s.privateKey)
 }
 
+// 验证token, 不校验接收方和签发者
 func (s *MsgSigner) Verify(tokenStr string) (string, error) {
+	claims, err := s.VerifyClaims(tokenStr, "", nil, 0)
+	if err != nil {
+		return "", err
+	}
+
+	return claims.Message, nil
+}
+
+// 验证token, 按kid选择公钥.
+// audience不为空时要求token的接收方包含audience, issuers不为空时要求签发者在其中.
+// leeway为校验过期和生效时间时容忍的时钟偏差
+func (s *MsgSigner) VerifyClaims(
+	tokenStr string, audience string, issuers []string, leeway time.Duration,
+) (*CommonClaims, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(leeway),
+	}
+	if len(audience) > 0 {
+		opts = append(opts, jwt.WithAudience(audience))
+	}
+
 	token, err := jwt.ParseWithClaims(tokenStr, &CommonClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return s.publicKey, nil
-	})
+		return s.verifyKey(token)
+	}, opts...)
 
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if !token.Valid {
-		return "", errors.New("invalid claims")
+		return nil, errors.New("invalid claims")
 	}
 
 	claims, ok := token.Claims.(*CommonClaims)
 	if !ok {
-		return "", errors.New("invalid claim type")
+		return nil, errors.New("invalid claim type")
 	}
 
-	return claims.Message, nil
+	if len(issuers) > 0 && !contains(issuers, claims.Issuer) {
+		return nil, fmt.Errorf("unexpected issuer: %s", claims.Issuer)
+	}
+
+	return claims, nil
+}
+
+// 选择验证token的公钥, 公钥限定了签发者时需一致
+func (s *MsgSigner) verifyKey(token *jwt.Token) (interface{}, error) {
+	if s.keeper == nil || s.publicKey == nil {
+		return nil, errors.New("invalid public key")
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, err := s.keeper.GetVerifyKey(kid)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(key.Issuer) > 0 {
+		issuer, _ := token.Claims.GetIssuer()
+		if issuer != key.Issuer {
+			return nil, fmt.Errorf("key %s is not for issuer %s", kid, issuer)
+		}
+	}
+
+	return key.PublicKey, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
 }
@@ -79,3 +79,51 @@ func Test_Verify_Expired(t *testing.T) {
 		})
 	})
 }
+
+func Test_VerifyClaims_Audience(t *testing.T) {
+	KeyPath = "./test/testdata/key.conf"
+	signer := NewMsgSigner()
+	secret, _ := signer.Sign("tester", "test message", []string{"executor"},
+		"test message string", time.Minute)
+
+	_, matchErr := signer.VerifyClaims(secret, "executor", []string{"tester"}, 0)
+	_, audienceErr := signer.VerifyClaims(secret, "collector", nil, 0)
+	_, issuerErr := signer.VerifyClaims(secret, "", []string{"other"}, 0)
+
+	Convey("verify the audience and issuer", t, func() {
+		Convey("should only accept the expected audience and issuer", func() {
+			So(matchErr, ShouldBeNil)
+			So(audienceErr, ShouldNotBeNil)
+			So(issuerErr, ShouldNotBeNil)
+		})
+	})
+}
+
+func Test_VerifyClaims_Rotation(t *testing.T) {
+	KeyPath = "./test/testdata/key_k1.conf"
+	oldSigner := NewMsgSigner()
+	oldSecret, _ := oldSigner.Sign("tester", "test message", []string{"tester"},
+		"test message string", time.Minute)
+	otherSecret, _ := oldSigner.Sign("other", "test message", []string{"tester"},
+		"test message string", time.Minute)
+
+	KeyPath = "./test/testdata/rotated.conf"
+	signer := NewMsgSigner()
+	newSecret, _ := signer.Sign("tester", "test message", []string{"tester"},
+		"test message string", time.Minute)
+
+	_, oldErr := signer.VerifyClaims(oldSecret, "", nil, 0)
+	_, otherErr := signer.VerifyClaims(otherSecret, "", nil, 0)
+	_, newErr := signer.VerifyClaims(newSecret, "", nil, 0)
+	_, revokedErr := oldSigner.VerifyClaims(newSecret, "", nil, 0)
+
+	Convey("verify tokens signed by the rotated keys", t, func() {
+		Convey("should select the public key by the key id", func() {
+			So(signer.keeper.GetKeyId(), ShouldEqual, "k2")
+			So(oldErr, ShouldBeNil)
+			So(newErr, ShouldBeNil)
+			So(otherErr, ShouldNotBeNil)
+			So(revokedErr, ShouldNotBeNil)
+		})
+	})
+}
@@ -3,15 +3,16 @@ package mysqltool
 import (
 	// 引入Go SQL驱动
 	"context"
-	"fmt"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/golang/glog"
 	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
 
 	"github.com/danenmao/pterergate-dtf/dtf/extconfig"
 	"github.com/danenmao/pterergate-dtf/internal/config"
+	"github.com/danenmao/pterergate-dtf/internal/dbdialect"
 )
 
 // 默认的MySQL数据库对象
@@ -22,31 +23,38 @@ func DefaultMySQL() *sqlx.DB {
 	return gs_MySQLDB
 }
 
-// 连接默认的MySQL数据库
+// 连接默认的MySQL数据库, 框架的语句按其方言转换
 func ConnectToDefaultMySQL() {
-	InitMySQLClient(&config.DefaultMySQL, "", &gs_MySQLDB)
+	dialect := InitMySQLClient(&config.DefaultMySQL, "", &gs_MySQLDB)
+	dbdialect.SetDefault(dialect)
 }
 
-// 初始化MySQL连接
-func InitMySQLClient(sqlConf *extconfig.MySQLAddress, setting string, targetDB **sqlx.DB) {
+// 初始化MySQL连接, 也用于PostgreSQL和SQLite, 返回连接使用的方言
+func InitMySQLClient(sqlConf *extconfig.MySQLAddress, setting string, targetDB **sqlx.DB) dbdialect.IDialect {
 
-	if len(setting) > 0 {
-		setting = fmt.Sprintf("&%s", setting)
+	dialect, err := dbdialect.Get(sqlConf.Dialect)
+	if err != nil {
+		glog.Warning("unknown SQL dialect: ", sqlConf.Dialect)
+		panic(err)
+	}
+
+	driverName := sqlConf.Type
+	if len(driverName) == 0 {
+		driverName = dialect.DriverName()
 	}
 
-	addr := fmt.Sprintf("%s:%s@%s(%s)/%s?charset=utf8mb4%s",
-		sqlConf.Username,
-		sqlConf.Password,
-		sqlConf.Protocol,
-		sqlConf.Address,
-		sqlConf.DB,
-		setting,
-	)
+	addr := sqlConf.DSN
+	if len(addr) == 0 {
+		addr = dialect.DSN(sqlConf, setting)
+	}
+
+	// 使用者注册的驱动名不一定为sqlx所知, 按方言指定命名参数的占位符
+	sqlx.BindDriver(driverName, dialect.BindType())
 
 	// 连接数据库，并发送ping进行验证，保证连接成功
-	db, err := sqlx.Connect(sqlConf.Type, addr)
+	db, err := sqlx.Connect(driverName, addr)
 	if err != nil {
-		glog.Warning("failed to connect to MySQL: ", err, sqlConf)
+		glog.Warning("failed to connect to SQL database: ", err, ", ", sqlConf.Name, ", ", sqlConf.Address)
 		panic(err)
 	}
 
@@ -56,8 +64,15 @@ func InitMySQLClient(sqlConf *extconfig.MySQLAddress, setting string, targetDB *
 	db.SetMaxOpenConns(config.MySQL_MaxOpenConns)
 	db.SetMaxIdleConns(config.MySQL_MaxIdleConns)
 
+	// SQLite只允许一个写者, 使用单个连接避免写冲突
+	initialConns := config.MySQL_InitialOpenConnections
+	if dialect.Name() == extconfig.SQLDialect_SQLite {
+		db.SetMaxOpenConns(1)
+		initialConns = 0
+	}
+
 	// 初始只有一个连接，这里创建多个连接
-	for i := 0; i < config.MySQL_InitialOpenConnections; i++ {
+	for i := 0; i < initialConns; i++ {
 		ctx := context.Background()
 		conn, err := db.Conn(ctx)
 		if err != nil {
@@ -68,4 +83,5 @@ func InitMySQLClient(sqlConf *extconfig.MySQLAddress, setting string, targetDB *
 	}
 
 	*targetDB = db
+	return dialect
 }
@@ -0,0 +1,162 @@
+package recordstore
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/internal/dbdef"
+)
+
+// 任务记录已存在
+var ErrDuplicateRecord = errors.New("duplicate task record")
+
+// 基于内存的任务记录存储, 用于单进程部署和测试
+// 记录不会持久化, 进程退出后丢失
+type MemoryRecordStore struct {
+	mutex   sync.Mutex
+	records map[uint64]dbdef.DBTaskRecord
+}
+
+// 创建基于内存的任务记录存储
+func NewMemoryRecordStore() *MemoryRecordStore {
+	return &MemoryRecordStore{
+		records: map[uint64]dbdef.DBTaskRecord{},
+	}
+}
+
+func (store *MemoryRecordStore) AddTaskRecord(ctx context.Context, record *dbdef.DBTaskRecord) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if _, ok := store.records[record.Id]; ok {
+		return ErrDuplicateRecord
+	}
+
+	store.records[record.Id] = *record
+	return nil
+}
+
+func (store *MemoryRecordStore) CompleteTaskRecord(ctx context.Context, record *dbdef.DBTaskRecord) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	current, ok := store.records[record.Id]
+	if !ok {
+		return errordef.ErrNotFound
+	}
+
+	current.FinishTime = record.FinishTime
+	current.TimeCost = record.TimeCost
+	current.TaskStatus = record.TaskStatus
+	store.records[record.Id] = current
+	return nil
+}
+
+func (store *MemoryRecordStore) GetExceptionalTasks(
+	ctx context.Context,
+	checkTime string,
+	limit int,
+	taskList *[]taskmodel.TaskIdType,
+) error {
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	// 时间格式为 GoTimeFormatStr, 可以直接按字符串比较
+	ids := []uint64{}
+	for id, record := range store.records {
+		if record.NextCheckTime < checkTime {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for idx, id := range ids {
+		if limit > 0 && idx >= limit {
+			break
+		}
+
+		*taskList = append(*taskList, taskmodel.TaskIdType(id))
+	}
+
+	return nil
+}
+
+func (store *MemoryRecordStore) UpdateTaskStatus(ctx context.Context, taskId uint64, status uint8) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	current, ok := store.records[taskId]
+	if !ok {
+		return errordef.ErrNotFound
+	}
+
+	current.TaskStatus = status
+	store.records[taskId] = current
+	return nil
+}
+
+func (store *MemoryRecordStore) ListTaskRecords(
+	ctx context.Context,
+	filter *TaskRecordFilter,
+	records *[]dbdef.DBTaskRecord,
+) error {
+
+	err := filter.check()
+	if err != nil {
+		return err
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	matched := []dbdef.DBTaskRecord{}
+	for _, record := range store.records {
+		if filter.match(&record) {
+			matched = append(matched, record)
+		}
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		cursor := filter.CursorOf(&matched[i])
+		return filter.less(&cursor, &matched[j])
+	})
+	if len(matched) > filter.limit() {
+		matched = matched[:filter.limit()]
+	}
+
+	*records = append(*records, matched...)
+	return nil
+}
+
+// 获取任务记录, 用于测试
+func (store *MemoryRecordStore) GetTaskRecord(taskId uint64, record *dbdef.DBTaskRecord) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	current, ok := store.records[taskId]
+	if !ok {
+		return errordef.ErrNotFound
+	}
+
+	*record = current
+	return nil
+}
+
+func (store *MemoryRecordStore) GetMaxTaskId(ctx context.Context) (uint64, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	var maxId uint64 = 0
+	for id := range store.records {
+		if id > maxId {
+			maxId = id
+		}
+	}
+
+	return maxId, nil
+}
@@ -0,0 +1,90 @@
+package recordstore
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/internal/dbdef"
+)
+
+func Test_MemoryRecordStore(t *testing.T) {
+	store := NewMemoryRecordStore()
+	ctx := context.Background()
+
+	Convey("add and complete task records", t, func() {
+		So(store.AddTaskRecord(ctx, &dbdef.DBTaskRecord{Id: 1, NextCheckTime: "2023-01-01 00:00:00"}), ShouldBeNil)
+		So(store.AddTaskRecord(ctx, &dbdef.DBTaskRecord{Id: 2, NextCheckTime: "2023-01-03 00:00:00"}), ShouldBeNil)
+		So(store.AddTaskRecord(ctx, &dbdef.DBTaskRecord{Id: 1}), ShouldEqual, ErrDuplicateRecord)
+
+		taskList := []taskmodel.TaskIdType{}
+		So(store.GetExceptionalTasks(ctx, "2023-01-02 00:00:00", 10, &taskList), ShouldBeNil)
+		So(taskList, ShouldResemble, []taskmodel.TaskIdType{1})
+
+		So(store.CompleteTaskRecord(ctx, &dbdef.DBTaskRecord{
+			Id: 2, FinishTime: "2023-01-02 00:00:00", TimeCost: 10, TaskStatus: 3,
+		}), ShouldBeNil)
+		So(store.CompleteTaskRecord(ctx, &dbdef.DBTaskRecord{Id: 3}), ShouldEqual, errordef.ErrNotFound)
+
+		record := dbdef.DBTaskRecord{}
+		So(store.GetTaskRecord(2, &record), ShouldBeNil)
+		So(record.TimeCost, ShouldEqual, 10)
+		So(record.TaskStatus, ShouldEqual, 3)
+		So(record.NextCheckTime, ShouldEqual, "2023-01-03 00:00:00")
+	})
+}
+
+func Test_MemoryRecordStore_ListTaskRecords(t *testing.T) {
+	store := NewMemoryRecordStore()
+	ctx := context.Background()
+	store.AddTaskRecord(ctx, &dbdef.DBTaskRecord{Id: 1, UID: 7, Name: "scan-a", StartTime: "2023-01-02 00:00:00"})
+	store.AddTaskRecord(ctx, &dbdef.DBTaskRecord{Id: 2, UID: 8, Name: "scan-b", StartTime: "2023-01-01 00:00:00"})
+	store.AddTaskRecord(ctx, &dbdef.DBTaskRecord{Id: 3, UID: 7, Name: "build", StartTime: "2023-01-02 00:00:00",
+		ResourceGroup: "2", TaskStatus: 5})
+
+	ids := func(records []dbdef.DBTaskRecord) []uint64 {
+		result := []uint64{}
+		for _, record := range records {
+			result = append(result, record.Id)
+		}
+		return result
+	}
+
+	Convey("filter task records", t, func() {
+		records := []dbdef.DBTaskRecord{}
+		So(store.ListTaskRecords(ctx, &TaskRecordFilter{UID: 7}, &records), ShouldBeNil)
+		So(ids(records), ShouldResemble, []uint64{3, 1})
+
+		records = []dbdef.DBTaskRecord{}
+		So(store.ListTaskRecords(ctx, &TaskRecordFilter{NamePrefix: "scan-"}, &records), ShouldBeNil)
+		So(ids(records), ShouldResemble, []uint64{2, 1})
+
+		records = []dbdef.DBTaskRecord{}
+		filter := TaskRecordFilter{ResourceGroup: "2", TaskStatus: 5}
+		So(store.ListTaskRecords(ctx, &filter, &records), ShouldBeNil)
+		So(ids(records), ShouldResemble, []uint64{3})
+
+		records = []dbdef.DBTaskRecord{}
+		filter = TaskRecordFilter{StartTimeFrom: "2023-01-01 12:00:00", StartTimeTo: "2023-01-03 00:00:00"}
+		So(store.ListTaskRecords(ctx, &filter, &records), ShouldBeNil)
+		So(ids(records), ShouldResemble, []uint64{3, 1})
+
+		So(store.ListTaskRecords(ctx, &TaskRecordFilter{OrderBy: "name"}, &records), ShouldEqual, errordef.ErrInvalidParameter)
+	})
+
+	Convey("page task records by start time", t, func() {
+		filter := TaskRecordFilter{OrderBy: dbdef.TaskTable_StartTime, Ascending: true, Limit: 2}
+		records := []dbdef.DBTaskRecord{}
+		So(store.ListTaskRecords(ctx, &filter, &records), ShouldBeNil)
+		So(ids(records), ShouldResemble, []uint64{2, 1})
+
+		cursor := filter.CursorOf(&records[1])
+		filter.After = &cursor
+		records = []dbdef.DBTaskRecord{}
+		So(store.ListTaskRecords(ctx, &filter, &records), ShouldBeNil)
+		So(ids(records), ShouldResemble, []uint64{3})
+	})
+}
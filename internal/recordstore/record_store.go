@@ -0,0 +1,181 @@
+package recordstore
+
+import (
+	"context"
+	"strings"
+
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/internal/dbdef"
+)
+
+// 任务记录存储, 保存任务表 tbl_task 中的记录
+type ITaskRecordStore interface {
+	// 添加任务记录
+	AddTaskRecord(ctx context.Context, record *dbdef.DBTaskRecord) error
+
+	// 更新任务记录, 写入完成时间、耗时和完成状态
+	CompleteTaskRecord(ctx context.Context, record *dbdef.DBTaskRecord) error
+
+	// 获取下次检查时间早于checkTime的任务, 最多返回limit个
+	GetExceptionalTasks(ctx context.Context, checkTime string, limit int, taskList *[]taskmodel.TaskIdType) error
+
+	// 更新任务记录的状态, 用于暂停、恢复和取消任务
+	UpdateTaskStatus(ctx context.Context, taskId uint64, status uint8) error
+
+	// 按条件查询任务记录, 按排序列和任务ID排列, 默认按任务ID从大到小排列
+	ListTaskRecords(ctx context.Context, filter *TaskRecordFilter, records *[]dbdef.DBTaskRecord) error
+
+	// 获取最大的任务ID, 没有记录时为0
+	GetMaxTaskId(ctx context.Context) (uint64, error)
+}
+
+// 未指定Limit时, 查询返回的最大记录数
+const DefaultTaskRecordLimit = 100
+
+// 任务记录的查询条件, 零值的条件不生效
+// 时间的格式为 dbdef.GoTimeFormatStr
+type TaskRecordFilter struct {
+	TaskStatus    uint8             // 任务的状态
+	TaskType      uint32            // 任务类型
+	UID           uint64            // 所有者id
+	Creator       string            // 创建者的账号
+	ResourceGroup string            // 任务所属的资源组名
+	NamePrefix    string            // 任务名的前缀
+	StartTimeFrom string            // 开始时间不早于此时间
+	StartTimeTo   string            // 开始时间早于此时间
+	OrderBy       string            // 排序的列, 支持id、start_time和finish_time, 为空时按任务ID排序
+	Ascending     bool              // 是否升序
+	After         *TaskRecordCursor // 分页的游标, 只返回排在此游标之后的记录
+	Limit         int               // 返回的最大记录数
+}
+
+// 分页查询的游标, 为上一页最后一条记录的排序列值和任务ID
+type TaskRecordCursor struct {
+	Value string `json:"value,omitempty"`
+	Id    uint64 `json:"id"`
+}
+
+// 可用于排序的列
+var gs_OrderColumns = map[string]bool{
+	dbdef.TaskTable_Id:         true,
+	dbdef.TaskTable_StartTime:  true,
+	dbdef.TaskTable_FinishTime: true,
+}
+
+// 获取记录在排序中的游标
+func (filter *TaskRecordFilter) CursorOf(record *dbdef.DBTaskRecord) TaskRecordCursor {
+	return TaskRecordCursor{Value: orderValue(record, filter.orderBy()), Id: record.Id}
+}
+
+// 检查查询条件
+func (filter *TaskRecordFilter) check() error {
+	if !gs_OrderColumns[filter.orderBy()] {
+		return errordef.ErrInvalidParameter
+	}
+
+	return nil
+}
+
+// 排序的列
+func (filter *TaskRecordFilter) orderBy() string {
+	if len(filter.OrderBy) == 0 {
+		return dbdef.TaskTable_Id
+	}
+
+	return filter.OrderBy
+}
+
+// 查询返回的最大记录数
+func (filter *TaskRecordFilter) limit() int {
+	if filter.Limit <= 0 {
+		return DefaultTaskRecordLimit
+	}
+
+	return filter.Limit
+}
+
+// 记录是否满足查询条件
+func (filter *TaskRecordFilter) match(record *dbdef.DBTaskRecord) bool {
+	if filter.TaskStatus != 0 && record.TaskStatus != filter.TaskStatus {
+		return false
+	}
+
+	if filter.TaskType != 0 && record.TaskType != filter.TaskType {
+		return false
+	}
+
+	if filter.UID != 0 && record.UID != filter.UID {
+		return false
+	}
+
+	if len(filter.Creator) > 0 && record.Creator != filter.Creator {
+		return false
+	}
+
+	if len(filter.ResourceGroup) > 0 && record.ResourceGroup != filter.ResourceGroup {
+		return false
+	}
+
+	if !strings.HasPrefix(record.Name, filter.NamePrefix) {
+		return false
+	}
+
+	// 时间格式可以直接按字符串比较
+	if len(filter.StartTimeFrom) > 0 && record.StartTime < filter.StartTimeFrom {
+		return false
+	}
+
+	if len(filter.StartTimeTo) > 0 && record.StartTime >= filter.StartTimeTo {
+		return false
+	}
+
+	if filter.After != nil && !filter.less(filter.After, record) {
+		return false
+	}
+
+	return true
+}
+
+// 在排序中, 游标是否排在记录之前
+func (filter *TaskRecordFilter) less(cursor *TaskRecordCursor, record *dbdef.DBTaskRecord) bool {
+	value := orderValue(record, filter.orderBy())
+	if value == cursor.Value {
+		if filter.Ascending {
+			return cursor.Id < record.Id
+		}
+
+		return cursor.Id > record.Id
+	}
+
+	if filter.Ascending {
+		return cursor.Value < value
+	}
+
+	return cursor.Value > value
+}
+
+// 记录的排序列的值, 按任务ID排序时为空
+func orderValue(record *dbdef.DBTaskRecord, column string) string {
+	switch column {
+	case dbdef.TaskTable_StartTime:
+		return record.StartTime
+	case dbdef.TaskTable_FinishTime:
+		return record.FinishTime
+	default:
+		return ""
+	}
+}
+
+// 默认的任务记录存储
+var gs_DefaultStore ITaskRecordStore = NewSQLRecordStore()
+
+// 获取默认的任务记录存储
+func Default() ITaskRecordStore {
+	return gs_DefaultStore
+}
+
+// 设置默认的任务记录存储, 需在服务启动前调用
+func SetDefault(store ITaskRecordStore) {
+	gs_DefaultStore = store
+}
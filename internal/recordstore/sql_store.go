@@ -0,0 +1,224 @@
+package recordstore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/internal/dbdef"
+	"github.com/danenmao/pterergate-dtf/internal/dbdialect"
+	"github.com/danenmao/pterergate-dtf/internal/metrics"
+	"github.com/danenmao/pterergate-dtf/internal/mysqltool"
+)
+
+// 基于SQL数据库的任务记录存储, 使用mysqltool的默认连接
+// 语句按默认连接的方言转换, 支持MySQL、PostgreSQL和SQLite
+type SQLRecordStore struct {
+}
+
+// 创建基于SQL数据库的任务记录存储
+func NewSQLRecordStore() *SQLRecordStore {
+	return &SQLRecordStore{}
+}
+
+func (store *SQLRecordStore) AddTaskRecord(ctx context.Context, record *dbdef.DBTaskRecord) error {
+	start := time.Now()
+	result, err := mysqltool.DefaultMySQL().NamedExecContext(ctx,
+		dbdialect.SQL(dbdef.SQL_TaskTable_InsertTask),
+		record,
+	)
+	metrics.ObserveStoreCall(metrics.Store_MySQL, "add_task_record", start, err)
+
+	if err != nil {
+		glog.Warning("failed to add task record: ", err.Error())
+		return err
+	}
+
+	lines, _ := result.RowsAffected()
+	glog.Info("added a task record: ", record.Id, lines)
+	return nil
+}
+
+func (store *SQLRecordStore) CompleteTaskRecord(ctx context.Context, record *dbdef.DBTaskRecord) error {
+	start := time.Now()
+	result, err := mysqltool.DefaultMySQL().NamedExecContext(ctx,
+		dbdialect.SQL(dbdef.SQL_TaskTable_CompleteTask),
+		record,
+	)
+	metrics.ObserveStoreCall(metrics.Store_MySQL, "complete_task_record", start, err)
+
+	if err != nil {
+		glog.Warning("failed to update task result: ", record.Id, err.Error())
+		return err
+	}
+
+	lines, _ := result.RowsAffected()
+	glog.Info("succeeded to write complete info to task table: ", record.Id, lines)
+	return nil
+}
+
+func (store *SQLRecordStore) GetExceptionalTasks(
+	ctx context.Context,
+	checkTime string,
+	limit int,
+	taskList *[]taskmodel.TaskIdType,
+) error {
+
+	queryFn := func(offset int, limit int) (*sqlx.Rows, error) {
+		start := time.Now()
+		rows, err := mysqltool.DefaultMySQL().QueryxContext(ctx,
+			dbdialect.SQL(dbdef.SQL_TaskTable_QueryExceptionalCreationTask),
+			checkTime,
+			limit, offset,
+		)
+		metrics.ObserveStoreCall(metrics.Store_MySQL, "get_exceptional_tasks", start, err)
+		return rows, err
+	}
+
+	readFn := func(rows *sqlx.Rows) error {
+		var id uint64 = 0
+		err := rows.Scan(&id)
+		if err != nil {
+			glog.Warning("failed to get exceptional task id: ", err)
+			return nil
+		}
+
+		*taskList = append(*taskList, taskmodel.TaskIdType(id))
+		return nil
+	}
+
+	return mysqltool.ReadDBByPageWithLimit(queryFn, readFn, limit)
+}
+
+func (store *SQLRecordStore) UpdateTaskStatus(ctx context.Context, taskId uint64, status uint8) error {
+	start := time.Now()
+	_, err := mysqltool.DefaultMySQL().ExecContext(ctx,
+		dbdialect.SQL(dbdef.SQL_TaskTable_UpdateTaskStatus),
+		status, taskId,
+	)
+	metrics.ObserveStoreCall(metrics.Store_MySQL, "update_task_status", start, err)
+
+	if err != nil {
+		glog.Warning("failed to update task status: ", taskId, ", ", err)
+		return err
+	}
+
+	return nil
+}
+
+func (store *SQLRecordStore) ListTaskRecords(
+	ctx context.Context,
+	filter *TaskRecordFilter,
+	records *[]dbdef.DBTaskRecord,
+) error {
+
+	err := filter.check()
+	if err != nil {
+		return err
+	}
+
+	// 拼装查询条件
+	conds := []string{}
+	args := []interface{}{}
+	addCond := func(cond string, vals ...interface{}) {
+		conds = append(conds, cond)
+		args = append(args, vals...)
+	}
+
+	if filter.TaskStatus != 0 {
+		addCond(fmt.Sprintf("`%s`=?", dbdef.TaskTable_TaskStatus), filter.TaskStatus)
+	}
+
+	if filter.TaskType != 0 {
+		addCond(fmt.Sprintf("`%s`=?", dbdef.TaskTable_TaskType), filter.TaskType)
+	}
+
+	if filter.UID != 0 {
+		addCond(fmt.Sprintf("`%s`=?", dbdef.TaskTable_UID), filter.UID)
+	}
+
+	if len(filter.Creator) > 0 {
+		addCond(fmt.Sprintf("`%s`=?", dbdef.TaskTable_Creator), filter.Creator)
+	}
+
+	if len(filter.ResourceGroup) > 0 {
+		addCond(fmt.Sprintf("`%s`=?", dbdef.TaskTable_ResourceGroup), filter.ResourceGroup)
+	}
+
+	if len(filter.NamePrefix) > 0 {
+		addCond(fmt.Sprintf("`%s` like ?%s", dbdef.TaskTable_Name, dbdialect.Default().LikeEscape()), escapeLike(filter.NamePrefix)+"%")
+	}
+
+	if len(filter.StartTimeFrom) > 0 {
+		addCond(fmt.Sprintf("`%s`>=?", dbdef.TaskTable_StartTime), filter.StartTimeFrom)
+	}
+
+	if len(filter.StartTimeTo) > 0 {
+		addCond(fmt.Sprintf("`%s`<?", dbdef.TaskTable_StartTime), filter.StartTimeTo)
+	}
+
+	// 游标之后的记录, 排序列相同时按任务ID排序
+	column := filter.orderBy()
+	op, direction := "<", "desc"
+	if filter.Ascending {
+		op, direction = ">", "asc"
+	}
+
+	if filter.After != nil {
+		if column == dbdef.TaskTable_Id {
+			addCond(fmt.Sprintf("`%s`%s?", dbdef.TaskTable_Id, op), filter.After.Id)
+		} else {
+			addCond(fmt.Sprintf("(`%s`%s? or (`%s`=? and `%s`%s?))",
+				column, op, column, dbdef.TaskTable_Id, op),
+				filter.After.Value, filter.After.Value, filter.After.Id)
+		}
+	}
+
+	query := dbdef.SQL_TaskTable_QueryTask
+	if len(conds) > 0 {
+		query += " where " + strings.Join(conds, " and ")
+	}
+
+	if column != dbdef.TaskTable_Id {
+		query += fmt.Sprintf(" order by `%s` %s, `%s` %s limit ?", column, direction, dbdef.TaskTable_Id, direction)
+	} else {
+		query += fmt.Sprintf(" order by `%s` %s limit ?", dbdef.TaskTable_Id, direction)
+	}
+
+	args = append(args, filter.limit())
+
+	start := time.Now()
+	err = mysqltool.DefaultMySQL().SelectContext(ctx, records, dbdialect.SQL(query), args...)
+	metrics.ObserveStoreCall(metrics.Store_MySQL, "list_task_records", start, err)
+
+	if err != nil {
+		glog.Warning("failed to list task records: ", err)
+		return err
+	}
+
+	return nil
+}
+
+func (store *SQLRecordStore) GetMaxTaskId(ctx context.Context) (uint64, error) {
+	var maxId uint64 = 0
+	start := time.Now()
+	err := mysqltool.DefaultMySQL().GetContext(ctx, &maxId, dbdialect.SQL(dbdef.SQL_TaskTable_QueryMaxId))
+	metrics.ObserveStoreCall(metrics.Store_MySQL, "get_max_task_id", start, err)
+
+	if err != nil {
+		glog.Warning("failed to get max task id: ", err)
+		return 0, err
+	}
+
+	return maxId, nil
+}
+
+// 转义like语句中的通配符
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
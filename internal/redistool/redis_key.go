@@ -0,0 +1,26 @@
+package redistool
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 生成带hash tag的key, 如 dtf.task.info.{100}
+// Cluster模式下, 同一id的key位于同一个slot, 可以在一个事务或脚本中操作
+func HashTagKey(prefix string, id interface{}) string {
+	return fmt.Sprintf("%s{%v}", prefix, id)
+}
+
+// 生成与key位于同一slot的关联key
+// key中已有hash tag时直接追加后缀, 否则以整个key作为hash tag
+func SameSlotKey(key string, suffix string) string {
+	start := strings.Index(key, "{")
+	if start >= 0 {
+		end := strings.Index(key[start+1:], "}")
+		if end > 0 {
+			return key + suffix
+		}
+	}
+
+	return "{" + key + "}" + suffix
+}
@@ -0,0 +1,21 @@
+package redistool
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func Test_HashTagKey(t *testing.T) {
+	Convey("generate a hash tagged key", t, func() {
+		So(HashTagKey("dtf.task.info.", 100), ShouldEqual, "dtf.task.info.{100}")
+		So(HashTagKey("dtf.schedule.", "team"), ShouldEqual, "dtf.schedule.{team}")
+	})
+}
+
+func Test_SameSlotKey(t *testing.T) {
+	Convey("generate a key in the same slot", t, func() {
+		So(SameSlotKey("dtf.task.info.{100}", ".lock"), ShouldEqual, "dtf.task.info.{100}.lock")
+		So(SameSlotKey("test_leader", ".fencing"), ShouldEqual, "{test_leader}.fencing")
+	})
+}
@@ -0,0 +1,259 @@
+package redistool
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"github.com/golang/glog"
+	"github.com/google/uuid"
+
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+)
+
+// 默认的领导者租约时长
+const DefaultLeaderLease = time.Second * 15
+
+// 领导者fencing token计数器key的后缀
+const leaderFencingSuffix = ".fencing"
+
+// 领导者选举使用的存储, 租约基于带持有者令牌的锁
+type ILeaderBackend interface {
+	ILockBackend
+
+	// 读取key的值, key不存在时返回errordef.ErrNotFound
+	Get(ctx context.Context, key string) (string, error)
+
+	// 将计数器增加step, 返回增加后的值
+	IncrBy(ctx context.Context, key string, step int64) (int64, error)
+}
+
+// 基于Redis的领导者选举存储
+type RedisLeaderBackend struct {
+	RedisLockBackend
+}
+
+func (RedisLeaderBackend) Get(ctx context.Context, key string) (string, error) {
+	val, err := DefaultRedis().Get(ctx, key).Result()
+	if err == goredis.Nil {
+		return "", errordef.ErrNotFound
+	}
+
+	return val, err
+}
+
+func (RedisLeaderBackend) IncrBy(ctx context.Context, key string, step int64) (int64, error) {
+	return DefaultRedis().IncrBy(ctx, key, step).Result()
+}
+
+// 领导者身份变化的观察函数
+type LeadershipObserver func(isLeader bool, fencingToken uint64)
+
+// 基于租约的领导者选举
+type LeaderElector struct {
+	Name    string         // 租约的key名
+	OwnerId string         // 当前实例的标识
+	Lease   time.Duration  // 租约时长
+	Backend ILeaderBackend // 租约的存储
+
+	mutex        sync.Mutex
+	isLeader     bool                 // 是否为领导者
+	fencingToken uint64               // 成为领导者时获得的fencing token
+	leaseExpiry  time.Time            // 本地记录的租约到期时间
+	observers    []LeadershipObserver // 身份变化的观察者
+}
+
+// 创建基于Redis的领导者选举对象
+func NewLeaderElector(name string, lease time.Duration) *LeaderElector {
+	return NewLeaderElectorWithBackend(name, lease, RedisLeaderBackend{})
+}
+
+// 创建使用指定存储的领导者选举对象
+func NewLeaderElectorWithBackend(name string, lease time.Duration, backend ILeaderBackend) *LeaderElector {
+	if lease <= 0 {
+		lease = DefaultLeaderLease
+	}
+
+	hostname, _ := os.Hostname()
+	return &LeaderElector{
+		Name:    name,
+		OwnerId: fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), uuid.NewString()),
+		Lease:   lease,
+		Backend: backend,
+	}
+}
+
+// 建议的竞选间隔, 为租约时长的1/3
+func (elector *LeaderElector) CampaignInterval() time.Duration {
+	return elector.Lease / 3
+}
+
+// 添加身份变化的观察者
+func (elector *LeaderElector) AddObserver(observer LeadershipObserver) {
+	elector.mutex.Lock()
+	defer elector.mutex.Unlock()
+	elector.observers = append(elector.observers, observer)
+}
+
+// 当前实例是否为领导者
+// 本地租约到期后即视为失去领导者身份, 避免与新领导者同时工作
+func (elector *LeaderElector) IsLeader() bool {
+	elector.mutex.Lock()
+	defer elector.mutex.Unlock()
+	return elector.isLeader && time.Now().Before(elector.leaseExpiry)
+}
+
+// 获取当前的fencing token, 非领导者时返回0
+func (elector *LeaderElector) FencingToken() uint64 {
+	elector.mutex.Lock()
+	defer elector.mutex.Unlock()
+	if !elector.isLeader {
+		return 0
+	}
+
+	return elector.fencingToken
+}
+
+// 检查fencing token是否仍是最新的
+// 写入共享状态前可用于拒绝过期领导者的操作
+func (elector *LeaderElector) IsFencingTokenValid(token uint64) (bool, error) {
+	val, err := elector.Backend.Get(context.Background(), elector.fencingKey())
+	if err != nil {
+		glog.Warning("failed to get fencing token: ", elector.Name, ", ", err)
+		return false, err
+	}
+
+	current, err := strconv.ParseUint(val, 10, 64)
+	if err != nil {
+		glog.Warning("invalid fencing token: ", elector.Name, ", ", val)
+		return false, err
+	}
+
+	return token != 0 && token == current, nil
+}
+
+// 读取当前领导者的标识, 无领导者时返回空串
+func (elector *LeaderElector) CurrentLeader() (string, error) {
+	owner, err := elector.Backend.Get(context.Background(), elector.Name)
+	if err == errordef.ErrNotFound {
+		return "", nil
+	}
+
+	if err != nil {
+		glog.Warning("failed to get current leader: ", elector.Name, ", ", err)
+		return "", err
+	}
+
+	return owner, nil
+}
+
+// 执行一轮竞选: 领导者续期租约, 非领导者尝试获取租约
+func (elector *LeaderElector) Campaign() {
+	elector.mutex.Lock()
+	wasLeader := elector.isLeader
+	elector.mutex.Unlock()
+
+	start := time.Now()
+	if wasLeader {
+		renewed, err := elector.renew()
+		if err != nil || !renewed {
+			glog.Warning("lost leadership: ", elector.Name, ", ", elector.OwnerId, ", ", err)
+			elector.setLeadership(false, 0, time.Time{})
+			return
+		}
+
+		elector.extendLease(start)
+		return
+	}
+
+	token, err := elector.acquire()
+	if err != nil {
+		glog.Warning("failed to campaign for leader: ", elector.Name, ", ", err)
+		return
+	}
+
+	if token == 0 {
+		return
+	}
+
+	glog.Info("became leader: ", elector.Name, ", ", elector.OwnerId, ", token: ", token)
+	elector.setLeadership(true, token, start.Add(elector.Lease))
+}
+
+// 主动放弃领导者身份
+func (elector *LeaderElector) Resign() error {
+	elector.mutex.Lock()
+	wasLeader := elector.isLeader
+	elector.mutex.Unlock()
+
+	if !wasLeader {
+		return nil
+	}
+
+	elector.setLeadership(false, 0, time.Time{})
+	_, err := elector.Backend.DeleteLockIfOwned(context.Background(), elector.Name, elector.OwnerId)
+	if err != nil {
+		glog.Warning("failed to release leader lease: ", elector.Name, ", ", err)
+		return err
+	}
+
+	glog.Info("resigned leadership: ", elector.Name, ", ", elector.OwnerId)
+	return nil
+}
+
+// fencing token计数器与租约位于同一slot
+func (elector *LeaderElector) fencingKey() string {
+	return SameSlotKey(elector.Name, leaderFencingSuffix)
+}
+
+// 尝试获取租约, 成功时递增并返回fencing token, 租约被其他实例持有时返回0
+func (elector *LeaderElector) acquire() (uint64, error) {
+	ctx := context.Background()
+	acquired, err := elector.Backend.SetLockIfAbsent(ctx, elector.Name, elector.OwnerId, elector.Lease)
+	if err != nil || !acquired {
+		return 0, err
+	}
+
+	// 未取得fencing token时释放租约, 不以旧的token工作
+	token, err := elector.Backend.IncrBy(ctx, elector.fencingKey(), 1)
+	if err != nil {
+		elector.Backend.DeleteLockIfOwned(ctx, elector.Name, elector.OwnerId)
+		return 0, err
+	}
+
+	return uint64(token), nil
+}
+
+// 续期租约
+func (elector *LeaderElector) renew() (bool, error) {
+	return elector.Backend.ExpireLockIfOwned(context.Background(), elector.Name, elector.OwnerId, elector.Lease)
+}
+
+func (elector *LeaderElector) extendLease(start time.Time) {
+	elector.mutex.Lock()
+	defer elector.mutex.Unlock()
+	elector.leaseExpiry = start.Add(elector.Lease)
+}
+
+// 更新领导者身份, 并通知观察者
+func (elector *LeaderElector) setLeadership(isLeader bool, token uint64, expiry time.Time) {
+	elector.mutex.Lock()
+	changed := elector.isLeader != isLeader
+	elector.isLeader = isLeader
+	elector.fencingToken = token
+	elector.leaseExpiry = expiry
+	observers := append([]LeadershipObserver{}, elector.observers...)
+	elector.mutex.Unlock()
+
+	if !changed {
+		return
+	}
+
+	for _, observer := range observers {
+		observer(isLeader, token)
+	}
+}
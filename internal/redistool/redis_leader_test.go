@@ -0,0 +1,153 @@
+package redistool
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func newTestElector() *LeaderElector {
+	elector := NewLeaderElector("test_leader", time.Second*3)
+	elector.OwnerId = "test_owner"
+	return elector
+}
+
+func Test_LeaderElector_Campaign_BecomeLeader(t *testing.T) {
+	elector := newTestElector()
+	changes := []bool{}
+	elector.AddObserver(func(isLeader bool, fencingToken uint64) {
+		changes = append(changes, isLeader)
+	})
+
+	ClientMock.ExpectSetNX("test_leader", "test_owner", time.Second*3).SetVal(true)
+	ClientMock.ExpectIncrBy("{test_leader}.fencing", 1).SetVal(7)
+	elector.Campaign()
+
+	Convey("become the leader", t, func() {
+		Convey("should be the leader with the fencing token", func() {
+			So(elector.IsLeader(), ShouldBeTrue)
+			So(elector.FencingToken(), ShouldEqual, 7)
+			So(changes, ShouldResemble, []bool{true})
+		})
+	})
+}
+
+func Test_LeaderElector_Campaign_OwnedByOther(t *testing.T) {
+	elector := newTestElector()
+
+	ClientMock.ExpectSetNX("test_leader", "test_owner", time.Second*3).SetVal(false)
+	elector.Campaign()
+
+	Convey("lease owned by other", t, func() {
+		Convey("should not be the leader", func() {
+			So(elector.IsLeader(), ShouldBeFalse)
+			So(elector.FencingToken(), ShouldEqual, 0)
+		})
+	})
+}
+
+func Test_LeaderElector_Campaign_LoseLeadership(t *testing.T) {
+	elector := newTestElector()
+	changes := []bool{}
+	elector.AddObserver(func(isLeader bool, fencingToken uint64) {
+		changes = append(changes, isLeader)
+	})
+
+	ClientMock.ExpectSetNX("test_leader", "test_owner", time.Second*3).SetVal(true)
+	ClientMock.ExpectIncrBy("{test_leader}.fencing", 1).SetVal(1)
+	elector.Campaign()
+
+	ClientMock.ExpectEval(compareAndExpireScript, []string{"test_leader"},
+		"test_owner", int64(3000)).SetVal(int64(1))
+	elector.Campaign()
+	renewed := elector.IsLeader()
+
+	ClientMock.ExpectEval(compareAndExpireScript, []string{"test_leader"},
+		"test_owner", int64(3000)).SetVal(int64(0))
+	elector.Campaign()
+
+	Convey("renew and then lose the leadership", t, func() {
+		Convey("should be the leader after renewal", func() {
+			So(renewed, ShouldBeTrue)
+		})
+		Convey("should not be the leader after a failed renewal", func() {
+			So(elector.IsLeader(), ShouldBeFalse)
+			So(changes, ShouldResemble, []bool{true, false})
+		})
+	})
+}
+
+func Test_LeaderElector_Campaign_RenewFail(t *testing.T) {
+	elector := newTestElector()
+
+	ClientMock.ExpectSetNX("test_leader", "test_owner", time.Second*3).SetVal(true)
+	ClientMock.ExpectIncrBy("{test_leader}.fencing", 1).SetVal(1)
+	elector.Campaign()
+
+	ClientMock.ExpectEval(compareAndExpireScript, []string{"test_leader"},
+		"test_owner", int64(3000)).SetErr(errors.New("failed to renew"))
+	elector.Campaign()
+
+	Convey("failed to renew the lease", t, func() {
+		Convey("should step down", func() {
+			So(elector.IsLeader(), ShouldBeFalse)
+		})
+	})
+}
+
+func Test_LeaderElector_Resign(t *testing.T) {
+	elector := newTestElector()
+
+	ClientMock.ExpectSetNX("test_leader", "test_owner", time.Second*3).SetVal(true)
+	ClientMock.ExpectIncrBy("{test_leader}.fencing", 1).SetVal(2)
+	elector.Campaign()
+
+	ClientMock.ExpectEval(compareAndDeleteScript, []string{"test_leader"}, "test_owner").SetVal(int64(1))
+	err := elector.Resign()
+
+	Convey("resign the leadership", t, func() {
+		Convey("should not be the leader", func() {
+			So(err, ShouldBeNil)
+			So(elector.IsLeader(), ShouldBeFalse)
+		})
+	})
+}
+
+func Test_LeaderElector_IsFencingTokenValid(t *testing.T) {
+	elector := newTestElector()
+
+	ClientMock.ExpectGet("{test_leader}.fencing").SetVal("5")
+	current, currentErr := elector.IsFencingTokenValid(5)
+
+	ClientMock.ExpectGet("{test_leader}.fencing").SetVal("6")
+	stale, staleErr := elector.IsFencingTokenValid(5)
+
+	Convey("check the fencing token", t, func() {
+		Convey("should be valid when it is the latest token", func() {
+			So(currentErr, ShouldBeNil)
+			So(current, ShouldBeTrue)
+		})
+		Convey("should be invalid when a newer token exists", func() {
+			So(staleErr, ShouldBeNil)
+			So(stale, ShouldBeFalse)
+		})
+	})
+}
+
+func Test_LeaderElector_Campaign_FencingFail(t *testing.T) {
+	elector := newTestElector()
+
+	ClientMock.ExpectSetNX("test_leader", "test_owner", time.Second*3).SetVal(true)
+	ClientMock.ExpectIncrBy("{test_leader}.fencing", 1).SetErr(errors.New("failed to incr"))
+	ClientMock.ExpectEval(compareAndDeleteScript, []string{"test_leader"}, "test_owner").SetVal(int64(1))
+	elector.Campaign()
+
+	Convey("failed to get a fencing token", t, func() {
+		Convey("should release the lease and not be the leader", func() {
+			So(elector.IsLeader(), ShouldBeFalse)
+			So(ClientMock.ExpectationsWereMet(), ShouldBeNil)
+		})
+	})
+}
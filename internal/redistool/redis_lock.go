@@ -36,6 +36,8 @@ func LockWithExpire(lockName string, timeoutMS uint, expire time.Duration) error
 	}
 }
 
+// Unlock deletes the lock without checking its owner.
+// Deprecated: use SafeLock, which only releases a lock it still holds.
 func Unlock(lockName string) error {
 	cmd := DefaultRedis().Del(context.Background(), lockName)
 	err := cmd.Err()
@@ -48,6 +50,8 @@ func Unlock(lockName string) error {
 	return nil
 }
 
+// RenewLock re-acquires the lock instead of extending it.
+// Deprecated: use SafeLock.Renew, which only extends a lock it still holds.
 func RenewLock(lockName string, expire time.Duration) error {
 	cmd := DefaultRedis().SetNX(context.Background(), lockName, 1, expire)
 	err := cmd.Err()
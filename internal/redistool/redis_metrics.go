@@ -0,0 +1,56 @@
+package redistool
+
+import (
+	"context"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+
+	"github.com/danenmao/pterergate-dtf/internal/metrics"
+)
+
+type startTimeKey struct{}
+
+// 统计Redis命令耗时的钩子
+type metricsHook struct {
+}
+
+func (hook metricsHook) BeforeProcess(ctx context.Context, cmd goredis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, startTimeKey{}, time.Now()), nil
+}
+
+func (hook metricsHook) AfterProcess(ctx context.Context, cmd goredis.Cmder) error {
+	observeCall(ctx, cmd.Name(), cmd.Err())
+	return nil
+}
+
+func (hook metricsHook) BeforeProcessPipeline(ctx context.Context, cmds []goredis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, startTimeKey{}, time.Now()), nil
+}
+
+func (hook metricsHook) AfterProcessPipeline(ctx context.Context, cmds []goredis.Cmder) error {
+	var err error
+	for _, cmd := range cmds {
+		if cmd.Err() != nil && cmd.Err() != goredis.Nil {
+			err = cmd.Err()
+			break
+		}
+	}
+
+	observeCall(ctx, "pipeline", err)
+	return nil
+}
+
+// 键不存在不视为调用失败
+func observeCall(ctx context.Context, operation string, err error) {
+	start, ok := ctx.Value(startTimeKey{}).(time.Time)
+	if !ok {
+		return
+	}
+
+	if err == goredis.Nil {
+		err = nil
+	}
+
+	metrics.ObserveStoreCall(metrics.Store_Redis, operation, start, err)
+}
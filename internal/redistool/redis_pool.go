@@ -4,6 +4,7 @@ import (
 	"context"
 	"net"
 	"strconv"
+	"strings"
 	"time"
 
 	goredis "github.com/go-redis/redis/v8"
@@ -13,11 +14,11 @@ import (
 	"github.com/danenmao/pterergate-dtf/internal/config"
 )
 
-// go-redis连接池
-var gs_RedisClient *goredis.Client
+// go-redis连接池, 可以是单节点、Sentinel或Cluster客户端
+var gs_RedisClient goredis.UniversalClient
 
 // 获取go-redis实例
-func DefaultRedis() *goredis.Client {
+func DefaultRedis() goredis.UniversalClient {
 	return gs_RedisClient
 }
 
@@ -27,13 +28,93 @@ func ConnectToDefaultRedis() {
 }
 
 // 初始化go-redis连接池
-func InitRedisClient(cfg *extconfig.RedisAddress) *goredis.Client {
+func InitRedisClient(cfg *extconfig.RedisAddress) goredis.UniversalClient {
+	var client goredis.UniversalClient
+	switch cfg.Mode {
+	case "", extconfig.RedisMode_Single:
+		client = goredis.NewClient(newRedisOptions(cfg))
+
+	case extconfig.RedisMode_Sentinel:
+		opt := newRedisOptions(cfg)
+		client = goredis.NewFailoverClient(&goredis.FailoverOptions{
+			MasterName:    cfg.MasterName,
+			SentinelAddrs: splitAddress(cfg.Address),
+			Password:      opt.Password,
+			DB:            opt.DB,
+
+			PoolSize:     opt.PoolSize,
+			MinIdleConns: opt.MinIdleConns,
+
+			DialTimeout:  opt.DialTimeout,
+			ReadTimeout:  opt.ReadTimeout,
+			WriteTimeout: opt.WriteTimeout,
+			PoolTimeout:  opt.PoolTimeout,
+
+			IdleCheckFrequency: opt.IdleCheckFrequency,
+			IdleTimeout:        opt.IdleTimeout,
+			MaxConnAge:         opt.MaxConnAge,
+
+			MaxRetries:      opt.MaxRetries,
+			MinRetryBackoff: opt.MinRetryBackoff,
+			MaxRetryBackoff: opt.MaxRetryBackoff,
+
+			Dialer: opt.Dialer,
+		})
+
+	case extconfig.RedisMode_Cluster:
+		// Cluster不支持选择DB
+		opt := newRedisOptions(&extconfig.RedisAddress{Type: cfg.Type, Password: cfg.Password, DB: "0"})
+		client = goredis.NewClusterClient(&goredis.ClusterOptions{
+			Addrs:    splitAddress(cfg.Address),
+			Password: opt.Password,
+
+			PoolSize:     opt.PoolSize,
+			MinIdleConns: opt.MinIdleConns,
+
+			DialTimeout:  opt.DialTimeout,
+			ReadTimeout:  opt.ReadTimeout,
+			WriteTimeout: opt.WriteTimeout,
+			PoolTimeout:  opt.PoolTimeout,
+
+			IdleCheckFrequency: opt.IdleCheckFrequency,
+			IdleTimeout:        opt.IdleTimeout,
+			MaxConnAge:         opt.MaxConnAge,
+
+			// 节点迁移时, 需要跟随MOVED/ASK重定向
+			MaxRedirects:    8,
+			MaxRetries:      opt.MaxRetries,
+			MinRetryBackoff: opt.MinRetryBackoff,
+			MaxRetryBackoff: opt.MaxRetryBackoff,
+
+			Dialer: opt.Dialer,
+		})
+
+	default:
+		glog.Fatal("unknown redis mode: ", cfg.Mode)
+		return nil
+	}
+
+	// 统计命令耗时
+	client.AddHook(metricsHook{})
+
+	// 激活连接
+	_, err := client.Ping(context.Background()).Result()
+	if err != nil {
+		glog.Fatal("failed to connect to redis", err)
+		return nil
+	}
+
+	return client
+}
+
+// 单节点的连接参数, 也作为Sentinel和Cluster模式的公共参数
+func newRedisOptions(cfg *extconfig.RedisAddress) *goredis.Options {
 	dbNo, err := strconv.Atoi(cfg.DB)
 	if err != nil {
 		panic(err.Error())
 	}
 
-	client := goredis.NewClient(&goredis.Options{
+	return &goredis.Options{
 		// 连接信息
 		Addr:     cfg.Address,
 		Password: cfg.Password,
@@ -74,14 +155,18 @@ func InitRedisClient(cfg *extconfig.RedisAddress) *goredis.Client {
 		OnConnect: func(ctx context.Context, conn *goredis.Conn) error {
 			return nil
 		},
-	})
+	}
+}
 
-	// 激活连接
-	_, err = client.Ping(context.Background()).Result()
-	if err != nil {
-		glog.Fatal("failed to connect to redis", err)
-		return nil
+// 拆分逗号分隔的地址列表
+func splitAddress(address string) []string {
+	addrs := []string{}
+	for _, addr := range strings.Split(address, ",") {
+		addr = strings.TrimSpace(addr)
+		if len(addr) > 0 {
+			addrs = append(addrs, addr)
+		}
 	}
 
-	return client
+	return addrs
 }
@@ -0,0 +1,230 @@
+package redistool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/google/uuid"
+)
+
+// 锁被其他实例持有
+var ErrLockNotAcquired = errors.New("lock owned by other")
+
+// 锁已不再由当前持有者持有, 可能已过期或被其他实例获取
+var ErrLockNotOwned = errors.New("lock not owned")
+
+// 仅当锁仍由自己持有时释放
+const compareAndDeleteScript = `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`
+
+// 仅当锁仍由自己持有时续期
+const compareAndExpireScript = `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`
+
+// 锁的存储接口
+type ILockBackend interface {
+	// 锁不存在时设置锁, 返回是否设置成功
+	SetLockIfAbsent(ctx context.Context, name string, token string, expire time.Duration) (bool, error)
+
+	// 锁由token持有时删除锁, 返回是否删除成功
+	DeleteLockIfOwned(ctx context.Context, name string, token string) (bool, error)
+
+	// 锁由token持有时续期锁, 返回是否续期成功
+	ExpireLockIfOwned(ctx context.Context, name string, token string, expire time.Duration) (bool, error)
+}
+
+// 基于Redis的锁存储
+type RedisLockBackend struct{}
+
+func (RedisLockBackend) SetLockIfAbsent(
+	ctx context.Context, name string, token string, expire time.Duration,
+) (bool, error) {
+	return DefaultRedis().SetNX(ctx, name, token, expire).Result()
+}
+
+func (RedisLockBackend) DeleteLockIfOwned(
+	ctx context.Context, name string, token string,
+) (bool, error) {
+	ret, err := DefaultRedis().Eval(ctx, compareAndDeleteScript, []string{name}, token).Int64()
+	return ret == 1, err
+}
+
+func (RedisLockBackend) ExpireLockIfOwned(
+	ctx context.Context, name string, token string, expire time.Duration,
+) (bool, error) {
+	ret, err := DefaultRedis().Eval(ctx, compareAndExpireScript, []string{name}, token,
+		expire.Milliseconds()).Int64()
+	return ret == 1, err
+}
+
+// 带持有者令牌的分布式锁
+// 释放和续期前会校验令牌, 不会误删或误续其他实例持有的锁
+type SafeLock struct {
+	Name    string        // 锁的key名
+	Token   string        // 持有者令牌
+	Expire  time.Duration // 锁的有效期
+	Backend ILockBackend  // 锁的存储
+
+	mutex          sync.Mutex
+	watchdogCancel context.CancelFunc // 停止看门狗的函数
+	lost           bool               // 看门狗续期失败, 锁已丢失
+}
+
+// 创建基于Redis的锁对象, 每个锁对象使用随机的持有者令牌
+func NewSafeLock(name string, expire time.Duration) *SafeLock {
+	return NewSafeLockWithBackend(name, expire, RedisLockBackend{})
+}
+
+// 创建使用指定存储的锁对象
+func NewSafeLockWithBackend(name string, expire time.Duration, backend ILockBackend) *SafeLock {
+	if expire <= 0 {
+		expire = defaultExpire
+	}
+
+	return &SafeLock{
+		Name:    name,
+		Token:   uuid.NewString(),
+		Expire:  expire,
+		Backend: backend,
+	}
+}
+
+// 尝试获取一次锁
+func (lock *SafeLock) TryLock(ctx context.Context) error {
+	gotLock, err := lock.Backend.SetLockIfAbsent(ctx, lock.Name, lock.Token, lock.Expire)
+	if err != nil {
+		glog.Warning("failed to set the lock: ", lock.Name, ", ", err)
+		return err
+	}
+
+	if !gotLock {
+		return ErrLockNotAcquired
+	}
+
+	lock.mutex.Lock()
+	lock.lost = false
+	lock.mutex.Unlock()
+
+	glog.Info("got the lock: ", lock.Name)
+	return nil
+}
+
+// 获取锁, 直到成功或ctx结束
+func (lock *SafeLock) Acquire(ctx context.Context) error {
+	for {
+		err := lock.TryLock(ctx)
+		if err != ErrLockNotAcquired {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			glog.Info("lock owned by other: ", lock.Name)
+			return ErrLockNotAcquired
+		case <-time.After(time.Millisecond * sleepInterval):
+		}
+	}
+}
+
+// 释放锁, 锁已不由自己持有时返回ErrLockNotOwned
+func (lock *SafeLock) Unlock() error {
+	lock.StopWatchdog()
+
+	released, err := lock.Backend.DeleteLockIfOwned(context.Background(), lock.Name, lock.Token)
+	if err != nil {
+		glog.Warning("failed to release the lock: ", lock.Name, ", ", err)
+		return err
+	}
+
+	if !released {
+		glog.Warning("lock to be released is not owned: ", lock.Name)
+		return ErrLockNotOwned
+	}
+
+	glog.Info("succeeded to release the lock: ", lock.Name)
+	return nil
+}
+
+// 续期锁, 锁已不由自己持有时返回ErrLockNotOwned
+func (lock *SafeLock) Renew() error {
+	renewed, err := lock.Backend.ExpireLockIfOwned(context.Background(), lock.Name, lock.Token, lock.Expire)
+	if err != nil {
+		glog.Warning("failed to renew the lock: ", lock.Name, ", ", err)
+		return err
+	}
+
+	if !renewed {
+		lock.mutex.Lock()
+		lock.lost = true
+		lock.mutex.Unlock()
+
+		glog.Warning("lock to be renewed is not owned: ", lock.Name)
+		return ErrLockNotOwned
+	}
+
+	return nil
+}
+
+// 锁是否已丢失
+func (lock *SafeLock) IsLost() bool {
+	lock.mutex.Lock()
+	defer lock.mutex.Unlock()
+	return lock.lost
+}
+
+// 启动看门狗, 按interval定期续期锁, 直到锁被释放或丢失
+func (lock *SafeLock) StartWatchdog(interval time.Duration) {
+	if interval <= 0 {
+		interval = lock.Expire / 3
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	lock.mutex.Lock()
+	if lock.watchdogCancel != nil {
+		lock.watchdogCancel()
+	}
+	lock.watchdogCancel = cancel
+	lock.mutex.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			// 锁已被其他实例获取, 停止续期
+			if lock.Renew() == ErrLockNotOwned {
+				glog.Warning("lock lost, stop the watchdog: ", lock.Name)
+				return
+			}
+		}
+	}()
+}
+
+// 停止看门狗
+func (lock *SafeLock) StopWatchdog() {
+	lock.mutex.Lock()
+	defer lock.mutex.Unlock()
+
+	if lock.watchdogCancel != nil {
+		lock.watchdogCancel()
+		lock.watchdogCancel = nil
+	}
+}
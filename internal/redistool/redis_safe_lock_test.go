@@ -0,0 +1,104 @@
+package redistool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func newTestSafeLock() *SafeLock {
+	lock := NewSafeLock("test_safe_lock", defaultExpire)
+	lock.Token = "test_token"
+	return lock
+}
+
+func Test_SafeLock_TryLock_Success(t *testing.T) {
+	lock := newTestSafeLock()
+	ClientMock.ExpectSetNX("test_safe_lock", "test_token", defaultExpire).SetVal(true)
+
+	err := lock.TryLock(context.Background())
+
+	Convey("get a safe lock successfully", t, func() {
+		Convey("should be nil", func() {
+			So(err, ShouldBeNil)
+		})
+	})
+}
+
+func Test_SafeLock_Acquire_OwnedByOther(t *testing.T) {
+	lock := newTestSafeLock()
+	for i := 0; i < 10; i++ {
+		ClientMock.ExpectSetNX("test_safe_lock", "test_token", defaultExpire).SetVal(false)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := lock.Acquire(ctx)
+	ClientMock.ClearExpect()
+
+	Convey("failed to acquire a safe lock owned by other", t, func() {
+		Convey("should be ErrLockNotAcquired", func() {
+			So(err, ShouldEqual, ErrLockNotAcquired)
+		})
+	})
+}
+
+func Test_SafeLock_Unlock_Owned(t *testing.T) {
+	lock := newTestSafeLock()
+	ClientMock.ExpectEval(compareAndDeleteScript, []string{"test_safe_lock"}, "test_token").SetVal(int64(1))
+
+	err := lock.Unlock()
+
+	Convey("release an owned safe lock", t, func() {
+		Convey("should be nil", func() {
+			So(err, ShouldBeNil)
+		})
+	})
+}
+
+func Test_SafeLock_Unlock_NotOwned(t *testing.T) {
+	lock := newTestSafeLock()
+	ClientMock.ExpectEval(compareAndDeleteScript, []string{"test_safe_lock"}, "test_token").SetVal(int64(0))
+
+	err := lock.Unlock()
+
+	Convey("release a safe lock acquired by other", t, func() {
+		Convey("should be ErrLockNotOwned", func() {
+			So(err, ShouldEqual, ErrLockNotOwned)
+		})
+	})
+}
+
+func Test_SafeLock_Renew(t *testing.T) {
+	lock := newTestSafeLock()
+	ms := defaultExpire.Milliseconds()
+
+	ClientMock.ExpectEval(compareAndExpireScript, []string{"test_safe_lock"}, "test_token", ms).SetVal(int64(1))
+	renewErr := lock.Renew()
+	lostAfterRenew := lock.IsLost()
+
+	ClientMock.ExpectEval(compareAndExpireScript, []string{"test_safe_lock"}, "test_token", ms).SetVal(int64(0))
+	notOwnedErr := lock.Renew()
+
+	ClientMock.ExpectEval(compareAndExpireScript, []string{"test_safe_lock"}, "test_token", ms).
+		SetErr(errors.New("failed to renew"))
+	failErr := lock.Renew()
+
+	Convey("renew a safe lock", t, func() {
+		Convey("should succeed when owned", func() {
+			So(renewErr, ShouldBeNil)
+			So(lostAfterRenew, ShouldBeFalse)
+		})
+		Convey("should return ErrLockNotOwned when not owned", func() {
+			So(notOwnedErr, ShouldEqual, ErrLockNotOwned)
+			So(lock.IsLost(), ShouldBeTrue)
+		})
+		Convey("should return the redis error", func() {
+			So(failErr, ShouldNotBeNil)
+			So(failErr, ShouldNotEqual, ErrLockNotOwned)
+		})
+	})
+}
@@ -5,7 +5,7 @@ import (
 	"github.com/go-redis/redismock/v8"
 )
 
-var s_actualClient *goredis.Client
+var s_actualClient goredis.UniversalClient
 var s_mockClient *goredis.Client
 var ClientMock redismock.ClientMock
 
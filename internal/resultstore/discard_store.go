@@ -0,0 +1,32 @@
+package resultstore
+
+import (
+	"context"
+
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/internal/dbdef"
+)
+
+// 丢弃子任务结果的存储, 未配置结果存储时使用
+type DiscardStore struct {
+}
+
+func NewDiscardStore() *DiscardStore {
+	return &DiscardStore{}
+}
+
+func (store *DiscardStore) AddSubtaskResults(ctx context.Context, results []dbdef.DBSubtaskResult) error {
+	return nil
+}
+
+func (store *DiscardStore) ListSubtaskResults(
+	ctx context.Context,
+	filter *SubtaskResultFilter,
+	results *[]dbdef.DBSubtaskResult,
+) error {
+	return errordef.ErrUninitialized
+}
+
+func (store *DiscardStore) GetMaxSubtaskId(ctx context.Context) (uint64, error) {
+	return 0, nil
+}
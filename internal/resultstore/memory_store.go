@@ -0,0 +1,71 @@
+package resultstore
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/danenmao/pterergate-dtf/internal/dbdef"
+)
+
+// 基于内存的子任务结果存储, 用于单进程部署和测试
+type MemoryStore struct {
+	mutex   sync.Mutex
+	results map[uint64]dbdef.DBSubtaskResult
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		results: map[uint64]dbdef.DBSubtaskResult{},
+	}
+}
+
+func (store *MemoryStore) AddSubtaskResults(ctx context.Context, results []dbdef.DBSubtaskResult) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for _, result := range results {
+		store.results[result.SubtaskId] = result
+	}
+
+	return nil
+}
+
+func (store *MemoryStore) ListSubtaskResults(
+	ctx context.Context,
+	filter *SubtaskResultFilter,
+	results *[]dbdef.DBSubtaskResult,
+) error {
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	matched := []dbdef.DBSubtaskResult{}
+	for _, result := range store.results {
+		if filter.match(&result) {
+			matched = append(matched, result)
+		}
+	}
+
+	sort.Slice(matched, func(i, j int) bool { return matched[i].SubtaskId < matched[j].SubtaskId })
+	if len(matched) > filter.limit() {
+		matched = matched[:filter.limit()]
+	}
+
+	*results = append(*results, matched...)
+	return nil
+}
+
+func (store *MemoryStore) GetMaxSubtaskId(ctx context.Context) (uint64, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	var maxId uint64 = 0
+	for id := range store.results {
+		if id > maxId {
+			maxId = id
+		}
+	}
+
+	return maxId, nil
+}
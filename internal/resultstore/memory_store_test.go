@@ -0,0 +1,51 @@
+package resultstore
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/internal/dbdef"
+)
+
+func Test_MemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	Convey("add and list subtask results", t, func() {
+		So(store.AddSubtaskResults(ctx, []dbdef.DBSubtaskResult{
+			{SubtaskId: 3, TaskId: 1, Result: 2, ResultCode: 7},
+			{SubtaskId: 1, TaskId: 1, Result: 1},
+			{SubtaskId: 2, TaskId: 1, Result: 2, ResultCode: 8},
+			{SubtaskId: 4, TaskId: 2, Result: 1},
+		}), ShouldBeNil)
+
+		// 重新执行的子任务覆盖之前的结果
+		So(store.AddSubtaskResults(ctx, []dbdef.DBSubtaskResult{{SubtaskId: 1, TaskId: 1, Result: 1, Attempts: 2}}), ShouldBeNil)
+
+		results := []dbdef.DBSubtaskResult{}
+		So(store.ListSubtaskResults(ctx, &SubtaskResultFilter{TaskId: 1}, &results), ShouldBeNil)
+		So(len(results), ShouldEqual, 3)
+		So(results[0].SubtaskId, ShouldEqual, 1)
+		So(results[0].Attempts, ShouldEqual, 2)
+		So(results[2].SubtaskId, ShouldEqual, 3)
+
+		results = []dbdef.DBSubtaskResult{}
+		So(store.ListSubtaskResults(ctx, &SubtaskResultFilter{TaskId: 1, Result: 2, AfterId: 2}, &results), ShouldBeNil)
+		So(len(results), ShouldEqual, 1)
+		So(results[0].SubtaskId, ShouldEqual, 3)
+
+		results = []dbdef.DBSubtaskResult{}
+		So(store.ListSubtaskResults(ctx, &SubtaskResultFilter{TaskId: 1, ResultCode: 8}, &results), ShouldBeNil)
+		So(len(results), ShouldEqual, 1)
+		So(results[0].SubtaskId, ShouldEqual, 2)
+	})
+
+	Convey("the discard store saves nothing", t, func() {
+		So(Enabled(), ShouldBeFalse)
+		results := []dbdef.DBSubtaskResult{}
+		So(Default().ListSubtaskResults(ctx, &SubtaskResultFilter{TaskId: 1}, &results), ShouldEqual, errordef.ErrUninitialized)
+	})
+}
@@ -0,0 +1,129 @@
+package resultstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/glog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/danenmao/pterergate-dtf/internal/dbdef"
+	"github.com/danenmao/pterergate-dtf/internal/metrics"
+	"github.com/danenmao/pterergate-dtf/internal/mongotool"
+)
+
+// 子任务结果的集合名
+const SubtaskResultCollection = "subtask_result"
+
+// 基于MongoDB的子任务结果存储, 使用mongotool的默认连接
+// 子任务ID作为文档的_id
+type MongoStore struct {
+}
+
+func NewMongoStore() *MongoStore {
+	return &MongoStore{}
+}
+
+// 创建按任务查询结果的索引
+func (store *MongoStore) CreateIndexes(ctx context.Context) error {
+	_, err := store.collection().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: dbdef.SubtaskResultTable_TaskId, Value: 1},
+			{Key: dbdef.SubtaskResultTable_Result, Value: 1},
+			{Key: "_id", Value: 1},
+		},
+	})
+
+	if err != nil {
+		glog.Warning("failed to create subtask result indexes: ", err)
+		return err
+	}
+
+	return nil
+}
+
+func (store *MongoStore) AddSubtaskResults(ctx context.Context, results []dbdef.DBSubtaskResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	models := []mongo.WriteModel{}
+	for idx := range results {
+		models = append(models, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": results[idx].SubtaskId}).
+			SetReplacement(&results[idx]).
+			SetUpsert(true))
+	}
+
+	start := time.Now()
+	_, err := store.collection().BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	metrics.ObserveStoreCall(metrics.Store_Mongo, "add_subtask_results", start, err)
+
+	if err != nil {
+		glog.Warning("failed to add subtask results: ", len(results), ", ", err)
+		return err
+	}
+
+	return nil
+}
+
+func (store *MongoStore) ListSubtaskResults(
+	ctx context.Context,
+	filter *SubtaskResultFilter,
+	results *[]dbdef.DBSubtaskResult,
+) error {
+
+	cond := bson.M{
+		dbdef.SubtaskResultTable_TaskId: filter.TaskId,
+		"_id":                           bson.M{"$gt": filter.AfterId},
+	}
+
+	if filter.Result != 0 {
+		cond[dbdef.SubtaskResultTable_Result] = filter.Result
+	}
+
+	if filter.ResultCode != 0 {
+		cond[dbdef.SubtaskResultTable_ResultCode] = filter.ResultCode
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(filter.limit()))
+
+	start := time.Now()
+	cursor, err := store.collection().Find(ctx, cond, opts)
+	if err == nil {
+		err = cursor.All(ctx, results)
+	}
+	metrics.ObserveStoreCall(metrics.Store_Mongo, "list_subtask_results", start, err)
+
+	if err != nil {
+		glog.Warning("failed to list subtask results: ", filter.TaskId, ", ", err)
+		return err
+	}
+
+	return nil
+}
+
+func (store *MongoStore) GetMaxSubtaskId(ctx context.Context) (uint64, error) {
+	result := dbdef.DBSubtaskResult{}
+	opts := options.FindOne().SetSort(bson.D{{Key: "_id", Value: -1}}).SetProjection(bson.M{"_id": 1})
+
+	start := time.Now()
+	err := store.collection().FindOne(ctx, bson.M{}, opts).Decode(&result)
+	if err == mongo.ErrNoDocuments {
+		err = nil
+	}
+	metrics.ObserveStoreCall(metrics.Store_Mongo, "get_max_subtask_id", start, err)
+
+	if err != nil {
+		glog.Warning("failed to get max subtask id: ", err)
+		return 0, err
+	}
+
+	return result.SubtaskId, nil
+}
+
+func (store *MongoStore) collection() *mongo.Collection {
+	return mongotool.GetDefaultMongoDB().Collection(SubtaskResultCollection)
+}
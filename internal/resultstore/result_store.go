@@ -0,0 +1,76 @@
+package resultstore
+
+import (
+	"context"
+
+	"github.com/danenmao/pterergate-dtf/internal/dbdef"
+)
+
+// 子任务结果存储, 在子任务信息过期后保留子任务的结果
+type ISubtaskResultStore interface {
+	// 添加子任务结果, 已存在的结果被覆盖
+	AddSubtaskResults(ctx context.Context, results []dbdef.DBSubtaskResult) error
+
+	// 按条件查询任务的子任务结果, 按子任务ID从小到大排列
+	ListSubtaskResults(ctx context.Context, filter *SubtaskResultFilter, results *[]dbdef.DBSubtaskResult) error
+
+	// 获取最大的子任务ID, 没有结果时为0
+	GetMaxSubtaskId(ctx context.Context) (uint64, error)
+}
+
+// 未指定Limit时, 查询返回的最大记录数
+const DefaultSubtaskResultLimit = 100
+
+// 子任务结果的查询条件, 零值的条件不生效
+type SubtaskResultFilter struct {
+	TaskId     uint64 // 任务ID, 必须指定
+	Result     uint32 // 子任务的结果
+	ResultCode uint32 // 子任务的结果码
+	AfterId    uint64 // 只返回子任务ID大于此值的结果, 用于分页
+	Limit      int    // 返回的最大记录数
+}
+
+// 查询返回的最大记录数
+func (filter *SubtaskResultFilter) limit() int {
+	if filter.Limit <= 0 {
+		return DefaultSubtaskResultLimit
+	}
+
+	return filter.Limit
+}
+
+// 结果是否满足查询条件
+func (filter *SubtaskResultFilter) match(result *dbdef.DBSubtaskResult) bool {
+	if result.TaskId != filter.TaskId || result.SubtaskId <= filter.AfterId {
+		return false
+	}
+
+	if filter.Result != 0 && result.Result != filter.Result {
+		return false
+	}
+
+	if filter.ResultCode != 0 && result.ResultCode != filter.ResultCode {
+		return false
+	}
+
+	return true
+}
+
+// 默认不保存子任务结果
+var gs_DefaultStore ISubtaskResultStore = NewDiscardStore()
+
+// 获取默认的子任务结果存储
+func Default() ISubtaskResultStore {
+	return gs_DefaultStore
+}
+
+// 设置默认的子任务结果存储, 需在服务启动前调用
+func SetDefault(store ISubtaskResultStore) {
+	gs_DefaultStore = store
+}
+
+// 是否保存子任务结果
+func Enabled() bool {
+	_, discard := gs_DefaultStore.(*DiscardStore)
+	return !discard
+}
@@ -0,0 +1,93 @@
+package resultstore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+
+	"github.com/danenmao/pterergate-dtf/internal/dbdef"
+	"github.com/danenmao/pterergate-dtf/internal/dbdialect"
+	"github.com/danenmao/pterergate-dtf/internal/metrics"
+	"github.com/danenmao/pterergate-dtf/internal/mysqltool"
+)
+
+// 基于SQL数据库的子任务结果存储, 使用mysqltool的默认连接
+// 语句按默认连接的方言转换, 支持MySQL、PostgreSQL和SQLite
+type SQLStore struct {
+}
+
+func NewSQLStore() *SQLStore {
+	return &SQLStore{}
+}
+
+func (store *SQLStore) AddSubtaskResults(ctx context.Context, results []dbdef.DBSubtaskResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	start := time.Now()
+	_, err := mysqltool.DefaultMySQL().NamedExecContext(ctx,
+		dbdialect.Default().Upsert(&dbdef.SubtaskResultTable),
+		results,
+	)
+	metrics.ObserveStoreCall(metrics.Store_MySQL, "add_subtask_results", start, err)
+
+	if err != nil {
+		glog.Warning("failed to add subtask results: ", len(results), ", ", err)
+		return err
+	}
+
+	return nil
+}
+
+func (store *SQLStore) ListSubtaskResults(
+	ctx context.Context,
+	filter *SubtaskResultFilter,
+	results *[]dbdef.DBSubtaskResult,
+) error {
+
+	// 拼装查询条件
+	query := dbdef.SQL_SubtaskResultTable_QueryResult + fmt.Sprintf(" where `%s`=? and `%s`>?",
+		dbdef.SubtaskResultTable_TaskId, dbdef.SubtaskResultTable_SubtaskId)
+	args := []interface{}{filter.TaskId, filter.AfterId}
+
+	if filter.Result != 0 {
+		query += fmt.Sprintf(" and `%s`=?", dbdef.SubtaskResultTable_Result)
+		args = append(args, filter.Result)
+	}
+
+	if filter.ResultCode != 0 {
+		query += fmt.Sprintf(" and `%s`=?", dbdef.SubtaskResultTable_ResultCode)
+		args = append(args, filter.ResultCode)
+	}
+
+	query += fmt.Sprintf(" order by `%s` asc limit ?", dbdef.SubtaskResultTable_SubtaskId)
+	args = append(args, filter.limit())
+
+	start := time.Now()
+	err := mysqltool.DefaultMySQL().SelectContext(ctx, results, dbdialect.SQL(query), args...)
+	metrics.ObserveStoreCall(metrics.Store_MySQL, "list_subtask_results", start, err)
+
+	if err != nil {
+		glog.Warning("failed to list subtask results: ", filter.TaskId, ", ", err)
+		return err
+	}
+
+	return nil
+}
+
+func (store *SQLStore) GetMaxSubtaskId(ctx context.Context) (uint64, error) {
+	var maxId uint64 = 0
+	start := time.Now()
+	err := mysqltool.DefaultMySQL().GetContext(ctx, &maxId, dbdialect.SQL(dbdef.SQL_SubtaskResultTable_QueryMaxId))
+	metrics.ObserveStoreCall(metrics.Store_MySQL, "get_max_subtask_id", start, err)
+
+	if err != nil {
+		glog.Warning("failed to get max subtask id: ", err)
+		return 0, err
+	}
+
+	return maxId, nil
+}
@@ -6,17 +6,27 @@ import (
 	"github.com/golang/glog"
 
 	"github.com/danenmao/pterergate-dtf/internal/exitctrl"
+	"github.com/danenmao/pterergate-dtf/internal/metrics"
 	"github.com/danenmao/pterergate-dtf/internal/misc"
 )
 
 // 例程类型
 type RoutineFn func()
 
+// 获取执行间隔的函数类型
+type IntervalFn func() time.Duration
+
+// 领导者身份检查接口
+type ILeaderChecker interface {
+	IsLeader() bool
+}
+
 // 工作例程结构
 type WorkingRoutine struct {
-	RoutineFn    RoutineFn     // 工作例程函数
-	RoutineCount uint          // 例程数量
-	Interval     time.Duration // 工作例程的执行间隔
+	RoutineFn    RoutineFn      // 工作例程函数
+	RoutineCount uint           // 例程数量
+	Interval     time.Duration  // 工作例程的执行间隔
+	Leader       ILeaderChecker // 非空时, 仅在当前实例为领导者时执行
 }
 
 // 启动所有的工作例程
@@ -25,7 +35,7 @@ func StartWorkingRoutine(workers []WorkingRoutine) error {
 	for _, worker := range workers {
 		name := misc.GetFunctionName(worker.RoutineFn)
 		for i := 0; i < int(worker.RoutineCount); i++ {
-			go WorkingRoutineWrapper(name, worker.RoutineFn, worker.Interval)()
+			go LeaderRoutineWrapper(name, worker.RoutineFn, worker.Interval, worker.Leader)()
 		}
 	}
 
@@ -39,24 +49,88 @@ func WorkingRoutineWrapper(name string, fn RoutineFn, interval time.Duration) Ro
 	}
 }
 
+// 仅在领导者上执行的工作例程包装函数
+func LeaderRoutineWrapper(name string, fn RoutineFn, interval time.Duration, leader ILeaderChecker) RoutineFn {
+	return func() {
+		ExecLeaderRoutineWithInterval(name, fn, interval, leader)
+	}
+}
+
+// 定期执行工作例程, 当前实例不是领导者时跳过本次执行
+// leader为空时, 等同于ExecRoutineWithInterval
+func ExecLeaderRoutineWithInterval(
+	name string,
+	routine RoutineFn,
+	interval time.Duration,
+	leader ILeaderChecker,
+) {
+	if leader == nil {
+		ExecRoutineWithInterval(name, routine, interval)
+		return
+	}
+
+	ExecRoutineWithInterval(name, func() {
+		if !leader.IsLeader() {
+			return
+		}
+
+		routine()
+	}, interval)
+}
+
+// 定期执行工作例程, 执行间隔在每次执行前由intervalFn获取
+// 用于执行间隔可在运行时调整的例程
+func ExecLeaderRoutineWithIntervalFn(
+	name string,
+	routine RoutineFn,
+	intervalFn IntervalFn,
+	leader ILeaderChecker,
+) {
+	if leader == nil {
+		ExecRoutineWithIntervalFn(name, routine, intervalFn)
+		return
+	}
+
+	ExecRoutineWithIntervalFn(name, func() {
+		if !leader.IsLeader() {
+			return
+		}
+
+		routine()
+	}, intervalFn)
+}
+
 // 定期执行工作例程
 func ExecRoutineWithInterval(
 	name string,
 	routine RoutineFn,
 	interval time.Duration,
+) {
+	ExecRoutineWithIntervalFn(name, routine, func() time.Duration {
+		return interval
+	})
+}
+
+// 定期执行工作例程, 执行间隔在每次执行前由intervalFn获取
+func ExecRoutineWithIntervalFn(
+	name string,
+	routine RoutineFn,
+	intervalFn IntervalFn,
 ) {
 	glog.Info("begin to ", name)
 
 	// 定期执行检查
 	for {
 		// 检查并等待退出信号
-		if exitctrl.WaitForSignal(interval) {
+		if exitctrl.WaitForSignal(intervalFn()) {
 			glog.Info("got to exit signal")
 			break
 		}
 
 		// 执行例程
+		start := time.Now()
 		routine()
+		metrics.RoutineDuration.ObserveSince(start, name)
 	}
 
 	glog.Info("leave ", name)
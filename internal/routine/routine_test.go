@@ -57,3 +57,57 @@ func Test_StartWorkingRoutine_NotifyToExit(t *testing.T) {
 		})
 	})
 }
+
+type testLeader struct {
+	leader bool
+}
+
+func (l *testLeader) IsLeader() bool {
+	return l.leader
+}
+
+func Test_ExecLeaderRoutineWithInterval_NotLeader(t *testing.T) {
+	counter := 0
+	exitctrl.Register()
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		exitctrl.NotifyToExit()
+	}()
+
+	ExecLeaderRoutineWithInterval("test",
+		func() {
+			counter += 1
+		},
+		50*time.Millisecond,
+		&testLeader{leader: false},
+	)
+
+	Convey("test to execute the leader routine on a follower", t, func() {
+		Convey("counter should be zero", func() {
+			So(counter, ShouldBeZeroValue)
+		})
+	})
+}
+
+func Test_ExecLeaderRoutineWithInterval_Leader(t *testing.T) {
+	counter := 0
+	exitctrl.Register()
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		exitctrl.NotifyToExit()
+	}()
+
+	ExecLeaderRoutineWithInterval("test",
+		func() {
+			counter += 1
+		},
+		50*time.Millisecond,
+		&testLeader{leader: true},
+	)
+
+	Convey("test to execute the leader routine on the leader", t, func() {
+		Convey("counter should be greater than 4", func() {
+			So(counter, ShouldBeGreaterThan, 4)
+		})
+	})
+}
@@ -0,0 +1,83 @@
+package rpcpb
+
+import (
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+)
+
+// 转换为protobuf的子任务
+func FromSubtaskBodies(subtasks []taskmodel.SubtaskBody) []*SubtaskBody {
+	bodies := make([]*SubtaskBody, 0, len(subtasks))
+	for i := range subtasks {
+		subtask := &subtasks[i]
+		bodies = append(bodies, &SubtaskBody{
+			SubtaskId:    uint64(subtask.SubtaskId),
+			TaskId:       uint64(subtask.TaskId),
+			TaskType:     subtask.TaskType,
+			Timeout:      subtask.Timeout,
+			TypeParam:    subtask.TypeParam,
+			CreatedAt:    timestamppb.New(subtask.CreatedAt),
+			TerminatedAt: timestamppb.New(subtask.TerminatedAt),
+			TraceParent:  subtask.TraceParent,
+		})
+	}
+
+	return bodies
+}
+
+// 转换为框架的子任务
+func ToSubtaskBodies(bodies []*SubtaskBody) []taskmodel.SubtaskBody {
+	subtasks := make([]taskmodel.SubtaskBody, 0, len(bodies))
+	for _, body := range bodies {
+		subtasks = append(subtasks, taskmodel.SubtaskBody{
+			SubtaskId:    taskmodel.SubtaskIdType(body.GetSubtaskId()),
+			TaskId:       taskmodel.TaskIdType(body.GetTaskId()),
+			TaskType:     body.GetTaskType(),
+			Timeout:      body.GetTimeout(),
+			TypeParam:    body.GetTypeParam(),
+			CreatedAt:    body.GetCreatedAt().AsTime(),
+			TerminatedAt: body.GetTerminatedAt().AsTime(),
+			TraceParent:  body.GetTraceParent(),
+		})
+	}
+
+	return subtasks
+}
+
+// 转换为protobuf的子任务结果
+func FromSubtaskResults(results []taskmodel.SubtaskResult) []*SubtaskResult {
+	pbResults := make([]*SubtaskResult, 0, len(results))
+	for i := range results {
+		result := &results[i]
+		pbResults = append(pbResults, &SubtaskResult{
+			SubtaskId:   uint64(result.SubtaskId),
+			TaskId:      uint64(result.TaskId),
+			Result:      uint32(result.Result),
+			ResultCode:  result.ResultCode,
+			ResultMsg:   result.ResultMsg,
+			ResultBody:  result.ResultBody,
+			TraceParent: result.TraceParent,
+		})
+	}
+
+	return pbResults
+}
+
+// 转换为框架的子任务结果
+func ToSubtaskResults(pbResults []*SubtaskResult) []taskmodel.SubtaskResult {
+	results := make([]taskmodel.SubtaskResult, 0, len(pbResults))
+	for _, result := range pbResults {
+		results = append(results, taskmodel.SubtaskResult{
+			SubtaskId:   taskmodel.SubtaskIdType(result.GetSubtaskId()),
+			TaskId:      taskmodel.TaskIdType(result.GetTaskId()),
+			Result:      taskmodel.SubtaskResultType(result.GetResult()),
+			ResultCode:  result.GetResultCode(),
+			ResultMsg:   result.GetResultMsg(),
+			ResultBody:  result.GetResultBody(),
+			TraceParent: result.GetTraceParent(),
+		})
+	}
+
+	return results
+}
@@ -0,0 +1,4 @@
+// gRPC传输的protobuf定义, 修改dtf_rpc.proto后重新生成
+package rpcpb
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=module=github.com/danenmao/pterergate-dtf --go-grpc_out=../.. --go-grpc_opt=module=github.com/danenmao/pterergate-dtf internal/rpcpb/dtf_rpc.proto
@@ -0,0 +1,681 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.31.0
+// 	protoc        (unknown)
+// source: internal/rpcpb/dtf_rpc.proto
+
+// 调度器到执行器、执行器到收集器的gRPC接口
+
+package rpcpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// 子任务, 对应taskmodel.SubtaskBody
+type SubtaskBody struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SubtaskId    uint64                 `protobuf:"varint,1,opt,name=subtask_id,json=subtaskId,proto3" json:"subtask_id,omitempty"`
+	TaskId       uint64                 `protobuf:"varint,2,opt,name=task_id,json=taskId,proto3" json:"task_id,omitempty"`
+	TaskType     uint32                 `protobuf:"varint,3,opt,name=task_type,json=taskType,proto3" json:"task_type,omitempty"`
+	Timeout      uint32                 `protobuf:"varint,4,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	TypeParam    string                 `protobuf:"bytes,5,opt,name=type_param,json=typeParam,proto3" json:"type_param,omitempty"`
+	CreatedAt    *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	TerminatedAt *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=terminated_at,json=terminatedAt,proto3" json:"terminated_at,omitempty"`
+	TraceParent  string                 `protobuf:"bytes,8,opt,name=trace_parent,json=traceParent,proto3" json:"trace_parent,omitempty"`
+}
+
+func (x *SubtaskBody) Reset() {
+	*x = SubtaskBody{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_rpcpb_dtf_rpc_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SubtaskBody) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubtaskBody) ProtoMessage() {}
+
+func (x *SubtaskBody) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpcpb_dtf_rpc_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubtaskBody.ProtoReflect.Descriptor instead.
+func (*SubtaskBody) Descriptor() ([]byte, []int) {
+	return file_internal_rpcpb_dtf_rpc_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SubtaskBody) GetSubtaskId() uint64 {
+	if x != nil {
+		return x.SubtaskId
+	}
+	return 0
+}
+
+func (x *SubtaskBody) GetTaskId() uint64 {
+	if x != nil {
+		return x.TaskId
+	}
+	return 0
+}
+
+func (x *SubtaskBody) GetTaskType() uint32 {
+	if x != nil {
+		return x.TaskType
+	}
+	return 0
+}
+
+func (x *SubtaskBody) GetTimeout() uint32 {
+	if x != nil {
+		return x.Timeout
+	}
+	return 0
+}
+
+func (x *SubtaskBody) GetTypeParam() string {
+	if x != nil {
+		return x.TypeParam
+	}
+	return ""
+}
+
+func (x *SubtaskBody) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *SubtaskBody) GetTerminatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.TerminatedAt
+	}
+	return nil
+}
+
+func (x *SubtaskBody) GetTraceParent() string {
+	if x != nil {
+		return x.TraceParent
+	}
+	return ""
+}
+
+// 子任务的结果, 对应taskmodel.SubtaskResult
+type SubtaskResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SubtaskId   uint64 `protobuf:"varint,1,opt,name=subtask_id,json=subtaskId,proto3" json:"subtask_id,omitempty"`
+	TaskId      uint64 `protobuf:"varint,2,opt,name=task_id,json=taskId,proto3" json:"task_id,omitempty"`
+	Result      uint32 `protobuf:"varint,3,opt,name=result,proto3" json:"result,omitempty"`
+	ResultCode  uint32 `protobuf:"varint,4,opt,name=result_code,json=resultCode,proto3" json:"result_code,omitempty"`
+	ResultMsg   string `protobuf:"bytes,5,opt,name=result_msg,json=resultMsg,proto3" json:"result_msg,omitempty"`
+	ResultBody  string `protobuf:"bytes,6,opt,name=result_body,json=resultBody,proto3" json:"result_body,omitempty"`
+	TraceParent string `protobuf:"bytes,7,opt,name=trace_parent,json=traceParent,proto3" json:"trace_parent,omitempty"`
+}
+
+func (x *SubtaskResult) Reset() {
+	*x = SubtaskResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_rpcpb_dtf_rpc_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SubtaskResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubtaskResult) ProtoMessage() {}
+
+func (x *SubtaskResult) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpcpb_dtf_rpc_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubtaskResult.ProtoReflect.Descriptor instead.
+func (*SubtaskResult) Descriptor() ([]byte, []int) {
+	return file_internal_rpcpb_dtf_rpc_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SubtaskResult) GetSubtaskId() uint64 {
+	if x != nil {
+		return x.SubtaskId
+	}
+	return 0
+}
+
+func (x *SubtaskResult) GetTaskId() uint64 {
+	if x != nil {
+		return x.TaskId
+	}
+	return 0
+}
+
+func (x *SubtaskResult) GetResult() uint32 {
+	if x != nil {
+		return x.Result
+	}
+	return 0
+}
+
+func (x *SubtaskResult) GetResultCode() uint32 {
+	if x != nil {
+		return x.ResultCode
+	}
+	return 0
+}
+
+func (x *SubtaskResult) GetResultMsg() string {
+	if x != nil {
+		return x.ResultMsg
+	}
+	return ""
+}
+
+func (x *SubtaskResult) GetResultBody() string {
+	if x != nil {
+		return x.ResultBody
+	}
+	return ""
+}
+
+func (x *SubtaskResult) GetTraceParent() string {
+	if x != nil {
+		return x.TraceParent
+	}
+	return ""
+}
+
+// 分发一批子任务, 流中用batch_id匹配应答
+type DispatchRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	BatchId  uint64         `protobuf:"varint,1,opt,name=batch_id,json=batchId,proto3" json:"batch_id,omitempty"`
+	Subtasks []*SubtaskBody `protobuf:"bytes,2,rep,name=subtasks,proto3" json:"subtasks,omitempty"`
+}
+
+func (x *DispatchRequest) Reset() {
+	*x = DispatchRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_rpcpb_dtf_rpc_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DispatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DispatchRequest) ProtoMessage() {}
+
+func (x *DispatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpcpb_dtf_rpc_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DispatchRequest.ProtoReflect.Descriptor instead.
+func (*DispatchRequest) Descriptor() ([]byte, []int) {
+	return file_internal_rpcpb_dtf_rpc_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *DispatchRequest) GetBatchId() uint64 {
+	if x != nil {
+		return x.BatchId
+	}
+	return 0
+}
+
+func (x *DispatchRequest) GetSubtasks() []*SubtaskBody {
+	if x != nil {
+		return x.Subtasks
+	}
+	return nil
+}
+
+type DispatchResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	BatchId uint64 `protobuf:"varint,1,opt,name=batch_id,json=batchId,proto3" json:"batch_id,omitempty"`
+	Error   string `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"` // 处理失败的原因, 为空表示成功
+}
+
+func (x *DispatchResponse) Reset() {
+	*x = DispatchResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_rpcpb_dtf_rpc_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DispatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DispatchResponse) ProtoMessage() {}
+
+func (x *DispatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpcpb_dtf_rpc_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DispatchResponse.ProtoReflect.Descriptor instead.
+func (*DispatchResponse) Descriptor() ([]byte, []int) {
+	return file_internal_rpcpb_dtf_rpc_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *DispatchResponse) GetBatchId() uint64 {
+	if x != nil {
+		return x.BatchId
+	}
+	return 0
+}
+
+func (x *DispatchResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// 上报一批子任务结果, 流中用batch_id匹配应答
+type ReportRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	BatchId uint64           `protobuf:"varint,1,opt,name=batch_id,json=batchId,proto3" json:"batch_id,omitempty"`
+	Results []*SubtaskResult `protobuf:"bytes,2,rep,name=results,proto3" json:"results,omitempty"`
+}
+
+func (x *ReportRequest) Reset() {
+	*x = ReportRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_rpcpb_dtf_rpc_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportRequest) ProtoMessage() {}
+
+func (x *ReportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpcpb_dtf_rpc_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportRequest.ProtoReflect.Descriptor instead.
+func (*ReportRequest) Descriptor() ([]byte, []int) {
+	return file_internal_rpcpb_dtf_rpc_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ReportRequest) GetBatchId() uint64 {
+	if x != nil {
+		return x.BatchId
+	}
+	return 0
+}
+
+func (x *ReportRequest) GetResults() []*SubtaskResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type ReportResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	BatchId uint64 `protobuf:"varint,1,opt,name=batch_id,json=batchId,proto3" json:"batch_id,omitempty"`
+	Error   string `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"` // 处理失败的原因, 为空表示成功
+}
+
+func (x *ReportResponse) Reset() {
+	*x = ReportResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_rpcpb_dtf_rpc_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReportResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportResponse) ProtoMessage() {}
+
+func (x *ReportResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_rpcpb_dtf_rpc_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportResponse.ProtoReflect.Descriptor instead.
+func (*ReportResponse) Descriptor() ([]byte, []int) {
+	return file_internal_rpcpb_dtf_rpc_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ReportResponse) GetBatchId() uint64 {
+	if x != nil {
+		return x.BatchId
+	}
+	return 0
+}
+
+func (x *ReportResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+var File_internal_rpcpb_dtf_rpc_proto protoreflect.FileDescriptor
+
+var file_internal_rpcpb_dtf_rpc_proto_rawDesc = []byte{
+	0x0a, 0x1c, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x72, 0x70, 0x63, 0x70, 0x62,
+	0x2f, 0x64, 0x74, 0x66, 0x5f, 0x72, 0x70, 0x63, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x12,
+	0x70, 0x74, 0x65, 0x72, 0x65, 0x72, 0x67, 0x61, 0x74, 0x65, 0x2e, 0x64, 0x74, 0x66, 0x2e, 0x72,
+	0x70, 0x63, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x22, 0xba, 0x02, 0x0a, 0x0b, 0x53, 0x75, 0x62, 0x74, 0x61, 0x73, 0x6b, 0x42,
+	0x6f, 0x64, 0x79, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x75, 0x62, 0x74, 0x61, 0x73, 0x6b, 0x5f, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x09, 0x73, 0x75, 0x62, 0x74, 0x61, 0x73, 0x6b,
+	0x49, 0x64, 0x12, 0x17, 0x0a, 0x07, 0x74, 0x61, 0x73, 0x6b, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x06, 0x74, 0x61, 0x73, 0x6b, 0x49, 0x64, 0x12, 0x1b, 0x0a, 0x09, 0x74,
+	0x61, 0x73, 0x6b, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08,
+	0x74, 0x61, 0x73, 0x6b, 0x54, 0x79, 0x70, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x74, 0x69, 0x6d, 0x65,
+	0x6f, 0x75, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x74, 0x69, 0x6d, 0x65, 0x6f,
+	0x75, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x74, 0x79, 0x70, 0x65, 0x5f, 0x70, 0x61, 0x72, 0x61, 0x6d,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x74, 0x79, 0x70, 0x65, 0x50, 0x61, 0x72, 0x61,
+	0x6d, 0x12, 0x39, 0x0a, 0x0a, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x52, 0x09, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x3f, 0x0a, 0x0d,
+	0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x07, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52,
+	0x0c, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x21, 0x0a,
+	0x0c, 0x74, 0x72, 0x61, 0x63, 0x65, 0x5f, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x18, 0x08, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0b, 0x74, 0x72, 0x61, 0x63, 0x65, 0x50, 0x61, 0x72, 0x65, 0x6e, 0x74,
+	0x22, 0xe3, 0x01, 0x0a, 0x0d, 0x53, 0x75, 0x62, 0x74, 0x61, 0x73, 0x6b, 0x52, 0x65, 0x73, 0x75,
+	0x6c, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x75, 0x62, 0x74, 0x61, 0x73, 0x6b, 0x5f, 0x69, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x09, 0x73, 0x75, 0x62, 0x74, 0x61, 0x73, 0x6b, 0x49,
+	0x64, 0x12, 0x17, 0x0a, 0x07, 0x74, 0x61, 0x73, 0x6b, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x06, 0x74, 0x61, 0x73, 0x6b, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65,
+	0x73, 0x75, 0x6c, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x72, 0x65, 0x73, 0x75,
+	0x6c, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x5f, 0x63, 0x6f, 0x64,
+	0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x43,
+	0x6f, 0x64, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x5f, 0x6d, 0x73,
+	0x67, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x4d,
+	0x73, 0x67, 0x12, 0x1f, 0x0a, 0x0b, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x5f, 0x62, 0x6f, 0x64,
+	0x79, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x42,
+	0x6f, 0x64, 0x79, 0x12, 0x21, 0x0a, 0x0c, 0x74, 0x72, 0x61, 0x63, 0x65, 0x5f, 0x70, 0x61, 0x72,
+	0x65, 0x6e, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x74, 0x72, 0x61, 0x63, 0x65,
+	0x50, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x22, 0x69, 0x0a, 0x0f, 0x44, 0x69, 0x73, 0x70, 0x61, 0x74,
+	0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x19, 0x0a, 0x08, 0x62, 0x61, 0x74,
+	0x63, 0x68, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x62, 0x61, 0x74,
+	0x63, 0x68, 0x49, 0x64, 0x12, 0x3b, 0x0a, 0x08, 0x73, 0x75, 0x62, 0x74, 0x61, 0x73, 0x6b, 0x73,
+	0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x70, 0x74, 0x65, 0x72, 0x65, 0x72, 0x67,
+	0x61, 0x74, 0x65, 0x2e, 0x64, 0x74, 0x66, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x53, 0x75, 0x62, 0x74,
+	0x61, 0x73, 0x6b, 0x42, 0x6f, 0x64, 0x79, 0x52, 0x08, 0x73, 0x75, 0x62, 0x74, 0x61, 0x73, 0x6b,
+	0x73, 0x22, 0x43, 0x0a, 0x10, 0x44, 0x69, 0x73, 0x70, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x19, 0x0a, 0x08, 0x62, 0x61, 0x74, 0x63, 0x68, 0x5f, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x62, 0x61, 0x74, 0x63, 0x68, 0x49, 0x64,
+	0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x67, 0x0a, 0x0d, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x19, 0x0a, 0x08, 0x62, 0x61, 0x74, 0x63, 0x68,
+	0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x62, 0x61, 0x74, 0x63, 0x68,
+	0x49, 0x64, 0x12, 0x3b, 0x0a, 0x07, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x18, 0x02, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x70, 0x74, 0x65, 0x72, 0x65, 0x72, 0x67, 0x61, 0x74, 0x65,
+	0x2e, 0x64, 0x74, 0x66, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x53, 0x75, 0x62, 0x74, 0x61, 0x73, 0x6b,
+	0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52, 0x07, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x22,
+	0x41, 0x0a, 0x0e, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x19, 0x0a, 0x08, 0x62, 0x61, 0x74, 0x63, 0x68, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x07, 0x62, 0x61, 0x74, 0x63, 0x68, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05,
+	0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72,
+	0x6f, 0x72, 0x32, 0xc2, 0x01, 0x0a, 0x08, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x6f, 0x72, 0x12,
+	0x55, 0x0a, 0x08, 0x44, 0x69, 0x73, 0x70, 0x61, 0x74, 0x63, 0x68, 0x12, 0x23, 0x2e, 0x70, 0x74,
+	0x65, 0x72, 0x65, 0x72, 0x67, 0x61, 0x74, 0x65, 0x2e, 0x64, 0x74, 0x66, 0x2e, 0x72, 0x70, 0x63,
+	0x2e, 0x44, 0x69, 0x73, 0x70, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x24, 0x2e, 0x70, 0x74, 0x65, 0x72, 0x65, 0x72, 0x67, 0x61, 0x74, 0x65, 0x2e, 0x64, 0x74,
+	0x66, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x44, 0x69, 0x73, 0x70, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x5f, 0x0a, 0x0e, 0x44, 0x69, 0x73, 0x70, 0x61, 0x74,
+	0x63, 0x68, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x12, 0x23, 0x2e, 0x70, 0x74, 0x65, 0x72, 0x65,
+	0x72, 0x67, 0x61, 0x74, 0x65, 0x2e, 0x64, 0x74, 0x66, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x44, 0x69,
+	0x73, 0x70, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x24, 0x2e,
+	0x70, 0x74, 0x65, 0x72, 0x65, 0x72, 0x67, 0x61, 0x74, 0x65, 0x2e, 0x64, 0x74, 0x66, 0x2e, 0x72,
+	0x70, 0x63, 0x2e, 0x44, 0x69, 0x73, 0x70, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x28, 0x01, 0x30, 0x01, 0x32, 0xb7, 0x01, 0x0a, 0x09, 0x43, 0x6f, 0x6c, 0x6c,
+	0x65, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x4f, 0x0a, 0x06, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x12,
+	0x21, 0x2e, 0x70, 0x74, 0x65, 0x72, 0x65, 0x72, 0x67, 0x61, 0x74, 0x65, 0x2e, 0x64, 0x74, 0x66,
+	0x2e, 0x72, 0x70, 0x63, 0x2e, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x22, 0x2e, 0x70, 0x74, 0x65, 0x72, 0x65, 0x72, 0x67, 0x61, 0x74, 0x65, 0x2e,
+	0x64, 0x74, 0x66, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x59, 0x0a, 0x0c, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74,
+	0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x12, 0x21, 0x2e, 0x70, 0x74, 0x65, 0x72, 0x65, 0x72, 0x67,
+	0x61, 0x74, 0x65, 0x2e, 0x64, 0x74, 0x66, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x52, 0x65, 0x70, 0x6f,
+	0x72, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x22, 0x2e, 0x70, 0x74, 0x65, 0x72,
+	0x65, 0x72, 0x67, 0x61, 0x74, 0x65, 0x2e, 0x64, 0x74, 0x66, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x52,
+	0x65, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x28, 0x01, 0x30,
+	0x01, 0x42, 0x33, 0x5a, 0x31, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
+	0x64, 0x61, 0x6e, 0x65, 0x6e, 0x6d, 0x61, 0x6f, 0x2f, 0x70, 0x74, 0x65, 0x72, 0x65, 0x72, 0x67,
+	0x61, 0x74, 0x65, 0x2d, 0x64, 0x74, 0x66, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c,
+	0x2f, 0x72, 0x70, 0x63, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_internal_rpcpb_dtf_rpc_proto_rawDescOnce sync.Once
+	file_internal_rpcpb_dtf_rpc_proto_rawDescData = file_internal_rpcpb_dtf_rpc_proto_rawDesc
+)
+
+func file_internal_rpcpb_dtf_rpc_proto_rawDescGZIP() []byte {
+	file_internal_rpcpb_dtf_rpc_proto_rawDescOnce.Do(func() {
+		file_internal_rpcpb_dtf_rpc_proto_rawDescData = protoimpl.X.CompressGZIP(file_internal_rpcpb_dtf_rpc_proto_rawDescData)
+	})
+	return file_internal_rpcpb_dtf_rpc_proto_rawDescData
+}
+
+var file_internal_rpcpb_dtf_rpc_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_internal_rpcpb_dtf_rpc_proto_goTypes = []interface{}{
+	(*SubtaskBody)(nil),           // 0: pterergate.dtf.rpc.SubtaskBody
+	(*SubtaskResult)(nil),         // 1: pterergate.dtf.rpc.SubtaskResult
+	(*DispatchRequest)(nil),       // 2: pterergate.dtf.rpc.DispatchRequest
+	(*DispatchResponse)(nil),      // 3: pterergate.dtf.rpc.DispatchResponse
+	(*ReportRequest)(nil),         // 4: pterergate.dtf.rpc.ReportRequest
+	(*ReportResponse)(nil),        // 5: pterergate.dtf.rpc.ReportResponse
+	(*timestamppb.Timestamp)(nil), // 6: google.protobuf.Timestamp
+}
+var file_internal_rpcpb_dtf_rpc_proto_depIdxs = []int32{
+	6, // 0: pterergate.dtf.rpc.SubtaskBody.created_at:type_name -> google.protobuf.Timestamp
+	6, // 1: pterergate.dtf.rpc.SubtaskBody.terminated_at:type_name -> google.protobuf.Timestamp
+	0, // 2: pterergate.dtf.rpc.DispatchRequest.subtasks:type_name -> pterergate.dtf.rpc.SubtaskBody
+	1, // 3: pterergate.dtf.rpc.ReportRequest.results:type_name -> pterergate.dtf.rpc.SubtaskResult
+	2, // 4: pterergate.dtf.rpc.Executor.Dispatch:input_type -> pterergate.dtf.rpc.DispatchRequest
+	2, // 5: pterergate.dtf.rpc.Executor.DispatchStream:input_type -> pterergate.dtf.rpc.DispatchRequest
+	4, // 6: pterergate.dtf.rpc.Collector.Report:input_type -> pterergate.dtf.rpc.ReportRequest
+	4, // 7: pterergate.dtf.rpc.Collector.ReportStream:input_type -> pterergate.dtf.rpc.ReportRequest
+	3, // 8: pterergate.dtf.rpc.Executor.Dispatch:output_type -> pterergate.dtf.rpc.DispatchResponse
+	3, // 9: pterergate.dtf.rpc.Executor.DispatchStream:output_type -> pterergate.dtf.rpc.DispatchResponse
+	5, // 10: pterergate.dtf.rpc.Collector.Report:output_type -> pterergate.dtf.rpc.ReportResponse
+	5, // 11: pterergate.dtf.rpc.Collector.ReportStream:output_type -> pterergate.dtf.rpc.ReportResponse
+	8, // [8:12] is the sub-list for method output_type
+	4, // [4:8] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_internal_rpcpb_dtf_rpc_proto_init() }
+func file_internal_rpcpb_dtf_rpc_proto_init() {
+	if File_internal_rpcpb_dtf_rpc_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_internal_rpcpb_dtf_rpc_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SubtaskBody); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_rpcpb_dtf_rpc_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SubtaskResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_rpcpb_dtf_rpc_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DispatchRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_rpcpb_dtf_rpc_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DispatchResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_rpcpb_dtf_rpc_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReportRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_internal_rpcpb_dtf_rpc_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReportResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_internal_rpcpb_dtf_rpc_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   2,
+		},
+		GoTypes:           file_internal_rpcpb_dtf_rpc_proto_goTypes,
+		DependencyIndexes: file_internal_rpcpb_dtf_rpc_proto_depIdxs,
+		MessageInfos:      file_internal_rpcpb_dtf_rpc_proto_msgTypes,
+	}.Build()
+	File_internal_rpcpb_dtf_rpc_proto = out.File
+	file_internal_rpcpb_dtf_rpc_proto_rawDesc = nil
+	file_internal_rpcpb_dtf_rpc_proto_goTypes = nil
+	file_internal_rpcpb_dtf_rpc_proto_depIdxs = nil
+}
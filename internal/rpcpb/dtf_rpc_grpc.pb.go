@@ -0,0 +1,341 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: internal/rpcpb/dtf_rpc.proto
+
+// 调度器到执行器、执行器到收集器的gRPC接口
+
+package rpcpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Executor_Dispatch_FullMethodName       = "/pterergate.dtf.rpc.Executor/Dispatch"
+	Executor_DispatchStream_FullMethodName = "/pterergate.dtf.rpc.Executor/DispatchStream"
+)
+
+// ExecutorClient is the client API for Executor service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ExecutorClient interface {
+	Dispatch(ctx context.Context, in *DispatchRequest, opts ...grpc.CallOption) (*DispatchResponse, error)
+	DispatchStream(ctx context.Context, opts ...grpc.CallOption) (Executor_DispatchStreamClient, error)
+}
+
+type executorClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewExecutorClient(cc grpc.ClientConnInterface) ExecutorClient {
+	return &executorClient{cc}
+}
+
+func (c *executorClient) Dispatch(ctx context.Context, in *DispatchRequest, opts ...grpc.CallOption) (*DispatchResponse, error) {
+	out := new(DispatchResponse)
+	err := c.cc.Invoke(ctx, Executor_Dispatch_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *executorClient) DispatchStream(ctx context.Context, opts ...grpc.CallOption) (Executor_DispatchStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Executor_ServiceDesc.Streams[0], Executor_DispatchStream_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &executorDispatchStreamClient{stream}
+	return x, nil
+}
+
+type Executor_DispatchStreamClient interface {
+	Send(*DispatchRequest) error
+	Recv() (*DispatchResponse, error)
+	grpc.ClientStream
+}
+
+type executorDispatchStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *executorDispatchStreamClient) Send(m *DispatchRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *executorDispatchStreamClient) Recv() (*DispatchResponse, error) {
+	m := new(DispatchResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ExecutorServer is the server API for Executor service.
+// All implementations must embed UnimplementedExecutorServer
+// for forward compatibility
+type ExecutorServer interface {
+	Dispatch(context.Context, *DispatchRequest) (*DispatchResponse, error)
+	DispatchStream(Executor_DispatchStreamServer) error
+	mustEmbedUnimplementedExecutorServer()
+}
+
+// UnimplementedExecutorServer must be embedded to have forward compatible implementations.
+type UnimplementedExecutorServer struct {
+}
+
+func (UnimplementedExecutorServer) Dispatch(context.Context, *DispatchRequest) (*DispatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Dispatch not implemented")
+}
+func (UnimplementedExecutorServer) DispatchStream(Executor_DispatchStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method DispatchStream not implemented")
+}
+func (UnimplementedExecutorServer) mustEmbedUnimplementedExecutorServer() {}
+
+// UnsafeExecutorServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ExecutorServer will
+// result in compilation errors.
+type UnsafeExecutorServer interface {
+	mustEmbedUnimplementedExecutorServer()
+}
+
+func RegisterExecutorServer(s grpc.ServiceRegistrar, srv ExecutorServer) {
+	s.RegisterService(&Executor_ServiceDesc, srv)
+}
+
+func _Executor_Dispatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DispatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExecutorServer).Dispatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Executor_Dispatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExecutorServer).Dispatch(ctx, req.(*DispatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Executor_DispatchStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ExecutorServer).DispatchStream(&executorDispatchStreamServer{stream})
+}
+
+type Executor_DispatchStreamServer interface {
+	Send(*DispatchResponse) error
+	Recv() (*DispatchRequest, error)
+	grpc.ServerStream
+}
+
+type executorDispatchStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *executorDispatchStreamServer) Send(m *DispatchResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *executorDispatchStreamServer) Recv() (*DispatchRequest, error) {
+	m := new(DispatchRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Executor_ServiceDesc is the grpc.ServiceDesc for Executor service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Executor_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pterergate.dtf.rpc.Executor",
+	HandlerType: (*ExecutorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Dispatch",
+			Handler:    _Executor_Dispatch_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "DispatchStream",
+			Handler:       _Executor_DispatchStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "internal/rpcpb/dtf_rpc.proto",
+}
+
+const (
+	Collector_Report_FullMethodName       = "/pterergate.dtf.rpc.Collector/Report"
+	Collector_ReportStream_FullMethodName = "/pterergate.dtf.rpc.Collector/ReportStream"
+)
+
+// CollectorClient is the client API for Collector service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CollectorClient interface {
+	Report(ctx context.Context, in *ReportRequest, opts ...grpc.CallOption) (*ReportResponse, error)
+	ReportStream(ctx context.Context, opts ...grpc.CallOption) (Collector_ReportStreamClient, error)
+}
+
+type collectorClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCollectorClient(cc grpc.ClientConnInterface) CollectorClient {
+	return &collectorClient{cc}
+}
+
+func (c *collectorClient) Report(ctx context.Context, in *ReportRequest, opts ...grpc.CallOption) (*ReportResponse, error) {
+	out := new(ReportResponse)
+	err := c.cc.Invoke(ctx, Collector_Report_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *collectorClient) ReportStream(ctx context.Context, opts ...grpc.CallOption) (Collector_ReportStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Collector_ServiceDesc.Streams[0], Collector_ReportStream_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &collectorReportStreamClient{stream}
+	return x, nil
+}
+
+type Collector_ReportStreamClient interface {
+	Send(*ReportRequest) error
+	Recv() (*ReportResponse, error)
+	grpc.ClientStream
+}
+
+type collectorReportStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *collectorReportStreamClient) Send(m *ReportRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *collectorReportStreamClient) Recv() (*ReportResponse, error) {
+	m := new(ReportResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// CollectorServer is the server API for Collector service.
+// All implementations must embed UnimplementedCollectorServer
+// for forward compatibility
+type CollectorServer interface {
+	Report(context.Context, *ReportRequest) (*ReportResponse, error)
+	ReportStream(Collector_ReportStreamServer) error
+	mustEmbedUnimplementedCollectorServer()
+}
+
+// UnimplementedCollectorServer must be embedded to have forward compatible implementations.
+type UnimplementedCollectorServer struct {
+}
+
+func (UnimplementedCollectorServer) Report(context.Context, *ReportRequest) (*ReportResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Report not implemented")
+}
+func (UnimplementedCollectorServer) ReportStream(Collector_ReportStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method ReportStream not implemented")
+}
+func (UnimplementedCollectorServer) mustEmbedUnimplementedCollectorServer() {}
+
+// UnsafeCollectorServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CollectorServer will
+// result in compilation errors.
+type UnsafeCollectorServer interface {
+	mustEmbedUnimplementedCollectorServer()
+}
+
+func RegisterCollectorServer(s grpc.ServiceRegistrar, srv CollectorServer) {
+	s.RegisterService(&Collector_ServiceDesc, srv)
+}
+
+func _Collector_Report_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CollectorServer).Report(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Collector_Report_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CollectorServer).Report(ctx, req.(*ReportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Collector_ReportStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(CollectorServer).ReportStream(&collectorReportStreamServer{stream})
+}
+
+type Collector_ReportStreamServer interface {
+	Send(*ReportResponse) error
+	Recv() (*ReportRequest, error)
+	grpc.ServerStream
+}
+
+type collectorReportStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *collectorReportStreamServer) Send(m *ReportResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *collectorReportStreamServer) Recv() (*ReportRequest, error) {
+	m := new(ReportRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Collector_ServiceDesc is the grpc.ServiceDesc for Collector service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Collector_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pterergate.dtf.rpc.Collector",
+	HandlerType: (*CollectorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Report",
+			Handler:    _Collector_Report_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ReportStream",
+			Handler:       _Collector_ReportStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "internal/rpcpb/dtf_rpc.proto",
+}
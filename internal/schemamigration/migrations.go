@@ -0,0 +1,51 @@
+package schemamigration
+
+import (
+	"github.com/danenmao/pterergate-dtf/internal/dbdef"
+)
+
+// 框架表的迁移, 按版本递增排列
+// 已发布的迁移不能修改, 表结构的变更需添加新的迁移, 同时更新dbdef中的表定义.
+// 建表语句描述最新的表结构, 后续的迁移用于升级旧版本创建的表.
+// 手工创建的表可能已包含部分变更, 因此每一步都先检查对象是否存在
+var gs_Migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create task table",
+		Steps: []Step{
+			{SQL: createTable(&dbdef.TaskTable)},
+		},
+	},
+	{
+		Version: 2,
+		Name:    "add resource group, priority and query indexes to task table",
+		Steps: []Step{
+			addColumn(&dbdef.TaskTable, dbdef.TaskTable_ResourceGroup),
+			addColumn(&dbdef.TaskTable, dbdef.TaskTable_Priority),
+			addIndex(&dbdef.TaskTable, "key_task_type"),
+			addIndex(&dbdef.TaskTable, "key_task_status"),
+			addIndex(&dbdef.TaskTable, "key_resource_group"),
+			addIndex(&dbdef.TaskTable, "key_name"),
+			addIndex(&dbdef.TaskTable, "key_finish_time"),
+		},
+	},
+	{
+		Version: 3,
+		Name:    "create subtask result table",
+		Steps: []Step{
+			{SQL: createTable(&dbdef.SubtaskResultTable)},
+		},
+	},
+	{
+		Version: 4,
+		Name:    "create id segment table",
+		Steps: []Step{
+			{SQL: createTable(&dbdef.IdSegmentTable)},
+		},
+	},
+}
+
+// 获取所有迁移
+func Migrations() []Migration {
+	return gs_Migrations
+}
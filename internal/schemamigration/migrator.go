@@ -0,0 +1,231 @@
+package schemamigration
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
+	"github.com/danenmao/pterergate-dtf/internal/dbdef"
+	"github.com/danenmao/pterergate-dtf/internal/dbdialect"
+)
+
+// 等待其他管理器执行迁移的超时时间, 秒
+const LockTimeout = 60
+
+// 未能获取迁移锁
+var ErrLockNotAcquired = dbdialect.ErrLockNotAcquired
+
+var gs_Logger = logging.With(logging.Role("migration"))
+
+// 一个版本的迁移
+type Migration struct {
+	Version uint32
+	Name    string
+	Steps   []Step
+}
+
+// 迁移中的一步, 由方言生成一条或多条语句
+type Step struct {
+	SQL  StatementFn
+	Skip SkipFn // 不为空且返回true时跳过此步
+}
+
+// 生成迁移语句
+type StatementFn func(dialect dbdialect.IDialect) []string
+
+// 检查是否跳过迁移语句
+type SkipFn func(ctx context.Context, conn *sqlx.Conn, dialect dbdialect.IDialect) (bool, error)
+
+// 执行未执行的迁移, 返回需执行的语句
+// dryRun为true时只返回需执行的语句, 不修改数据库.
+// 迁移期间持有方言的命名锁, 同时启动的多个管理器依次执行, 后执行的管理器不再有需执行的迁移
+func Migrate(ctx context.Context, db *sqlx.DB, dialect dbdialect.IDialect, dryRun bool, plan *[]string) error {
+	conn, err := db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// 命名锁属于连接, 加锁和迁移需使用同一个连接
+	err = dialect.Lock(ctx, conn, dbdef.SchemaMigrationLockName, LockTimeout)
+	if err != nil {
+		return err
+	}
+	defer unlock(conn, dialect)
+
+	applied, err := appliedVersions(ctx, conn, dialect, dryRun, plan)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range gs_Migrations {
+		if applied[migration.Version] {
+			continue
+		}
+
+		err = apply(ctx, conn, dialect, &migration, dryRun, plan)
+		if err != nil {
+			gs_Logger.Error("failed to apply schema migration",
+				logging.Any("version", migration.Version), logging.Any("name", migration.Name), logging.Err(err))
+			return err
+		}
+	}
+
+	// 数据库由更新版本的框架迁移过
+	latest := gs_Migrations[len(gs_Migrations)-1].Version
+	for version := range applied {
+		if version > latest {
+			gs_Logger.Warning("the schema is newer than this framework version",
+				logging.Any("schema_version", version), logging.Any("latest_version", latest))
+			break
+		}
+	}
+
+	return nil
+}
+
+// 执行一个版本的迁移, 并记录到迁移表
+func apply(
+	ctx context.Context,
+	conn *sqlx.Conn,
+	dialect dbdialect.IDialect,
+	migration *Migration,
+	dryRun bool,
+	plan *[]string,
+) error {
+	for _, step := range migration.Steps {
+		if step.Skip != nil {
+			skip, err := step.Skip(ctx, conn, dialect)
+			if err != nil {
+				return err
+			}
+
+			if skip {
+				continue
+			}
+		}
+
+		err := execute(ctx, conn, step.SQL(dialect), dryRun, plan)
+		if err != nil {
+			return err
+		}
+	}
+
+	if dryRun {
+		return nil
+	}
+
+	_, err := conn.ExecContext(ctx, sqlx.Rebind(dialect.BindType(), dialect.Quote(dbdef.SQL_SchemaMigrationTable_InsertVersion)),
+		migration.Version, migration.Name, time.Now().Format(dbdef.GoTimeFormatStr))
+	if err != nil {
+		return err
+	}
+
+	gs_Logger.Info("applied schema migration",
+		logging.Any("version", migration.Version), logging.Any("name", migration.Name))
+	return nil
+}
+
+// 读取已执行的迁移版本, 迁移表不存在时创建
+func appliedVersions(
+	ctx context.Context,
+	conn *sqlx.Conn,
+	dialect dbdialect.IDialect,
+	dryRun bool,
+	plan *[]string,
+) (map[uint32]bool, error) {
+	applied := map[uint32]bool{}
+	exists, err := dialect.TableExists(ctx, conn, dbdef.SchemaMigrationTableName)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		err = execute(ctx, conn, dialect.CreateTable(&dbdef.SchemaMigrationTable), dryRun, plan)
+		if err != nil {
+			return nil, err
+		}
+
+		return applied, nil
+	}
+
+	versions := []uint32{}
+	err = conn.SelectContext(ctx, &versions, dialect.Quote(dbdef.SQL_SchemaMigrationTable_QueryVersion))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, version := range versions {
+		applied[version] = true
+	}
+
+	return applied, nil
+}
+
+// 记录并执行语句, 试运行时只记录
+func execute(ctx context.Context, conn *sqlx.Conn, statements []string, dryRun bool, plan *[]string) error {
+	for _, statement := range statements {
+		*plan = append(*plan, statement)
+		if dryRun {
+			continue
+		}
+
+		_, err := conn.ExecContext(ctx, statement)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func unlock(conn *sqlx.Conn, dialect dbdialect.IDialect) {
+	err := dialect.Unlock(context.Background(), conn, dbdef.SchemaMigrationLockName)
+	if err != nil {
+		gs_Logger.Warning("failed to release the schema migration lock", logging.Err(err))
+	}
+}
+
+// 建表
+func createTable(table *dbdialect.TableDef) StatementFn {
+	return func(dialect dbdialect.IDialect) []string {
+		return dialect.CreateTable(table)
+	}
+}
+
+// 添加列, 列存在时跳过.
+// 表不存在时也跳过, 建表语句总是包含最新的表结构, 只有试运行时建表语句未执行
+func addColumn(table *dbdialect.TableDef, column string) Step {
+	return Step{
+		SQL: func(dialect dbdialect.IDialect) []string {
+			return []string{dialect.AddColumn(table, column)}
+		},
+		Skip: func(ctx context.Context, conn *sqlx.Conn, dialect dbdialect.IDialect) (bool, error) {
+			exists, err := dialect.TableExists(ctx, conn, table.Name)
+			if err != nil || !exists {
+				return true, err
+			}
+
+			return dialect.ColumnExists(ctx, conn, table.Name, column)
+		},
+	}
+}
+
+// 添加索引, 索引存在时跳过, 表不存在时同上
+func addIndex(table *dbdialect.TableDef, index string) Step {
+	return Step{
+		SQL: func(dialect dbdialect.IDialect) []string {
+			return []string{dialect.AddIndex(table, index)}
+		},
+		Skip: func(ctx context.Context, conn *sqlx.Conn, dialect dbdialect.IDialect) (bool, error) {
+			exists, err := dialect.TableExists(ctx, conn, table.Name)
+			if err != nil || !exists {
+				return true, err
+			}
+
+			return dialect.IndexExists(ctx, conn, table.Name, index)
+		},
+	}
+}
@@ -0,0 +1,216 @@
+package schemamigration
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/danenmao/pterergate-dtf/dtf/extconfig"
+	"github.com/danenmao/pterergate-dtf/internal/config"
+	"github.com/danenmao/pterergate-dtf/internal/dbdef"
+	"github.com/danenmao/pterergate-dtf/internal/dbdialect"
+	"github.com/danenmao/pterergate-dtf/internal/mysqltool"
+	"github.com/danenmao/pterergate-dtf/internal/recordstore"
+	"github.com/danenmao/pterergate-dtf/internal/resultstore"
+)
+
+// MySQL方言检查对象是否存在的语句
+const (
+	tableExistsSQL  = "select count(*) from information_schema.tables where table_schema=database() and table_name=?"
+	columnExistsSQL = "select count(*) from information_schema.columns " +
+		"where table_schema=database() and table_name=? and column_name=?"
+	indexExistsSQL = "select count(*) from information_schema.statistics " +
+		"where table_schema=database() and table_name=? and index_name=?"
+)
+
+var gs_MySQL = &dbdialect.MySQLDialect{}
+
+func expectLock(result int) {
+	mysqltool.DBMock.ExpectQuery("select GET_LOCK(?, ?)").
+		WithArgs(dbdef.SchemaMigrationLockName, LockTimeout).
+		WillReturnRows(sqlmock.NewRows([]string{"result"}).AddRow(result))
+}
+
+func expectUnlock() {
+	mysqltool.DBMock.ExpectQuery("select RELEASE_LOCK(?)").
+		WithArgs(dbdef.SchemaMigrationLockName).
+		WillReturnRows(sqlmock.NewRows([]string{"result"}).AddRow(1))
+}
+
+func expectCount(query string, count int, table string) {
+	mysqltool.DBMock.ExpectQuery(query).WithArgs(table).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(count))
+}
+
+func expectExec(statements []string) {
+	for _, statement := range statements {
+		mysqltool.DBMock.ExpectExec(statement).WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+}
+
+func expectVersion(version uint32) {
+	mysqltool.DBMock.ExpectExec(dbdef.SQL_SchemaMigrationTable_InsertVersion).
+		WithArgs(version, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+// 版本2的前两步添加列, 其余添加索引
+func expectUpgradeChecks(missing int) {
+	for idx := range gs_Migrations[1].Steps {
+		expectCount(tableExistsSQL, 1, dbdef.TaskTableName)
+		query := indexExistsSQL
+		if idx < 2 {
+			query = columnExistsSQL
+		}
+
+		count := 1
+		if idx == missing {
+			count = 0
+		}
+
+		mysqltool.DBMock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(count))
+	}
+}
+
+func Test_Migrate(t *testing.T) {
+	Convey("create all tables in an empty database", t, func() {
+		mysqltool.Setup()
+		defer mysqltool.Teardown()
+
+		expectLock(1)
+		expectCount(tableExistsSQL, 0, dbdef.SchemaMigrationTableName)
+		expectExec(gs_MySQL.CreateTable(&dbdef.SchemaMigrationTable))
+
+		expectExec(gs_MySQL.CreateTable(&dbdef.TaskTable))
+		expectVersion(1)
+
+		// 新建的任务表已包含所有的列和索引
+		expectUpgradeChecks(-1)
+		expectVersion(2)
+
+		expectExec(gs_MySQL.CreateTable(&dbdef.SubtaskResultTable))
+		expectVersion(3)
+
+		expectExec(gs_MySQL.CreateTable(&dbdef.IdSegmentTable))
+		expectVersion(4)
+		expectUnlock()
+
+		plan := []string{}
+		So(Migrate(context.Background(), mysqltool.DefaultMySQL(), gs_MySQL, false, &plan), ShouldBeNil)
+		So(plan, ShouldResemble, []string{
+			gs_MySQL.CreateTable(&dbdef.SchemaMigrationTable)[0],
+			gs_MySQL.CreateTable(&dbdef.TaskTable)[0],
+			gs_MySQL.CreateTable(&dbdef.SubtaskResultTable)[0],
+			gs_MySQL.CreateTable(&dbdef.IdSegmentTable)[0],
+		})
+		So(mysqltool.DBMock.ExpectationsWereMet(), ShouldBeNil)
+	})
+
+	Convey("upgrade a task table created by hand in dry run", t, func() {
+		mysqltool.Setup()
+		defer mysqltool.Teardown()
+
+		expectLock(1)
+		expectCount(tableExistsSQL, 1, dbdef.SchemaMigrationTableName)
+		mysqltool.DBMock.ExpectQuery(dbdef.SQL_SchemaMigrationTable_QueryVersion).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+
+		// 只缺少priority列
+		expectUpgradeChecks(1)
+		expectUnlock()
+
+		plan := []string{}
+		So(Migrate(context.Background(), mysqltool.DefaultMySQL(), gs_MySQL, true, &plan), ShouldBeNil)
+		So(plan, ShouldResemble, []string{
+			gs_MySQL.AddColumn(&dbdef.TaskTable, dbdef.TaskTable_Priority),
+			gs_MySQL.CreateTable(&dbdef.SubtaskResultTable)[0],
+			gs_MySQL.CreateTable(&dbdef.IdSegmentTable)[0],
+		})
+		So(mysqltool.DBMock.ExpectationsWereMet(), ShouldBeNil)
+	})
+
+	Convey("do nothing if the schema is up to date", t, func() {
+		mysqltool.Setup()
+		defer mysqltool.Teardown()
+
+		expectLock(1)
+		expectCount(tableExistsSQL, 1, dbdef.SchemaMigrationTableName)
+		mysqltool.DBMock.ExpectQuery(dbdef.SQL_SchemaMigrationTable_QueryVersion).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1).AddRow(2).AddRow(3).AddRow(4))
+		expectUnlock()
+
+		plan := []string{}
+		So(Migrate(context.Background(), mysqltool.DefaultMySQL(), gs_MySQL, false, &plan), ShouldBeNil)
+		So(plan, ShouldBeEmpty)
+		So(mysqltool.DBMock.ExpectationsWereMet(), ShouldBeNil)
+	})
+
+	Convey("fail if another manager holds the lock", t, func() {
+		mysqltool.Setup()
+		defer mysqltool.Teardown()
+
+		expectLock(0)
+
+		plan := []string{}
+		So(Migrate(context.Background(), mysqltool.DefaultMySQL(), gs_MySQL, false, &plan), ShouldEqual, ErrLockNotAcquired)
+	})
+
+	Convey("plan the PostgreSQL statements in dry run", t, func() {
+		plan := []string{}
+		pg := &dbdialect.PostgreSQLDialect{}
+		for _, migration := range gs_Migrations {
+			for _, step := range migration.Steps {
+				plan = append(plan, step.SQL(pg)...)
+			}
+		}
+
+		So(plan, ShouldContain, `ALTER TABLE "tbl_task" ADD COLUMN IF NOT EXISTS "priority" bigint NOT NULL DEFAULT 0`)
+		So(plan, ShouldContain, `CREATE INDEX IF NOT EXISTS "tbl_task_key_name" ON "tbl_task" ("name")`)
+	})
+}
+
+func Test_Migrate_SQLite(t *testing.T) {
+	former := config.DefaultMySQL
+	defer func() {
+		config.DefaultMySQL = former
+		dbdialect.SetDefault(gs_MySQL)
+	}()
+
+	config.DefaultMySQL = extconfig.MySQLAddress{
+		Dialect: extconfig.SQLDialect_SQLite,
+		Address: filepath.Join(t.TempDir(), "dtf.db"),
+	}
+	mysqltool.ConnectToDefaultMySQL()
+
+	ctx := context.Background()
+	plan := []string{}
+	err := Migrate(ctx, mysqltool.DefaultMySQL(), dbdialect.Default(), false, &plan)
+
+	// 已执行的迁移不再执行
+	again := []string{}
+	againErr := Migrate(ctx, mysqltool.DefaultMySQL(), dbdialect.Default(), false, &again)
+
+	records := recordstore.NewSQLRecordStore()
+	recordErr := records.AddTaskRecord(ctx, &dbdef.DBTaskRecord{Id: 12, Name: "task", TaskType: 1})
+	maxTaskId, maxTaskErr := records.GetMaxTaskId(ctx)
+
+	results := resultstore.NewSQLStore()
+	resultErr := results.AddSubtaskResults(ctx, []dbdef.DBSubtaskResult{{SubtaskId: 34, TaskId: 12}})
+	maxSubtaskId, maxSubtaskErr := results.GetMaxSubtaskId(ctx)
+
+	Convey("migrate a SQLite file and write the records", t, func() {
+		So(err, ShouldBeNil)
+		So(plan, ShouldNotBeEmpty)
+		So(againErr, ShouldBeNil)
+		So(again, ShouldBeEmpty)
+		So(recordErr, ShouldBeNil)
+		So(maxTaskErr, ShouldBeNil)
+		So(maxTaskId, ShouldEqual, 12)
+		So(resultErr, ShouldBeNil)
+		So(maxSubtaskErr, ShouldBeNil)
+		So(maxSubtaskId, ShouldEqual, 34)
+	})
+}
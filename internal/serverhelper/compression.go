@@ -0,0 +1,178 @@
+package serverhelper
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+)
+
+// 支持的内容编码
+const (
+	Encoding_Identity = "identity"
+	Encoding_Gzip     = "gzip"
+	Encoding_Zstd     = "zstd"
+)
+
+// 请求和响应体的默认大小上限, 压缩的请求体同时限制解压后的大小
+const DefaultMaxBodySize = 16 * 1024 * 1024
+
+// 小于此大小的请求和响应体不压缩
+const CompressThreshold = 1024
+
+// 客户端可接受的响应编码, 按优先级排列
+const AcceptEncodings = Encoding_Zstd + ", " + Encoding_Gzip
+
+var ErrUnsupportedEncoding = errors.New("unsupported content encoding")
+
+// 无状态的zstd编码器, EncodeAll可并发调用
+var gs_ZstdEncoder, _ = zstd.NewWriter(nil)
+
+// 检查编码是否支持, 空串和identity表示不压缩
+func IsSupportedEncoding(encoding string) bool {
+	switch encoding {
+	case "", Encoding_Identity, Encoding_Gzip, Encoding_Zstd:
+		return true
+	}
+
+	return false
+}
+
+// 从Accept-Encoding中选择响应的编码, 优先使用zstd, 都不接受时返回空串
+func NegotiateEncoding(acceptEncoding string) string {
+	accepted := map[string]bool{}
+	for _, item := range strings.Split(acceptEncoding, ",") {
+		parts := strings.Split(item, ";")
+		name := strings.ToLower(strings.TrimSpace(parts[0]))
+		if len(parts) > 1 && strings.ReplaceAll(strings.TrimSpace(parts[1]), " ", "") == "q=0" {
+			continue
+		}
+
+		accepted[name] = true
+	}
+
+	for _, encoding := range []string{Encoding_Zstd, Encoding_Gzip} {
+		if accepted[encoding] {
+			return encoding
+		}
+	}
+
+	return ""
+}
+
+// 按编码压缩数据, 不压缩时返回原数据
+func Compress(encoding string, data []byte) ([]byte, error) {
+	switch encoding {
+	case "", Encoding_Identity:
+		return data, nil
+
+	case Encoding_Zstd:
+		return gs_ZstdEncoder.EncodeAll(data, make([]byte, 0, len(data)/2)), nil
+
+	case Encoding_Gzip:
+		buf := bytes.Buffer{}
+		writer := gzip.NewWriter(&buf)
+		_, err := writer.Write(data)
+		if err != nil {
+			return nil, err
+		}
+
+		err = writer.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		return buf.Bytes(), nil
+	}
+
+	return nil, ErrUnsupportedEncoding
+}
+
+// 返回按编码流式解压的reader, 压缩前后的数据都不能超过maxSize
+func NewDecodeReader(encoding string, body io.Reader, maxSize int64) (io.ReadCloser, error) {
+	body = NewLimitedReader(body, maxSize)
+
+	switch strings.ToLower(encoding) {
+	case "", Encoding_Identity:
+		return io.NopCloser(body), nil
+
+	case Encoding_Gzip:
+		reader, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+
+		return &decodeReader{Reader: NewLimitedReader(reader, maxSize), close: reader.Close}, nil
+
+	case Encoding_Zstd:
+		reader, err := zstd.NewReader(body, zstd.WithDecoderConcurrency(1),
+			zstd.WithDecoderMaxMemory(uint64(maxSize)))
+		if err != nil {
+			return nil, err
+		}
+
+		return &decodeReader{
+			Reader: &zstdReader{NewLimitedReader(reader, maxSize)},
+			close:  func() error { reader.Close(); return nil },
+		}, nil
+	}
+
+	return nil, ErrUnsupportedEncoding
+}
+
+type decodeReader struct {
+	io.Reader
+	close func() error
+}
+
+func (r *decodeReader) Close() error {
+	return r.close()
+}
+
+// 将zstd的解压大小错误转换为errordef.ErrBodyTooLarge
+type zstdReader struct {
+	io.Reader
+}
+
+func (r *zstdReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err == zstd.ErrDecoderSizeExceeded || err == zstd.ErrWindowSizeExceeded {
+		err = errordef.ErrBodyTooLarge
+	}
+
+	return n, err
+}
+
+// 超过大小上限时返回errordef.ErrBodyTooLarge的reader
+type LimitedReader struct {
+	reader    io.Reader
+	remaining int64
+}
+
+func NewLimitedReader(reader io.Reader, maxSize int64) *LimitedReader {
+	return &LimitedReader{reader: reader, remaining: maxSize}
+}
+
+func (r *LimitedReader) Read(p []byte) (int, error) {
+	if r.remaining < 0 {
+		return 0, errordef.ErrBodyTooLarge
+	}
+
+	// 多读一个字节, 以区分恰好达到上限和超过上限
+	if int64(len(p)) > r.remaining+1 {
+		p = p[:r.remaining+1]
+	}
+
+	n, err := r.reader.Read(p)
+	r.remaining -= int64(n)
+	if r.remaining < 0 {
+		return n + int(r.remaining), errordef.ErrBodyTooLarge
+	}
+
+	return n, err
+}
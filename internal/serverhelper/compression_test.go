@@ -0,0 +1,110 @@
+package serverhelper
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+)
+
+func Test_NegotiateEncoding(t *testing.T) {
+	Convey("negotiate the response encoding", t, func() {
+		Convey("should prefer zstd and skip the refused encodings", func() {
+			So(NegotiateEncoding("gzip, zstd"), ShouldEqual, Encoding_Zstd)
+			So(NegotiateEncoding("zstd;q=0, gzip"), ShouldEqual, Encoding_Gzip)
+			So(NegotiateEncoding("br"), ShouldEqual, "")
+			So(NegotiateEncoding(""), ShouldEqual, "")
+		})
+	})
+}
+
+func Test_SimpleInvoker_DefaultEncoding(t *testing.T) {
+	encodings := []string{}
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodings = append(encodings, r.Header.Get("Content-Encoding"))
+		w.Write([]byte("{}"))
+	}))
+	defer svr.Close()
+
+	largeBody := strings.Repeat("x", 64*1024)
+	invoker := NewSimpleInvoker()
+	invoker.Post(svr.URL, "test", largeBody)
+
+	invoker.SetCompression(Encoding_Gzip)
+	invoker.Post(svr.URL, "test", largeBody)
+
+	Convey("the requests are compressed only if it's enabled", t, func() {
+		So(encodings, ShouldResemble, []string{"", Encoding_Gzip})
+	})
+}
+
+func Test_Compress_Decode(t *testing.T) {
+	data := []byte(strings.Repeat("subtask result ", 1000))
+
+	Convey("compress and decode a body", t, func() {
+		for _, encoding := range []string{"", Encoding_Gzip, Encoding_Zstd} {
+			compressed, err := Compress(encoding, data)
+			So(err, ShouldBeNil)
+
+			reader, err := NewDecodeReader(encoding, bytes.NewReader(compressed), int64(len(data)))
+			So(err, ShouldBeNil)
+			decoded, err := io.ReadAll(reader)
+			reader.Close()
+			So(err, ShouldBeNil)
+			So(decoded, ShouldResemble, data)
+
+			// 解压后超过上限
+			reader, err = NewDecodeReader(encoding, bytes.NewReader(compressed), int64(len(data)-1))
+			So(err, ShouldBeNil)
+			_, err = io.ReadAll(reader)
+			reader.Close()
+			So(err, ShouldEqual, errordef.ErrBodyTooLarge)
+		}
+
+		_, err := Compress("br", data)
+		So(err, ShouldEqual, ErrUnsupportedEncoding)
+	})
+}
+
+func Test_Post_Compressed(t *testing.T) {
+	largeBody := strings.Repeat("x", 64*1024)
+	svr := NewSimpleServer(8090,
+		map[string]RequestHandler{"/test": func(
+			header RequestHeader, requestBody string) (responseBody string, err error) {
+			return requestBody, nil
+		}})
+	svr.SetMaxBodySize(128 * 1024)
+
+	go func() {
+		svr.Serve()
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	invoker := NewSimpleInvoker()
+	invoker.SetCompression(Encoding_Zstd)
+	zstdRsp, zstdErr := invoker.Post("http://localhost:8090/test", "test", largeBody)
+
+	// 解压后超过服务端的上限
+	_, serverErr := invoker.Post("http://localhost:8090/test", "test", strings.Repeat(largeBody, 4))
+
+	// 超过客户端的上限, 不发送
+	invoker.SetMaxBodySize(1024)
+	_, localErr := invoker.Post("http://localhost:8090/test", "test", largeBody)
+	svr.Shutdown()
+
+	Convey("post a large request", t, func() {
+		Convey("should be compressed or rejected by the size limit", func() {
+			So(zstdErr, ShouldBeNil)
+			So(zstdRsp, ShouldEqual, largeBody)
+			So(serverErr, ShouldEqual, errordef.ErrBodyTooLarge)
+			So(localErr, ShouldEqual, errordef.ErrBodyTooLarge)
+		})
+	})
+}
@@ -1,32 +1,40 @@
 package serverhelper
 
 import (
-	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/glog"
 
+	"github.com/danenmao/pterergate-dtf/dtf/auth"
 	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
+	"github.com/danenmao/pterergate-dtf/dtf/tracing"
 	"github.com/danenmao/pterergate-dtf/internal/config"
-	"github.com/danenmao/pterergate-dtf/internal/msgsigner"
+	"github.com/danenmao/pterergate-dtf/internal/metrics"
 )
 
 const BODY_HASH = "BodyHash"
 const USER_NAME = "UserName"
+const IDENTITY = "Identity"
 
 type RequestHandler func(header RequestHeader, requestBody string) (responseBody string, err error)
 type SimpleServer struct {
-	ServerPort uint16
-	handlerMap map[string]RequestHandler
-	server     *http.Server
-	signer     *msgsigner.MsgSigner
+	ServerPort    uint16
+	handlerMap    map[string]RequestHandler
+	server        *http.Server
+	authenticator auth.IAuthenticator
+	replayGuard   *auth.ReplayGuard
+	tlsConfig     *tls.Config
+	maxBodySize   int64
 }
 
 func NewSimpleServer(serverPort uint16, handlerMap map[string]RequestHandler) *SimpleServer {
@@ -34,7 +42,10 @@ func NewSimpleServer(serverPort uint16, handlerMap map[string]RequestHandler) *S
 		handlerMap: make(map[string]RequestHandler),
 		ServerPort: serverPort,
 		server:     nil,
-		signer:     msgsigner.NewMsgSigner(),
+		// 默认不校验接收方
+		authenticator: auth.NewJWTAuthenticator(""),
+		replayGuard:   auth.NewMemoryReplayGuard(),
+		maxBodySize:   DefaultMaxBodySize,
 	}
 
 	if handlerMap != nil {
@@ -44,6 +55,26 @@ func NewSimpleServer(serverPort uint16, handlerMap map[string]RequestHandler) *S
 	return server
 }
 
+// 设置认证器, 需在Serve前调用
+func (s *SimpleServer) SetAuthenticator(authenticator auth.IAuthenticator) {
+	s.authenticator = authenticator
+}
+
+// 设置防重放检查, 需在Serve前调用. 为nil时不检查
+func (s *SimpleServer) SetReplayGuard(replayGuard *auth.ReplayGuard) {
+	s.replayGuard = replayGuard
+}
+
+// 设置TLS配置, 需在Serve前调用. 为nil时使用HTTP
+func (s *SimpleServer) SetTLSConfig(tlsConfig *tls.Config) {
+	s.tlsConfig = tlsConfig
+}
+
+// 设置请求体的大小上限, 需在Serve前调用. 超过上限的请求返回413
+func (s *SimpleServer) SetMaxBodySize(maxBodySize int64) {
+	s.maxBodySize = maxBodySize
+}
+
 func (s *SimpleServer) Serve() error {
 	ginMode := gin.DebugMode
 	if config.WorkEnv == config.ENV_ONLINE {
@@ -62,11 +93,20 @@ func (s *SimpleServer) Serve() error {
 	}
 
 	s.server = &http.Server{
-		Addr:    fmt.Sprintf(":%d", s.ServerPort),
-		Handler: router,
+		Addr:      fmt.Sprintf(":%d", s.ServerPort),
+		Handler:   router,
+		TLSConfig: s.tlsConfig,
 	}
 
-	if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	var err error
+	if s.tlsConfig != nil {
+		// 证书已在TLSConfig中
+		err = s.server.ListenAndServeTLS("", "")
+	} else {
+		err = s.server.ListenAndServe()
+	}
+
+	if err != nil && err != http.ErrServerClosed {
 		glog.Error("failed to run the gin server: ", err.Error())
 	}
 
@@ -95,73 +135,46 @@ func (s *SimpleServer) ShutdownWithDuration(duration time.Duration) error {
 	return nil
 }
 
+// continue the trace of the caller from the traceparent header
 func (s *SimpleServer) requestTracing() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		ctx, span := tracing.Extract(c.Request.Context(), c.Request.Header, tracing.SpanName_ServerRequest)
+		span.SetAttribute("uri", c.Request.URL.Path)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		span.SetAttribute("status", c.Writer.Status())
+		span.End()
 	}
 }
 
 func (s *SimpleServer) authMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		glog.Info("verify the Authentication header")
-		authHeader := c.Request.Header.Get("Authorization")
-		if authHeader == "" {
-			returnErrorResponse(c, "", errordef.Error_Msg_AuthorizationFailed,
-				"No Authorization")
-			c.Abort()
-			return
-		}
-
-		parts := strings.SplitN(authHeader, " ", 2)
-		if !(len(parts) == 2 && parts[0] == "Bearer") {
-			returnErrorResponse(c, "", errordef.Error_Msg_AuthorizationFailed,
-				"invalid Authorization format")
-			c.Abort()
-			return
-		}
-
-		msg, err := s.signer.Verify(parts[1])
-		if err != nil {
-			returnErrorResponse(c, "", errordef.Error_Msg_AuthorizationFailed,
-				"invalid Authorization token")
-			c.Abort()
-			return
-		}
-
-		commonMsg := CommonMessage{}
-		err = json.Unmarshal([]byte(msg), &commonMsg)
+		identity, err := s.authenticator.Authenticate(c.Request)
 		if err != nil {
+			logging.Warning("failed to authenticate the request", logging.Any("uri", c.Request.URL.Path),
+				logging.Err(err))
+			metrics.RequestRejections.Inc(c.Request.URL.Path, metrics.RejectReason_Unauthenticated)
 			returnErrorResponse(c, "", errordef.Error_Msg_AuthorizationFailed,
-				"invalid token data")
+				"invalid Authorization")
 			c.Abort()
 			return
 		}
 
-		c.Set(USER_NAME, commonMsg.UserName)
-		c.Set(BODY_HASH, commonMsg.BodyHash)
+		c.Set(IDENTITY, identity)
+		c.Set(USER_NAME, identity.Name)
+		c.Set(BODY_HASH, identity.BodyHash)
 		c.Next()
 	}
 }
 
 func (s *SimpleServer) handleRequest(handler RequestHandler) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		body, err := io.ReadAll(c.Request.Body)
-		if err != nil {
-			glog.Warning("failed to get body: ", err.Error())
-			returnErrorResponse(c, "", errordef.Error_Msg_ParsingParam,
-				"NO request id found")
-			return
-		}
-
 		var request = CommonRequest{}
-		err = json.Unmarshal(body, &request)
-		if err != nil {
-			glog.Warning("failed to parse common parameter: ", err.Error())
-			glog.Warning(string(body))
-			returnErrorResponse(c, "", errordef.Error_Msg_ParsingParam,
-				"failed to parse parameter")
+		if !s.decodeRequest(c, &request) {
 			return
 		}
-		c.Request.Body = io.NopCloser(bytes.NewReader(body))
 
 		actualBodyHash := CalcMsgHash(request.Body)
 		expectedHash, existed := c.Get(BODY_HASH)
@@ -170,26 +183,154 @@ func (s *SimpleServer) handleRequest(handler RequestHandler) gin.HandlerFunc {
 			return
 		}
 
-		if actualBodyHash != expectedHash {
+		// 认证方式不提供请求体哈希时不校验
+		if expectedHash != "" && actualBodyHash != expectedHash {
+			metrics.RequestRejections.Inc(c.Request.URL.Path, metrics.RejectReason_BodyHash)
 			returnErrorResponse(c, request.Header.RequestId,
 				errordef.Error_Msg_AuthorizationFailed,
 				"invalid body hash")
 			return
 		}
 
+		if !s.checkReplay(c, &request.Header) {
+			return
+		}
+
 		start := time.Now()
 		response, err := s.invokeHandler(handler, request)
-		timeCost := time.Since(start)
-		glog.Info("handler stat, requestId:", request.Header.RequestId, ", timeCost: ", timeCost)
+		logging.Debug("handler stat", logging.RequestId(request.Header.RequestId),
+			logging.Any("uri", c.Request.URL.Path), logging.Any("cost", time.Since(start)))
 
 		if err == nil {
-			c.JSON(http.StatusOK, response)
+			s.writeResponse(c, response)
 		} else {
 			returnInternalErrorResponse(c, request.Header.RequestId)
 		}
 	}
 }
 
+// 按Content-Encoding流式解码请求, 压缩前后的请求体都不能超过大小上限
+func (s *SimpleServer) decodeRequest(c *gin.Context, request *CommonRequest) bool {
+	uri := c.Request.URL.Path
+	encoding := c.GetHeader("Content-Encoding")
+	if !IsSupportedEncoding(strings.ToLower(encoding)) {
+		logging.Warning("unsupported request encoding", logging.Any("uri", uri), logging.Any("encoding", encoding))
+		c.JSON(http.StatusUnsupportedMediaType, ReturnErrorResponse("", errordef.Error_Msg_ParsingParam,
+			"unsupported content encoding"))
+		return false
+	}
+
+	if c.Request.ContentLength > s.maxBodySize {
+		s.returnBodyTooLarge(c, c.Request.ContentLength)
+		return false
+	}
+
+	reader, err := NewDecodeReader(encoding, c.Request.Body, s.maxBodySize)
+	if err == nil {
+		defer reader.Close()
+		err = json.NewDecoder(reader).Decode(request)
+	}
+
+	if errors.Is(err, errordef.ErrBodyTooLarge) {
+		s.returnBodyTooLarge(c, c.Request.ContentLength)
+		return false
+	}
+
+	if err != nil {
+		logging.Warning("failed to parse common parameter", logging.Any("uri", uri), logging.Err(err))
+		returnErrorResponse(c, "", errordef.Error_Msg_ParsingParam,
+			"failed to parse parameter")
+		return false
+	}
+
+	return true
+}
+
+func (s *SimpleServer) returnBodyTooLarge(c *gin.Context, contentLength int64) {
+	logging.Warning("the request body is too large", logging.Any("uri", c.Request.URL.Path),
+		logging.Any("length", contentLength), logging.Any("limit", s.maxBodySize))
+	metrics.RequestRejections.Inc(c.Request.URL.Path, metrics.RejectReason_BodyTooLarge)
+	c.Header("Connection", "close")
+	c.JSON(http.StatusRequestEntityTooLarge, ReturnErrorResponse("", errordef.Error_Msg_BodyTooLarge,
+		fmt.Sprintf("the request body exceeds the limit of %d bytes", s.maxBodySize)))
+}
+
+// 客户端接受压缩时, 压缩较大的响应
+func (s *SimpleServer) writeResponse(c *gin.Context, response IResponse) {
+	data, err := json.Marshal(response)
+	if err != nil {
+		returnInternalErrorResponse(c, "")
+		return
+	}
+
+	encoding := NegotiateEncoding(c.GetHeader("Accept-Encoding"))
+	if encoding != "" && len(data) >= CompressThreshold {
+		compressed, err := Compress(encoding, data)
+		if err == nil {
+			data = compressed
+			c.Header("Content-Encoding", encoding)
+		}
+	}
+
+	c.Header("Vary", "Accept-Encoding")
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
+
+// 拒绝时间戳超出时钟偏差或nonce已使用过的请求
+func (s *SimpleServer) checkReplay(c *gin.Context, header *RequestHeader) bool {
+	if s.replayGuard == nil {
+		return true
+	}
+
+	var identity *auth.Identity
+	if value, ok := c.Get(IDENTITY); ok {
+		identity = value.(*auth.Identity)
+	}
+
+	uri := c.Request.URL.Path
+	err := CheckReplay(c.Request.Context(), s.replayGuard, uri, header.Timestamp, header.RequestId, identity)
+	if err != nil {
+		returnErrorResponse(c, header.RequestId, errordef.Error_Msg_AuthorizationFailed,
+			"replayed or expired request")
+		return false
+	}
+
+	return true
+}
+
+// 检查请求是否重放, 记录拒绝的原因. timestamp为16进制的Unix时间戳
+func CheckReplay(
+	ctx context.Context, guard *auth.ReplayGuard, uri string,
+	timestamp string, requestId string, identity *auth.Identity,
+) error {
+	seconds, err := strconv.ParseInt(timestamp, 16, 64)
+	if err != nil {
+		err = auth.ErrRequestExpired
+	} else {
+		err = guard.CheckRequest(ctx, time.Unix(seconds, 0), requestId, identity)
+	}
+
+	if err == nil {
+		return nil
+	}
+
+	reason := metrics.RejectReason_Replayed
+	if err == auth.ErrRequestExpired {
+		reason = metrics.RejectReason_ClockSkew
+	}
+
+	logging.Warning("rejected a replayed or expired request", logging.RequestId(requestId),
+		logging.Any("uri", uri), logging.Any("timestamp", timestamp), logging.Any("reason", reason),
+		logging.Err(err))
+	metrics.RequestRejections.Inc(uri, reason)
+	return err
+}
+
+// 请求头中的时间戳, 16进制的Unix时间戳
+func FormatTimestamp(t time.Time) string {
+	return strconv.FormatInt(t.Unix(), 16)
+}
+
 func (s *SimpleServer) invokeHandler(
 	handler RequestHandler,
 	request CommonRequest,
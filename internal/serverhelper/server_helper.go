@@ -5,14 +5,12 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/danenmao/pterergate-dtf/dtf/auth"
 	"github.com/danenmao/pterergate-dtf/dtf/errordef"
 )
 
 // claim
-type CommonMessage struct {
-	UserName string `json:"username"`
-	BodyHash string `json:"bodyhash"`
-}
+type CommonMessage = auth.TokenMessage
 
 // Request
 //
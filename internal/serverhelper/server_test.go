@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -27,7 +28,7 @@ func Test_NewSimpleServer_Success(t *testing.T) {
 			So(svr, ShouldNotBeNil)
 			So(svr.ServerPort == 8090, ShouldBeTrue)
 			So(len(svr.handlerMap) == 1, ShouldBeTrue)
-			So(svr.signer, ShouldNotBeNil)
+			So(svr.authenticator, ShouldNotBeNil)
 		})
 	})
 }
@@ -40,7 +41,7 @@ func Test_NewSimpleServer_NilMap(t *testing.T) {
 			So(svr, ShouldNotBeNil)
 			So(svr.ServerPort == 8090, ShouldBeTrue)
 			So(len(svr.handlerMap) == 0, ShouldBeTrue)
-			So(svr.signer, ShouldNotBeNil)
+			So(svr.authenticator, ShouldNotBeNil)
 		})
 	})
 }
@@ -59,7 +60,7 @@ func Test_Shutdown_NilServer(t *testing.T) {
 			So(svr, ShouldNotBeNil)
 			So(svr.ServerPort == 8090, ShouldBeTrue)
 			So(len(svr.handlerMap) == 1, ShouldBeTrue)
-			So(svr.signer, ShouldNotBeNil)
+			So(svr.authenticator, ShouldNotBeNil)
 		})
 	})
 }
@@ -245,7 +246,7 @@ func Test_authMiddleware_InvalidMsg(t *testing.T) {
 
 	httpReq, err := http.NewRequest(http.MethodPost, "http://localhost:8090/test",
 		strings.NewReader("test"))
-	sign, _ := msgsigner.NewMsgSigner().Sign("", "", []string{""}, "test", time.Minute)
+	sign, _ := msgsigner.NewMsgSigner().Sign(Issuer, "", []string{""}, "test", time.Minute)
 	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", sign))
 	rsp, err := http.DefaultClient.Do(httpReq)
 
@@ -289,7 +290,7 @@ func Test_handleRequest_DifferentHash(t *testing.T) {
 		BodyHash: request.Header.BodyHash,
 	}
 	msgPlain, _ := json.Marshal(msg)
-	sign, _ := msgsigner.NewMsgSigner().Sign("", "", []string{""}, string(msgPlain), time.Minute)
+	sign, _ := msgsigner.NewMsgSigner().Sign(Issuer, "", []string{""}, string(msgPlain), time.Minute)
 
 	httpReq, err := http.NewRequest(http.MethodPost, "http://localhost:8090/test",
 		strings.NewReader(string(cr)))
@@ -331,7 +332,7 @@ func Test_handleRequest_InvalidCommonRequest(t *testing.T) {
 		BodyHash: "1234567890",
 	}
 	msgPlain, _ := json.Marshal(msg)
-	sign, _ := msgsigner.NewMsgSigner().Sign("", "", []string{""}, string(msgPlain), time.Minute)
+	sign, _ := msgsigner.NewMsgSigner().Sign(Issuer, "", []string{""}, string(msgPlain), time.Minute)
 
 	httpReq, err := http.NewRequest(http.MethodPost, "http://localhost:8090/test",
 		strings.NewReader("test request data"))
@@ -371,6 +372,7 @@ func Test_handleRequest_HandlerReturnError(t *testing.T) {
 	var request = CommonRequest{}
 	request.Body = "body test data"
 	request.Header.BodyHash = CalcMsgHash(request.Body)
+	request.Header.Timestamp = strconv.FormatInt(time.Now().Unix(), 16)
 	cr, _ := json.Marshal(&request)
 
 	msg := CommonMessage{
@@ -378,7 +380,7 @@ func Test_handleRequest_HandlerReturnError(t *testing.T) {
 		BodyHash: request.Header.BodyHash,
 	}
 	msgPlain, _ := json.Marshal(msg)
-	sign, _ := msgsigner.NewMsgSigner().Sign("", "", []string{""}, string(msgPlain), time.Minute)
+	sign, _ := msgsigner.NewMsgSigner().Sign(Issuer, "", []string{""}, string(msgPlain), time.Minute)
 
 	httpReq, err := http.NewRequest(http.MethodPost, "http://localhost:8090/test",
 		strings.NewReader(string(cr)))
@@ -400,3 +402,67 @@ func Test_handleRequest_HandlerReturnError(t *testing.T) {
 		})
 	})
 }
+
+// send a signed request with the token and timestamp
+func postSignedRequest(token string, timestamp time.Time) CommonResponse {
+	var request = CommonRequest{}
+	request.Body = "body test data"
+	request.Header.RequestId = "request-id"
+	request.Header.BodyHash = CalcMsgHash(request.Body)
+	request.Header.Timestamp = strconv.FormatInt(timestamp.Unix(), 16)
+	cr, _ := json.Marshal(&request)
+
+	commonResp := CommonResponse{}
+	httpReq, _ := http.NewRequest(http.MethodPost, "http://localhost:8090/test",
+		strings.NewReader(string(cr)))
+	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	rsp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return commonResp
+	}
+
+	defer rsp.Body.Close()
+	body, _ := io.ReadAll(rsp.Body)
+	json.Unmarshal(body, &commonResp)
+	return commonResp
+}
+
+func signBodyHash(bodyHash string) string {
+	msg := CommonMessage{
+		UserName: "test",
+		BodyHash: bodyHash,
+	}
+	msgPlain, _ := json.Marshal(msg)
+	sign, _ := msgsigner.NewMsgSigner().Sign(Issuer, "", []string{""}, string(msgPlain), time.Minute)
+	return sign
+}
+
+func Test_checkReplay(t *testing.T) {
+	requestCount := 0
+	svr := NewSimpleServer(8090,
+		map[string]RequestHandler{"/test": func(
+			header RequestHeader, requestBody string) (responseBody string, err error) {
+			requestCount++
+			return "", nil
+		}})
+
+	go func() {
+		svr.Serve()
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	token := signBodyHash(CalcMsgHash("body test data"))
+	firstResp := postSignedRequest(token, time.Now())
+	replayedResp := postSignedRequest(token, time.Now())
+	staleResp := postSignedRequest(signBodyHash(CalcMsgHash("body test data")), time.Now().Add(-time.Hour))
+	svr.ShutdownWithDuration(100 * time.Millisecond)
+
+	Convey("send replayed and stale requests", t, func() {
+		Convey("should only accept the first request", func() {
+			So(firstResp.Header.Code, ShouldEqual, errordef.Error_Msg_Success)
+			So(replayedResp.Header.Code, ShouldEqual, errordef.Error_Msg_AuthorizationFailed)
+			So(staleResp.Header.Code, ShouldEqual, errordef.Error_Msg_AuthorizationFailed)
+			So(requestCount, ShouldEqual, 1)
+		})
+	})
+}
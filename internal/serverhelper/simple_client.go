@@ -1,45 +1,81 @@
 package serverhelper
 
 import (
+	"bytes"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/golang/glog"
 	"github.com/google/uuid"
 
+	"github.com/danenmao/pterergate-dtf/dtf/auth"
 	"github.com/danenmao/pterergate-dtf/dtf/errordef"
-	"github.com/danenmao/pterergate-dtf/internal/msgsigner"
+	"github.com/danenmao/pterergate-dtf/dtf/tracing"
 )
 
-const TokenExpireDuration time.Duration = 5 * time.Minute
-const Issuer = "SimpleInvoker"
-const Subject = "pterergate-service"
+const TokenExpireDuration = auth.TokenExpireDuration
+const Issuer = auth.DefaultIssuer
+const Subject = auth.Subject
 
 type SimpleInvoker struct {
-	client *http.Client
-	signer *msgsigner.MsgSigner
+	client        *http.Client
+	transport     *http.Transport
+	authenticator auth.IAuthenticator
+	encoding      string
+	maxBodySize   int64
 }
 
 func NewSimpleInvoker() *SimpleInvoker {
 	s := &SimpleInvoker{}
-	s.signer = msgsigner.NewMsgSigner()
+	s.authenticator = auth.NewJWTAuthenticator("")
+	s.encoding = Encoding_Identity
+	s.maxBodySize = DefaultMaxBodySize
+	s.transport = &http.Transport{
+		MaxConnsPerHost: 5,
+	}
 	s.client = &http.Client{
-		Timeout: time.Second * 10,
-		Transport: &http.Transport{
-			MaxConnsPerHost: 5,
-		},
+		Timeout:   time.Second * 10,
+		Transport: s.transport,
 	}
 
 	return s
 }
 
+// 设置认证器
+func (s *SimpleInvoker) SetAuthenticator(authenticator auth.IAuthenticator) {
+	s.authenticator = authenticator
+}
+
+// 设置访问https服务的TLS配置
+func (s *SimpleInvoker) SetTLSConfig(tlsConfig *tls.Config) {
+	s.transport.TLSClientConfig = tlsConfig
+}
+
+// 设置请求的压缩编码, 为空或identity时不压缩. 默认不压缩, 服务端需支持所选的编码
+func (s *SimpleInvoker) SetCompression(encoding string) error {
+	if !IsSupportedEncoding(encoding) {
+		return ErrUnsupportedEncoding
+	}
+
+	s.encoding = encoding
+	return nil
+}
+
+// 设置请求和响应体的大小上限, 超过时返回errordef.ErrBodyTooLarge
+func (s *SimpleInvoker) SetMaxBodySize(maxBodySize int64) {
+	s.maxBodySize = maxBodySize
+}
+
 func (s *SimpleInvoker) Post(url string, userName string, requestBody string) (string, error) {
+	return s.PostWithTrace(url, userName, requestBody, "")
+}
+
+// post a request, the trace context is sent in the traceparent header
+func (s *SimpleInvoker) PostWithTrace(url string, userName string, requestBody string, traceParent string) (string, error) {
 	// generate the request json
 	commonReq := s.genCommonRequest(requestBody)
 	commonReqData, err := json.Marshal(commonReq)
@@ -47,23 +83,41 @@ func (s *SimpleInvoker) Post(url string, userName string, requestBody string) (s
 		return "", err
 	}
 
-	// sign the request body
-	sign, err := s.sign(userName, commonReq)
+	if int64(len(commonReqData)) > s.maxBodySize {
+		return "", errordef.ErrBodyTooLarge
+	}
+
+	// compress the request body
+	encoding := ""
+	if len(commonReqData) >= CompressThreshold {
+		encoding = s.encoding
+	}
+
+	commonReqData, err = Compress(encoding, commonReqData)
 	if err != nil {
 		return "", err
 	}
 
 	// new a request
-	httpReq, err := http.NewRequest(http.MethodPost, url, strings.NewReader(string(commonReqData)))
+	httpReq, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(commonReqData))
 	if err != nil {
 		return "", nil
 	}
 
+	// sign the request body
+	err = s.authenticator.Sign(httpReq, userName, commonReq.Header.BodyHash)
+	if err != nil {
+		return "", err
+	}
+
 	// set http headers
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Accept", "application/json")
-	httpReq.Header.Set("Accept-Encoding", "gzip")
-	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", sign))
+	httpReq.Header.Set("Accept-Encoding", AcceptEncodings)
+	if encoding != "" && encoding != Encoding_Identity {
+		httpReq.Header.Set("Content-Encoding", encoding)
+	}
+	tracing.Inject(httpReq.Header, traceParent)
 
 	// send the request
 	rsp, err := s.client.Do(httpReq)
@@ -73,19 +127,25 @@ func (s *SimpleInvoker) Post(url string, userName string, requestBody string) (s
 	}
 
 	// parse the response
+	defer rsp.Body.Close()
+	if rsp.StatusCode == http.StatusRequestEntityTooLarge {
+		return "", errordef.ErrBodyTooLarge
+	}
+
 	if rsp.StatusCode != 200 {
 		return "", fmt.Errorf("error HTTP status %d", rsp.StatusCode)
 	}
 
-	defer rsp.Body.Close()
-	respBody, err := io.ReadAll(rsp.Body)
+	// Accept-Encoding is set explicitly, so the transport does not decode the response
+	respReader, err := NewDecodeReader(rsp.Header.Get("Content-Encoding"), rsp.Body, s.maxBodySize)
 	if err != nil {
 		return "", err
 	}
+	defer respReader.Close()
 
 	// parse the common response
 	commonResp := CommonResponse{}
-	err = json.Unmarshal(respBody, &commonResp)
+	err = json.NewDecoder(respReader).Decode(&commonResp)
 	if err != nil {
 		return "", err
 	}
@@ -105,7 +165,7 @@ func (s *SimpleInvoker) genCommonRequest(requestBody string) *CommonRequest {
 	// fill header
 	req.Header.Version = "1.0"
 	req.Header.RequestId = uuid.NewString()
-	req.Header.Timestamp = strconv.FormatInt(time.Now().Unix(), 16)
+	req.Header.Timestamp = FormatTimestamp(time.Now())
 	req.Header.Module = ""
 	req.Header.Action = ""
 
@@ -114,18 +174,3 @@ func (s *SimpleInvoker) genCommonRequest(requestBody string) *CommonRequest {
 
 	return req
 }
-
-func (s *SimpleInvoker) sign(userName string, req *CommonRequest) (string, error) {
-	msg := CommonMessage{
-		UserName: userName,
-		BodyHash: req.Header.BodyHash,
-	}
-
-	data, err := json.Marshal(msg)
-	if err != nil {
-		return "", err
-	}
-
-	audience := []string{"executor", "collector"}
-	return s.signer.Sign(Issuer, Subject, audience, string(data), TokenExpireDuration)
-}
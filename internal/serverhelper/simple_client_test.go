@@ -36,6 +36,7 @@ func Test_Post_Success(t *testing.T) {
 	go func() {
 		svr.Serve()
 	}()
+	time.Sleep(10 * time.Millisecond)
 
 	invoker := NewSimpleInvoker()
 	_, err := invoker.Post("http://localhost:8090/test", "test", "test")
@@ -61,6 +62,7 @@ func Test_Post_ResponseError(t *testing.T) {
 	go func() {
 		svr.Serve()
 	}()
+	time.Sleep(10 * time.Millisecond)
 
 	invoker := NewSimpleInvoker()
 	_, err := invoker.Post("http://localhost:8090/test", "test", "test")
@@ -0,0 +1,83 @@
+package serverhelper
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"os"
+
+	"github.com/danenmao/pterergate-dtf/dtf/extconfig"
+)
+
+// 生成服务端的TLS配置, 配置了CA时要求客户端证书
+func NewServerTLSConfig(cfg *extconfig.TLSConfig) (*tls.Config, error) {
+	if cfg == nil || len(cfg.CertFile) == 0 || len(cfg.KeyFile) == 0 {
+		return nil, errors.New("no server certificate")
+	}
+
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+
+	if len(cfg.CAFile) > 0 {
+		tlsConfig.ClientCAs, err = loadCertPool(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
+}
+
+// 生成客户端的TLS配置, 配置了证书时用于mTLS
+func NewClientTLSConfig(cfg *extconfig.TLSConfig) (*tls.Config, error) {
+	if cfg == nil {
+		return nil, errors.New("no tls config")
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: cfg.ServerName,
+	}
+
+	var err error
+	if len(cfg.CAFile) > 0 {
+		tlsConfig.RootCAs, err = loadCertPool(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(cfg.CertFile) > 0 {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+func loadCertPool(caFile string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, errors.New("no certificate found in " + caFile)
+	}
+
+	return pool, nil
+}
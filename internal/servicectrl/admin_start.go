@@ -0,0 +1,28 @@
+package servicectrl
+
+import (
+	"sync"
+
+	"github.com/danenmao/pterergate-dtf/dtf/dtfdef"
+	"github.com/danenmao/pterergate-dtf/internal/exitctrl"
+	"github.com/danenmao/pterergate-dtf/internal/services/admin"
+)
+
+var gs_AdminOnce sync.Once
+
+// start the admin server of the process after the stores are connected,
+// the server is started only once when several roles start in one process
+func startAdmin(cfg *dtfdef.ServiceConfig) {
+	if cfg.AdminPort == 0 {
+		return
+	}
+
+	gs_AdminOnce.Do(func() {
+		server := admin.NewAdminServer(cfg.AdminPort)
+		go server.Serve()
+
+		exitctrl.AddExitRoutine(func() {
+			server.Shutdown()
+		})
+	})
+}
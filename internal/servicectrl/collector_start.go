@@ -5,19 +5,17 @@ import (
 
 	"github.com/danenmao/pterergate-dtf/dtf/dtfdef"
 	"github.com/danenmao/pterergate-dtf/internal/config"
-	"github.com/danenmao/pterergate-dtf/internal/mysqltool"
-	"github.com/danenmao/pterergate-dtf/internal/redistool"
+	"github.com/danenmao/pterergate-dtf/internal/metrics"
 	"github.com/danenmao/pterergate-dtf/internal/routine"
 	"github.com/danenmao/pterergate-dtf/internal/services/collector"
 )
 
 func StartCollector(cfg *dtfdef.ServiceConfig) error {
 
-	config.DefaultMySQL = cfg.MySQLServer
-	mysqltool.ConnectToDefaultMySQL()
+	connectStores(cfg)
 
-	config.DefaultRedisServer = cfg.RedisServer
-	redistool.ConnectToDefaultRedis()
+	collector.SetRoutineLimit(config.EnvSubtaskRoutineLimit)
+	metrics.AddCollector(collector.CollectMetrics)
 
 	routine.StartWorkingRoutine([]routine.WorkingRoutine{
 		{
@@ -0,0 +1,71 @@
+package servicectrl
+
+import (
+	"strings"
+
+	"github.com/golang/glog"
+
+	"github.com/danenmao/pterergate-dtf/dtf/dtfdef"
+	"github.com/danenmao/pterergate-dtf/internal/config"
+	"github.com/danenmao/pterergate-dtf/internal/exitctrl"
+	"github.com/danenmao/pterergate-dtf/internal/taskframework/tasklogic/schedulerlogic/quotagroup"
+)
+
+// the section of the runtime config which can be reloaded without restart
+const reloadableSection = "scheduling."
+
+// watch the config file and apply the scheduling settings when it changes,
+// the tasks being scheduled keep running, the new settings take effect on the next round
+func startConfigReload(cfg *dtfdef.ServiceConfig) {
+	if len(cfg.ConfigFile) == 0 {
+		return
+	}
+
+	stop, err := config.WatchConfigFile(cfg.ConfigFile, func() {
+		reloadRuntimeConfig(cfg)
+	})
+	if err != nil {
+		glog.Warning("failed to watch config file, hot reload is disabled: ", cfg.ConfigFile, ", ", err)
+		return
+	}
+
+	exitctrl.AddExitRoutine(exitctrl.ExitRoutine(stop))
+	glog.Info("watching config file for hot reload: ", cfg.ConfigFile)
+}
+
+// reload the config file and apply the scheduling section
+func reloadRuntimeConfig(cfg *dtfdef.ServiceConfig) {
+	loaded := baseRuntimeConfig(cfg)
+	err := config.LoadRuntimeConfig(cfg.ConfigFile, &loaded)
+	if err != nil {
+		glog.Warning("failed to reload runtime config, keep the current config: ", err)
+		return
+	}
+
+	current := config.GetRuntimeConfig()
+	applied := current
+	applied.Scheduling = loaded.Scheduling
+
+	err = config.SetRuntimeConfig(&applied)
+	if err != nil {
+		glog.Warning("invalid runtime config, keep the current config: ", err)
+		return
+	}
+
+	changes := config.DiffRuntimeConfig(&current, &loaded)
+	if len(changes) == 0 {
+		glog.Info("runtime config reloaded, nothing changed")
+		return
+	}
+
+	for _, change := range changes {
+		if strings.HasPrefix(change, reloadableSection) {
+			glog.Info("runtime config changed: ", change)
+		} else {
+			glog.Warning("runtime config changed, takes effect after restart: ", change)
+		}
+	}
+
+	// make the new quotas take effect now
+	quotagroup.GetQuotaGroupMgr().Refresh()
+}
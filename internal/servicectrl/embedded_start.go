@@ -0,0 +1,117 @@
+package servicectrl
+
+import (
+	"github.com/golang/glog"
+
+	"github.com/danenmao/pterergate-dtf/dtf/dtfdef"
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/extconfig"
+	"github.com/danenmao/pterergate-dtf/dtf/serversupport"
+	"github.com/danenmao/pterergate-dtf/internal/config"
+	"github.com/danenmao/pterergate-dtf/internal/exitctrl"
+	"github.com/danenmao/pterergate-dtf/internal/mysqltool"
+	"github.com/danenmao/pterergate-dtf/internal/recordstore"
+	"github.com/danenmao/pterergate-dtf/internal/resultstore"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
+)
+
+// start all service roles in one process.
+// the state, task records and subtask results are kept in memory,
+// or the records and results in a SQLite file if WithMySQL sets the sqlite dialect.
+// other databases are not supported, a MySQL server in cfg is rejected instead of ignored.
+// the executor and collector are invoked through in-process channels
+func StartEmbedded(cfg *dtfdef.ServiceConfig) error {
+	applyLogger(cfg)
+
+	err := loadRuntimeConfig(cfg)
+	if err != nil {
+		glog.Warning("failed to load runtime config: ", err)
+		return err
+	}
+
+	if embeddedMySQL(cfg) {
+		glog.Warning("only a SQLite file can be used as the database in embedded mode: ", cfg.MySQLServer.Dialect)
+		return errordef.ErrInvalidParameter
+	}
+
+	// the id segments can be kept in a database only with a SQLite file
+	if cfg.IdGenerator == dtfdef.IdGenerator_MySQL && !embeddedSQLite(cfg) {
+		glog.Warning("the MySQL id generator needs a SQLite file in embedded mode")
+		return errordef.ErrInvalidParameter
+	}
+
+	// the tables are created before the roles start, as they read the max ids
+	if embeddedSQLite(cfg) {
+		config.DefaultMySQL = cfg.MySQLServer
+		mysqltool.ConnectToDefaultMySQL()
+
+		err = migrateSchema(cfg)
+		if err != nil {
+			return err
+		}
+
+		recordstore.SetDefault(recordstore.NewSQLRecordStore())
+		resultstore.SetDefault(resultstore.NewSQLStore())
+	} else {
+		recordstore.SetDefault(recordstore.NewMemoryRecordStore())
+		resultstore.SetDefault(resultstore.NewMemoryStore())
+	}
+
+	gs_Embedded = true
+	statestore.SetDefault(statestore.NewMemoryStore())
+	applyKeyPrefix(cfg)
+	applyBlobStore(cfg)
+	applyTraceExporter(cfg)
+
+	executorChannel := serversupport.NewChannelExecutor(0)
+	cfg.ExecutorService = executorChannel.GetInvoker()
+	cfg.ExecutorHandlerRegister = executorChannel.GetRegister()
+
+	collectorChannel := serversupport.NewChannelCollector(0)
+	cfg.CollectorService = collectorChannel.GetInvoker()
+	cfg.CollectorHandlerRegister = collectorChannel.GetRegister()
+
+	// to process the exit signal
+	exitctrl.RegisterWithDuration(cfg.PrestopDuration)
+
+	startMetrics(cfg,
+		dtfdef.ServiceRole_Collector,
+		dtfdef.ServiceRole_Executor,
+		dtfdef.ServiceRole_Scheduler,
+		dtfdef.ServiceRole_Generator,
+		dtfdef.ServiceRole_Manager,
+	)
+
+	// start the downstream roles first, so requests can be consumed
+	starters := []ServiceStartFn{
+		StartCollector,
+		StartExecutor,
+		StartScheduler,
+		StartGenerator,
+		StartManager,
+	}
+
+	for _, starter := range starters {
+		err = starter(cfg)
+		if err != nil {
+			glog.Warning("failed to start embedded service: ", err)
+			return err
+		}
+	}
+
+	startAdmin(cfg)
+
+	glog.Info("succeeded to start embedded services")
+	return nil
+}
+
+// whether the embedded services keep the records in a SQLite file
+func embeddedSQLite(cfg *dtfdef.ServiceConfig) bool {
+	return cfg.MySQLServer.Dialect == extconfig.SQLDialect_SQLite
+}
+
+// 配置了SQLite以外的数据库
+func embeddedMySQL(cfg *dtfdef.ServiceConfig) bool {
+	server := cfg.MySQLServer
+	return !embeddedSQLite(cfg) && (server.Address != "" || server.DSN != "")
+}
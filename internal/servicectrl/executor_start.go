@@ -4,20 +4,13 @@ import (
 	"time"
 
 	"github.com/danenmao/pterergate-dtf/dtf/dtfdef"
-	"github.com/danenmao/pterergate-dtf/internal/config"
-	"github.com/danenmao/pterergate-dtf/internal/mysqltool"
-	"github.com/danenmao/pterergate-dtf/internal/redistool"
 	"github.com/danenmao/pterergate-dtf/internal/routine"
 	"github.com/danenmao/pterergate-dtf/internal/services/executor"
 )
 
 func StartExecutor(cfg *dtfdef.ServiceConfig) error {
 
-	config.DefaultMySQL = cfg.MySQLServer
-	mysqltool.ConnectToDefaultMySQL()
-
-	config.DefaultRedisServer = cfg.RedisServer
-	redistool.ConnectToDefaultRedis()
+	connectStores(cfg)
 
 	executor.CollectorInvoker = cfg.CollectorService
 	executor.GetExecutorService().Init()
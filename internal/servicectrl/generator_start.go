@@ -5,23 +5,25 @@ import (
 
 	"github.com/danenmao/pterergate-dtf/dtf/dtfdef"
 	"github.com/danenmao/pterergate-dtf/internal/config"
-	"github.com/danenmao/pterergate-dtf/internal/idtool"
-	"github.com/danenmao/pterergate-dtf/internal/mysqltool"
-	"github.com/danenmao/pterergate-dtf/internal/redistool"
+	"github.com/danenmao/pterergate-dtf/internal/resultstore"
 	"github.com/danenmao/pterergate-dtf/internal/routine"
 	"github.com/danenmao/pterergate-dtf/internal/services/generator"
 )
 
 func StartGenerator(cfg *dtfdef.ServiceConfig) error {
 
-	config.DefaultMySQL = cfg.MySQLServer
-	mysqltool.ConnectToDefaultMySQL()
+	connectStores(cfg)
 
-	config.DefaultRedisServer = cfg.RedisServer
-	redistool.ConnectToDefaultRedis()
+	// init dependencies, the subtask ids must be above the saved subtask results
+	err := applyIdGenerator(cfg)
+	if err != nil {
+		return err
+	}
 
-	// init dependencies
-	idtool.Init(config.SubtaskIdKey)
+	err = initIdGenerator(config.SubtaskIdKey, resultstore.Default().GetMaxSubtaskId)
+	if err != nil {
+		return err
+	}
 
 	// start service working routines
 	routine.StartWorkingRoutine([]routine.WorkingRoutine{
@@ -0,0 +1,38 @@
+package servicectrl
+
+import (
+	"time"
+
+	"github.com/golang/glog"
+
+	"github.com/danenmao/pterergate-dtf/internal/config"
+	"github.com/danenmao/pterergate-dtf/internal/exitctrl"
+	"github.com/danenmao/pterergate-dtf/internal/redistool"
+	"github.com/danenmao/pterergate-dtf/internal/routine"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
+)
+
+// start to campaign for the leader lease named by key,
+// the lease is kept in the state store, renewed in background and released on exit
+func startLeaderElection(key string) routine.ILeaderChecker {
+	elector := redistool.NewLeaderElectorWithBackend(key, time.Duration(config.EnvLeaderLease)*time.Second,
+		statestore.Default())
+	elector.AddObserver(func(isLeader bool, fencingToken uint64) {
+		glog.Info("leadership changed: ", key, ", leader: ", isLeader, ", token: ", fencingToken)
+	})
+
+	// campaign once before routines start, so the leader can work immediately
+	elector.Campaign()
+
+	go routine.ExecRoutineWithInterval(
+		"LeaderElection."+key,
+		elector.Campaign,
+		elector.CampaignInterval(),
+	)
+
+	exitctrl.AddExitRoutine(func() {
+		elector.Resign()
+	})
+
+	return elector
+}
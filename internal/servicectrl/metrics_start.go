@@ -0,0 +1,32 @@
+package servicectrl
+
+import (
+	"sync"
+
+	"github.com/danenmao/pterergate-dtf/dtf/dtfdef"
+	"github.com/danenmao/pterergate-dtf/internal/exitctrl"
+	"github.com/danenmao/pterergate-dtf/internal/metrics"
+)
+
+var gs_MetricsOnce sync.Once
+
+// start the metrics server of the process and record the started roles,
+// the server is started only once when several roles start in one process
+func startMetrics(cfg *dtfdef.ServiceConfig, roles ...dtfdef.ServiceRole) {
+	for _, role := range roles {
+		metrics.ServiceInfo.Set(1, gs_ServiceRoleName[role])
+	}
+
+	if len(cfg.MetricsAddress) == 0 {
+		return
+	}
+
+	gs_MetricsOnce.Do(func() {
+		server := metrics.NewServer(cfg.MetricsAddress)
+		go server.Serve()
+
+		exitctrl.AddExitRoutine(func() {
+			server.Shutdown()
+		})
+	})
+}
@@ -5,23 +5,34 @@ import (
 
 	"github.com/danenmao/pterergate-dtf/dtf/dtfdef"
 	"github.com/danenmao/pterergate-dtf/internal/config"
-	"github.com/danenmao/pterergate-dtf/internal/idtool"
-	"github.com/danenmao/pterergate-dtf/internal/mysqltool"
-	"github.com/danenmao/pterergate-dtf/internal/redistool"
+	"github.com/danenmao/pterergate-dtf/internal/recordstore"
 	"github.com/danenmao/pterergate-dtf/internal/routine"
 	"github.com/danenmao/pterergate-dtf/internal/services/taskmgmt"
 )
 
 func StartManager(cfg *dtfdef.ServiceConfig) error {
 
-	config.DefaultMySQL = cfg.MySQLServer
-	mysqltool.ConnectToDefaultMySQL()
+	connectStores(cfg)
 
-	config.DefaultRedisServer = cfg.RedisServer
-	redistool.ConnectToDefaultRedis()
+	// create and upgrade the framework tables before using them
+	err := migrateSchema(cfg)
+	if err != nil {
+		return err
+	}
 
-	// init dependencies
-	idtool.Init(config.TaskIdKey)
+	// init dependencies, the task ids must be above those in the task table
+	err = applyIdGenerator(cfg)
+	if err != nil {
+		return err
+	}
+
+	err = initIdGenerator(config.TaskIdKey, recordstore.Default().GetMaxTaskId)
+	if err != nil {
+		return err
+	}
+
+	// the monitors scan shared state, only the leader runs them
+	leader := startLeaderElection(config.ManagerLeaderKey)
 
 	// start service working routines
 	routine.StartWorkingRoutine([]routine.WorkingRoutine{
@@ -29,16 +40,19 @@ func StartManager(cfg *dtfdef.ServiceConfig) error {
 			RoutineFn:    taskmgmt.MonitorTaskTableRoutine,
 			RoutineCount: config.EnvMonitorTaskTblCountLimit,
 			Interval:     time.Duration(config.EnvMonitorTaskTblInterval) * time.Second,
+			Leader:       leader,
 		},
 		{
 			RoutineFn:    taskmgmt.MonitorTaskTimeout,
 			RoutineCount: config.EnvMonitorTaskTimeoutCountLimit,
 			Interval:     time.Duration(config.EnvMonitorTaskTimeoutInterval) * time.Second,
+			Leader:       leader,
 		},
 		{
 			RoutineFn:    taskmgmt.MonitorCompletedTask,
 			RoutineCount: config.EnvMonitorTaskCompletedCountLimit,
 			Interval:     time.Duration(config.EnvMonitorTaskCompletedInterval) * time.Second,
+			Leader:       leader,
 		},
 	})
 
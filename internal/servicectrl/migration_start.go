@@ -0,0 +1,37 @@
+package servicectrl
+
+import (
+	"context"
+
+	"github.com/golang/glog"
+
+	"github.com/danenmao/pterergate-dtf/dtf/dtfdef"
+	"github.com/danenmao/pterergate-dtf/internal/dbdialect"
+	"github.com/danenmao/pterergate-dtf/internal/mysqltool"
+	"github.com/danenmao/pterergate-dtf/internal/schemamigration"
+)
+
+// create and upgrade the framework tables in the SQL database,
+// in embedded mode it is done before the roles start if a SQLite file is used
+func migrateSchema(cfg *dtfdef.ServiceConfig) error {
+	if gs_Embedded || cfg.SchemaMigration == dtfdef.SchemaMigration_Disabled {
+		return nil
+	}
+
+	dryRun := cfg.SchemaMigration == dtfdef.SchemaMigration_DryRun
+	plan := []string{}
+	err := schemamigration.Migrate(context.Background(), mysqltool.DefaultMySQL(), dbdialect.Default(), dryRun, &plan)
+	if err != nil {
+		glog.Warning("failed to migrate the framework tables: ", err)
+		return err
+	}
+
+	if dryRun {
+		for _, stmt := range plan {
+			glog.Warning("pending schema migration: ", stmt)
+		}
+	}
+
+	glog.Info("the framework tables are up to date, statements: ", len(plan), ", dry run: ", dryRun)
+	return nil
+}
@@ -13,3 +13,12 @@ var gs_ServiceRoleStarter = map[dtfdef.ServiceRole]ServiceStartFn{
 	dtfdef.ServiceRole_Executor:  StartExecutor,
 	dtfdef.ServiceRole_Collector: StartCollector,
 }
+
+// 各服务role的名称
+var gs_ServiceRoleName = map[dtfdef.ServiceRole]string{
+	dtfdef.ServiceRole_Manager:   "manager",
+	dtfdef.ServiceRole_Generator: "generator",
+	dtfdef.ServiceRole_Scheduler: "scheduler",
+	dtfdef.ServiceRole_Executor:  "executor",
+	dtfdef.ServiceRole_Collector: "collector",
+}
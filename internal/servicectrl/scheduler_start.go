@@ -5,8 +5,7 @@ import (
 
 	"github.com/danenmao/pterergate-dtf/dtf/dtfdef"
 	"github.com/danenmao/pterergate-dtf/internal/config"
-	"github.com/danenmao/pterergate-dtf/internal/mysqltool"
-	"github.com/danenmao/pterergate-dtf/internal/redistool"
+	"github.com/danenmao/pterergate-dtf/internal/metrics"
 	"github.com/danenmao/pterergate-dtf/internal/routine"
 	"github.com/danenmao/pterergate-dtf/internal/services/scheduler"
 	"github.com/danenmao/pterergate-dtf/internal/taskframework/tasklogic/schedulerlogic/executorconnector"
@@ -15,14 +14,18 @@ import (
 
 func StartScheduler(cfg *dtfdef.ServiceConfig) error {
 
-	config.DefaultMySQL = cfg.MySQLServer
-	mysqltool.ConnectToDefaultMySQL()
-
-	config.DefaultRedisServer = cfg.RedisServer
-	redistool.ConnectToDefaultRedis()
+	connectStores(cfg)
 
 	executorconnector.ExecutorService = cfg.ExecutorService
+
+	// priority boost and lost task repair modify the shared scheduling queues,
+	// only the leader runs them
+	quotagroup.GetQuotaGroupMgr().Leader = startLeaderElection(config.SchedulerLeaderKey)
 	quotagroup.GetQuotaGroupMgr().Init()
+	metrics.AddCollector(quotagroup.GetQuotaGroupMgr().CollectMetrics)
+
+	// apply the scheduling settings when the config file changes
+	startConfigReload(cfg)
 
 	routine.StartWorkingRoutine([]routine.WorkingRoutine{
 		{
@@ -30,6 +33,11 @@ func StartScheduler(cfg *dtfdef.ServiceConfig) error {
 			RoutineCount: config.EnvScheduleTaskConcurrencyLimit,
 			Interval:     time.Duration(config.EnvScheduleTaskInterval) * time.Millisecond,
 		},
+		{
+			RoutineFn:    executorconnector.RetryPushToExecutor,
+			RoutineCount: config.EnvRetryPushSubtaskConcurrencyLimit,
+			Interval:     time.Second * time.Duration(config.EnvRetryPushSubtaskInterval),
+		},
 		{
 			RoutineFn:    scheduler.MonitorSubtaskComplete,
 			RoutineCount: config.EnvMonitorSubtaskCompleteConcurrencyLimit,
@@ -1,16 +1,35 @@
 package servicectrl
 
 import (
+	"context"
+	"sync"
+
 	"github.com/golang/glog"
 
 	"github.com/danenmao/pterergate-dtf/dtf/dtfdef"
 	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/extconfig"
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
+	"github.com/danenmao/pterergate-dtf/dtf/tracing"
+	"github.com/danenmao/pterergate-dtf/internal/blobtool"
+	"github.com/danenmao/pterergate-dtf/internal/config"
 	"github.com/danenmao/pterergate-dtf/internal/exitctrl"
+	"github.com/danenmao/pterergate-dtf/internal/idtool"
+	"github.com/danenmao/pterergate-dtf/internal/mongotool"
+	"github.com/danenmao/pterergate-dtf/internal/mysqltool"
+	"github.com/danenmao/pterergate-dtf/internal/redistool"
+	"github.com/danenmao/pterergate-dtf/internal/resultstore"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
 )
 
+// 是否以单进程嵌入模式运行
+var gs_Embedded = false
+
 // start the specified service
 func StartService(role dtfdef.ServiceRole, cfg *dtfdef.ServiceConfig) error {
 
+	applyLogger(cfg)
+
 	// search service role start fn
 	starter, found := gs_ServiceRoleStarter[role]
 	if !found {
@@ -18,15 +37,195 @@ func StartService(role dtfdef.ServiceRole, cfg *dtfdef.ServiceConfig) error {
 		return errordef.ErrInvalidParameter
 	}
 
+	err := loadRuntimeConfig(cfg)
+	if err != nil {
+		glog.Warning("failed to load runtime config: ", err)
+		return err
+	}
+
 	// to process the exit signal
 	exitctrl.RegisterWithDuration(cfg.PrestopDuration)
 
+	applyKeyPrefix(cfg)
+	applyTraceExporter(cfg)
+	applyBlobStore(cfg)
+	startMetrics(cfg, role)
+
 	// invoke the start fn
-	starter(cfg)
+	err = starter(cfg)
+	if err != nil {
+		glog.Warning("failed to start service: ", gs_ServiceRoleName[role], ", ", err)
+		return err
+	}
 
+	startAdmin(cfg)
 	return nil
 }
 
+// connect to MySQL and Redis,
+// in embedded mode the in-memory stores are used instead
+func connectStores(cfg *dtfdef.ServiceConfig) {
+	if gs_Embedded {
+		return
+	}
+
+	config.DefaultMySQL = cfg.MySQLServer
+	mysqltool.ConnectToDefaultMySQL()
+
+	config.DefaultRedisServer = cfg.RedisServer
+	redistool.ConnectToDefaultRedis()
+
+	if cfg.RedisServer.ShardCount > 0 {
+		config.EnvSubtaskSetShardCount = cfg.RedisServer.ShardCount
+	}
+
+	applyResultStore(cfg)
+}
+
+var gs_ResultStoreOnce sync.Once
+
+// set the store of the subtask results, the results are not saved if no store is set.
+// the store is set only once when several roles start in one process
+func applyResultStore(cfg *dtfdef.ServiceConfig) {
+	gs_ResultStoreOnce.Do(func() {
+		switch cfg.ResultStore {
+		case dtfdef.ResultStore_MySQL:
+			resultstore.SetDefault(resultstore.NewSQLStore())
+
+		case dtfdef.ResultStore_MongoDB:
+			config.DefaultMongoDB = cfg.MongoServer
+			mongotool.InitDefaultMongoClient()
+
+			store := resultstore.NewMongoStore()
+			store.CreateIndexes(context.Background())
+			resultstore.SetDefault(store)
+		}
+	})
+}
+
+var gs_IdGeneratorOnce sync.Once
+var gs_IdGeneratorErr error
+
+// set how the task and subtask ids are generated, only once in a process
+func applyIdGenerator(cfg *dtfdef.ServiceConfig) error {
+	gs_IdGeneratorOnce.Do(func() {
+		switch cfg.IdGenerator {
+		case dtfdef.IdGenerator_MySQL:
+			idtool.SetDefault(idtool.NewSegmentGenerator(idtool.NewSQLSegmentSource()))
+
+		case dtfdef.IdGenerator_Snowflake:
+			generator, err := idtool.NewSnowflakeGenerator(cfg.SnowflakeNodeId)
+			if err != nil {
+				gs_IdGeneratorErr = err
+				return
+			}
+
+			idtool.SetDefault(generator)
+		}
+	})
+
+	return gs_IdGeneratorErr
+}
+
+// init the id generator of a key, and skip the ids already persisted,
+// so the ids do not collide after the redis data is lost
+func initIdGenerator(keyName string, getMaxId func(ctx context.Context) (uint64, error)) error {
+	err := idtool.Init(keyName)
+	if err != nil {
+		glog.Warning("failed to init id generator: ", keyName, ", ", err)
+		return err
+	}
+
+	ctx := context.Background()
+	maxId, err := getMaxId(ctx)
+	if err != nil {
+		glog.Warning("failed to get the max persisted id: ", keyName, ", ", err)
+		return err
+	}
+
+	err = idtool.EnsureIdAbove(ctx, keyName, maxId)
+	if err != nil {
+		glog.Warning("failed to ensure the id counter above ", maxId, ": ", keyName, ", ", err)
+		return err
+	}
+
+	glog.Info("the id counter is above the max persisted id: ", keyName, ", ", maxId)
+	return nil
+}
+
+// the default runtime config, taken before any config is loaded
+var gs_DefaultRuntimeConfig = config.GetRuntimeConfig()
+
+func DefaultRuntimeConfig() extconfig.RuntimeConfig {
+	return gs_DefaultRuntimeConfig
+}
+
+// load the runtime config from the options, the config file and environment variables,
+// then validate and apply it
+func loadRuntimeConfig(cfg *dtfdef.ServiceConfig) error {
+	runtimeCfg := baseRuntimeConfig(cfg)
+	err := config.LoadRuntimeConfig(cfg.ConfigFile, &runtimeCfg)
+	if err != nil {
+		return err
+	}
+
+	err = config.SetRuntimeConfig(&runtimeCfg)
+	if err != nil {
+		return err
+	}
+
+	config.DumpRuntimeConfig()
+	return nil
+}
+
+// the runtime config from the options, or the default one,
+// the values in the config file and environment variables override it
+func baseRuntimeConfig(cfg *dtfdef.ServiceConfig) extconfig.RuntimeConfig {
+	if cfg.RuntimeConfig != nil {
+		return *cfg.RuntimeConfig
+	}
+
+	return gs_DefaultRuntimeConfig
+}
+
+// add the namespace prefix to all keys,
+// the prefix is applied only once when several roles start in one process
+func applyKeyPrefix(cfg *dtfdef.ServiceConfig) {
+	if len(cfg.KeyPrefix) == 0 || len(config.KeyPrefix) > 0 {
+		return
+	}
+
+	config.KeyPrefix = cfg.KeyPrefix
+	statestore.SetDefault(statestore.NewPrefixStore(cfg.KeyPrefix, statestore.Default()))
+}
+
+// set the exporter of the spans, the spans are dropped if no exporter is set
+func applyTraceExporter(cfg *dtfdef.ServiceConfig) {
+	if cfg.TraceExporter == nil {
+		return
+	}
+
+	tracing.SetExporter(cfg.TraceExporter)
+}
+
+// set the store of the large subtask params and results, they are kept inline if no store is set
+func applyBlobStore(cfg *dtfdef.ServiceConfig) {
+	if cfg.BlobStore == nil {
+		return
+	}
+
+	blobtool.SetDefault(cfg.BlobStore, cfg.BlobThreshold)
+}
+
+// set the logger of the services, the logs are written to glog if no logger is set
+func applyLogger(cfg *dtfdef.ServiceConfig) {
+	if cfg.Logger == nil {
+		return
+	}
+
+	logging.SetLogger(cfg.Logger)
+}
+
 // notify to stop all routines
 func NotifyStop() error {
 	exitctrl.NotifyToExit()
@@ -0,0 +1,82 @@
+package admin
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/danenmao/pterergate-dtf/dtf/auth"
+	"github.com/danenmao/pterergate-dtf/internal/serverhelper"
+)
+
+// the client of the admin server, used by dtfctl
+type Client struct {
+	Address  string // address of the admin server, such as http://127.0.0.1:9200
+	UserName string // the user name in the authorization token
+	invoker  *serverhelper.SimpleInvoker
+}
+
+func NewClient(address string, userName string) *Client {
+	c := &Client{
+		Address:  strings.TrimSuffix(address, "/"),
+		UserName: userName,
+		invoker:  serverhelper.NewSimpleInvoker(),
+	}
+
+	c.invoker.SetAuthenticator(auth.NewJWTAuthenticator(auth.Audience_Admin))
+	return c
+}
+
+func (c *Client) ListTasks(req *ListTasksRequest, rsp *ListTasksResponse) error {
+	return c.call(URI_ListTasks, req, rsp)
+}
+
+func (c *Client) GetTask(req *TaskRequest, rsp *GetTaskResponse) error {
+	return c.call(URI_GetTask, req, rsp)
+}
+
+func (c *Client) ListSubtasks(req *ListSubtasksRequest, rsp *ListSubtasksResponse) error {
+	return c.call(URI_ListSubtasks, req, rsp)
+}
+
+func (c *Client) CancelTask(req *TaskRequest) error {
+	return c.call(URI_CancelTask, req, &TaskOperationResponse{})
+}
+
+func (c *Client) PauseTask(req *TaskRequest) error {
+	return c.call(URI_PauseTask, req, &TaskOperationResponse{})
+}
+
+func (c *Client) ResumeTask(req *TaskRequest) error {
+	return c.call(URI_ResumeTask, req, &TaskOperationResponse{})
+}
+
+func (c *Client) RequeueSubtasks(req *RequeueSubtasksRequest, rsp *RequeueSubtasksResponse) error {
+	return c.call(URI_RequeueSubtasks, req, rsp)
+}
+
+func (c *Client) ListQueues(req *ListQueuesRequest, rsp *ListQueuesResponse) error {
+	return c.call(URI_ListQueues, req, rsp)
+}
+
+func (c *Client) ListRetryQueue(req *ListRetryQueueRequest, rsp *ListRetryQueueResponse) error {
+	return c.call(URI_ListRetryQueue, req, rsp)
+}
+
+func (c *Client) ListTaskTypes(rsp *ListTaskTypesResponse) error {
+	return c.call(URI_ListTaskTypes, &ListTaskTypesRequest{}, rsp)
+}
+
+// post the request to the uri, and parse the response body
+func (c *Client) call(uri string, req interface{}, rsp interface{}) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	rspBody, err := c.invoker.Post(c.Address+uri, c.UserName, string(data))
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal([]byte(rspBody), rsp)
+}
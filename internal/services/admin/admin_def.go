@@ -0,0 +1,176 @@
+package admin
+
+import (
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/internal/dbdef"
+	"github.com/danenmao/pterergate-dtf/internal/taskframework/tasklogic/schedulerlogic/executorconnector"
+)
+
+// 管理接口的URI
+const (
+	URI_ListTasks       = "/admin/task/list"
+	URI_GetTask         = "/admin/task/get"
+	URI_ListSubtasks    = "/admin/task/subtasks"
+	URI_CancelTask      = "/admin/task/cancel"
+	URI_PauseTask       = "/admin/task/pause"
+	URI_ResumeTask      = "/admin/task/resume"
+	URI_RequeueSubtasks = "/admin/subtask/requeue"
+	URI_ListQueues      = "/admin/queue/list"
+	URI_ListRetryQueue  = "/admin/retry/list"
+	URI_ListTaskTypes   = "/admin/tasktype/list"
+)
+
+// 列表查询默认和最大的返回数量
+const (
+	DefaultListCount = 20
+	MaxListCount     = 1000
+)
+
+// 查询任务列表的请求, 零值的条件不生效
+type ListTasksRequest struct {
+	TaskStatus taskmodel.TaskStatusType `json:"task_status"` // 任务的状态
+	TaskType   uint32                   `json:"task_type"`   // 任务类型
+	Creator    string                   `json:"creator"`     // 创建者的账号
+	Limit      int                      `json:"limit"`       // 返回的最大记录数
+}
+
+type ListTasksResponse struct {
+	Tasks []dbdef.DBTaskRecord `json:"tasks"`
+}
+
+// 操作单个任务的请求
+type TaskRequest struct {
+	TaskId taskmodel.TaskIdType `json:"task_id"`
+}
+
+type GetTaskResponse struct {
+	Task taskmodel.TaskStatusData `json:"task"`
+}
+
+// 查询任务下未完成的子任务的请求
+type ListSubtasksRequest struct {
+	TaskId taskmodel.TaskIdType `json:"task_id"`
+	Offset int64                `json:"offset"`
+	Count  int64                `json:"count"`
+}
+
+// 子任务的运行状态
+type SubtaskState struct {
+	SubtaskId    taskmodel.SubtaskIdType `json:"subtask_id"`    // 子任务ID
+	Status       uint32                  `json:"status"`        // 子任务的运行状态
+	StartTime    uint64                  `json:"start_time"`    // 开始执行的时间戳
+	EndTime      uint64                  `json:"end_time"`      // 结束执行的时间戳
+	CompleteCode uint32                  `json:"complete_code"` // 子任务的完成码
+}
+
+type ListSubtasksResponse struct {
+	Total    int64          `json:"total"` // 任务下未完成的子任务数
+	Subtasks []SubtaskState `json:"subtasks"`
+}
+
+type TaskOperationResponse struct {
+}
+
+// 将卡住的子任务重新推送给执行器的请求
+type RequeueSubtasksRequest struct {
+	SubtaskIds []taskmodel.SubtaskIdType `json:"subtask_ids"`
+}
+
+type RequeueSubtasksResponse struct {
+	Requeued []taskmodel.SubtaskIdType `json:"requeued"` // 已重新推送的子任务
+	Skipped  []taskmodel.SubtaskIdType `json:"skipped"`  // 不在运行中或信息不存在的子任务
+}
+
+// 查询资源组和调度队列的请求
+type ListQueuesRequest struct {
+	GroupName string `json:"group_name"` // 资源组名, 为空时返回所有资源组
+	Count     int64  `json:"count"`      // 每个队列返回的最大任务数
+}
+
+// 调度队列的内容
+type QueueState struct {
+	Name    string                 `json:"name"`     // 队列名, 如P0, RR
+	KeyName string                 `json:"key_name"` // 队列的Key名
+	Length  int64                  `json:"length"`   // 队列中的任务数
+	TaskIds []taskmodel.TaskIdType `json:"task_ids"` // 队首的任务
+}
+
+// 资源组的状态
+type QuotaGroupState struct {
+	Name   string       `json:"name"`
+	Quota  float32      `json:"quota"`
+	Queues []QueueState `json:"queues"`
+}
+
+type ListQueuesResponse struct {
+	Groups []QuotaGroupState `json:"groups"`
+}
+
+// 查询重试队列的请求
+type ListRetryQueueRequest struct {
+	Count int64 `json:"count"`
+}
+
+type ListRetryQueueResponse struct {
+	Length   int64                                `json:"length"`
+	Subtasks []executorconnector.RetrySubtaskData `json:"subtasks"`
+}
+
+type ListTaskTypesRequest struct {
+}
+
+// 任务类型的注册信息
+type TaskTypeInfo struct {
+	TaskType    uint32 `json:"task_type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type ListTaskTypesResponse struct {
+	TaskTypes []TaskTypeInfo `json:"task_types"`
+}
+
+// 任务状态的名称, 用于命令行参数和输出
+var gs_TaskStatusName = map[taskmodel.TaskStatusType]string{
+	taskmodel.TaskStatus_Created:     "created",
+	taskmodel.TaskStatus_Running:     "running",
+	taskmodel.TaskStatus_Paused:      "paused",
+	taskmodel.TaskStatus_Cacelled:    "cancelled",
+	taskmodel.TaskStatus_Completed:   "completed",
+	taskmodel.TaskStatus_Exceptional: "exceptional",
+}
+
+// 获取任务状态的名称
+func TaskStatusName(status taskmodel.TaskStatusType) string {
+	name, ok := gs_TaskStatusName[status]
+	if !ok {
+		return "unknown"
+	}
+
+	return name
+}
+
+// 根据名称获取任务状态
+func ParseTaskStatus(name string) (taskmodel.TaskStatusType, error) {
+	for status, statusName := range gs_TaskStatusName {
+		if statusName == name {
+			return status, nil
+		}
+	}
+
+	return 0, errordef.ErrInvalidParameter
+}
+
+// 列表查询的返回数量, 未指定时使用默认值, 超出上限时使用上限
+func listCount(count int64) int64 {
+	if count <= 0 {
+		return DefaultListCount
+	}
+
+	if count > MaxListCount {
+		return MaxListCount
+	}
+
+	return count
+}
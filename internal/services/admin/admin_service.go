@@ -0,0 +1,68 @@
+package admin
+
+import (
+	"encoding/json"
+
+	"github.com/danenmao/pterergate-dtf/dtf/auth"
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
+	"github.com/danenmao/pterergate-dtf/internal/serverhelper"
+)
+
+// logger of the admin service
+var gs_Logger = logging.With(logging.Role("admin"))
+
+// create the admin server, the requests are authorized as the other simple servers.
+// the handlers read and modify the shared state directly, so the server can run with any role
+func NewAdminServer(port uint16) *serverhelper.SimpleServer {
+	server := serverhelper.NewSimpleServer(port, GetHandlerMap())
+	server.SetAuthenticator(auth.NewJWTAuthenticator(auth.Audience_Admin))
+	return server
+}
+
+// get the handlers of the admin URIs
+func GetHandlerMap() map[string]serverhelper.RequestHandler {
+	return map[string]serverhelper.RequestHandler{
+		URI_ListTasks:       handle(ListTasks),
+		URI_GetTask:         handle(GetTask),
+		URI_ListSubtasks:    handle(ListSubtasks),
+		URI_CancelTask:      handle(CancelTask),
+		URI_PauseTask:       handle(PauseTask),
+		URI_ResumeTask:      handle(ResumeTask),
+		URI_RequeueSubtasks: handle(RequeueSubtasks),
+		URI_ListQueues:      handle(ListQueues),
+		URI_ListRetryQueue:  handle(ListRetryQueue),
+		URI_ListTaskTypes:   handle(ListTaskTypes),
+	}
+}
+
+// wrap a typed handler as a request handler,
+// parse the request body and serialize the response body
+func handle[Req any, Rsp any](fn func(req *Req, rsp *Rsp) error) serverhelper.RequestHandler {
+	return func(header serverhelper.RequestHeader, requestBody string) (string, error) {
+		req := new(Req)
+		if len(requestBody) > 0 {
+			err := json.Unmarshal([]byte(requestBody), req)
+			if err != nil {
+				gs_Logger.Warning("failed to parse request body",
+					logging.RequestId(header.RequestId), logging.Err(err))
+				return "", errordef.ErrInvalidParameter
+			}
+		}
+
+		rsp := new(Rsp)
+		err := fn(req, rsp)
+		if err != nil {
+			gs_Logger.Warning("failed to handle admin request",
+				logging.RequestId(header.RequestId), logging.Any("request", req), logging.Err(err))
+			return "", err
+		}
+
+		data, err := json.Marshal(rsp)
+		if err != nil {
+			return "", err
+		}
+
+		return string(data), nil
+	}
+}
@@ -0,0 +1,163 @@
+package admin
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/internal/config"
+	"github.com/danenmao/pterergate-dtf/internal/dbdef"
+	"github.com/danenmao/pterergate-dtf/internal/recordstore"
+	"github.com/danenmao/pterergate-dtf/internal/serverhelper"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
+	"github.com/danenmao/pterergate-dtf/internal/taskframework/tasklogic/generationqueue"
+	"github.com/danenmao/pterergate-dtf/internal/taskframework/tasklogic/schedulerlogic/schedulingqueue"
+	"github.com/danenmao/pterergate-dtf/internal/tasktool"
+)
+
+func setupStores() func() {
+	statestore.SetDefault(statestore.NewMemoryStore())
+	recordstore.SetDefault(recordstore.NewMemoryRecordStore())
+	return func() {
+		statestore.SetDefault(statestore.NewRedisStore())
+		recordstore.SetDefault(recordstore.NewSQLRecordStore())
+	}
+}
+
+func addTask(taskId taskmodel.TaskIdType, creator string) {
+	param := taskmodel.TaskParam{
+		Creator:  taskmodel.TaskCreator{UID: 1, Name: creator},
+		TaskType: 7,
+	}
+
+	tasktool.CreateTaskInfoKey(taskId, &param)
+	recordstore.Default().AddTaskRecord(context.Background(), &dbdef.DBTaskRecord{
+		Id:         uint64(taskId),
+		Creator:    creator,
+		TaskType:   7,
+		TaskStatus: uint8(taskmodel.TaskStatus_Running),
+	})
+}
+
+func addRunningSubtask(taskId taskmodel.TaskIdType, subtaskId taskmodel.SubtaskIdType) {
+	subtask := taskmodel.SubtaskBody{SubtaskId: subtaskId, TaskId: taskId, TaskType: 7, TypeParam: "{}", Timeout: 3600}
+	tasktool.CreateSubtaskInfoKey(uint64(subtaskId), &subtask)
+	tasktool.AddSubtaskToTask(taskId, uint64(subtaskId))
+	tasktool.AddSubtaskToRunningList(&[]taskmodel.SubtaskBody{subtask})
+}
+
+func Test_TaskControl(t *testing.T) {
+	defer setupStores()()
+	addTask(1, "alice")
+	addTask(2, "bob")
+
+	Convey("pause, resume and cancel a task", t, func() {
+		req := TaskRequest{TaskId: 1}
+		So(PauseTask(&req, &TaskOperationResponse{}), ShouldBeNil)
+		So(PauseTask(&req, &TaskOperationResponse{}), ShouldEqual, errordef.ErrInvalidStatus)
+
+		rsp := GetTaskResponse{}
+		So(GetTask(&req, &rsp), ShouldBeNil)
+		So(rsp.Task.TaskStatus, ShouldEqual, taskmodel.TaskStatus_Paused)
+		So(rsp.Task.TaskType, ShouldEqual, 7)
+
+		So(ResumeTask(&req, &TaskOperationResponse{}), ShouldBeNil)
+		So(ResumeTask(&req, &TaskOperationResponse{}), ShouldEqual, errordef.ErrInvalidStatus)
+
+		queue := generationqueue.GenerationQueue{TaskId: 1}
+		queue.Push(&taskmodel.SubtaskBody{TaskId: 1, SubtaskId: 10})
+		So(CancelTask(&req, &TaskOperationResponse{}), ShouldBeNil)
+		So(tasktool.IsTaskCancelled(1), ShouldBeTrue)
+
+		count, _ := generationqueue.GetSubtaskCount(1)
+		So(count, ShouldEqual, 0)
+		So(ResumeTask(&req, &TaskOperationResponse{}), ShouldEqual, errordef.ErrInvalidStatus)
+	})
+
+	Convey("list tasks by status and creator", t, func() {
+		rsp := ListTasksResponse{}
+		So(ListTasks(&ListTasksRequest{TaskStatus: taskmodel.TaskStatus_Cacelled}, &rsp), ShouldBeNil)
+		So(len(rsp.Tasks), ShouldEqual, 1)
+		So(rsp.Tasks[0].Id, ShouldEqual, 1)
+
+		rsp = ListTasksResponse{}
+		So(ListTasks(&ListTasksRequest{Creator: "bob"}, &rsp), ShouldBeNil)
+		So(len(rsp.Tasks), ShouldEqual, 1)
+		So(rsp.Tasks[0].Id, ShouldEqual, 2)
+
+		rsp = ListTasksResponse{}
+		So(ListTasks(&ListTasksRequest{TaskType: 7}, &rsp), ShouldBeNil)
+		So(len(rsp.Tasks), ShouldEqual, 2)
+	})
+}
+
+func Test_Subtasks(t *testing.T) {
+	defer setupStores()()
+	addTask(1, "alice")
+	addRunningSubtask(1, 100)
+	addRunningSubtask(1, 101)
+
+	Convey("list the subtasks of a task", t, func() {
+		rsp := ListSubtasksResponse{}
+		So(ListSubtasks(&ListSubtasksRequest{TaskId: 1}, &rsp), ShouldBeNil)
+		So(rsp.Total, ShouldEqual, 2)
+		So(len(rsp.Subtasks), ShouldEqual, 2)
+		So(rsp.Subtasks[0].Status, ShouldEqual, taskmodel.SubtaskStatus_Running)
+	})
+
+	Convey("requeue running subtasks", t, func() {
+		// 101已被收集器移出执行中的子任务列表, 状态尚未更新
+		runningKey := tasktool.GetShardKey(config.RunningSubtaskZset, 101)
+		statestore.Default().ZRem(context.Background(), runningKey, uint64(101))
+
+		rsp := RequeueSubtasksResponse{}
+		req := RequeueSubtasksRequest{SubtaskIds: []taskmodel.SubtaskIdType{100, 101, 999}}
+		So(RequeueSubtasks(&req, &rsp), ShouldBeNil)
+		So(rsp.Requeued, ShouldResemble, []taskmodel.SubtaskIdType{100})
+		So(rsp.Skipped, ShouldResemble, []taskmodel.SubtaskIdType{101, 999})
+
+		running, _ := tasktool.IsSubtaskInRunningList(101)
+		So(running, ShouldBeFalse)
+
+		retryRsp := ListRetryQueueResponse{}
+		So(ListRetryQueue(&ListRetryQueueRequest{}, &retryRsp), ShouldBeNil)
+		So(retryRsp.Length, ShouldEqual, 1)
+		So(retryRsp.Subtasks[0].SubtaskId, ShouldEqual, 100)
+		So(retryRsp.Subtasks[0].TypeParam, ShouldEqual, "{}")
+		So(retryRsp.Subtasks[0].Timeout, ShouldEqual, 3600)
+	})
+}
+
+func Test_ListQueues(t *testing.T) {
+	defer setupStores()()
+	statestore.Default().RPush(context.Background(), schedulingqueue.PriorityQueueKeyName("2", 0), 5, 6)
+
+	Convey("list the scheduling queues of a group", t, func() {
+		rsp := ListQueuesResponse{}
+		So(ListQueues(&ListQueuesRequest{GroupName: "2", Count: 1}, &rsp), ShouldBeNil)
+		So(len(rsp.Groups), ShouldEqual, 1)
+		So(len(rsp.Groups[0].Queues), ShouldEqual, schedulingqueue.PrioirtyQueueCount+1)
+
+		queue := rsp.Groups[0].Queues[0]
+		So(queue.Name, ShouldEqual, "P0")
+		So(queue.Length, ShouldEqual, 2)
+		So(queue.TaskIds, ShouldResemble, []taskmodel.TaskIdType{5})
+	})
+}
+
+func Test_Handle(t *testing.T) {
+	defer setupStores()()
+	handler := GetHandlerMap()[URI_ListTaskTypes]
+
+	Convey("parse the request body", t, func() {
+		body, err := handler(serverhelper.RequestHeader{RequestId: "test"}, "")
+		So(err, ShouldBeNil)
+		So(body, ShouldEqual, `{"task_types":[]}`)
+
+		_, err = handler(serverhelper.RequestHeader{RequestId: "test"}, "{")
+		So(err, ShouldEqual, errordef.ErrInvalidParameter)
+	})
+}
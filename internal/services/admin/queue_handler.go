@@ -0,0 +1,119 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/dtf/taskplugin"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
+	"github.com/danenmao/pterergate-dtf/internal/taskframework/taskloader"
+	"github.com/danenmao/pterergate-dtf/internal/taskframework/tasklogic/schedulerlogic/executorconnector"
+	"github.com/danenmao/pterergate-dtf/internal/taskframework/tasklogic/schedulerlogic/quotagroup"
+	"github.com/danenmao/pterergate-dtf/internal/taskframework/tasklogic/schedulerlogic/schedulingqueue"
+)
+
+// 查询资源组及其调度队列中的任务
+// 资源组的记录和调度队列的Key名是确定的, 不要求在调度器进程中查询
+func ListQueues(req *ListQueuesRequest, rsp *ListQueuesResponse) error {
+	records := []quotagroup.QuotaGroupRecord{}
+	err := quotagroup.ListQuotaGroupRecords(&records)
+	if err != nil {
+		return err
+	}
+
+	rsp.Groups = []QuotaGroupState{}
+	for _, record := range records {
+		if len(req.GroupName) > 0 && record.Name != req.GroupName {
+			continue
+		}
+
+		group := QuotaGroupState{
+			Name:   record.Name,
+			Quota:  record.Quota,
+			Queues: []QueueState{},
+		}
+
+		for _, queue := range teamQueues(record.Name) {
+			state := QueueState{
+				Name:    queue.Name(),
+				KeyName: queue.QueueKeyName,
+				TaskIds: []taskmodel.TaskIdType{},
+			}
+
+			state.Length, err = queue.Length()
+			if err != nil {
+				return err
+			}
+
+			err = queue.ListTasks(listCount(req.Count), &state.TaskIds)
+			if err != nil {
+				return err
+			}
+
+			group.Queues = append(group.Queues, state)
+		}
+
+		rsp.Groups = append(rsp.Groups, group)
+	}
+
+	return nil
+}
+
+// 资源组的调度队列, 仅用于读取队列的内容
+func teamQueues(teamName string) []*schedulingqueue.SchedulingQueue {
+	queues := []*schedulingqueue.SchedulingQueue{}
+	for i := uint32(0); i < schedulingqueue.PrioirtyQueueCount; i++ {
+		queues = append(queues, schedulingqueue.NewPriorityQueue(teamName,
+			schedulingqueue.PriorityQueueKeyName(teamName, i), i))
+	}
+
+	return append(queues, schedulingqueue.NewRRQueue(teamName, schedulingqueue.RRQueueKeyName(teamName)))
+}
+
+// 查询推送执行器失败, 等待重试的子任务
+func ListRetryQueue(req *ListRetryQueueRequest, rsp *ListRetryQueueResponse) error {
+	keyName := executorconnector.RedisRetryToPushExecutorQueue
+	length, err := statestore.Default().LLen(context.Background(), keyName)
+	if err != nil {
+		return err
+	}
+
+	vals, err := statestore.Default().LRange(context.Background(), keyName, 0, listCount(req.Count)-1)
+	if err != nil {
+		return err
+	}
+
+	rsp.Length = length
+	rsp.Subtasks = []executorconnector.RetrySubtaskData{}
+	for _, val := range vals {
+		retryData := executorconnector.RetrySubtaskData{}
+		err = json.Unmarshal([]byte(val), &retryData)
+		if err != nil {
+			gs_Logger.Warning("failed to unmarshal retry subtask", logging.Any("data", val), logging.Err(err))
+			continue
+		}
+
+		rsp.Subtasks = append(rsp.Subtasks, retryData)
+	}
+
+	return nil
+}
+
+// 查询本进程中注册的任务类型
+func ListTaskTypes(req *ListTaskTypesRequest, rsp *ListTaskTypesResponse) error {
+	registrations := []taskplugin.TaskPluginRegistration{}
+	taskloader.ListTaskTypes(&registrations)
+
+	rsp.TaskTypes = []TaskTypeInfo{}
+	for _, registration := range registrations {
+		rsp.TaskTypes = append(rsp.TaskTypes, TaskTypeInfo{
+			TaskType:    registration.TaskType,
+			Name:        registration.Name,
+			Description: registration.Description,
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package admin
+
+import (
+	"context"
+	"time"
+
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/internal/config"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
+	"github.com/danenmao/pterergate-dtf/internal/subtasktool"
+	"github.com/danenmao/pterergate-dtf/internal/taskframework/tasklogic/schedulerlogic/executorconnector"
+	"github.com/danenmao/pterergate-dtf/internal/tasktool"
+)
+
+// 将卡住的子任务重新推送给执行器
+// 子任务及其任务需处于运行状态. 子任务的超时时间被重新计算,
+// 并放入执行器的重试队列, 由调度器的重试例程推送.
+// 超时时间只在子任务仍在执行中的子任务列表中时修改, 期间已完成的子任务被跳过
+func RequeueSubtasks(req *RequeueSubtasksRequest, rsp *RequeueSubtasksResponse) error {
+	rsp.Requeued = []taskmodel.SubtaskIdType{}
+	rsp.Skipped = []taskmodel.SubtaskIdType{}
+
+	subtasks := []taskmodel.SubtaskBody{}
+	for _, subtaskId := range req.SubtaskIds {
+		subtask := taskmodel.SubtaskBody{}
+		if !readRunningSubtask(subtaskId, &subtask) {
+			rsp.Skipped = append(rsp.Skipped, subtaskId)
+			continue
+		}
+
+		deadline := time.Now().Add(time.Duration(subtask.Timeout) * time.Second)
+		updated, err := tasktool.SetSubtaskRunningDeadline(subtaskId, deadline)
+		if err != nil {
+			return err
+		}
+
+		if !updated {
+			rsp.Skipped = append(rsp.Skipped, subtaskId)
+			continue
+		}
+
+		subtasks = append(subtasks, subtask)
+		rsp.Requeued = append(rsp.Requeued, subtaskId)
+	}
+
+	if len(subtasks) == 0 {
+		return nil
+	}
+
+	err := tasktool.IncrSubtaskAttemptCount(&subtasks)
+	if err != nil {
+		return err
+	}
+
+	err = executorconnector.AddSubtasksToRetryQueue(&subtasks)
+	if err != nil {
+		return err
+	}
+
+	gs_Logger.Info("succeeded to requeue subtasks",
+		logging.Any("requeued", rsp.Requeued), logging.Any("skipped", rsp.Skipped))
+	return nil
+}
+
+// 读取运行中的子任务, 重建子任务的参数体
+func readRunningSubtask(subtaskId taskmodel.SubtaskIdType, subtask *taskmodel.SubtaskBody) bool {
+	if !subtasktool.IsSubtaskRunning(subtaskId) {
+		return false
+	}
+
+	infos, err := statestore.Default().HGetAll(context.Background(), tasktool.GetSubtaskKey(uint64(subtaskId)))
+	if err != nil || len(infos) == 0 {
+		return false
+	}
+
+	taskId := taskmodel.TaskIdType(parseUint(infos[config.SubtaskInfo_TaskIdField]))
+	if !tasktool.IsTaskRunning(taskId) {
+		return false
+	}
+
+	// 使用子任务创建时的超时值
+	timeout := uint32(parseUint(infos[config.SubtaskInfo_TimeoutField]))
+	if timeout == 0 {
+		timeout = tasktool.DefaultSubtaskTimeout
+	}
+
+	traceParent, _ := tasktool.GetTaskTraceParent(taskId)
+	*subtask = taskmodel.SubtaskBody{
+		SubtaskId:   subtaskId,
+		TaskId:      taskId,
+		TaskType:    uint32(parseUint(infos[config.SubtaskInfo_TaskTypeField])),
+		Timeout:     timeout,
+		TypeParam:   infos[config.SubtaskInfo_Param],
+		CreatedAt:   time.Now(),
+		TraceParent: traceParent,
+	}
+
+	return true
+}
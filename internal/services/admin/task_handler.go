@@ -0,0 +1,124 @@
+package admin
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/internal/config"
+	"github.com/danenmao/pterergate-dtf/internal/recordstore"
+	"github.com/danenmao/pterergate-dtf/internal/services/taskmgmt"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
+	"github.com/danenmao/pterergate-dtf/internal/tasktool"
+)
+
+// 按状态、类型或创建者查询任务记录
+func ListTasks(req *ListTasksRequest, rsp *ListTasksResponse) error {
+	filter := recordstore.TaskRecordFilter{
+		TaskStatus: uint8(req.TaskStatus),
+		TaskType:   req.TaskType,
+		Creator:    req.Creator,
+		Limit:      int(listCount(int64(req.Limit))),
+	}
+
+	return recordstore.Default().ListTaskRecords(context.Background(), &filter, &rsp.Tasks)
+}
+
+// 查询任务的运行状态
+func GetTask(req *TaskRequest, rsp *GetTaskResponse) error {
+	return taskmgmt.GetTaskStatus(req.TaskId, &rsp.Task)
+}
+
+// 查询任务下未完成的子任务及其状态
+func ListSubtasks(req *ListSubtasksRequest, rsp *ListSubtasksResponse) error {
+	if req.TaskId == 0 {
+		return errordef.ErrInvalidParameter
+	}
+
+	keyName := tasktool.GetTaskSubtaskListKey(req.TaskId)
+	total, err := statestore.Default().ZCard(context.Background(), keyName)
+	if err != nil {
+		return err
+	}
+
+	opt := statestore.RangeBy{
+		Min: "-inf", Max: "+inf",
+		Offset: req.Offset, Count: listCount(req.Count),
+	}
+
+	idList, err := statestore.Default().ZRangeByScore(context.Background(), keyName, &opt)
+	if err != nil {
+		return err
+	}
+
+	rsp.Total = total
+	rsp.Subtasks = []SubtaskState{}
+	for _, idStr := range idList {
+		subtaskId, err := strconv.ParseUint(idStr, 10, 64)
+		if err != nil {
+			gs_Logger.Warning("failed to convert subtask id", logging.Any("id", idStr))
+			continue
+		}
+
+		state := SubtaskState{}
+		err = readSubtaskState(subtaskId, &state)
+		if err != nil {
+			gs_Logger.Warning("failed to read subtask state", logging.SubtaskId(subtaskId), logging.Err(err))
+			continue
+		}
+
+		rsp.Subtasks = append(rsp.Subtasks, state)
+	}
+
+	return nil
+}
+
+// 取消任务
+func CancelTask(req *TaskRequest, rsp *TaskOperationResponse) error {
+	gs_Logger.Info("to cancel task", logging.TaskId(req.TaskId))
+	return taskmgmt.CancelTask(req.TaskId)
+}
+
+// 暂停任务
+func PauseTask(req *TaskRequest, rsp *TaskOperationResponse) error {
+	gs_Logger.Info("to pause task", logging.TaskId(req.TaskId))
+	return taskmgmt.PauseTask(req.TaskId)
+}
+
+// 恢复任务
+func ResumeTask(req *TaskRequest, rsp *TaskOperationResponse) error {
+	gs_Logger.Info("to resume task", logging.TaskId(req.TaskId))
+	return taskmgmt.ResumeTask(req.TaskId)
+}
+
+// 从subtask info key中读取子任务的状态
+func readSubtaskState(subtaskId uint64, state *SubtaskState) error {
+	infos, err := statestore.Default().HGetAll(context.Background(), tasktool.GetSubtaskKey(subtaskId))
+	if err != nil {
+		return err
+	}
+
+	// 子任务还未分发时, 没有subtask info key
+	state.SubtaskId = taskmodel.SubtaskIdType(subtaskId)
+	if len(infos) == 0 {
+		return nil
+	}
+
+	state.Status = uint32(parseUint(infos[config.SubtaskInfo_StatusField]))
+	state.StartTime = parseUint(infos[config.SubtaskInfo_StartTimeField])
+	state.EndTime = parseUint(infos[config.SubtaskInfo_EndTimeField])
+	state.CompleteCode = uint32(parseUint(infos[config.SubtaskInfo_Complete_code]))
+	return nil
+}
+
+// 转换字段中的无符号整数, 格式错误时返回0
+func parseUint(str string) uint64 {
+	val, err := strconv.ParseUint(str, 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return val
+}
@@ -1,6 +1,12 @@
 package collector
 
-import "github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+import (
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+)
+
+// logger of the collector service
+var gs_Logger = logging.With(logging.Role("collector"))
 
 // handle collector requests
 func CollectorRequestHandler(results []taskmodel.SubtaskResult) error {
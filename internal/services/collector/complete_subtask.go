@@ -5,14 +5,14 @@ import (
 	"encoding/json"
 	"time"
 
-	"github.com/go-redis/redis/v8"
-	"github.com/golang/glog"
-
 	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/dtf/tracing"
+	"github.com/danenmao/pterergate-dtf/internal/blobtool"
 	"github.com/danenmao/pterergate-dtf/internal/config"
-	"github.com/danenmao/pterergate-dtf/internal/redistool"
 	"github.com/danenmao/pterergate-dtf/internal/routine"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
 	"github.com/danenmao/pterergate-dtf/internal/subtasktool"
 	"github.com/danenmao/pterergate-dtf/internal/taskframework/tasklogic/collectorlogic"
 	"github.com/danenmao/pterergate-dtf/internal/tasktool"
@@ -21,7 +21,7 @@ import (
 const (
 	// complete subtask routine limit
 	SubtaskRoutineCountDefaultLimit = 300
-	SubtaskRoutineLimitEnvName      = "SUBTASK_ROUTINE_LIMIT"
+	SubtaskRoutineLimitEnvName      = config.SubtaskRoutineLimitEnvName
 )
 
 var (
@@ -31,6 +31,11 @@ var (
 	}
 )
 
+// set the complete subtask routine limit, should be called before the routines start
+func SetRoutineLimit(limit uint) {
+	gs_RoutineLimit.UpperLimit = uint32(limit)
+}
+
 // to complete subtask
 func CompleteSubtaskRoutine() {
 
@@ -79,27 +84,30 @@ func completeSubtask(list []*SubtaskElem) {
 	// stat the time cost
 	startTime := time.Now()
 	defer func() {
-		cost := time.Since(startTime)
-		glog.Info("processing subtasks costs: ", cost)
+		gs_Logger.Info("processed subtasks",
+			logging.Any("count", len(list)), logging.Any("cost", time.Since(startTime)))
 	}()
 
 	// to complete the subtasks
 	err := doCompleteSubtask(list)
 	if err != nil {
 		// if failed, push them back to the list
-		glog.Warning("re-insert elements into the list: ", err, len(list))
+		gs_Logger.Warning("re-insert elements into the list",
+			logging.Any("count", len(list)), logging.Err(err))
 		InsertSubtaskList(&list)
 	}
 }
 
 func doCompleteSubtask(elems []*SubtaskElem) error {
 
-	var zlist = []*redis.Z{}
+	var zmap = map[string][]statestore.Z{}
+	var idMap = map[string][]interface{}{}
 	var idList = []interface{}{}
-	pipeline := redistool.DefaultRedis().Pipeline()
+	var results = []*taskmodel.SubtaskResult{}
+	var retryElems = []*SubtaskElem{}
+	batch := statestore.Default().Batch()
 	endTime := time.Now().Unix()
 
-	glog.Info("subtask count to process: ", len(elems))
 	for _, elem := range elems {
 
 		if elem == nil {
@@ -107,16 +115,26 @@ func doCompleteSubtask(elems []*SubtaskElem) error {
 		}
 
 		if elem.Result == nil {
-			glog.Error("invalid body pointer")
+			gs_Logger.Error("invalid body pointer")
 			continue
 		}
 
 		result := elem.Result
-		glog.Info("begin to process subtask: ", result.SubtaskId, result.TaskId)
+		gs_Logger.Debug("begin to process subtask",
+			logging.TaskId(result.TaskId), logging.SubtaskId(result.SubtaskId))
+
+		span := tracing.StartSpan(result.TraceParent, tracing.SpanName_CollectResult)
+		span.SetAttribute("task_id", result.TaskId)
+		span.SetAttribute("subtask_id", result.SubtaskId)
 
 		subtaskCompleted := false
-		err := processSubtaskResult(result, pipeline, &subtaskCompleted)
+		err := processSubtaskResult(result, batch, &subtaskCompleted)
+		span.SetAttribute("completed", subtaskCompleted)
+		span.SetError(err)
+		span.End()
 		if err != errordef.ErrNotFound && err != nil {
+			// the result is not acked, process it again later
+			retryElems = append(retryElems, elem)
 			continue
 		}
 
@@ -125,40 +143,54 @@ func doCompleteSubtask(elems []*SubtaskElem) error {
 			continue
 		}
 
-		z := redis.Z{
+		z := statestore.Z{
 			Score:  float64(endTime),
 			Member: result.SubtaskId,
 		}
 
-		zlist = append(zlist, &z)
+		runningKey := tasktool.GetShardKey(config.RunningSubtaskZset, uint64(result.SubtaskId))
+		completedKey := tasktool.GetShardKey(config.CompletedSubtaskList, uint64(result.SubtaskId))
+		zmap[completedKey] = append(zmap[completedKey], z)
+		idMap[runningKey] = append(idMap[runningKey], result.SubtaskId)
 		idList = append(idList, result.SubtaskId)
-		glog.Infof("processed completed subtask: ", result.SubtaskId)
+		results = append(results, result)
+		gs_Logger.Debug("processed completed subtask",
+			logging.TaskId(result.TaskId), logging.SubtaskId(result.SubtaskId))
 	} // for
 
 	// remove from running subtask list
-	if len(idList) > 0 {
-		pipeline.ZRem(context.Background(), config.RunningSubtaskZset, idList...)
+	for keyName, ids := range idMap {
+		batch.ZRem(keyName, ids...)
 	}
 
 	// insert to completed subtask list
-	if len(zlist) > 0 {
-		pipeline.ZAdd(context.Background(), config.CompletedSubtaskList, zlist...)
+	for keyName, zlist := range zmap {
+		batch.ZAdd(keyName, zlist...)
 	}
 
-	// exec pipeline
-	_, err := pipeline.Exec(context.Background())
+	// exec batch
+	err := batch.Exec(context.Background())
 	if err != nil {
-		glog.Warning("failed to add subtask to redis: ", err, len(elems))
+		gs_Logger.Warning("failed to add subtask to store",
+			logging.Any("count", len(elems)), logging.Err(err))
 		return err
 	}
 
-	glog.Info("succeed to process completed subtask: ", idList)
+	// the results are kept after the subtask info key expires
+	subtasktool.SaveSubtaskResults(results)
+
+	if len(retryElems) > 0 {
+		gs_Logger.Warning("re-insert the failed results into the list", logging.Any("count", len(retryElems)))
+		InsertSubtaskList(&retryElems)
+	}
+
+	gs_Logger.Debug("succeed to process completed subtasks", logging.Any("subtask_ids", idList))
 	return nil
 }
 
 func processSubtaskResult(
 	result *taskmodel.SubtaskResult,
-	pipeline redis.Pipeliner,
+	batch statestore.IBatch,
 	subtaskCompleted *bool,
 ) error {
 
@@ -166,19 +198,44 @@ func processSubtaskResult(
 
 	running := subtasktool.IsSubtaskRunning(result.SubtaskId)
 	if !running {
-		glog.Info("subtask is not running: ", result.SubtaskId)
+		gs_Logger.Debug("subtask is not running",
+			logging.TaskId(result.TaskId), logging.SubtaskId(result.SubtaskId))
 		return nil
 	}
 
-	running = tasktool.IsTaskRunning(result.TaskId)
-	if !running {
-		glog.Info("task is not running: ", result.TaskId)
+	var status taskmodel.TaskStatusType = 0
+	err := tasktool.ReadTaskStatus(result.TaskId, &status)
+	if err != nil {
 		return nil
 	}
 
-	collectorlogic.OnSubtaskResult(result, subtaskCompleted)
+	switch status {
+	case taskmodel.TaskStatus_Running, taskmodel.TaskStatus_Paused:
+
+	// the task is cancelled or exceptional, complete the subtask without the collector callback
+	case taskmodel.TaskStatus_Cacelled, taskmodel.TaskStatus_Exceptional:
+		*subtaskCompleted = true
+		SetSubtaskResult(result.SubtaskId, result, batch)
+		return nil
+
+	default:
+		gs_Logger.Debug("task is not running",
+			logging.TaskId(result.TaskId), logging.SubtaskId(result.SubtaskId))
+		return nil
+	}
+
+	// the collector gets the result body from the blob store, and the reference is kept in the subtask info
+	resolved := *result
+	err = blobtool.ResolveResult(&resolved)
+	if err != nil {
+		gs_Logger.Warning("failed to load the subtask result body",
+			logging.TaskId(result.TaskId), logging.SubtaskId(result.SubtaskId), logging.Err(err))
+		return err
+	}
+
+	collectorlogic.OnSubtaskResult(&resolved, subtaskCompleted)
 	if *subtaskCompleted {
-		SetSubtaskResult(result.SubtaskId, result, &pipeline)
+		SetSubtaskResult(result.SubtaskId, result, batch)
 	}
 
 	return nil
@@ -187,18 +244,20 @@ func processSubtaskResult(
 func SetSubtaskResult(
 	subtaskId taskmodel.SubtaskIdType,
 	result *taskmodel.SubtaskResult,
-	ppipeline *redis.Pipeliner,
+	batch statestore.IBatch,
 ) error {
 
 	data, err := json.Marshal(result)
 	if err != nil {
-		glog.Warning("failed to serialize subtask: ", result.SubtaskId, result.TaskId)
+		gs_Logger.Warning("failed to serialize subtask result",
+			logging.TaskId(result.TaskId), logging.SubtaskId(result.SubtaskId), logging.Err(err))
 		data = []byte("")
 	}
 
-	err = subtasktool.SetSubtaskResult(uint64(subtaskId), result.Result, string(data), ppipeline)
+	err = subtasktool.SetSubtaskResult(uint64(subtaskId), result.Result, string(data), batch)
 	if err != nil {
-		glog.Warning("failed to set subtask result: ", subtaskId, err)
+		gs_Logger.Warning("failed to set subtask result",
+			logging.TaskId(result.TaskId), logging.SubtaskId(subtaskId), logging.Err(err))
 		return err
 	}
 
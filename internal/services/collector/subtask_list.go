@@ -4,9 +4,9 @@ import (
 	"sync"
 	"time"
 
-	"github.com/golang/glog"
-
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/internal/metrics"
 )
 
 type SubtaskElem struct {
@@ -28,7 +28,8 @@ func InsertSubtask(result *taskmodel.SubtaskResult) {
 
 	// check if exceed the max length
 	if len(s_SubtaskElemList) >= MaxSubtaskElemCount {
-		glog.Info("the length of subtask list exceeded the max count")
+		gs_Logger.Warning("the length of subtask list exceeded the max count",
+			logging.TaskId(result.TaskId), logging.SubtaskId(result.SubtaskId))
 		return
 	}
 
@@ -41,6 +42,10 @@ func InsertSubtaskResults(results []taskmodel.SubtaskResult) {
 	s_SubtaskLock.Lock()
 	defer s_SubtaskLock.Unlock()
 
+	if len(results) == 0 {
+		return
+	}
+
 	cap := MaxSubtaskElemCount - len(s_SubtaskElemList)
 	count := len(results)
 	if count > cap {
@@ -48,12 +53,13 @@ func InsertSubtaskResults(results []taskmodel.SubtaskResult) {
 	}
 
 	if count <= 0 {
-		glog.Info("the length of subtask list exceeded the max count")
+		gs_Logger.Warning("the length of subtask list exceeded the max count",
+			logging.Any("dropped_count", len(results)))
 		return
 	}
 
-	for _, result := range results[0:count] {
-		subtask := SubtaskElem{Result: &result, InsertTime: time.Now()}
+	for i := range results[0:count] {
+		subtask := SubtaskElem{Result: &results[i], InsertTime: time.Now()}
 		s_SubtaskElemList = append(s_SubtaskElemList, &subtask)
 	}
 }
@@ -63,7 +69,7 @@ func InsertSubtaskList(newElems *[]*SubtaskElem) {
 	defer s_SubtaskLock.Unlock()
 
 	if len(s_SubtaskElemList)+len(*newElems) >= MaxSubtaskElemCount {
-		glog.Warning("too many elements in the list")
+		gs_Logger.Warning("too many elements in the list", logging.Any("count", len(*newElems)))
 		return
 	}
 
@@ -79,7 +85,7 @@ func PopSubtaskList(retList *[]*SubtaskElem) {
 	if totalCount <= 0 {
 		return
 	}
-	glog.Info("subtask elem count in list: ", totalCount)
+	gs_Logger.Debug("subtask elem count in list", logging.Any("count", totalCount))
 
 	// get the element count
 	count := 1
@@ -98,3 +104,15 @@ func PopSubtaskList(retList *[]*SubtaskElem) {
 	// remove the elements
 	s_SubtaskElemList = s_SubtaskElemList[count:]
 }
+
+// get the count of the subtask results waiting to be processed
+func GetSubtaskListLength() int {
+	s_SubtaskLock.RLock()
+	defer s_SubtaskLock.RUnlock()
+	return len(s_SubtaskElemList)
+}
+
+// update the collector backlog metric, called before the metrics are exported
+func CollectMetrics() {
+	metrics.CollectorBacklog.Set(float64(GetSubtaskListLength()))
+}
@@ -1,22 +1,28 @@
 package executor
 
 import (
+	"errors"
 	"sync"
 	"time"
 
-	"github.com/golang/glog"
-
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
 	"github.com/danenmao/pterergate-dtf/dtf/taskplugin"
+	"github.com/danenmao/pterergate-dtf/dtf/tracing"
+	"github.com/danenmao/pterergate-dtf/internal/blobtool"
 	"github.com/danenmao/pterergate-dtf/internal/taskframework/taskloader"
 )
 
+// logger of the executor service
+var gs_Logger = logging.With(logging.Role("executor"))
+
 // collector service invoker
 var CollectorInvoker taskmodel.CollectorInvoker
 
 type ExecutorService struct {
 	ExecutorMap map[uint32]taskmodel.ITaskExecutor
 	Lock        sync.Mutex
+	executing   map[taskmodel.SubtaskIdType]struct{} // 正在执行的子任务
 }
 
 var gs_ExecutorService ExecutorService
@@ -30,9 +36,14 @@ func ExecutorRequestHandler(subtasks []taskmodel.SubtaskBody) error {
 
 	// check if exceed the subtask count
 
-	// execute each subtask in a go routine
-	for _, subtask := range subtasks {
-		go GetExecutorService().execSubtask(&subtask)
+	// execute each subtask in a go routine, the duplicate subtasks are refused
+	for i := range subtasks {
+		subtask := &subtasks[i]
+		if !GetExecutorService().acceptSubtask(subtask) {
+			continue
+		}
+
+		go GetExecutorService().execSubtask(subtask)
 	}
 
 	return nil
@@ -45,26 +56,48 @@ func (service *ExecutorService) Init() error {
 
 // to execute subtask
 func (service *ExecutorService) execSubtask(subtask *taskmodel.SubtaskBody) error {
+	defer service.finishSubtask(subtask.SubtaskId)
+
+	logger := gs_Logger.With(logging.TaskId(subtask.TaskId), logging.SubtaskId(subtask.SubtaskId),
+		logging.TaskType(subtask.TaskType))
 
 	// get the task executor object
 	var executor taskmodel.ITaskExecutor
 	err := service.getTaskExecutor(subtask.TaskType, &executor)
 	if err != nil {
-		glog.Warning("failed to get the task executor: ", err)
+		logger.Warning("failed to get the task executor", logging.Err(err))
 		return err
 	}
 
+	// the spans created by the executor become children of the execution span
+	span := tracing.StartSpan(subtask.TraceParent, tracing.SpanName_ExecuteSubtask)
+	span.SetAttribute("task_id", subtask.TaskId)
+	span.SetAttribute("subtask_id", subtask.SubtaskId)
+	subtask.TraceParent = span.TraceParent()
+
 	// execute this subtask asynchronously
 	resultChan := make(chan taskmodel.SubtaskResult, 1)
 	go func() {
 		result := taskmodel.SubtaskResult{
-			TaskId:    subtask.TaskId,
-			SubtaskId: subtask.SubtaskId,
+			TaskId:      subtask.TaskId,
+			SubtaskId:   subtask.SubtaskId,
+			TraceParent: subtask.TraceParent,
+		}
+
+		// 参数在执行前从大对象存储中读取
+		execSubtask := *subtask
+		err := blobtool.ResolveParam(&execSubtask)
+		if err != nil {
+			logger.Warning("failed to load the subtask param", logging.Err(err))
+			result.Result = taskmodel.SubtaskResult_Failure
+			result.ResultMsg = "failed to load the subtask param"
+			resultChan <- result
+			return
 		}
 
-		err := executor.Execute(subtask, &result)
+		err = executor.Execute(&execSubtask, &result)
 		if err != nil {
-			glog.Warning("TaskExecutor returned err: ", err)
+			logger.Warning("TaskExecutor returned err", logging.Err(err))
 			result.Result = taskmodel.SubtaskResult_Failure
 			result.ResultMsg = err.Error()
 		} else {
@@ -78,21 +111,33 @@ func (service *ExecutorService) execSubtask(subtask *taskmodel.SubtaskBody) erro
 
 	// wait
 	result := taskmodel.SubtaskResult{
-		TaskId:    subtask.TaskId,
-		SubtaskId: subtask.SubtaskId,
+		TaskId:      subtask.TaskId,
+		SubtaskId:   subtask.SubtaskId,
+		TraceParent: subtask.TraceParent,
 	}
 
 	select {
 	case result = <-resultChan:
-		glog.Info("subtask completed: ", subtask.SubtaskId, " of ", subtask.TaskId)
+		logger.Debug("subtask completed")
 
 	case <-time.After(time.Second * time.Duration(subtask.Timeout)):
-		glog.Info("subtask timeout: ", subtask.SubtaskId, " of ", subtask.TaskId)
+		logger.Info("subtask timeout")
 		result.Result = taskmodel.SubtaskResult_Timeout
 		result.ResultMsg = "timeout"
 	}
 
 	subtask.TerminatedAt = time.Now()
+	span.SetAttribute("result", result.Result)
+	if result.Result != taskmodel.SubtaskResult_Success {
+		span.SetError(errors.New(result.ResultMsg))
+	}
+	span.End()
+
+	// 较大的结果保存到大对象存储, 失败时保留在结果中
+	err = blobtool.OffloadResult(&result)
+	if err != nil {
+		logger.Warning("failed to offload the subtask result", logging.Err(err))
+	}
 
 	// add result to notify queue
 	GetReporter().AddSubtaskResult(&result)
@@ -130,18 +175,18 @@ func GetTaskExecutor(taskType uint32, executor *taskmodel.ITaskExecutor) error {
 	var plugin taskplugin.ITaskPlugin = nil
 	err := taskloader.LookupTaskPlugin(taskType, &plugin)
 	if err != nil {
-		glog.Warning("failed to get task plugin: ", taskType)
+		gs_Logger.Warning("failed to get task plugin", logging.TaskType(taskType), logging.Err(err))
 		return err
 	}
 
 	var pluginBody taskmodel.PluginBody
 	err = plugin.GetPluginBody(&pluginBody)
 	if err != nil {
-		glog.Warning("failed to get task context: ", err.Error())
+		gs_Logger.Warning("failed to get task context", logging.TaskType(taskType), logging.Err(err))
 		return err
 	}
 
 	*executor = pluginBody.Executor
-	glog.Info("succeeded to get task scheduler: ", taskType)
+	gs_Logger.Info("succeeded to get task executor", logging.TaskType(taskType))
 	return nil
 }
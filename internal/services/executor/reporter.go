@@ -3,8 +3,7 @@ package executor
 import (
 	"sync"
 
-	"github.com/golang/glog"
-
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
 )
 
@@ -40,7 +39,8 @@ func (reporter *SubtaskResultReporter) AddSubtaskResult(result *taskmodel.Subtas
 
 	// too many results, refuse
 	if len(reporter.Results) >= MaxSubtaskElemCount {
-		glog.Warning("the length of result list exceeded the max count")
+		gs_Logger.Warning("the length of result list exceeded the max count",
+			logging.TaskId(result.TaskId), logging.SubtaskId(result.SubtaskId))
 		return nil
 	}
 
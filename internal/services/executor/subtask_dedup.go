@@ -0,0 +1,49 @@
+package executor
+
+import (
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/internal/metrics"
+	"github.com/danenmao/pterergate-dtf/internal/tasktool"
+)
+
+// 接收一个子任务, 拒绝正在执行的重复子任务和未被调度器分发的子任务
+func (service *ExecutorService) acceptSubtask(subtask *taskmodel.SubtaskBody) bool {
+	logger := gs_Logger.With(logging.TaskId(subtask.TaskId), logging.SubtaskId(subtask.SubtaskId),
+		logging.TaskType(subtask.TaskType))
+
+	service.Lock.Lock()
+	if service.executing == nil {
+		service.executing = map[taskmodel.SubtaskIdType]struct{}{}
+	}
+
+	_, existed := service.executing[subtask.SubtaskId]
+	if !existed {
+		service.executing[subtask.SubtaskId] = struct{}{}
+	}
+	service.Lock.Unlock()
+
+	if existed {
+		logger.Warning("refused a duplicate subtask being executed")
+		metrics.AddSubtaskEvent(subtask.TaskType, metrics.SubtaskEvent_Duplicate, 1)
+		return false
+	}
+
+	// 已完成或从未分发的子任务不在执行中的子任务列表中. 读取失败时仍然执行
+	running, err := tasktool.IsSubtaskInRunningList(subtask.SubtaskId)
+	if err == nil && !running {
+		logger.Warning("refused a subtask not dispatched by the scheduler")
+		metrics.AddSubtaskEvent(subtask.TaskType, metrics.SubtaskEvent_Duplicate, 1)
+		service.finishSubtask(subtask.SubtaskId)
+		return false
+	}
+
+	return true
+}
+
+// 子任务执行结束, 之后重新分发的同一子任务可以再次执行
+func (service *ExecutorService) finishSubtask(subtaskId taskmodel.SubtaskIdType) {
+	service.Lock.Lock()
+	delete(service.executing, subtaskId)
+	service.Lock.Unlock()
+}
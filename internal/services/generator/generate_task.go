@@ -6,24 +6,30 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/go-redis/redis/v8"
-	"github.com/golang/glog"
-
 	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
 	"github.com/danenmao/pterergate-dtf/internal/config"
 	"github.com/danenmao/pterergate-dtf/internal/redistool"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
 	"github.com/danenmao/pterergate-dtf/internal/taskframework/tasklogic/generationlogic"
+	"github.com/danenmao/pterergate-dtf/internal/taskframework/tasklogic/generationqueue"
 	"github.com/danenmao/pterergate-dtf/internal/taskframework/tasklogic/schedulerlogic"
 	"github.com/danenmao/pterergate-dtf/internal/taskframework/tasklogic/tasklogicdef"
 	"github.com/danenmao/pterergate-dtf/internal/tasktool"
 )
 
+// logger of the generator service
+var gs_Logger = logging.With(logging.Role("generator"))
+
+// 任务正由其他协程生成
+var ErrGeneratingByOther = errors.New("task be generating by other")
+
 // 协程, 检查并处理要生成的任务，执行生成操作
 func StartTaskGenerationRoutine() {
 	// 检查当前实例生成的任务数是否超过上限
 	if IsFull() {
-		glog.Warning("exceed task generation limit")
+		gs_Logger.Warning("exceed task generation limit")
 		return
 	}
 
@@ -34,12 +40,12 @@ func StartTaskGenerationRoutine() {
 	}
 
 	if err != nil {
-		glog.Warning("failed to get task id to schedule: ", err)
+		gs_Logger.Warning("failed to get task id to schedule", logging.Err(err))
 		return
 	}
 
 	if taskId == 0 {
-		glog.Error("zero task id")
+		gs_Logger.Error("zero task id")
 		return
 	}
 
@@ -50,32 +56,31 @@ func StartTaskGenerationRoutine() {
 // 获取要执行生成的任务ID
 func getTaskIdToGenerate() (taskmodel.TaskIdType, error) {
 	// 按优先级从高到低从待生成任务列表中取要执行调度的任务ID
-	opt := redis.ZRangeBy{
+	opt := statestore.RangeBy{
 		Min: "-inf", Max: "+inf",
 		Offset: 0, Count: 1,
 	}
 
-	cmd := redistool.DefaultRedis().ZRangeByScore(
+	taskList, err := statestore.Default().ZRangeByScore(
 		context.Background(), config.ToGenerateTaskZset, &opt,
 	)
 
-	err := cmd.Err()
 	if err != nil {
-		glog.Warning("failed to get a task to generate: ", err)
+		gs_Logger.Warning("failed to get a task to generate", logging.Err(err))
 		return 0, err
 	}
 
 	// 如果列表为空，表示没有待生成的任务
-	taskList := cmd.Val()
 	if len(taskList) == 0 {
 		return 0, errordef.ErrNotFound
 	}
 
-	glog.Info("got a task to generate: ", taskList[0])
+	gs_Logger.Info("got a task to generate", logging.Any("task_id", taskList[0]))
 
 	// 尝试获取生成的计数
 	if !IncrIfNotFull() {
-		glog.Info("exceed the limit, cannot get a generation routine: ", taskList[0])
+		gs_Logger.Info("exceed the limit, cannot get a generation routine",
+			logging.Any("task_id", taskList[0]))
 		return 0, errordef.ErrNotFound
 	}
 
@@ -87,16 +92,15 @@ func getTaskIdToGenerate() (taskmodel.TaskIdType, error) {
 	}()
 
 	// 尝试删除，如果删除成功，则获取了此元素
-	remCmd := redistool.DefaultRedis().ZRem(context.Background(), config.ToGenerateTaskZset, taskList[0])
-	err = remCmd.Err()
+	removed, err := statestore.Default().ZRem(context.Background(), config.ToGenerateTaskZset, taskList[0])
 	if err != nil {
-		glog.Warning("failed to rem task from to-generate list: ", taskList[0])
+		gs_Logger.Warning("failed to rem task from to-generate list", logging.Any("task_id", taskList[0]))
 		return 0, err
 	}
 
 	// 如果返回1，表示删除成功，获取了些任务ID; 返回0, 表示元素不存在，被其他实例删除
-	if remCmd.Val() == 0 {
-		glog.Info("task removed by other routine: ", taskList[0])
+	if removed == 0 {
+		gs_Logger.Info("task removed by other routine", logging.Any("task_id", taskList[0]))
 		return 0, errordef.ErrNotFound
 	}
 
@@ -105,35 +109,49 @@ func getTaskIdToGenerate() (taskmodel.TaskIdType, error) {
 	var taskId uint64 = 0
 	taskId, err = strconv.ParseUint(taskIdStr, 10, 64)
 	if err != nil {
-		glog.Warning("failed to convert task id: ", taskIdStr)
+		gs_Logger.Warning("failed to convert task id", logging.Any("task_id_str", taskIdStr))
 		return 0, err
 	}
 
 	if taskId == 0 {
-		glog.Warning("zero task id from to-generate queue")
+		gs_Logger.Warning("zero task id from to-generate queue")
 		return 0, errordef.ErrNotFound
 	}
 
 	toDecrGeneratingCount = false
-	glog.Info("got a task to generate: ", taskId)
+	gs_Logger.Info("got a task to generate", logging.TaskId(taskId))
 	return taskmodel.TaskIdType(taskId), nil
 }
 
 // 开始生成流程
 func startGeneration(taskId taskmodel.TaskIdType) {
 	err := tasktool.TryToOwnTask(taskId)
+	if err == redistool.ErrLockNotAcquired {
+		// 任务正由其他实例生成
+		gs_Logger.Info("task owned by other, skip", logging.TaskId(taskId))
+		Decr()
+		return
+	}
+
 	if err != nil {
-		glog.Warning("failed to own task: ", taskId, err)
+		// 放回待生成列表, 稍后重试
+		gs_Logger.Warning("failed to own task", logging.TaskId(taskId), logging.Err(err))
+		statestore.Default().ZAdd(context.Background(), config.ToGenerateTaskZset, statestore.Z{
+			Score:  float64(time.Now().Unix()),
+			Member: taskId,
+		})
+		Decr()
+		return
 	}
 
 	// 启动生成例程
-	glog.Info("to start a generation routine: ", taskId)
+	gs_Logger.Info("to start a generation routine", logging.TaskId(taskId))
 	go TaskGenerationRoutine(taskId, false)
 }
 
 // 任务的生成例程
 func TaskGenerationRoutine(taskId taskmodel.TaskIdType, toRecover bool) {
-	glog.Info("begin to generate task: ", taskId, ", ", toRecover)
+	gs_Logger.Info("begin to generate task", logging.TaskId(taskId), logging.Any("to_recover", toRecover))
 
 	// 减少正在生成的例程数
 	defer Decr()
@@ -145,27 +163,27 @@ func TaskGenerationRoutine(taskId taskmodel.TaskIdType, toRecover bool) {
 	taskType := uint32(0)
 	err := tasktool.GetTaskType(taskmodel.TaskIdType(taskId), &taskType)
 	if err != nil {
-		glog.Warning("failed to get task type, return: ", taskId, ", ", err)
+		gs_Logger.Warning("failed to get task type, return", logging.TaskId(taskId), logging.Err(err))
 		return
 	}
 
 	// 根据任务类型，执行不同的生成逻辑
-	glog.Info("task being generated type: ", taskId, ", ", taskType)
+	gs_Logger.Info("task being generated type", logging.TaskId(taskId), logging.TaskType(taskType))
 	taskGenerationImpl(taskId, toRecover, taskType)
 
 	// 清理操作
-	glog.Info("finished to generate task: ", taskId)
+	gs_Logger.Info("finished to generate task", logging.TaskId(taskId))
 }
 
 func taskGenerationImpl(taskId taskmodel.TaskIdType, toRecover bool, taskType uint32) {
 
-	glog.Info("begin to generate a plugin task: ", taskId, ", ", taskType)
+	gs_Logger.Info("begin to generate a plugin task", logging.TaskId(taskId), logging.TaskType(taskType))
 
 	// 获取任务的信息，信息在创建任务的流程中提供
 	createParam := tasklogicdef.TaskCreateParam{}
 	err := tasktool.GetTaskCreateParam(taskmodel.TaskIdType(taskId), &createParam)
 	if err != nil {
-		glog.Warning("failed to get image task create param: ", taskId, ", ", err)
+		gs_Logger.Warning("failed to get image task create param", logging.TaskId(taskId), logging.Err(err))
 		return
 	}
 
@@ -174,7 +192,8 @@ func taskGenerationImpl(taskId taskmodel.TaskIdType, toRecover bool, taskType ui
 		err = AddTaskToScheduler(taskId, createParam.ResourceGroupName, createParam.TaskType,
 			createParam.Priority)
 		if err != nil {
-			glog.Warning("failed to add image task to scheduler: ", taskId, ", ", err)
+			gs_Logger.Warning("failed to add image task to scheduler",
+				logging.TaskId(taskId), logging.Err(err))
 			return
 		}
 	}
@@ -182,11 +201,28 @@ func taskGenerationImpl(taskId taskmodel.TaskIdType, toRecover bool, taskType ui
 	// 执行生成逻辑
 	step := uint32(0)
 	err = InitGeneration(taskId, &step)
+	if err == ErrGeneratingByOther {
+		return
+	}
+
 	if err == nil {
-		GenerationMainLoop(taskId, &createParam)
+		err = GenerationMainLoop(taskId, &createParam)
 	}
 
-	FinishGeneration(taskId)
+	// 生成未完成时不能设置完成标记, 交由其他实例从保存的状态继续生成
+	switch err {
+	case nil:
+		FinishGeneration(taskId)
+
+	case redistool.ErrLockNotOwned:
+		gs_Logger.Info("task generation owned by other", logging.TaskId(taskId))
+
+	case generationlogic.ErrGenerationHandoff:
+		HandoffGeneration(taskId)
+
+	default:
+		FailGeneration(taskId, err)
+	}
 }
 
 // 将文件添加到调度队列中
@@ -199,11 +235,11 @@ func AddTaskToScheduler(
 	return schedulerlogic.AddTaskToScheduler(taskId, groupName, taskType, priority)
 }
 
-// 任务插件的生成逻辑
+// 任务插件的生成逻辑, 生成完成或任务被取消时返回nil
 func GenerationMainLoop(
 	taskId taskmodel.TaskIdType,
 	createParam *tasklogicdef.TaskCreateParam,
-) {
+) error {
 
 	// 创建生成工作流
 	flow := generationlogic.NewGenerationFlow()
@@ -220,21 +256,24 @@ func GenerationMainLoop(
 
 	err := flow.InitGeneration(taskmodel.TaskIdType(taskId), createParam.TaskType, &taskData)
 	if err != nil {
-		glog.Warning("failed to init task generation: ", taskId, createParam.TaskType, ",", err)
-		return
+		gs_Logger.Warning("failed to init task generation",
+			logging.TaskId(taskId), logging.TaskType(createParam.TaskType), logging.Err(err))
+		return err
 	}
 
 	// 执行生成循环
-	err = flow.GenerationLoop()
-	if err != nil {
-		glog.Warning("task generation loop failed: ", taskId, ", ", err)
+	loopErr := flow.GenerationLoop()
+	if loopErr != nil {
+		gs_Logger.Info("task generation loop not finished", logging.TaskId(taskId), logging.Err(loopErr))
 	}
 
-	// 结束生成操作
+	// 结束本实例的生成操作, 交接时生成器在其他实例上以保存的状态重新开始
 	err = flow.FinishGeneration()
 	if err != nil {
-		glog.Warning("failed to finish task generation: ", taskId, ", ", err)
+		gs_Logger.Warning("failed to finish task generation", logging.TaskId(taskId), logging.Err(err))
 	}
+
+	return loopErr
 }
 
 func InitGeneration(taskId taskmodel.TaskIdType, step *uint32) error {
@@ -248,75 +287,148 @@ func InitGeneration(taskId taskmodel.TaskIdType, step *uint32) error {
 	var toGenerate bool = false
 	err := CheckGenerationStatus(taskId, &toGenerate, &currentStep)
 	if err != nil {
-		glog.Warning("failed to check generation status: ", taskId, err)
+		gs_Logger.Warning("failed to check generation status", logging.TaskId(taskId), logging.Err(err))
 		return err
 	}
 
 	// 有其他生成协程在处理, 退出
 	if !toGenerate {
-		glog.Info("task be generating by other")
-		return errors.New("task be generating by other")
+		gs_Logger.Info("task be generating by other", logging.TaskId(taskId))
+		return ErrGeneratingByOther
 	}
 
 	// 返回任务之前生成逻辑的进展
 	*step = currentStep
-	glog.Info("former task generation step: ", taskId, currentStep)
+	gs_Logger.Info("former task generation step",
+		logging.TaskId(taskId), logging.Any("current_step", currentStep))
 
-	pipeline := redistool.DefaultRedis().Pipeline()
+	batch := statestore.Default().Batch()
 
 	// 创建 redis_task_generation.$taskid.progress, 更新next_check_time
 	progressMap := map[string]interface{}{
 		config.TaskGenerationKey_NextCheckTimeField: uint64(time.Now().Add(time.Minute).Unix()),
 		config.TaskGenerationKey_StepField:          currentStep,
 	}
-	pipeline.HMSet(context.Background(), tasktool.GetTaskGenerationProgressKey(taskId), progressMap)
+	batch.HMSet(tasktool.GetTaskGenerationProgressKey(taskId), progressMap)
 
 	// 将 $taskid 移入 redis_task_generation_zset，按照插入时间排序，表示任务进入了生成状态。
-	pipeline.ZAdd(context.Background(), config.GeneratingTaskZset, &redis.Z{
+	batch.ZAdd(config.GeneratingTaskZset, statestore.Z{
+		Score:  float64(time.Now().Unix()),
+		Member: taskId,
+	})
+
+	// 执行batch
+	err = batch.Exec(context.Background())
+	if err != nil {
+		gs_Logger.Warning("failed to exec batch", logging.TaskId(taskId), logging.Err(err))
+		return err
+	}
+
+	gs_Logger.Info("succeeded to init task generation",
+		logging.TaskId(taskId), logging.Any("current_step", currentStep))
+	return nil
+}
+
+// 交接任务的生成操作
+// 保留生成进度和生成状态, 将next_check_time置为过期, 由生成监控在任一实例上恢复生成
+func HandoffGeneration(taskId taskmodel.TaskIdType) error {
+
+	batch := statestore.Default().Batch()
+
+	// 设置redis_task_generation.$taskid.progress的next_check_time过期
+	batch.HSet(tasktool.GetTaskGenerationProgressKey(taskId), config.TaskGenerationKey_NextCheckTimeField, 0)
+
+	// 确保 $taskid 在 redis_task_generation_zset 中, 以被生成监控发现
+	batch.ZAdd(config.GeneratingTaskZset, statestore.Z{
 		Score:  float64(time.Now().Unix()),
 		Member: taskId,
 	})
 
-	// 执行pipeline
-	_, err = pipeline.Exec(context.Background())
+	err := batch.Exec(context.Background())
 	if err != nil {
-		glog.Warning("failed to exec pipeline: ", taskId, err)
+		gs_Logger.Warning("failed to exec batch", logging.TaskId(taskId), logging.Err(err))
 		return err
 	}
 
-	glog.Info("succeeded to init task generation: ", taskId, currentStep)
+	gs_Logger.Info("handed off task generation", logging.TaskId(taskId))
 	return nil
 }
 
+// 记录任务生成的失败
+// 未达到失败次数上限时, 推送失败的生成立即交接, 其他失败在next_check_time过期后由生成监控重试;
+// 达到上限后, 任务被置为异常, 不再生成
+func FailGeneration(taskId taskmodel.TaskIdType, cause error) error {
+
+	failures, err := statestore.Default().HIncrBy(context.Background(), tasktool.GetTaskInfoKey(taskId),
+		config.TaskInfo_GenerationFailuresField, 1)
+	if err != nil {
+		gs_Logger.Warning("failed to count generation failures", logging.TaskId(taskId), logging.Err(err))
+		return err
+	}
+
+	if failures < int64(config.EnvTaskGenerationMaxFailures) {
+		gs_Logger.Warning("task generation failed, to retry", logging.TaskId(taskId),
+			logging.Any("failures", failures), logging.Err(cause))
+		if cause == generationlogic.ErrPushSubtaskFailed {
+			return HandoffGeneration(taskId)
+		}
+
+		return nil
+	}
+
+	gs_Logger.Error("task generation failed too many times, set the task exceptional", logging.TaskId(taskId),
+		logging.Any("failures", failures), logging.Err(cause))
+	return AbortGeneration(taskId)
+}
+
+// 终止任务的生成, 将任务置为异常
+// 丢弃未分发的子任务, 设置生成完成标记, 任务在已分发的子任务结束后完成
+func AbortGeneration(taskId taskmodel.TaskIdType) error {
+
+	err := tasktool.ChangeTaskStatus(taskId, taskmodel.TaskStatus_Exceptional,
+		taskmodel.TaskStatus_Running, taskmodel.TaskStatus_Paused)
+	if err != nil && err != errordef.ErrInvalidStatus {
+		gs_Logger.Warning("failed to set task exceptional", logging.TaskId(taskId), logging.Err(err))
+		return err
+	}
+
+	err = generationqueue.ClearSubtasks(taskId)
+	if err != nil {
+		gs_Logger.Warning("failed to clear subtasks of exceptional task", logging.TaskId(taskId), logging.Err(err))
+	}
+
+	return FinishGeneration(taskId)
+}
+
 // 完成任务生成操作
 func FinishGeneration(taskId taskmodel.TaskIdType) error {
 
-	pipeline := redistool.DefaultRedis().Pipeline()
+	batch := statestore.Default().Batch()
 
 	// 从 redis_task_generation_zset 中移除 $taskid
-	pipeline.ZRem(context.Background(), config.GeneratingTaskZset, taskId)
+	batch.ZRem(config.GeneratingTaskZset, taskId)
 
 	// 推入 redis_task_schedule_zset中
-	pipeline.ZAdd(context.Background(), config.RunningTaskZset, &redis.Z{
+	batch.ZAdd(config.RunningTaskZset, statestore.Z{
 		Score:  float64(time.Now().Unix()),
 		Member: taskId,
 	})
 
 	// 设置redis_task_generation.$taskid.progress 12小时后过期
-	pipeline.Expire(context.Background(), tasktool.GetTaskGenerationProgressKey(taskId), time.Hour*12)
+	batch.Expire(tasktool.GetTaskGenerationProgressKey(taskId), time.Hour*12)
 
 	// 设置任务生成完成的标记. redis_task_info.$taskinfo,
 	// task_generation_completed = 1.
-	pipeline.HSet(context.Background(), tasktool.GetTaskInfoKey(taskId), config.TaskInfo_GenerationCompletedField, 1)
+	batch.HSet(tasktool.GetTaskInfoKey(taskId), config.TaskInfo_GenerationCompletedField, 1)
 
 	// 执行
-	_, err := pipeline.Exec(context.Background())
+	err := batch.Exec(context.Background())
 	if err != nil {
-		glog.Warning("failed to exec pipeline: ", err)
+		gs_Logger.Warning("failed to exec batch", logging.Err(err))
 		return err
 	}
 
-	glog.Info("succeeded to generate task: ", taskId)
+	gs_Logger.Info("succeeded to generate task", logging.TaskId(taskId))
 	return nil
 }
 
@@ -324,17 +436,16 @@ func FinishGeneration(taskId taskmodel.TaskIdType) error {
 func CheckGenerationStatus(taskId taskmodel.TaskIdType, toGenerate *bool, currentStep *uint32) error {
 
 	// 读取redis_task_generation.$taskid.progress
-	cmd := redistool.DefaultRedis().HGetAll(context.Background(), tasktool.GetTaskGenerationProgressKey(taskId))
-	err := cmd.Err()
+	valMap, err := statestore.Default().HGetAll(context.Background(), tasktool.GetTaskGenerationProgressKey(taskId))
 	if err != nil {
-		glog.Warning("failed to get task generation progress key: ", taskId, err)
+		gs_Logger.Warning("failed to get task generation progress key",
+			logging.TaskId(taskId), logging.Err(err))
 		return err
 	}
 
 	// map为空，表示key不存在，可以执行生成流程
-	valMap := cmd.Val()
 	if len(valMap) == 0 {
-		glog.Info("empty task generation progress")
+		gs_Logger.Info("empty task generation progress")
 		*toGenerate = true
 		*currentStep = 0
 		return nil
@@ -343,7 +454,7 @@ func CheckGenerationStatus(taskId taskmodel.TaskIdType, toGenerate *bool, curren
 	// 检查 next_check_time值是否存在,或已过期
 	nextCheckTimeStr, ok := valMap[config.TaskGenerationKey_NextCheckTimeField]
 	if !ok {
-		glog.Info("no next_check_time field")
+		gs_Logger.Info("no next_check_time field")
 		*toGenerate = true
 		*currentStep = 0
 		return nil
@@ -351,13 +462,14 @@ func CheckGenerationStatus(taskId taskmodel.TaskIdType, toGenerate *bool, curren
 
 	nextCheckTime, err := strconv.ParseUint(nextCheckTimeStr, 10, 64)
 	if err != nil {
-		glog.Warning("failed to convert next_check_time: ", nextCheckTimeStr, err)
+		gs_Logger.Warning("failed to convert next_check_time",
+			logging.Any("next_check_time_str", nextCheckTimeStr), logging.Err(err))
 		return err
 	}
 
 	// 若存在且未过期,表示有其他协程在处理, 退出
 	if nextCheckTime >= uint64(time.Now().Unix()) {
-		glog.Info("task generation not expired: ", taskId)
+		gs_Logger.Info("task generation not expired", logging.TaskId(taskId))
 		*toGenerate = false
 		return nil
 	}
@@ -366,35 +478,35 @@ func CheckGenerationStatus(taskId taskmodel.TaskIdType, toGenerate *bool, curren
 	*toGenerate = true
 	stepStr, ok := valMap[config.TaskGenerationKey_StepField]
 	if !ok {
-		glog.Info("no step field")
+		gs_Logger.Info("no step field")
 		*currentStep = 0
 		return nil
 	}
 
 	step, err := strconv.Atoi(stepStr)
 	if err != nil {
-		glog.Warning("failed to convert step: ", stepStr, err)
+		gs_Logger.Warning("failed to convert step", logging.Any("step_str", stepStr), logging.Err(err))
 		return err
 	}
 
 	*currentStep = uint32(step)
-	glog.Info("found task generation step: ", taskId, step)
+	gs_Logger.Info("found task generation step", logging.TaskId(taskId), logging.Any("step", step))
 	return nil
 }
 
 // 更新生成的step值
 func RefreshTaskGenerationStep(taskId taskmodel.TaskIdType, step uint32) error {
 
-	cmd := redistool.DefaultRedis().HSet(
+	err := statestore.Default().HSet(
 		context.Background(),
 		tasktool.GetTaskGenerationProgressKey(taskId),
 		config.TaskGenerationKey_StepField,
 		step,
 	)
 
-	err := cmd.Err()
 	if err != nil {
-		glog.Warning("failed to refresh task generation step value: ", taskId, step, err)
+		gs_Logger.Warning("failed to refresh task generation step value",
+			logging.TaskId(taskId), logging.Any("step", step), logging.Err(err))
 		return err
 	}
 
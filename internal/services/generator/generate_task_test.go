@@ -1 +1,99 @@
 package generator
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/internal/config"
+	"github.com/danenmao/pterergate-dtf/internal/recordstore"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
+	"github.com/danenmao/pterergate-dtf/internal/taskframework/tasklogic/generationlogic"
+	"github.com/danenmao/pterergate-dtf/internal/tasktool"
+)
+
+func Test_HandoffGeneration(t *testing.T) {
+	statestore.SetDefault(statestore.NewMemoryStore())
+	defer statestore.SetDefault(statestore.NewRedisStore())
+
+	taskId := taskmodel.TaskIdType(301)
+	step := uint32(0)
+	initErr := InitGeneration(taskId, &step)
+	refreshErr := RefreshTaskGenerationStep(taskId, 3)
+
+	// 生成未过期时, 其他协程不能接手
+	otherStep := uint32(0)
+	otherErr := InitGeneration(taskId, &otherStep)
+
+	handoffErr := HandoffGeneration(taskId)
+	exceptional, checkErr := isTaskGenerationExceptional(taskId)
+
+	// 交接后, 从保存的进度继续生成
+	resumedStep := uint32(0)
+	resumeErr := InitGeneration(taskId, &resumedStep)
+
+	Convey("the handed off generation is resumed, and not marked completed", t, func() {
+		So(initErr, ShouldBeNil)
+		So(refreshErr, ShouldBeNil)
+		So(otherErr, ShouldEqual, ErrGeneratingByOther)
+		So(handoffErr, ShouldBeNil)
+		So(checkErr, ShouldBeNil)
+		So(exceptional, ShouldBeTrue)
+		So(resumeErr, ShouldBeNil)
+		So(resumedStep, ShouldEqual, 3)
+		So(tasktool.CheckIfTaskGenerationCompleted(taskId), ShouldBeFalse)
+	})
+
+	Convey("the finished generation is marked completed", t, func() {
+		So(FinishGeneration(taskId), ShouldBeNil)
+		So(tasktool.CheckIfTaskGenerationCompleted(taskId), ShouldBeTrue)
+	})
+}
+
+func Test_FailGeneration(t *testing.T) {
+	statestore.SetDefault(statestore.NewMemoryStore())
+	defer statestore.SetDefault(statestore.NewRedisStore())
+	recordstore.SetDefault(recordstore.NewMemoryRecordStore())
+	defer recordstore.SetDefault(recordstore.NewSQLRecordStore())
+
+	defer func(limit int) { config.EnvTaskGenerationMaxFailures = limit }(config.EnvTaskGenerationMaxFailures)
+	config.EnvTaskGenerationMaxFailures = 3
+
+	taskId := taskmodel.TaskIdType(302)
+	step := uint32(0)
+	initErr := InitGeneration(taskId, &step)
+	tasktool.SetTaskStatus(taskId, taskmodel.TaskStatus_Running)
+
+	// 插件的失败不立即交接, 由生成监控在next_check_time过期后重试
+	pluginErr := FailGeneration(taskId, errors.New("plugin failed"))
+	pluginExceptional, _ := isTaskGenerationExceptional(taskId)
+
+	// 推送失败立即交接
+	pushErr := FailGeneration(taskId, generationlogic.ErrPushSubtaskFailed)
+	pushExceptional, _ := isTaskGenerationExceptional(taskId)
+
+	running := taskmodel.TaskStatusType(0)
+	tasktool.ReadTaskStatus(taskId, &running)
+	runningCompleted := tasktool.CheckIfTaskGenerationCompleted(taskId)
+
+	// 达到失败次数上限, 任务被置为异常
+	lastErr := FailGeneration(taskId, errors.New("plugin failed"))
+	status := taskmodel.TaskStatusType(0)
+	tasktool.ReadTaskStatus(taskId, &status)
+
+	Convey("the task is set exceptional after its generation fails too many times", t, func() {
+		So(initErr, ShouldBeNil)
+		So(pluginErr, ShouldBeNil)
+		So(pluginExceptional, ShouldBeFalse)
+		So(pushErr, ShouldBeNil)
+		So(pushExceptional, ShouldBeTrue)
+		So(running, ShouldEqual, taskmodel.TaskStatus_Running)
+		So(runningCompleted, ShouldBeFalse)
+
+		So(lastErr, ShouldBeNil)
+		So(status, ShouldEqual, taskmodel.TaskStatus_Exceptional)
+		So(tasktool.CheckIfTaskGenerationCompleted(taskId), ShouldBeTrue)
+	})
+}
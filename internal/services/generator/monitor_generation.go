@@ -6,13 +6,11 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/go-redis/redis/v8"
-	"github.com/golang/glog"
-
 	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
 	"github.com/danenmao/pterergate-dtf/internal/config"
-	"github.com/danenmao/pterergate-dtf/internal/redistool"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
 	"github.com/danenmao/pterergate-dtf/internal/tasktool"
 )
 
@@ -20,7 +18,7 @@ import (
 func MonitorTaskGenerationRoutine() {
 	// 检查当前实例生成的任务数是否超过上限
 	if IsFull() {
-		glog.Info("exceed generating limit")
+		gs_Logger.Info("exceed generating limit")
 		return
 	}
 
@@ -32,14 +30,14 @@ func MonitorTaskGenerationRoutine() {
 	}
 
 	if err != nil {
-		glog.Warning("failed to get generating task: ", err)
+		gs_Logger.Warning("failed to get generating task", logging.Err(err))
 		return
 	}
 
 	// 修复任务
 	err = repairTaskGeneration(taskId)
 	if err != nil {
-		glog.Warning("failed to repair task generation: ", taskId, err)
+		gs_Logger.Warning("failed to repair task generation", logging.TaskId(taskId), logging.Err(err))
 	}
 }
 
@@ -66,15 +64,15 @@ func getExceptionalGenerationTask(taskId *taskmodel.TaskIdType) error {
 
 		// 尝试增加当前实例生成的任务数
 		if !IncrIfNotFull() {
-			glog.Info("exceed generating limit")
+			gs_Logger.Info("exceed generating limit")
 			continue
 		}
 
-		glog.Info("found an exceptional task: ", id)
+		gs_Logger.Info("found an exceptional task", logging.Any("id", id))
 		err = tasktool.TryToOwnTask(id)
 		if err != nil {
 			Decr()
-			glog.Info("failed to own task: ", id, err)
+			gs_Logger.Info("failed to own task", logging.Any("id", id), logging.Err(err))
 			continue
 		}
 
@@ -89,49 +87,45 @@ func getExceptionalGenerationTask(taskId *taskmodel.TaskIdType) error {
 func getGeneratingTaskList(taskList *[]taskmodel.TaskIdType) error {
 
 	// 取redis_task_generation_zset的元素数目
-	intCmd := redistool.DefaultRedis().ZCard(context.Background(), config.GeneratingTaskZset)
-	err := intCmd.Err()
+	zcard, err := statestore.Default().ZCard(context.Background(), config.GeneratingTaskZset)
 	if err != nil {
-		glog.Warning("failed to get zcard:", err)
+		gs_Logger.Warning("failed to get zcard", logging.Err(err))
 		return err
 	}
 
 	var limit int64 = 10
 	var offset int64 = 0
-	zcard := intCmd.Val()
 	if zcard > limit {
 		offset = int64(rand.Intn(int(zcard - limit)))
 	}
 
 	// 按照时间从redis_task_generation_zset的随机位置取$taskid
-	opt := redis.ZRangeBy{
+	opt := statestore.RangeBy{
 		Min: "-inf", Max: "+inf",
 		Offset: offset, Count: limit,
 	}
 
-	cmd := redistool.DefaultRedis().ZRangeByScore(
+	taskStrList, err := statestore.Default().ZRangeByScore(
 		context.Background(), config.GeneratingTaskZset, &opt,
 	)
 
-	err = cmd.Err()
 	if err != nil {
-		glog.Warning("failed to get generating task from redis: ", err)
+		gs_Logger.Warning("failed to get generating task from store", logging.Err(err))
 		return err
 	}
 
 	// 如果列表为空，表示没有生成中的任务
-	taskStrList := cmd.Val()
 	if len(taskStrList) == 0 {
-		glog.Info("get empty list, no generating task")
+		gs_Logger.Info("get empty list, no generating task")
 		return nil
 	}
 
-	glog.Info("got task: ", taskStrList)
+	gs_Logger.Info("got task", logging.Any("task_str_list", taskStrList))
 
 	for _, taskIdStr := range taskStrList {
 		taskId, err := strconv.ParseUint(taskIdStr, 10, 64)
 		if err != nil {
-			glog.Warning("failed to convert task id: ", taskIdStr)
+			gs_Logger.Warning("failed to convert task id", logging.Any("task_id_str", taskIdStr))
 			continue
 		}
 
@@ -144,24 +138,23 @@ func getGeneratingTaskList(taskList *[]taskmodel.TaskIdType) error {
 // 检查任务生成过程是否异常
 func isTaskGenerationExceptional(taskId taskmodel.TaskIdType) (bool, error) {
 	// 检查redis_task_generation.$taskid.progress
-	cmd := redistool.DefaultRedis().HGetAll(context.Background(), tasktool.GetTaskGenerationProgressKey(taskId))
-	err := cmd.Err()
+	valMap, err := statestore.Default().HGetAll(context.Background(), tasktool.GetTaskGenerationProgressKey(taskId))
 	if err != nil {
-		glog.Warning("failed to get generation progress key: ", taskId, err)
+		gs_Logger.Warning("failed to get generation progress key", logging.TaskId(taskId), logging.Err(err))
 		return false, err
 	}
 
 	// 如果 redis_task_generation.$taskid.progress 不存在, 或者 next_check_time 过期,即状态异常
-	valMap := cmd.Val()
 	nextCheckTimeStr, ok := valMap[config.TaskGenerationKey_NextCheckTimeField]
 	if !ok {
-		glog.Info("no next_check_time key: ", taskId)
+		gs_Logger.Info("no next_check_time key", logging.TaskId(taskId))
 		return true, nil
 	}
 
 	nextCheckTime, err := strconv.ParseUint(nextCheckTimeStr, 10, 64)
 	if err != nil {
-		glog.Warning("failed to convert next_check_time: ", taskId, nextCheckTimeStr, err)
+		gs_Logger.Warning("failed to convert next_check_time",
+			logging.TaskId(taskId), logging.Any("next_check_time_str", nextCheckTimeStr), logging.Err(err))
 		return false, err
 	}
 
@@ -4,22 +4,27 @@ import (
 	"context"
 	"strconv"
 
-	"github.com/go-redis/redis/v8"
-	"github.com/golang/glog"
-
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
 	"github.com/danenmao/pterergate-dtf/internal/config"
-	"github.com/danenmao/pterergate-dtf/internal/redistool"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
 	"github.com/danenmao/pterergate-dtf/internal/tasktool"
 )
 
 // 监视已完成的子任务
 func MonitorSubtaskComplete() {
+	// 依次处理每个分片
+	for _, keyName := range tasktool.GetShardKeys(config.CompletedSubtaskList) {
+		monitorSubtaskCompleteOfShard(keyName)
+	}
+}
+
+func monitorSubtaskCompleteOfShard(keyName string) {
 	// 取已完成的子任务
 	var subtaskList = []uint64{}
-	err := getCompletedSubtask(&subtaskList)
+	err := getCompletedSubtask(keyName, &subtaskList)
 	if err != nil {
-		glog.Warning("failed to get completed subtasks: ", err)
+		gs_Logger.Warning("failed to get completed subtasks", logging.Any("key", keyName), logging.Err(err))
 		return
 	}
 
@@ -28,47 +33,42 @@ func MonitorSubtaskComplete() {
 	}
 
 	// 处理已完成的子任务
-	err = processCompletedSubtask(&subtaskList)
+	err = processCompletedSubtask(keyName, &subtaskList)
 	if err != nil {
-		glog.Warning("failed to process completed subtasks: ", err)
+		gs_Logger.Warning("failed to process completed subtasks", logging.Any("key", keyName), logging.Err(err))
 		return
 	}
 }
 
 // 获取已完成的子任务列表
-func getCompletedSubtask(subtaskList *[]uint64) error {
+func getCompletedSubtask(keyName string, subtaskList *[]uint64) error {
 
 	if subtaskList == nil {
 		panic("invalid subtaskList pointer")
 	}
 
 	// 从subtask_complete_list中取完成的子任务
-	opt := redis.ZRangeBy{
+	opt := statestore.RangeBy{
 		Min: "-inf", Max: "+inf",
 		Offset: 0, Count: 100,
 	}
 
-	cmd := redistool.DefaultRedis().ZRangeByScore(
+	strList, err := statestore.Default().ZRangeByScore(
 		context.Background(),
-		config.CompletedSubtaskList, &opt,
+		keyName, &opt,
 	)
 
-	err := cmd.Err()
 	if err != nil {
-		glog.Warning("failed to get completed subtask from redis: ", err)
+		gs_Logger.Warning("failed to get completed subtask from store", logging.Any("key", keyName), logging.Err(err))
 		return err
 	}
 
-	strList := cmd.Val()
-	if len(strList) > 0 {
-		glog.Info("got completed subtask: ", strList)
-	}
 	// 转换查询到的子任务ID
 	var wrongFormatList = []interface{}{}
 	for _, str := range strList {
 		id, err := strconv.ParseUint(str, 10, 64)
 		if err != nil {
-			glog.Warning("failed to convert completed subtask id: ", str)
+			gs_Logger.Warning("failed to convert completed subtask id", logging.Any("id", str))
 
 			// 如果转换失败，说明数据格式错误，移除元素
 			wrongFormatList = append(wrongFormatList, str)
@@ -79,26 +79,28 @@ func getCompletedSubtask(subtaskList *[]uint64) error {
 	}
 
 	// 删除转换失败的子任务数据
-	redistool.DefaultRedis().ZRem(context.Background(), config.CompletedSubtaskList, wrongFormatList...)
+	if len(wrongFormatList) > 0 {
+		statestore.Default().ZRem(context.Background(), keyName, wrongFormatList...)
+	}
 
 	// 如果列表为空，表示没有超时的任务
 	if len(*subtaskList) == 0 {
 		return nil
 	}
 
-	glog.Info("got completed subtasks: ", *subtaskList)
+	gs_Logger.Debug("got completed subtasks", logging.Any("count", len(*subtaskList)))
 	return nil
 }
 
 // 处理已完成的子任务列表
-func processCompletedSubtask(subtaskList *[]uint64) error {
+func processCompletedSubtask(keyName string, subtaskList *[]uint64) error {
 
 	if subtaskList == nil {
 		panic("invalid subtaskList pointer")
 	}
 
 	ownedSubtaskList := []uint64{}
-	err := ownCompletedSubtasks(subtaskList, &ownedSubtaskList)
+	err := ownCompletedSubtasks(keyName, subtaskList, &ownedSubtaskList)
 	if err != nil {
 		return err
 	}
@@ -107,28 +109,29 @@ func processCompletedSubtask(subtaskList *[]uint64) error {
 		return nil
 	}
 
-	pipeline := redistool.DefaultRedis().Pipeline()
+	batch := statestore.Default().Batch()
 	for _, subtaskId := range ownedSubtaskList {
 
 		// 获取子任务所属的任务id
 		var taskId taskmodel.TaskIdType = 0
 		err := tasktool.GetTaskIdOfSubtask(subtaskId, &taskId)
 		if err != nil {
-			glog.Warning("failed to get task id of subtask: ", subtaskId, ", ", err)
+			gs_Logger.Warning("failed to get task id of subtask",
+				logging.SubtaskId(subtaskId), logging.Err(err))
 			continue
 		}
 
 		// 从redis_subtask_list.$taskid 中删除子任务.
-		pipeline.ZRem(context.Background(), tasktool.GetTaskSubtaskListKey(taskId), subtaskId)
+		batch.ZRem(tasktool.GetTaskSubtaskListKey(taskId), subtaskId)
 
 		// 执行子任务后处理
 		OnSubtaskCompleted(taskId, subtaskId)
 	}
 
-	// 执行pipeline
-	_, err = pipeline.Exec(context.Background())
+	// 执行batch
+	err = batch.Exec(context.Background())
 	if err != nil {
-		glog.Warning("failed to exec pipeline: ", err)
+		gs_Logger.Warning("failed to exec batch", logging.Err(err))
 		return err
 	}
 
@@ -136,8 +139,8 @@ func processCompletedSubtask(subtaskList *[]uint64) error {
 }
 
 // 试图获取完成子任务的所有权
-func ownCompletedSubtasks(subtaskList *[]uint64, ownedSubtaskList *[]uint64) error {
-	return redistool.TryToOwnElements(config.CompletedSubtaskList, subtaskList, ownedSubtaskList)
+func ownCompletedSubtasks(keyName string, subtaskList *[]uint64, ownedSubtaskList *[]uint64) error {
+	return statestore.TryToOwnElements(keyName, subtaskList, ownedSubtaskList)
 }
 
 // 执行子任务后处理
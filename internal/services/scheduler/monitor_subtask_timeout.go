@@ -5,21 +5,28 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/go-redis/redis/v8"
-	"github.com/golang/glog"
-
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
 	"github.com/danenmao/pterergate-dtf/internal/config"
-	"github.com/danenmao/pterergate-dtf/internal/redistool"
+	"github.com/danenmao/pterergate-dtf/internal/metrics"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
 	"github.com/danenmao/pterergate-dtf/internal/subtasktool"
+	"github.com/danenmao/pterergate-dtf/internal/tasktool"
 )
 
 func MonitorTimeoutSubtask() {
+	// 依次处理每个分片
+	for _, keyName := range tasktool.GetShardKeys(config.RunningSubtaskZset) {
+		monitorTimeoutSubtaskOfShard(keyName)
+	}
+}
+
+func monitorTimeoutSubtaskOfShard(keyName string) {
 	// 取超时的子任务
 	var subtaskList = []uint64{}
-	err := getTimeoutSubtasks(&subtaskList)
+	err := getTimeoutSubtasks(keyName, &subtaskList)
 	if err != nil {
-		glog.Warning("failed to get timeout subtasks: ", err)
+		gs_Logger.Warning("failed to get timeout subtasks", logging.Any("key", keyName), logging.Err(err))
 		return
 	}
 
@@ -28,14 +35,14 @@ func MonitorTimeoutSubtask() {
 	}
 
 	// 处理超时的子任务
-	err = repairTimeoutSubtasks(&subtaskList)
+	err = repairTimeoutSubtasks(keyName, &subtaskList)
 	if err != nil {
-		glog.Warning("failed to repair timeout subtask: ", err)
+		gs_Logger.Warning("failed to repair timeout subtasks", logging.Any("key", keyName), logging.Err(err))
 		return
 	}
 }
 
-func getTimeoutSubtasks(subtaskList *[]uint64) error {
+func getTimeoutSubtasks(keyName string, subtaskList *[]uint64) error {
 
 	if subtaskList == nil {
 		panic("invalid subtaskList pointer")
@@ -44,31 +51,25 @@ func getTimeoutSubtasks(subtaskList *[]uint64) error {
 	// 从redis_subtask_scanning_zset 中取超时的子任务
 	now := time.Now().Unix()
 	nowStr := strconv.FormatUint(uint64(now), 10)
-	opt := redis.ZRangeBy{
+	opt := statestore.RangeBy{
 		Min: "-inf", Max: nowStr,
 		Offset: 0, Count: 100,
 	}
 
-	cmd := redistool.DefaultRedis().ZRangeByScore(
-		context.Background(), config.RunningSubtaskZset, &opt,
+	strList, err := statestore.Default().ZRangeByScore(
+		context.Background(), keyName, &opt,
 	)
 
-	err := cmd.Err()
 	if err != nil {
-		glog.Warning("failed to get timeout subtask from redis: ", err)
+		gs_Logger.Warning("failed to get timeout subtask from store", logging.Any("key", keyName), logging.Err(err))
 		return err
 	}
 
-	strList := cmd.Val()
-	if len(strList) > 0 {
-		glog.Info("got timeout subtask: ", strList)
-	}
-
 	// 转换查询到的子任务ID
 	for _, str := range strList {
 		id, err := strconv.ParseUint(str, 10, 64)
 		if err != nil {
-			glog.Warning("failed to convert timeout subtask id: ", str)
+			gs_Logger.Warning("failed to convert timeout subtask id", logging.Any("id", str))
 			continue
 		}
 
@@ -77,16 +78,15 @@ func getTimeoutSubtasks(subtaskList *[]uint64) error {
 
 	// no timeout subtask
 	if len(*subtaskList) == 0 {
-		glog.Info("get empty list, no timeout subtask")
 		return nil
 	}
 
-	glog.Info("got timeout subtasks: ", *subtaskList)
+	gs_Logger.Info("got timeout subtasks", logging.Any("count", len(*subtaskList)))
 	return nil
 }
 
 // 修复子任务的超时状态
-func repairTimeoutSubtasks(subtaskList *[]uint64) error {
+func repairTimeoutSubtasks(keyName string, subtaskList *[]uint64) error {
 
 	if subtaskList == nil {
 		panic("invalid subtaskList pointer")
@@ -94,7 +94,7 @@ func repairTimeoutSubtasks(subtaskList *[]uint64) error {
 
 	// remove this subtask from running subtasks list
 	owndSubtaskList := []uint64{}
-	err := redistool.TryToOwnElements(config.RunningSubtaskZset, subtaskList, &owndSubtaskList)
+	err := statestore.TryToOwnElements(keyName, subtaskList, &owndSubtaskList)
 	if err != nil {
 		return err
 	}
@@ -104,32 +104,45 @@ func repairTimeoutSubtasks(subtaskList *[]uint64) error {
 	}
 
 	completeTime := time.Now().Unix()
-	pipeline := redistool.DefaultRedis().Pipeline()
+	batch := statestore.Default().Batch()
+	results := []*taskmodel.SubtaskResult{}
 	for _, id := range owndSubtaskList {
 
-		glog.Info("owned subtask, set subtask to complete: ", id)
+		gs_Logger.Info("owned subtask, set subtask to timeout", logging.SubtaskId(id))
 
 		// set completion code to timeout
-		err = subtasktool.SetSubtaskResult(id, taskmodel.SubtaskResult_Timeout, "", &pipeline)
+		err = subtasktool.SetSubtaskResult(id, taskmodel.SubtaskResult_Timeout, "", batch)
 		if err != nil {
-			glog.Warning("failed to set subtask timeout: ", id, err)
+			gs_Logger.Warning("failed to set subtask timeout",
+				logging.SubtaskId(id), logging.Err(err))
+		}
+
+		taskType := uint32(0)
+		if subtasktool.GetSubtaskTaskType(id, &taskType) == nil {
+			metrics.AddSubtaskEvent(taskType, metrics.SubtaskEvent_Timeout, 1)
 		}
 
 		// 插入到 redis_subtask_complete_list 完成队列中
-		z := redis.Z{
+		z := statestore.Z{
 			Member: id,
 			Score:  float64(completeTime),
 		}
 
-		pipeline.ZAdd(context.Background(), config.CompletedSubtaskList, &z)
+		batch.ZAdd(tasktool.GetShardKey(config.CompletedSubtaskList, id), z)
+		results = append(results, &taskmodel.SubtaskResult{
+			SubtaskId: taskmodel.SubtaskIdType(id),
+			Result:    taskmodel.SubtaskResult_Timeout,
+			ResultMsg: "subtask timeout",
+		})
 	}
 
-	_, err = pipeline.Exec(context.Background())
+	err = batch.Exec(context.Background())
 	if err != nil {
-		glog.Warning("failed to exec pipeline: ", err)
+		gs_Logger.Warning("failed to exec batch", logging.Err(err))
 		return err
 	}
 
-	glog.Info("succeeded to repair timeout subtasks: ", *subtaskList)
+	subtasktool.SaveSubtaskResults(results)
+	gs_Logger.Info("succeeded to repair timeout subtasks", logging.Any("subtask_ids", owndSubtaskList))
 	return nil
 }
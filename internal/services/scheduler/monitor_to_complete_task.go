@@ -5,13 +5,11 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/go-redis/redis/v8"
-	"github.com/golang/glog"
-
 	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
 	"github.com/danenmao/pterergate-dtf/internal/config"
-	"github.com/danenmao/pterergate-dtf/internal/redistool"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
 	"github.com/danenmao/pterergate-dtf/internal/tasktool"
 )
 
@@ -20,7 +18,7 @@ func MonitorRunningTaskToComplete() {
 	var taskList = []uint64{}
 	err := getToBeCompletedTask(&taskList)
 	if err != nil {
-		glog.Warning("failed to get completed tasks: ", err)
+		gs_Logger.Warning("failed to get completed tasks", logging.Err(err))
 		return
 	}
 
@@ -31,7 +29,7 @@ func MonitorRunningTaskToComplete() {
 	// 对任务进行完成操作
 	err = completeTask(&taskList)
 	if err != nil {
-		glog.Warning("failed to process completed tasks: ", taskList, err)
+		gs_Logger.Warning("failed to process completed tasks", logging.Any("task_ids", taskList), logging.Err(err))
 		return
 	}
 }
@@ -42,29 +40,27 @@ func getToBeCompletedTask(taskList *[]uint64) error {
 	}
 
 	// 取redis_task_schedule_zset 中的 $taskid
-	opt := redis.ZRangeBy{
+	opt := statestore.RangeBy{
 		Min: "-inf", Max: "+inf",
 		Offset: 0, Count: 10,
 	}
 
-	cmd := redistool.DefaultRedis().ZRangeByScore(
+	strList, err := statestore.Default().ZRangeByScore(
 		context.Background(), config.RunningTaskZset, &opt,
 	)
 
-	err := cmd.Err()
 	if err != nil {
-		glog.Warning("failed to get completed tasks from redis: ", err)
+		gs_Logger.Warning("failed to get completed tasks from store", logging.Err(err))
 		return err
 	}
 
-	strList := cmd.Val()
-	glog.Info("got running tasks: ", strList)
+	gs_Logger.Debug("got running tasks", logging.Any("task_ids", strList))
 
 	// 转换查询到的任务ID
 	for _, str := range strList {
 		id, err := strconv.ParseUint(str, 10, 64)
 		if err != nil {
-			glog.Warning("failed to convert completed subtask id: ", str)
+			gs_Logger.Warning("failed to convert running task id", logging.Any("id", str))
 			continue
 		}
 
@@ -80,11 +76,10 @@ func getToBeCompletedTask(taskList *[]uint64) error {
 
 	// 如果列表为空，表示没有完成的任务
 	if len(*taskList) == 0 {
-		glog.Info("get empty list, no completed task")
 		return nil
 	}
 
-	glog.Info("got completed tasks: ", *taskList)
+	gs_Logger.Info("got completed tasks", logging.Any("task_ids", *taskList))
 	return nil
 }
 
@@ -115,7 +110,7 @@ func completeTask(taskList *[]uint64) error {
 
 	// 批量从redis_task_schedule_zset中删除taskid
 	var ownedTasks = []uint64{}
-	err := redistool.TryToOwnElements(config.RunningTaskZset, taskList, &ownedTasks)
+	err := statestore.TryToOwnElements(config.RunningTaskZset, taskList, &ownedTasks)
 	if err != nil {
 		return err
 	}
@@ -125,18 +120,18 @@ func completeTask(taskList *[]uint64) error {
 	}
 
 	// 处理有所有权的任务, 执行任务的完成操作
-	pipeline := redistool.DefaultRedis().Pipeline()
+	batch := statestore.Default().Batch()
 	for _, taskId := range ownedTasks {
-		err = PerformCompleteTask(taskId, &pipeline)
+		err = PerformCompleteTask(taskId, batch)
 		if err != nil {
-			glog.Warning("failed to complete task: ", taskId, err)
+			gs_Logger.Warning("failed to complete task", logging.TaskId(taskId), logging.Err(err))
 		}
 	}
 
-	// 执行pipeline
-	_, err = pipeline.Exec(context.Background())
+	// 执行batch
+	err = batch.Exec(context.Background())
 	if err != nil {
-		glog.Warning("failed to exec pipeline: ", err)
+		gs_Logger.Warning("failed to exec batch", logging.Err(err))
 		return err
 	}
 
@@ -144,16 +139,14 @@ func completeTask(taskList *[]uint64) error {
 }
 
 // 设置任务完成, 执行一些完成操作
-func PerformCompleteTask(taskId uint64, ppipeline *redis.Pipeliner) error {
-
-	pipeline := *ppipeline
+func PerformCompleteTask(taskId uint64, batch statestore.IBatch) error {
 
 	// 将任务id放到已完成列表中
-	z := redis.Z{
+	z := statestore.Z{
 		Score:  float64(time.Now().Unix()),
 		Member: taskId,
 	}
-	pipeline.ZAdd(context.Background(), config.CompletedTaskList, &z)
+	batch.ZAdd(config.CompletedTaskList, z)
 
 	// 清理临时的redis key.
 
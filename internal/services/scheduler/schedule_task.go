@@ -1,13 +1,15 @@
 package scheduler
 
 import (
-	"github.com/golang/glog"
-
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
 	"github.com/danenmao/pterergate-dtf/internal/taskframework/tasklogic/generationqueue"
 	"github.com/danenmao/pterergate-dtf/internal/taskframework/tasklogic/schedulerlogic"
 )
 
+// logger of the scheduler service
+var gs_Logger = logging.With(logging.Role("scheduler"))
+
 // go_schedule_subtask
 func ScheduleTaskRoutine() {
 	// get the task and subtasks to schedule
@@ -15,7 +17,7 @@ func ScheduleTaskRoutine() {
 	var subtasks = []taskmodel.SubtaskBody{}
 	err := schedulerlogic.ScheduleSubtasks(&taskId, &subtasks)
 	if err != nil {
-		glog.Warning("failed to get task to schedule: ", err)
+		gs_Logger.Warning("failed to get task to schedule", logging.Err(err))
 		return
 	}
 
@@ -24,13 +26,14 @@ func ScheduleTaskRoutine() {
 		return
 	}
 
-	glog.Info("to exec subtasks of task: ", taskId, ", ", len(subtasks))
+	logger := gs_Logger.With(logging.TaskId(taskId))
+	logger.Debug("to exec subtasks of task", logging.Any("count", len(subtasks)))
 
 	// to execute subtasks
 	toPushbackSubtask := []taskmodel.SubtaskBody{}
 	err = schedulerlogic.ExecSubtasks(taskId, &subtasks, &toPushbackSubtask)
 	if err != nil {
-		glog.Warning("failed to exec subtask: ", taskId, ",", err)
+		logger.Warning("failed to exec subtasks", logging.Err(err))
 
 		// if failed, push back all subtasks
 		generationqueue.PushSubtaskBack(taskId, &subtasks)
@@ -39,9 +42,9 @@ func ScheduleTaskRoutine() {
 
 	// push back all subtasks
 	if len(toPushbackSubtask) > 0 {
-		glog.Info("push subtasks back to generation queue: ", taskId, ", ", len(toPushbackSubtask))
+		logger.Info("push subtasks back to generation queue", logging.Any("count", len(toPushbackSubtask)))
 		generationqueue.PushSubtaskBack(taskId, &toPushbackSubtask)
 	}
 
-	glog.Info("succeeded to schedule subtasks: ", taskId)
+	logger.Debug("succeeded to schedule subtasks", logging.Any("count", len(subtasks)))
 }
@@ -5,13 +5,12 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/go-redis/redis/v8"
-	"github.com/golang/glog"
-
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/internal/blobtool"
 	"github.com/danenmao/pterergate-dtf/internal/config"
 	"github.com/danenmao/pterergate-dtf/internal/dbdef"
-	"github.com/danenmao/pterergate-dtf/internal/redistool"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
 	"github.com/danenmao/pterergate-dtf/internal/tasktool"
 )
 
@@ -32,63 +31,59 @@ func getCompletedTask(taskList *[]taskmodel.TaskIdType) {
 
 	// 取已完成列表中的最先完成的5个任务
 	currentTime := strconv.FormatUint(uint64(time.Now().Unix()), 10)
-	opt := redis.ZRangeBy{
+	opt := statestore.RangeBy{
 		Min: "-inf", Max: currentTime,
 		Offset: 0, Count: 5,
 	}
 
-	cmd := redistool.DefaultRedis().ZRangeByScore(context.Background(), config.CompletedTaskList, &opt)
-	err := cmd.Err()
+	strArr, err := statestore.Default().ZRangeByScore(context.Background(), config.CompletedTaskList, &opt)
 	if err != nil {
-		glog.Warning("failed to get completed taskid: ", err.Error())
+		gs_Logger.Warning("failed to get completed taskid", logging.Err(err))
 		return
 	}
 
 	// 解析出任务ID
-	strArr, _ := cmd.Result()
 	for _, str := range strArr {
 		taskId, err := strconv.ParseUint(str, 10, 64)
 		if err != nil {
-			glog.Warning("failed to convert task id: ", str, err.Error())
+			gs_Logger.Warning("failed to convert task id", logging.Any("str", str), logging.Err(err))
 			continue
 		}
 
 		*taskList = append(*taskList, taskmodel.TaskIdType(taskId))
 	}
 
-	glog.Info("succeeded to get completed tasks: ", *taskList)
+	gs_Logger.Info("succeeded to get completed tasks", logging.Any("task_list", *taskList))
 }
 
 // 完成任务
 func completeTask(taskId taskmodel.TaskIdType) {
 
 	// 从task list中删除任务记录，避免被monitor_task_timeout处理
-	cmd := redistool.DefaultRedis().ZRem(context.Background(), config.TaskZset, taskId)
-	_, err := cmd.Result()
+	_, err := statestore.Default().ZRem(context.Background(), config.TaskZset, taskId)
 	if err != nil {
-		glog.Warning("failed to zrem task from task list: ", err.Error())
+		gs_Logger.Warning("failed to zrem task from task list", logging.Err(err))
 	}
 
 	// 从已完成队列中删除任务记录
-	cmd = redistool.DefaultRedis().ZRem(context.Background(), config.CompletedTaskList, taskId)
-	val, err := cmd.Result()
+	val, err := statestore.Default().ZRem(context.Background(), config.CompletedTaskList, taskId)
 	if err != nil {
-		glog.Warning("failed to zrem task from completed task list: ", err.Error())
+		gs_Logger.Warning("failed to zrem task from completed task list", logging.Err(err))
 	}
 
 	// 为0, 表示被其他例程处理了
 	if val == 0 {
-		glog.Info("owned by other: ", taskId)
+		gs_Logger.Info("owned by other", logging.TaskId(taskId))
 		return
 	}
 
-	glog.Info("owned completed task: ", taskId)
+	gs_Logger.Info("owned completed task", logging.TaskId(taskId))
 
 	// 将取到的任务设置为已完成
 	var taskRecord = dbdef.DBTaskRecord{}
 	err = tasktool.CompleteTask(taskId, &taskRecord)
 	if err != nil {
-		glog.Warning("failed to complete task: ", taskId, err.Error())
+		gs_Logger.Warning("failed to complete task", logging.TaskId(taskId), logging.Err(err))
 		return
 	}
 
@@ -97,24 +92,25 @@ func completeTask(taskId taskmodel.TaskIdType) {
 
 	// 执行清理操作
 	cleanTaskKeys(taskId)
+	blobtool.DeleteTaskBlobs(taskId)
 
-	glog.Info("succeeded to complete task: ", taskId)
+	gs_Logger.Info("succeeded to complete task", logging.TaskId(taskId))
 }
 
 // 清理任务的redis key
 func cleanTaskKeys(taskId taskmodel.TaskIdType) error {
 
-	pipeline := redistool.DefaultRedis().Pipeline()
+	batch := statestore.Default().Batch()
 
 	// 从redis_task_schedule_zset 中删除taskid
-	pipeline.ZRem(context.Background(), config.GeneratingTaskZset, taskId)
-	pipeline.ZRem(context.Background(), config.RunningTaskZset, taskId)
-	pipeline.ZRem(context.Background(), config.ToGenerateTaskZset, taskId)
+	batch.ZRem(config.GeneratingTaskZset, taskId)
+	batch.ZRem(config.RunningTaskZset, taskId)
+	batch.ZRem(config.ToGenerateTaskZset, taskId)
 
-	// 执行pipeline
-	_, err := pipeline.Exec(context.Background())
+	// 执行batch
+	err := batch.Exec(context.Background())
 	if err != nil {
-		glog.Warning("failed to exec clean task keys pipeline: ", err)
+		gs_Logger.Warning("failed to exec clean task keys batch", logging.Err(err))
 		return err
 	}
 
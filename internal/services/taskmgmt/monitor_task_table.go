@@ -6,15 +6,12 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/golang/glog"
-	"github.com/jmoiron/sqlx"
-
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
 	"github.com/danenmao/pterergate-dtf/internal/basedef"
 	"github.com/danenmao/pterergate-dtf/internal/config"
-	"github.com/danenmao/pterergate-dtf/internal/dbdef"
-	"github.com/danenmao/pterergate-dtf/internal/mysqltool"
-	"github.com/danenmao/pterergate-dtf/internal/redistool"
+	"github.com/danenmao/pterergate-dtf/internal/recordstore"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
 	"github.com/danenmao/pterergate-dtf/internal/taskframework/tasklogic/tasklogicdef"
 	"github.com/danenmao/pterergate-dtf/internal/tasktool"
 )
@@ -34,56 +31,36 @@ func MonitorTaskTableRoutine() {
 // 获取异常创建的任务的列表
 func getExceptionalTasks(taskList *[]taskmodel.TaskIdType) error {
 
-	queryFn := func(offset int, limit int) (*sqlx.Rows, error) {
-		return mysqltool.DefaultMySQL().Queryx(
-			dbdef.SQL_TaskTable_QueryExceptionalCreationTask,
-			time.Now().Format(basedef.GoTimeFormatStr),
-			offset, limit,
-		)
-	}
-
-	readFn := func(rows *sqlx.Rows) error {
-		var id uint64 = 0
-		err := rows.Scan(&id)
-		if err != nil {
-			glog.Warning("failed to get exceptional task id: ", err)
-			return nil
-		}
-
-		*taskList = append(*taskList, taskmodel.TaskIdType(id))
-		return nil
-	}
-
-	err := mysqltool.ReadDBByPageWithLimit(queryFn, readFn, 10)
+	err := recordstore.Default().GetExceptionalTasks(context.Background(),
+		time.Now().Format(basedef.GoTimeFormatStr), 10, taskList)
 	if err != nil {
-		glog.Warning("failed to get exceptional tasks: ", err)
+		gs_Logger.Warning("failed to get exceptional tasks", logging.Err(err))
 		return err
 	}
 
-	glog.Info("succeeded to get exceptional tasks: ", taskList)
+	gs_Logger.Info("succeeded to get exceptional tasks", logging.Any("task_list", taskList))
 	return nil
 }
 
 // 修复创建过程异常的任务
 func repairExceptionalTask(taskId taskmodel.TaskIdType) {
 
-	cmd := redistool.DefaultRedis().ZScore(context.Background(), config.CreatingTaskZset,
+	_, err := statestore.Default().ZScore(context.Background(), config.CreatingTaskZset,
 		strconv.FormatUint(uint64(taskId), 10))
 
-	// zscore，取一个不存在的key, go得到的是err(redis: nil)，冒号后面有个空格
-	// zscore，取一个不存在的member时, go得到的是err(redis: nil)，冒号后面有个空格
-	if cmd.Err() == nil {
-		glog.Info("need to do nothing for task: ", taskId)
+	// zscore，取一个不存在的key或member时, 返回errordef.ErrNotFound
+	if err == nil {
+		gs_Logger.Info("need to do nothing for task", logging.TaskId(taskId))
 		return
 	}
 
-	glog.Info("try to repair task creation: ", taskId)
+	gs_Logger.Info("try to repair task creation", logging.TaskId(taskId))
 
 	// 重新获取任务结构
 	var taskParam = taskmodel.TaskParam{}
-	err := RefillTaskParam(taskId, &taskParam)
+	err = RefillTaskParam(taskId, &taskParam)
 	if err != nil {
-		glog.Warning("failed to refill task record for task: ", taskId, err)
+		gs_Logger.Warning("failed to refill task record for task", logging.TaskId(taskId), logging.Err(err))
 		return
 	}
 
@@ -98,24 +75,24 @@ func RefillTaskParam(
 ) error {
 
 	if taskId == 0 {
-		glog.Warning("invalid task id")
+		gs_Logger.Warning("invalid task id")
 		return errors.New("invalid task id")
 	}
 
 	createParam := tasklogicdef.TaskCreateParam{}
 	err := tasktool.GetTaskCreateParam(taskId, &createParam)
 	if err != nil {
-		glog.Warning("failed to get the create param of task: ", taskId, err)
+		gs_Logger.Warning("failed to get the create param of task", logging.TaskId(taskId), logging.Err(err))
 		return err
 	}
 
 	var typeParam string
 	err = tasktool.GetTaskRawTypeParam(taskId, &typeParam)
 	if err != nil {
-		glog.Warning("failed to get the type param of task: ", taskId, err)
+		gs_Logger.Warning("failed to get the type param of task", logging.TaskId(taskId), logging.Err(err))
 		return err
 	}
 
-	glog.Info("succeeded to get init task record of task: ", taskId)
+	gs_Logger.Info("succeeded to get init task record of task", logging.TaskId(taskId))
 	return nil
 }
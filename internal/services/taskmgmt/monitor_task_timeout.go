@@ -5,12 +5,10 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/go-redis/redis/v8"
-	"github.com/golang/glog"
-
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
 	"github.com/danenmao/pterergate-dtf/internal/config"
-	"github.com/danenmao/pterergate-dtf/internal/redistool"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
 	"github.com/danenmao/pterergate-dtf/internal/tasktool"
 )
 
@@ -25,26 +23,25 @@ func MonitorTaskTimeout() {
 	// 设置这些任务为超时状态
 	for _, taskId := range taskList {
 
-		cmd := redistool.DefaultRedis().ZRem(context.Background(), config.TaskZset, taskId)
-		val, err := cmd.Result()
+		val, err := statestore.Default().ZRem(context.Background(), config.TaskZset, taskId)
 		if err != nil {
-			glog.Warning("failed to zrem task from task list: ", err.Error())
+			gs_Logger.Warning("failed to zrem task from task list", logging.Err(err))
 			continue
 		}
 
 		// 为0, 表示被其他例程处理了
 		if val == 0 {
-			glog.Info("owned by other: ", taskId)
+			gs_Logger.Info("owned by other", logging.TaskId(taskId))
 			continue
 		}
 
 		err = setTaskTimeout(taskId)
 		if err != nil {
-			glog.Warning("failed to set task timeout: ", taskId)
+			gs_Logger.Warning("failed to set task timeout", logging.TaskId(taskId))
 			continue
 		}
 
-		glog.Info("succeeded to set task timeout: ", taskId)
+		gs_Logger.Info("succeeded to set task timeout", logging.TaskId(taskId))
 	}
 }
 
@@ -53,36 +50,33 @@ func getTimeoutTask(taskList *[]taskmodel.TaskIdType) {
 
 	// 检查过期时间戳在当前时间之前的元素数目
 	currentTime := strconv.FormatUint(uint64(time.Now().Unix()), 10)
-	countCmd := redistool.DefaultRedis().ZCount(context.Background(), config.TaskZset, "-inf", currentTime)
-	_, err := countCmd.Result()
+	count, err := statestore.Default().ZCount(context.Background(), config.TaskZset, "-inf", currentTime)
 	if err != nil {
-		glog.Warning("failed to get count of timeout task: ", err.Error())
+		gs_Logger.Warning("failed to get count of timeout task", logging.Err(err))
 		return
 	}
 
-	glog.Info("found timeout task, count: ", countCmd.Val())
-	if countCmd.Val() == 0 {
+	gs_Logger.Info("found timeout tasks", logging.Any("count", count))
+	if count == 0 {
 		return
 	}
 
 	// 取前五个过期的元素
-	opt := redis.ZRangeBy{
+	opt := statestore.RangeBy{
 		Min: "-inf", Max: currentTime,
 		Offset: 0, Count: 5,
 	}
 
-	rangeCmd := redistool.DefaultRedis().ZRangeByScore(context.Background(), config.TaskZset, &opt)
-	err = rangeCmd.Err()
+	strArr, err := statestore.Default().ZRangeByScore(context.Background(), config.TaskZset, &opt)
 	if err != nil {
-		glog.Warning("failed to get timeout taskid: ", err.Error())
+		gs_Logger.Warning("failed to get timeout taskid", logging.Err(err))
 		return
 	}
 
-	strArr, _ := rangeCmd.Result()
 	for _, str := range strArr {
 		taskId, err := strconv.ParseUint(str, 10, 64)
 		if err != nil {
-			glog.Warning("failed to convert task id: ", str, err.Error())
+			gs_Logger.Warning("failed to convert task id", logging.Any("str", str), logging.Err(err))
 			continue
 		}
 
@@ -90,7 +84,7 @@ func getTimeoutTask(taskList *[]taskmodel.TaskIdType) {
 	}
 
 	if len(*taskList) > 0 {
-		glog.Info("get timeout task list: ", *taskList)
+		gs_Logger.Info("get timeout task list", logging.Any("task_list", *taskList))
 	}
 }
 
@@ -103,9 +97,9 @@ func setTaskTimeout(taskId taskmodel.TaskIdType) error {
 func RemoveFromRunningList(taskId taskmodel.TaskIdType) error {
 
 	// 从redis_task_schedule_zset 中删除taskid
-	err := redistool.DefaultRedis().ZRem(context.Background(), config.RunningTaskZset, taskId)
+	_, err := statestore.Default().ZRem(context.Background(), config.RunningTaskZset, taskId)
 	if err != nil {
-		glog.Warningf("failed to remove from running task list: %d, %s", taskId, err.Err().Error())
+		gs_Logger.Warning("failed to remove from running task list", logging.TaskId(taskId), logging.Err(err))
 	}
 
 	return nil
@@ -0,0 +1,104 @@
+package taskmgmt
+
+import (
+	"context"
+	"encoding/base64"
+	"strconv"
+
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/internal/dbdef"
+	"github.com/danenmao/pterergate-dtf/internal/resultstore"
+)
+
+// 查询任务的子任务结果, 按子任务ID分页返回
+// 未配置结果存储时返回ErrUninitialized
+func ListSubtaskResults(
+	taskId taskmodel.TaskIdType,
+	filter *taskmodel.SubtaskResultFilter,
+	page *taskmodel.PageParam,
+	list *taskmodel.SubtaskResultList,
+) error {
+
+	if taskId == 0 || page.Limit < 0 {
+		return errordef.ErrInvalidParameter
+	}
+
+	storeFilter := resultstore.SubtaskResultFilter{
+		TaskId:     uint64(taskId),
+		Result:     uint32(filter.Result),
+		ResultCode: filter.ResultCode,
+		Limit:      page.Limit,
+	}
+
+	if storeFilter.Limit == 0 {
+		storeFilter.Limit = DefaultTaskListLimit
+	} else if storeFilter.Limit > MaxTaskListLimit {
+		storeFilter.Limit = MaxTaskListLimit
+	}
+
+	if len(page.Cursor) > 0 {
+		afterId, err := decodeSubtaskCursor(page.Cursor)
+		if err != nil {
+			gs_Logger.Warning("invalid subtask result cursor", logging.TaskId(taskId), logging.Any("cursor", page.Cursor))
+			return errordef.ErrInvalidParameter
+		}
+
+		storeFilter.AfterId = afterId
+	}
+
+	// 多查一条记录以判断是否有下一页
+	limit := storeFilter.Limit
+	storeFilter.Limit = limit + 1
+
+	records := []dbdef.DBSubtaskResult{}
+	err := resultstore.Default().ListSubtaskResults(context.Background(), &storeFilter, &records)
+	if err == errordef.ErrUninitialized {
+		return err
+	}
+
+	if err != nil {
+		gs_Logger.Warning("failed to list subtask results", logging.TaskId(taskId), logging.Err(err))
+		return errordef.ErrOperationFailed
+	}
+
+	list.Results = make([]taskmodel.SubtaskResultRecord, 0, len(records))
+	list.NextCursor = ""
+	if len(records) > limit {
+		records = records[:limit]
+		list.NextCursor = encodeSubtaskCursor(records[limit-1].SubtaskId)
+	}
+
+	for _, record := range records {
+		list.Results = append(list.Results, taskmodel.SubtaskResultRecord{
+			SubtaskId:  taskmodel.SubtaskIdType(record.SubtaskId),
+			TaskId:     taskmodel.TaskIdType(record.TaskId),
+			TaskType:   record.TaskType,
+			Result:     taskmodel.SubtaskResultType(record.Result),
+			ResultCode: record.ResultCode,
+			ResultMsg:  record.ResultMsg,
+			ResultBody: record.ResultBody,
+			TypeParam:  record.TypeParam,
+			StartTime:  parseRecordTime(record.StartTime),
+			EndTime:    parseRecordTime(record.EndTime),
+			TimeCost:   record.TimeCost,
+			Attempts:   record.Attempts,
+		})
+	}
+
+	return nil
+}
+
+func encodeSubtaskCursor(subtaskId uint64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(subtaskId, 10)))
+}
+
+func decodeSubtaskCursor(value string) (uint64, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseUint(string(data), 10, 64)
+}
@@ -0,0 +1,73 @@
+package taskmgmt
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/internal/resultstore"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
+	"github.com/danenmao/pterergate-dtf/internal/subtasktool"
+	"github.com/danenmao/pterergate-dtf/internal/tasktool"
+)
+
+func Test_ListSubtaskResults(t *testing.T) {
+	statestore.SetDefault(statestore.NewMemoryStore())
+	defer statestore.SetDefault(statestore.NewRedisStore())
+
+	Convey("the results are not saved without a result store", t, func() {
+		list := taskmodel.SubtaskResultList{}
+		err := ListSubtaskResults(1, &taskmodel.SubtaskResultFilter{}, &taskmodel.PageParam{}, &list)
+		So(err, ShouldEqual, errordef.ErrUninitialized)
+	})
+
+	resultstore.SetDefault(resultstore.NewMemoryStore())
+	defer resultstore.SetDefault(resultstore.NewDiscardStore())
+
+	results := []*taskmodel.SubtaskResult{}
+	for id := taskmodel.SubtaskIdType(1); id <= 3; id++ {
+		subtask := taskmodel.SubtaskBody{SubtaskId: id, TaskId: 1, TaskType: 7, TypeParam: `{"input":1}`}
+		tasktool.CreateSubtaskInfoKey(uint64(id), &subtask)
+		tasktool.AddSubtaskToRunningList(&[]taskmodel.SubtaskBody{subtask})
+		results = append(results, &taskmodel.SubtaskResult{
+			SubtaskId: id, TaskId: 1, Result: taskmodel.SubtaskResult_Failure, ResultMsg: "bad input",
+		})
+	}
+
+	// 超时的子任务只有子任务ID
+	results[2] = &taskmodel.SubtaskResult{SubtaskId: 3, Result: taskmodel.SubtaskResult_Timeout}
+
+	Convey("list the saved results page by page", t, func() {
+		So(subtasktool.SaveSubtaskResults(results), ShouldBeNil)
+
+		page := taskmodel.PageParam{Limit: 2}
+		list := taskmodel.SubtaskResultList{}
+		So(ListSubtaskResults(1, &taskmodel.SubtaskResultFilter{}, &page, &list), ShouldBeNil)
+		So(len(list.Results), ShouldEqual, 2)
+		So(list.Results[0].TypeParam, ShouldEqual, `{"input":1}`)
+		So(list.Results[0].ResultMsg, ShouldEqual, "bad input")
+		So(list.Results[0].TaskType, ShouldEqual, 7)
+		So(list.Results[0].Attempts, ShouldEqual, 1)
+		So(list.Results[0].StartTime.IsZero(), ShouldBeFalse)
+		So(list.NextCursor, ShouldNotBeEmpty)
+
+		page.Cursor = list.NextCursor
+		So(ListSubtaskResults(1, &taskmodel.SubtaskResultFilter{}, &page, &list), ShouldBeNil)
+		So(len(list.Results), ShouldEqual, 1)
+		So(list.Results[0].SubtaskId, ShouldEqual, 3)
+		So(list.Results[0].Result, ShouldEqual, taskmodel.SubtaskResult_Timeout)
+		So(list.NextCursor, ShouldBeEmpty)
+	})
+
+	Convey("filter the results", t, func() {
+		filter := taskmodel.SubtaskResultFilter{Result: taskmodel.SubtaskResult_Failure}
+		list := taskmodel.SubtaskResultList{}
+		So(ListSubtaskResults(1, &filter, &taskmodel.PageParam{}, &list), ShouldBeNil)
+		So(len(list.Results), ShouldEqual, 2)
+
+		So(ListSubtaskResults(1, &filter, &taskmodel.PageParam{Cursor: "!"}, &list), ShouldEqual, errordef.ErrInvalidParameter)
+		So(ListSubtaskResults(0, &filter, &taskmodel.PageParam{}, &list), ShouldEqual, errordef.ErrInvalidParameter)
+	})
+}
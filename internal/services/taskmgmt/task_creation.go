@@ -6,14 +6,13 @@ import (
 	"errors"
 	"time"
 
-	"github.com/golang/glog"
-
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
 	"github.com/danenmao/pterergate-dtf/internal/basedef"
 	"github.com/danenmao/pterergate-dtf/internal/config"
 	"github.com/danenmao/pterergate-dtf/internal/dbdef"
 	"github.com/danenmao/pterergate-dtf/internal/idtool"
-	"github.com/danenmao/pterergate-dtf/internal/redistool"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
 	"github.com/danenmao/pterergate-dtf/internal/taskframework/tasklogic/tasklogicdef"
 	"github.com/danenmao/pterergate-dtf/internal/tasktool"
 )
@@ -24,26 +23,27 @@ func TaskCreationRoutine(
 	taskType uint32,
 	taskParam *taskmodel.TaskParam,
 ) {
-	glog.Info("begin to create a task: ", taskId)
+	gs_Logger.Info("begin to create a task", logging.TaskId(taskId))
 
 	// 将 $taskid 添加到创建中的任务列表
 	err := tasktool.AddTaskToCreatingQueue(taskId)
 	if err != nil {
-		glog.Warning("failed to add task to creating queue, return: ", err)
+		gs_Logger.Warning("failed to add task to creating queue, return", logging.Err(err))
 		return
 	}
 
 	// 为任务添加task info key, 为 hash key.
 	err = tasktool.CreateTaskInfoKey(taskId, taskParam)
 	if err != nil {
-		glog.Warning("failed to add task to creating task list: ", taskId, err.Error())
+		gs_Logger.Warning("failed to add task to creating task list",
+			logging.TaskId(taskId), logging.Err(err))
 		return
 	}
 
 	// 将任务添加到已存在任务列表中, 表示任务已经存在
 	err = tasktool.AddTaskToExistingTaskList(taskId, taskParam.Timeout)
 	if err != nil {
-		glog.Warning("failed to add task to existing list, return: ", err)
+		gs_Logger.Warning("failed to add task to existing list, return", logging.Err(err))
 		return
 	}
 
@@ -62,7 +62,7 @@ func TaskCreationRoutine(
 
 	// 结束创建过程
 	finishInitialization(taskId, taskType)
-	glog.Info("succeeded to create a task, task creation routine exited: ", taskId)
+	gs_Logger.Info("succeeded to create a task, task creation routine exited", logging.TaskId(taskId))
 }
 
 // 生成任务ID
@@ -70,7 +70,7 @@ func generateTaskId() (taskmodel.TaskIdType, error) {
 
 	id, err := idtool.GetId(config.TaskIdKey)
 	if err != nil {
-		glog.Warning("failed to get task id: ", err)
+		gs_Logger.Warning("failed to get task id", logging.Err(err))
 		return 0, errors.New("failed to get task id")
 	}
 
@@ -109,11 +109,13 @@ func addTaskRecord(
 	taskRecord.TaskType = param.TaskType
 	taskRecord.Name = param.TaskName
 	taskRecord.Description = param.Description
+	taskRecord.ResourceGroup = param.ResourceGroup
+	taskRecord.Priority = param.Priority
 
 	// 往数据库中添加任务记录
 	err := tasktool.AddTaskRecord(taskRecord)
 	if err != nil {
-		glog.Warning("failed to add task record: ", err.Error())
+		gs_Logger.Warning("failed to add task record", logging.Err(err))
 		return err
 	}
 
@@ -133,19 +135,20 @@ func saveInitTaskRecord(taskRecord *dbdef.DBTaskRecord) {
 
 	data, err := json.Marshal(taskRecord)
 	if err != nil {
-		glog.Warning("failed to marshal task record: ", taskRecord.Id, err)
+		gs_Logger.Warning("failed to marshal task record", logging.TaskId(taskRecord.Id), logging.Err(err))
 		return
 	}
 
 	taskInfoKey := tasktool.GetTaskInfoKey(taskmodel.TaskIdType(taskRecord.Id))
-	cmd := redistool.DefaultRedis().HSet(context.Background(), taskInfoKey,
+	err = statestore.Default().HSet(context.Background(), taskInfoKey,
 		config.TaskInfo_InitTaskRecord, string(data))
-	if cmd.Err() != nil {
-		glog.Warning("failed to set init task record for task: ", taskRecord.Id, cmd.Err())
+	if err != nil {
+		gs_Logger.Warning("failed to set init task record for task",
+			logging.TaskId(taskRecord.Id), logging.Err(err))
 		return
 	}
 
-	glog.Info("succeeded to save init task record of task: ", taskRecord.Id)
+	gs_Logger.Info("succeeded to save init task record of task", logging.TaskId(taskRecord.Id))
 }
 
 // 完成任务初始化
@@ -153,5 +156,12 @@ func finishInitialization(
 	taskId taskmodel.TaskIdType,
 	taskType uint32,
 ) {
-	glog.Info("succeeded to finish initialization of task: ", taskId)
+	// 将任务交给生成器
+	err := tasktool.AddTaskToGenerateQueue(taskId)
+	if err != nil {
+		gs_Logger.Warning("failed to add task to generate queue", logging.TaskId(taskId), logging.Err(err))
+		return
+	}
+
+	gs_Logger.Info("succeeded to finish initialization of task", logging.TaskId(taskId))
 }
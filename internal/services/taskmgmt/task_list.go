@@ -0,0 +1,153 @@
+package taskmgmt
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/internal/dbdef"
+	"github.com/danenmao/pterergate-dtf/internal/recordstore"
+)
+
+// 每页的默认和最大记录数
+const (
+	DefaultTaskListLimit = 20
+	MaxTaskListLimit     = 1000
+)
+
+// 排序字段对应的任务表的列
+var gs_TaskSortColumn = map[taskmodel.TaskSortField]string{
+	taskmodel.TaskSort_CreateTime: dbdef.TaskTable_Id,
+	taskmodel.TaskSort_StartTime:  dbdef.TaskTable_StartTime,
+	taskmodel.TaskSort_FinishTime: dbdef.TaskTable_FinishTime,
+}
+
+// 查询任务表, 多查一条记录以判断是否有下一页
+func listTasks(query *taskmodel.TaskQuery, list *taskmodel.TaskList) error {
+	filter := recordstore.TaskRecordFilter{}
+	err := toTaskRecordFilter(query, &filter)
+	if err != nil {
+		return err
+	}
+
+	limit := filter.Limit
+	filter.Limit = limit + 1
+
+	records := []dbdef.DBTaskRecord{}
+	err = recordstore.Default().ListTaskRecords(context.Background(), &filter, &records)
+	if err != nil {
+		gs_Logger.Warning("failed to list task records", logging.Err(err))
+		return errordef.ErrOperationFailed
+	}
+
+	list.Tasks = make([]taskmodel.TaskSummary, 0, len(records))
+	list.NextCursor = ""
+	if len(records) > limit {
+		records = records[:limit]
+		list.NextCursor = encodeTaskCursor(filter.CursorOf(&records[limit-1]))
+	}
+
+	for idx := range records {
+		summary := taskmodel.TaskSummary{}
+		toTaskSummary(&records[idx], &summary)
+		list.Tasks = append(list.Tasks, summary)
+	}
+
+	return nil
+}
+
+// 将查询条件转换为任务表的查询条件
+func toTaskRecordFilter(query *taskmodel.TaskQuery, filter *recordstore.TaskRecordFilter) error {
+	column, ok := gs_TaskSortColumn[query.SortBy]
+	if !ok || query.Limit < 0 {
+		return errordef.ErrInvalidParameter
+	}
+
+	*filter = recordstore.TaskRecordFilter{
+		TaskStatus:    uint8(query.TaskStatus),
+		TaskType:      query.TaskType,
+		UID:           query.CreatorUID,
+		ResourceGroup: query.ResourceGroup,
+		NamePrefix:    query.NamePrefix,
+		OrderBy:       column,
+		Ascending:     query.Ascending,
+		Limit:         query.Limit,
+	}
+
+	if !query.StartTimeFrom.IsZero() {
+		filter.StartTimeFrom = query.StartTimeFrom.Local().Format(dbdef.GoTimeFormatStr)
+	}
+
+	if !query.StartTimeTo.IsZero() {
+		filter.StartTimeTo = query.StartTimeTo.Local().Format(dbdef.GoTimeFormatStr)
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = DefaultTaskListLimit
+	} else if filter.Limit > MaxTaskListLimit {
+		filter.Limit = MaxTaskListLimit
+	}
+
+	if len(query.Cursor) > 0 {
+		cursor := recordstore.TaskRecordCursor{}
+		err := decodeTaskCursor(query.Cursor, &cursor)
+		if err != nil {
+			gs_Logger.Warning("invalid task list cursor", logging.Any("cursor", query.Cursor))
+			return errordef.ErrInvalidParameter
+		}
+
+		filter.After = &cursor
+	}
+
+	return nil
+}
+
+// 将任务记录转换为任务列表中的任务
+func toTaskSummary(record *dbdef.DBTaskRecord, summary *taskmodel.TaskSummary) {
+	*summary = taskmodel.TaskSummary{
+		TaskId:        taskmodel.TaskIdType(record.Id),
+		TaskType:      record.TaskType,
+		TaskStatus:    taskmodel.TaskStatusType(record.TaskStatus),
+		Priority:      record.Priority,
+		StartTime:     parseRecordTime(record.StartTime),
+		FinishTime:    parseRecordTime(record.FinishTime),
+		TimeCost:      record.TimeCost,
+		ResourceGroup: record.ResourceGroup,
+		TaskName:      record.Name,
+		Description:   record.Description,
+		Creator:       taskmodel.TaskCreator{UID: record.UID, Name: record.Creator},
+	}
+}
+
+// 解析任务表中的时间, 空时间返回零值
+func parseRecordTime(value string) time.Time {
+	if len(value) == 0 || value == dbdef.DBNullTimeStr {
+		return time.Time{}
+	}
+
+	t, err := time.ParseInLocation(dbdef.GoTimeFormatStr, value, time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return t
+}
+
+// 游标对调用者是不透明的字符串
+func encodeTaskCursor(cursor recordstore.TaskRecordCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeTaskCursor(value string, cursor *recordstore.TaskRecordCursor) error {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, cursor)
+}
@@ -0,0 +1,70 @@
+package taskmgmt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/internal/dbdef"
+	"github.com/danenmao/pterergate-dtf/internal/recordstore"
+)
+
+func Test_ListTasks(t *testing.T) {
+	recordstore.SetDefault(recordstore.NewMemoryRecordStore())
+	defer recordstore.SetDefault(recordstore.NewSQLRecordStore())
+
+	for id := uint64(1); id <= 5; id++ {
+		recordstore.Default().AddTaskRecord(context.Background(), &dbdef.DBTaskRecord{
+			Id:         id,
+			UID:        id % 2,
+			Creator:    "user",
+			StartTime:  time.Date(2023, 1, int(id), 0, 0, 0, 0, time.Local).Format(dbdef.GoTimeFormatStr),
+			FinishTime: dbdef.DBNullTimeStr,
+			TaskStatus: uint8(taskmodel.TaskStatus_Running),
+		})
+	}
+
+	Convey("list tasks page by page", t, func() {
+		query := taskmodel.TaskQuery{CreatorUID: 1, Limit: 2}
+		list := taskmodel.TaskList{}
+		So(ListTasks(&query, &list), ShouldBeNil)
+		So(len(list.Tasks), ShouldEqual, 2)
+		So(list.Tasks[0].TaskId, ShouldEqual, 5)
+		So(list.Tasks[1].TaskId, ShouldEqual, 3)
+		So(list.Tasks[0].Creator.UID, ShouldEqual, 1)
+		So(list.Tasks[0].StartTime.Day(), ShouldEqual, 5)
+		So(list.Tasks[0].FinishTime.IsZero(), ShouldBeTrue)
+		So(list.NextCursor, ShouldNotBeEmpty)
+
+		query.Cursor = list.NextCursor
+		So(ListTasks(&query, &list), ShouldBeNil)
+		So(len(list.Tasks), ShouldEqual, 1)
+		So(list.Tasks[0].TaskId, ShouldEqual, 1)
+		So(list.NextCursor, ShouldBeEmpty)
+	})
+
+	Convey("list tasks by start time", t, func() {
+		query := taskmodel.TaskQuery{
+			SortBy:        taskmodel.TaskSort_StartTime,
+			Ascending:     true,
+			StartTimeFrom: time.Date(2023, 1, 2, 0, 0, 0, 0, time.Local),
+			StartTimeTo:   time.Date(2023, 1, 4, 0, 0, 0, 0, time.Local),
+		}
+
+		list := taskmodel.TaskList{}
+		So(ListTasks(&query, &list), ShouldBeNil)
+		So(len(list.Tasks), ShouldEqual, 2)
+		So(list.Tasks[0].TaskId, ShouldEqual, 2)
+		So(list.Tasks[1].TaskId, ShouldEqual, 3)
+	})
+
+	Convey("reject invalid queries", t, func() {
+		list := taskmodel.TaskList{}
+		So(ListTasks(&taskmodel.TaskQuery{Cursor: "!"}, &list), ShouldEqual, errordef.ErrInvalidParameter)
+		So(ListTasks(&taskmodel.TaskQuery{SortBy: 9}, &list), ShouldEqual, errordef.ErrInvalidParameter)
+	})
+}
@@ -1,22 +1,35 @@
 package taskmgmt
 
 import (
-	"github.com/golang/glog"
-
 	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/dtf/tracing"
 	"github.com/danenmao/pterergate-dtf/internal/dbdef"
+	"github.com/danenmao/pterergate-dtf/internal/taskframework/tasklogic/generationqueue"
+	"github.com/danenmao/pterergate-dtf/internal/tasktool"
 )
 
+// logger of the task management service
+var gs_Logger = logging.With(logging.Role("manager"))
+
 // 创建任务
 func CreateTask(taskType uint32, param *taskmodel.TaskParam) (taskmodel.TaskIdType, error) {
+	// 任务的trace从创建开始, 后续的子任务都属于这个trace
+	span := tracing.StartSpan(param.TraceParent, tracing.SpanName_CreateTask)
+	span.SetAttribute("task_type", taskType)
+	defer span.End()
+
 	// 获取任务ID
 	taskId, err := generateTaskId()
 	if err != nil {
-		glog.Warning("failed to create a task id: ", err)
+		gs_Logger.Warning("failed to create a task id", logging.Err(err))
+		span.SetError(err)
 		return 0, errordef.ErrOperationFailed
 	}
 
+	span.SetAttribute("task_id", taskId)
+
 	// 创建任务记录
 	var taskRecord = dbdef.DBTaskRecord{}
 	initTaskRecord(&taskRecord)
@@ -28,33 +41,58 @@ func CreateTask(taskType uint32, param *taskmodel.TaskParam) (taskmodel.TaskIdTy
 	// 向MySQL中添加任务记录
 	err = addTaskRecord(taskId, param, &taskRecord)
 	if err != nil {
-		glog.Warning("failed to add task record: ", taskId, err)
+		gs_Logger.Warning("failed to add task record", logging.TaskId(taskId), logging.Err(err))
 		return 0, nil
 	}
 
-	// 启动创建协程
-	go TaskCreationRoutine(taskId, taskType, param)
+	// 启动创建协程, 记录任务的跟踪上下文
+	taskParam := *param
+	taskParam.TraceParent = span.TraceParent()
+	go TaskCreationRoutine(taskId, taskType, &taskParam)
 
-	glog.Info("succeeded to create a task: ", taskId)
+	gs_Logger.Info("succeeded to create a task", logging.TaskId(taskId))
 	return taskId, nil
 }
 
 // 暂停任务
+// 暂停的任务继续生成子任务, 但调度器不再分发它的子任务
 func PauseTask(taskId taskmodel.TaskIdType) error {
-	return nil
+	return changeTaskStatus(taskId, taskmodel.TaskStatus_Paused, taskmodel.TaskStatus_Running)
 }
 
 // 恢复暂停中的任务
 func ResumeTask(taskId taskmodel.TaskIdType) error {
-	return nil
+	return changeTaskStatus(taskId, taskmodel.TaskStatus_Running, taskmodel.TaskStatus_Paused)
 }
 
 // 停止正在运行中的任务
+// 丢弃未分发的子任务, 执行中的子任务的结果不再交给收集器回调
 func CancelTask(taskId taskmodel.TaskIdType) error {
+	err := changeTaskStatus(taskId, taskmodel.TaskStatus_Cacelled,
+		taskmodel.TaskStatus_Running, taskmodel.TaskStatus_Paused)
+	if err != nil {
+		return err
+	}
+
+	err = generationqueue.ClearSubtasks(taskId)
+	if err != nil {
+		gs_Logger.Warning("failed to clear subtasks of cancelled task", logging.TaskId(taskId), logging.Err(err))
+	}
+
 	return nil
 }
 
 // 查询任务的运行状态
 func GetTaskStatus(taskId taskmodel.TaskIdType, status *taskmodel.TaskStatusData) error {
-	return nil
+	if taskId == 0 {
+		return errordef.ErrInvalidParameter
+	}
+
+	return tasktool.GetTaskStatusData(taskId, status)
+}
+
+// 查询任务列表
+// 结果按查询条件的排序字段分页返回, 以上一页的NextCursor查询下一页
+func ListTasks(query *taskmodel.TaskQuery, list *taskmodel.TaskList) error {
+	return listTasks(query, list)
 }
@@ -1 +1,30 @@
 package taskmgmt
+
+import (
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/internal/tasktool"
+)
+
+// 修改任务的运行状态, 任务的当前状态需为fromList中的一种
+func changeTaskStatus(
+	taskId taskmodel.TaskIdType,
+	to taskmodel.TaskStatusType,
+	fromList ...taskmodel.TaskStatusType,
+) error {
+
+	if taskId == 0 {
+		return errordef.ErrInvalidParameter
+	}
+
+	err := tasktool.ChangeTaskStatus(taskId, to, fromList...)
+	if err != nil {
+		gs_Logger.Warning("failed to change task status",
+			logging.TaskId(taskId), logging.Any("to", to), logging.Err(err))
+		return err
+	}
+
+	gs_Logger.Info("succeeded to change task status", logging.TaskId(taskId), logging.Any("to", to))
+	return nil
+}
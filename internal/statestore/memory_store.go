@@ -0,0 +1,821 @@
+package statestore
+
+import (
+	"context"
+	"encoding"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+)
+
+// 键的类型与操作不匹配
+var ErrWrongType = errors.New("operation against a key holding the wrong kind of value")
+
+// 内存中的键值
+type memoryEntry struct {
+	str      string
+	hash     map[string]string
+	list     []string
+	zset     map[string]float64
+	expireAt time.Time // 零值表示不过期
+}
+
+// 基于内存的状态存储, 用于单进程部署和测试
+// 语义与Redis命令保持一致, 所有操作在一把锁下执行
+type MemoryStore struct {
+	mutex   sync.Mutex
+	entries map[string]*memoryEntry
+}
+
+// 创建基于内存的状态存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: map[string]*memoryEntry{},
+	}
+}
+
+// 获取所有未过期的key, 用于测试和诊断
+func (store *MemoryStore) Keys() []string {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	keys := make([]string, 0, len(store.entries))
+	for key := range store.entries {
+		if store.lookup(key) != nil {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// 取未过期的键值, 调用者需持有锁
+func (store *MemoryStore) lookup(key string) *memoryEntry {
+	entry, ok := store.entries[key]
+	if !ok {
+		return nil
+	}
+
+	if !entry.expireAt.IsZero() && !time.Now().Before(entry.expireAt) {
+		delete(store.entries, key)
+		return nil
+	}
+
+	return entry
+}
+
+// 取键值, 不存在时创建, 调用者需持有锁
+func (store *MemoryStore) lookupOrCreate(key string, create func() *memoryEntry) *memoryEntry {
+	entry := store.lookup(key)
+	if entry == nil {
+		entry = create()
+		store.entries[key] = entry
+	}
+
+	return entry
+}
+
+// 删除变为空的集合类型键, 与Redis的行为一致
+func (store *MemoryStore) dropIfEmpty(key string, entry *memoryEntry) {
+	if entry.hash != nil && len(entry.hash) == 0 ||
+		entry.zset != nil && len(entry.zset) == 0 ||
+		entry.list != nil && len(entry.list) == 0 {
+		delete(store.entries, key)
+	}
+}
+
+func (store *MemoryStore) Get(ctx context.Context, key string) (string, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	entry := store.lookup(key)
+	if entry == nil {
+		return "", errordef.ErrNotFound
+	}
+
+	if entry.hash != nil || entry.list != nil || entry.zset != nil {
+		return "", ErrWrongType
+	}
+
+	return entry.str, nil
+}
+
+func (store *MemoryStore) Set(ctx context.Context, key string, value interface{}, expire time.Duration) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.set(key, value, expire)
+	return nil
+}
+
+func (store *MemoryStore) set(key string, value interface{}, expire time.Duration) {
+	entry := &memoryEntry{str: toString(value)}
+	if expire > 0 {
+		entry.expireAt = time.Now().Add(expire)
+	}
+
+	store.entries[key] = entry
+}
+
+func (store *MemoryStore) Del(ctx context.Context, keys ...string) (int64, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	return store.del(keys...), nil
+}
+
+func (store *MemoryStore) del(keys ...string) int64 {
+	count := int64(0)
+	for _, key := range keys {
+		if store.lookup(key) != nil {
+			delete(store.entries, key)
+			count++
+		}
+	}
+
+	return count
+}
+
+func (store *MemoryStore) Expire(ctx context.Context, key string, expire time.Duration) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.expire(key, expire)
+	return nil
+}
+
+func (store *MemoryStore) expire(key string, expire time.Duration) {
+	entry := store.lookup(key)
+	if entry == nil {
+		return
+	}
+
+	if expire <= 0 {
+		delete(store.entries, key)
+		return
+	}
+
+	entry.expireAt = time.Now().Add(expire)
+}
+
+func (store *MemoryStore) HGet(ctx context.Context, key string, field string) (string, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	entry := store.lookup(key)
+	if entry == nil {
+		return "", errordef.ErrNotFound
+	}
+
+	if entry.hash == nil {
+		return "", ErrWrongType
+	}
+
+	val, ok := entry.hash[field]
+	if !ok {
+		return "", errordef.ErrNotFound
+	}
+
+	return val, nil
+}
+
+func (store *MemoryStore) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	ret := map[string]string{}
+	entry := store.lookup(key)
+	if entry == nil {
+		return ret, nil
+	}
+
+	if entry.hash == nil {
+		return nil, ErrWrongType
+	}
+
+	for field, val := range entry.hash {
+		ret[field] = val
+	}
+
+	return ret, nil
+}
+
+func (store *MemoryStore) HSet(ctx context.Context, key string, field string, value interface{}) error {
+	return store.HMSet(ctx, key, map[string]interface{}{field: value})
+}
+
+func (store *MemoryStore) HMSet(ctx context.Context, key string, values map[string]interface{}) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	return store.hmset(key, values)
+}
+
+func (store *MemoryStore) hmset(key string, values map[string]interface{}) error {
+	entry := store.lookupOrCreate(key, func() *memoryEntry {
+		return &memoryEntry{hash: map[string]string{}}
+	})
+
+	if entry.hash == nil {
+		return ErrWrongType
+	}
+
+	for field, val := range values {
+		entry.hash[field] = toString(val)
+	}
+
+	return nil
+}
+
+func (store *MemoryStore) HIncrBy(ctx context.Context, key string, field string, incr int64) (int64, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	return store.hincrby(key, field, incr)
+}
+
+func (store *MemoryStore) hincrby(key string, field string, incr int64) (int64, error) {
+	entry := store.lookupOrCreate(key, func() *memoryEntry {
+		return &memoryEntry{hash: map[string]string{}}
+	})
+
+	if entry.hash == nil {
+		return 0, ErrWrongType
+	}
+
+	current := int64(0)
+	if str, ok := entry.hash[field]; ok {
+		val, err := strconv.ParseInt(str, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+
+		current = val
+	}
+
+	current += incr
+	entry.hash[field] = strconv.FormatInt(current, 10)
+	return current, nil
+}
+
+func (store *MemoryStore) RPush(ctx context.Context, key string, values ...interface{}) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	return store.rpush(key, values...)
+}
+
+func (store *MemoryStore) rpush(key string, values ...interface{}) error {
+	entry := store.lookupOrCreate(key, func() *memoryEntry {
+		return &memoryEntry{list: []string{}}
+	})
+
+	if entry.list == nil {
+		return ErrWrongType
+	}
+
+	for _, val := range values {
+		entry.list = append(entry.list, toString(val))
+	}
+
+	return nil
+}
+
+func (store *MemoryStore) LPop(ctx context.Context, key string) (string, error) {
+	vals, err := store.LPopN(ctx, key, 1)
+	if err != nil {
+		return "", err
+	}
+
+	if len(vals) == 0 {
+		return "", errordef.ErrNotFound
+	}
+
+	return vals[0], nil
+}
+
+func (store *MemoryStore) LPopN(ctx context.Context, key string, count int) ([]string, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	entry := store.lookup(key)
+	if entry == nil {
+		return []string{}, nil
+	}
+
+	if entry.list == nil {
+		return nil, ErrWrongType
+	}
+
+	if count > len(entry.list) {
+		count = len(entry.list)
+	}
+
+	vals := append([]string{}, entry.list[:count]...)
+	entry.list = entry.list[count:]
+	store.dropIfEmpty(key, entry)
+	return vals, nil
+}
+
+func (store *MemoryStore) LPopToZSet(
+	ctx context.Context, key string, zsetKey string, count int, score float64,
+) ([]string, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	entry := store.lookup(key)
+	if entry == nil {
+		return []string{}, nil
+	}
+
+	if entry.list == nil {
+		return nil, ErrWrongType
+	}
+
+	if count > len(entry.list) {
+		count = len(entry.list)
+	}
+
+	vals := append([]string{}, entry.list[:count]...)
+	members := make([]Z, 0, len(vals))
+	for _, val := range vals {
+		members = append(members, Z{Score: score, Member: val})
+	}
+
+	err := store.zadd(zsetKey, members...)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.list = entry.list[count:]
+	store.dropIfEmpty(key, entry)
+	return vals, nil
+}
+
+func (store *MemoryStore) LLen(ctx context.Context, key string) (int64, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	entry := store.lookup(key)
+	if entry == nil {
+		return 0, nil
+	}
+
+	if entry.list == nil {
+		return 0, ErrWrongType
+	}
+
+	return int64(len(entry.list)), nil
+}
+
+func (store *MemoryStore) LRange(ctx context.Context, key string, start int64, stop int64) ([]string, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	entry := store.lookup(key)
+	if entry == nil {
+		return []string{}, nil
+	}
+
+	if entry.list == nil {
+		return nil, ErrWrongType
+	}
+
+	// 负数下标从列表尾部计算
+	length := int64(len(entry.list))
+	if start < 0 {
+		start += length
+	}
+
+	if stop < 0 {
+		stop += length
+	}
+
+	if start < 0 {
+		start = 0
+	}
+
+	if stop >= length {
+		stop = length - 1
+	}
+
+	if start > stop {
+		return []string{}, nil
+	}
+
+	return append([]string{}, entry.list[start:stop+1]...), nil
+}
+
+func (store *MemoryStore) ZAdd(ctx context.Context, key string, members ...Z) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	return store.zadd(key, members...)
+}
+
+func (store *MemoryStore) zadd(key string, members ...Z) error {
+	entry := store.lookupOrCreate(key, func() *memoryEntry {
+		return &memoryEntry{zset: map[string]float64{}}
+	})
+
+	if entry.zset == nil {
+		return ErrWrongType
+	}
+
+	for _, member := range members {
+		entry.zset[toString(member.Member)] = member.Score
+	}
+
+	return nil
+}
+
+func (store *MemoryStore) ZRem(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	return store.zrem(key, members...)
+}
+
+func (store *MemoryStore) zrem(key string, members ...interface{}) (int64, error) {
+	entry := store.lookup(key)
+	if entry == nil {
+		return 0, nil
+	}
+
+	if entry.zset == nil {
+		return 0, ErrWrongType
+	}
+
+	count := int64(0)
+	for _, member := range members {
+		name := toString(member)
+		if _, ok := entry.zset[name]; ok {
+			delete(entry.zset, name)
+			count++
+		}
+	}
+
+	store.dropIfEmpty(key, entry)
+	return count, nil
+}
+
+func (store *MemoryStore) ZRangeByScore(ctx context.Context, key string, opt *RangeBy) ([]string, error) {
+	min, minExclusive, err := parseScoreBound(opt.Min)
+	if err != nil {
+		return nil, err
+	}
+
+	max, maxExclusive, err := parseScoreBound(opt.Max)
+	if err != nil {
+		return nil, err
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	entry := store.lookup(key)
+	if entry == nil {
+		return []string{}, nil
+	}
+
+	if entry.zset == nil {
+		return nil, ErrWrongType
+	}
+
+	// 按分值排序, 分值相同时按成员的字典序排序
+	members := []Z{}
+	for name, score := range entry.zset {
+		if inScoreRange(score, min, minExclusive, max, maxExclusive) {
+			members = append(members, Z{Score: score, Member: name})
+		}
+	}
+
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].Score != members[j].Score {
+			return members[i].Score < members[j].Score
+		}
+		return members[i].Member.(string) < members[j].Member.(string)
+	})
+
+	ret := []string{}
+	for idx, member := range members {
+		if int64(idx) < opt.Offset {
+			continue
+		}
+
+		if opt.Count > 0 && int64(len(ret)) >= opt.Count {
+			break
+		}
+
+		ret = append(ret, member.Member.(string))
+	}
+
+	return ret, nil
+}
+
+func (store *MemoryStore) ZCard(ctx context.Context, key string) (int64, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	entry := store.lookup(key)
+	if entry == nil {
+		return 0, nil
+	}
+
+	if entry.zset == nil {
+		return 0, ErrWrongType
+	}
+
+	return int64(len(entry.zset)), nil
+}
+
+func (store *MemoryStore) ZCount(ctx context.Context, key string, minStr string, maxStr string) (int64, error) {
+	members, err := store.ZRangeByScore(ctx, key, &RangeBy{Min: minStr, Max: maxStr})
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(members)), nil
+}
+
+func (store *MemoryStore) ZScore(ctx context.Context, key string, member string) (float64, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	entry := store.lookup(key)
+	if entry == nil {
+		return 0, errordef.ErrNotFound
+	}
+
+	if entry.zset == nil {
+		return 0, ErrWrongType
+	}
+
+	score, ok := entry.zset[member]
+	if !ok {
+		return 0, errordef.ErrNotFound
+	}
+
+	return score, nil
+}
+
+func (store *MemoryStore) ZAddIfExists(ctx context.Context, key string, member Z) (bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	entry := store.lookup(key)
+	if entry == nil {
+		return false, nil
+	}
+
+	if entry.zset == nil {
+		return false, ErrWrongType
+	}
+
+	name := toString(member.Member)
+	if _, ok := entry.zset[name]; !ok {
+		return false, nil
+	}
+
+	entry.zset[name] = member.Score
+	return true, nil
+}
+
+func (store *MemoryStore) ZRemEach(ctx context.Context, key string, members []interface{}) ([]bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	removed := make([]bool, len(members))
+	for idx, member := range members {
+		count, err := store.zrem(key, member)
+		if err != nil {
+			return nil, err
+		}
+
+		removed[idx] = count > 0
+	}
+
+	return removed, nil
+}
+
+func (store *MemoryStore) IncrBy(ctx context.Context, key string, step int64) (int64, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	current := int64(0)
+	entry := store.lookup(key)
+	if entry != nil {
+		if entry.hash != nil || entry.list != nil || entry.zset != nil {
+			return 0, ErrWrongType
+		}
+
+		val, err := strconv.ParseInt(entry.str, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+
+		current = val
+	}
+
+	current += step
+	if entry == nil {
+		store.entries[key] = &memoryEntry{str: strconv.FormatInt(current, 10)}
+	} else {
+		entry.str = strconv.FormatInt(current, 10)
+	}
+
+	return current, nil
+}
+
+func (store *MemoryStore) SetLockIfAbsent(
+	ctx context.Context, name string, token string, expire time.Duration,
+) (bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if store.lookup(name) != nil {
+		return false, nil
+	}
+
+	store.set(name, token, expire)
+	return true, nil
+}
+
+func (store *MemoryStore) DeleteLockIfOwned(
+	ctx context.Context, name string, token string,
+) (bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	entry := store.lookup(name)
+	if entry == nil || entry.str != token {
+		return false, nil
+	}
+
+	delete(store.entries, name)
+	return true, nil
+}
+
+func (store *MemoryStore) ExpireLockIfOwned(
+	ctx context.Context, name string, token string, expire time.Duration,
+) (bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	entry := store.lookup(name)
+	if entry == nil || entry.str != token {
+		return false, nil
+	}
+
+	entry.expireAt = time.Now().Add(expire)
+	return true, nil
+}
+
+func (store *MemoryStore) Batch() IBatch {
+	return &memoryBatch{store: store}
+}
+
+// 内存存储的批量写操作, Exec时在一把锁下依次执行
+type memoryBatch struct {
+	store *MemoryStore
+	ops   []func() error
+}
+
+func (batch *memoryBatch) add(op func() error) {
+	batch.ops = append(batch.ops, op)
+}
+
+func (batch *memoryBatch) Set(key string, value interface{}, expire time.Duration) {
+	batch.add(func() error { batch.store.set(key, value, expire); return nil })
+}
+
+func (batch *memoryBatch) Del(keys ...string) {
+	batch.add(func() error { batch.store.del(keys...); return nil })
+}
+
+func (batch *memoryBatch) Expire(key string, expire time.Duration) {
+	batch.add(func() error { batch.store.expire(key, expire); return nil })
+}
+
+func (batch *memoryBatch) HSet(key string, field string, value interface{}) {
+	batch.HMSet(key, map[string]interface{}{field: value})
+}
+
+func (batch *memoryBatch) HMSet(key string, values map[string]interface{}) {
+	batch.add(func() error { return batch.store.hmset(key, values) })
+}
+
+func (batch *memoryBatch) HIncrBy(key string, field string, incr int64) {
+	batch.add(func() error { _, err := batch.store.hincrby(key, field, incr); return err })
+}
+
+func (batch *memoryBatch) RPush(key string, values ...interface{}) {
+	batch.add(func() error { return batch.store.rpush(key, values...) })
+}
+
+func (batch *memoryBatch) ZAdd(key string, members ...Z) {
+	batch.add(func() error { return batch.store.zadd(key, members...) })
+}
+
+func (batch *memoryBatch) ZRem(key string, members ...interface{}) {
+	batch.add(func() error { _, err := batch.store.zrem(key, members...); return err })
+}
+
+// 依次执行所有操作, 与Redis pipeline一致, 某个操作失败不影响其他操作, 返回第一个错误
+func (batch *memoryBatch) Exec(ctx context.Context) error {
+	batch.store.mutex.Lock()
+	defer batch.store.mutex.Unlock()
+
+	var firstErr error
+	for _, op := range batch.ops {
+		if err := op(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	batch.ops = nil
+	return firstErr
+}
+
+// 按go-redis的参数编码规则将值转换为字符串
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case int:
+		return strconv.FormatInt(int64(v), 10)
+	case int8:
+		return strconv.FormatInt(int64(v), 10)
+	case int16:
+		return strconv.FormatInt(int64(v), 10)
+	case int32:
+		return strconv.FormatInt(int64(v), 10)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint:
+		return strconv.FormatUint(uint64(v), 10)
+	case uint8:
+		return strconv.FormatUint(uint64(v), 10)
+	case uint16:
+		return strconv.FormatUint(uint64(v), 10)
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 64)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case encoding.BinaryMarshaler:
+		data, err := v.MarshalBinary()
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// 解析分值范围的边界
+func parseScoreBound(bound string) (float64, bool, error) {
+	exclusive := strings.HasPrefix(bound, "(")
+	bound = strings.TrimPrefix(bound, "(")
+
+	switch bound {
+	case "-inf":
+		return math.Inf(-1), exclusive, nil
+	case "+inf", "inf":
+		return math.Inf(1), exclusive, nil
+	}
+
+	score, err := strconv.ParseFloat(bound, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid score bound: %s", bound)
+	}
+
+	return score, exclusive, nil
+}
+
+func inScoreRange(score float64, min float64, minExclusive bool, max float64, maxExclusive bool) bool {
+	if score < min || minExclusive && score == min {
+		return false
+	}
+
+	if score > max || maxExclusive && score == max {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,288 @@
+package statestore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/internal/redistool"
+)
+
+func Test_MemoryStore_KeyValue(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	Convey("key value operations", t, func() {
+		_, err := store.Get(ctx, "key")
+		So(err, ShouldEqual, errordef.ErrNotFound)
+
+		So(store.Set(ctx, "key", []byte("value"), 0), ShouldBeNil)
+		val, err := store.Get(ctx, "key")
+		So(err, ShouldBeNil)
+		So(val, ShouldEqual, "value")
+
+		count, err := store.Del(ctx, "key", "none")
+		So(err, ShouldBeNil)
+		So(count, ShouldEqual, 1)
+
+		_, err = store.Get(ctx, "key")
+		So(err, ShouldEqual, errordef.ErrNotFound)
+	})
+}
+
+func Test_MemoryStore_Expire(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	Convey("expired keys are removed", t, func() {
+		So(store.Set(ctx, "key", "value", 10*time.Millisecond), ShouldBeNil)
+		So(store.HSet(ctx, "hash", "field", 1), ShouldBeNil)
+		So(store.Expire(ctx, "hash", 10*time.Millisecond), ShouldBeNil)
+
+		time.Sleep(20 * time.Millisecond)
+
+		_, err := store.Get(ctx, "key")
+		So(err, ShouldEqual, errordef.ErrNotFound)
+
+		valMap, err := store.HGetAll(ctx, "hash")
+		So(err, ShouldBeNil)
+		So(len(valMap), ShouldEqual, 0)
+	})
+}
+
+func Test_MemoryStore_Hash(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	Convey("hash operations", t, func() {
+		So(store.HMSet(ctx, "hash", map[string]interface{}{
+			"uint":  uint64(10),
+			"float": 1.5,
+			"bool":  true,
+			"id":    taskmodel.TaskIdType(7),
+		}), ShouldBeNil)
+
+		valMap, err := store.HGetAll(ctx, "hash")
+		So(err, ShouldBeNil)
+		So(valMap, ShouldResemble, map[string]string{
+			"uint": "10", "float": "1.5", "bool": "1", "id": "7",
+		})
+
+		val, err := store.HIncrBy(ctx, "hash", "uint", 5)
+		So(err, ShouldBeNil)
+		So(val, ShouldEqual, 15)
+
+		_, err = store.HGet(ctx, "hash", "none")
+		So(err, ShouldEqual, errordef.ErrNotFound)
+
+		_, err = store.Get(ctx, "hash")
+		So(err, ShouldEqual, ErrWrongType)
+	})
+}
+
+func Test_MemoryStore_List(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	Convey("list operations", t, func() {
+		So(store.RPush(ctx, "list", 1, 2, 3), ShouldBeNil)
+
+		count, err := store.LLen(ctx, "list")
+		So(err, ShouldBeNil)
+		So(count, ShouldEqual, 3)
+
+		vals, err := store.LRange(ctx, "list", 0, -1)
+		So(err, ShouldBeNil)
+		So(vals, ShouldResemble, []string{"1", "2", "3"})
+
+		vals, _ = store.LRange(ctx, "list", -2, 10)
+		So(vals, ShouldResemble, []string{"2", "3"})
+
+		val, err := store.LPop(ctx, "list")
+		So(err, ShouldBeNil)
+		So(val, ShouldEqual, "1")
+
+		vals, err = store.LPopN(ctx, "list", 10)
+		So(err, ShouldBeNil)
+		So(vals, ShouldResemble, []string{"2", "3"})
+
+		_, err = store.LPop(ctx, "list")
+		So(err, ShouldEqual, errordef.ErrNotFound)
+	})
+
+	Convey("pop list elements into a sorted set", t, func() {
+		So(store.RPush(ctx, "queue", "a", "b", "c"), ShouldBeNil)
+
+		vals, err := store.LPopToZSet(ctx, "queue", "leased", 2, 5)
+		So(err, ShouldBeNil)
+		So(vals, ShouldResemble, []string{"a", "b"})
+
+		leased, _ := store.ZRangeByScore(ctx, "leased", &RangeBy{Min: "5", Max: "5"})
+		So(leased, ShouldResemble, []string{"a", "b"})
+
+		vals, _ = store.LPopToZSet(ctx, "queue", "leased", 2, 5)
+		So(vals, ShouldResemble, []string{"c"})
+
+		vals, err = store.LPopToZSet(ctx, "queue", "leased", 2, 5)
+		So(err, ShouldBeNil)
+		So(vals, ShouldBeEmpty)
+	})
+}
+
+func Test_MemoryStore_SortedSet(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	Convey("sorted set operations", t, func() {
+		So(store.ZAdd(ctx, "zset",
+			Z{Score: 3, Member: uint64(3)},
+			Z{Score: 1, Member: uint64(1)},
+			Z{Score: 2, Member: uint64(2)},
+		), ShouldBeNil)
+
+		vals, err := store.ZRangeByScore(ctx, "zset", &RangeBy{Min: "-inf", Max: "+inf"})
+		So(err, ShouldBeNil)
+		So(vals, ShouldResemble, []string{"1", "2", "3"})
+
+		vals, err = store.ZRangeByScore(ctx, "zset", &RangeBy{Min: "(1", Max: "3", Offset: 1, Count: 1})
+		So(err, ShouldBeNil)
+		So(vals, ShouldResemble, []string{"3"})
+
+		count, err := store.ZCount(ctx, "zset", "-inf", "2")
+		So(err, ShouldBeNil)
+		So(count, ShouldEqual, 2)
+
+		score, err := store.ZScore(ctx, "zset", "2")
+		So(err, ShouldBeNil)
+		So(score, ShouldEqual, 2)
+
+		removed, err := store.ZRemEach(ctx, "zset", []interface{}{uint64(1), uint64(4), uint64(1)})
+		So(err, ShouldBeNil)
+		So(removed, ShouldResemble, []bool{true, false, false})
+
+		card, err := store.ZCard(ctx, "zset")
+		So(err, ShouldBeNil)
+		So(card, ShouldEqual, 2)
+
+		_, err = store.ZScore(ctx, "zset", "1")
+		So(err, ShouldEqual, errordef.ErrNotFound)
+
+		updated, err := store.ZAddIfExists(ctx, "zset", Z{Score: 9, Member: uint64(2)})
+		So(err, ShouldBeNil)
+		So(updated, ShouldBeTrue)
+		score, _ = store.ZScore(ctx, "zset", "2")
+		So(score, ShouldEqual, 9)
+
+		updated, err = store.ZAddIfExists(ctx, "zset", Z{Score: 9, Member: uint64(1)})
+		So(err, ShouldBeNil)
+		So(updated, ShouldBeFalse)
+		_, err = store.ZScore(ctx, "zset", "1")
+		So(err, ShouldEqual, errordef.ErrNotFound)
+	})
+}
+
+func Test_MemoryStore_IncrBy(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	Convey("incr a counter", t, func() {
+		val, err := store.IncrBy(ctx, "counter", 100)
+		So(err, ShouldBeNil)
+		So(val, ShouldEqual, 100)
+
+		val, err = store.IncrBy(ctx, "counter", 100)
+		So(err, ShouldBeNil)
+		So(val, ShouldEqual, 200)
+	})
+}
+
+func Test_MemoryStore_Batch(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	Convey("batch is applied on exec", t, func() {
+		batch := store.Batch()
+		batch.RPush("list", "a")
+		batch.HIncrBy("hash", "count", 2)
+		batch.ZAdd("zset", Z{Score: 1, Member: "m"})
+
+		count, _ := store.LLen(ctx, "list")
+		So(count, ShouldEqual, 0)
+
+		So(batch.Exec(ctx), ShouldBeNil)
+
+		count, _ = store.LLen(ctx, "list")
+		So(count, ShouldEqual, 1)
+
+		val, _ := store.HGet(ctx, "hash", "count")
+		So(val, ShouldEqual, "2")
+
+		card, _ := store.ZCard(ctx, "zset")
+		So(card, ShouldEqual, 1)
+	})
+}
+
+func Test_MemoryStore_SafeLock(t *testing.T) {
+	store := NewMemoryStore()
+
+	Convey("safe lock on memory store", t, func() {
+		lock := redistool.NewSafeLockWithBackend("lock", time.Minute, store)
+		other := redistool.NewSafeLockWithBackend("lock", time.Minute, store)
+
+		So(lock.TryLock(context.Background()), ShouldBeNil)
+		So(other.TryLock(context.Background()), ShouldEqual, redistool.ErrLockNotAcquired)
+		So(other.Renew(), ShouldEqual, redistool.ErrLockNotOwned)
+		So(other.Unlock(), ShouldEqual, redistool.ErrLockNotOwned)
+		So(lock.Renew(), ShouldBeNil)
+		So(lock.Unlock(), ShouldBeNil)
+		So(other.TryLock(context.Background()), ShouldBeNil)
+	})
+}
+
+func Test_MemoryStore_LeaderElection(t *testing.T) {
+	store := NewMemoryStore()
+	first := redistool.NewLeaderElectorWithBackend("leader", time.Second*3, store)
+	second := redistool.NewLeaderElectorWithBackend("leader", time.Second*3, store)
+
+	first.Campaign()
+	second.Campaign()
+	firstToken := first.FencingToken()
+	firstValid, _ := first.IsFencingTokenValid(firstToken)
+	leader, _ := first.CurrentLeader()
+
+	// 领导者放弃后, 其他实例获得更新的fencing token
+	first.Resign()
+	second.Campaign()
+	staleValid, _ := first.IsFencingTokenValid(firstToken)
+
+	Convey("elect a leader on the memory store", t, func() {
+		So(firstToken, ShouldEqual, 1)
+		So(firstValid, ShouldBeTrue)
+		So(leader, ShouldEqual, first.OwnerId)
+		So(first.IsLeader(), ShouldBeFalse)
+		So(second.IsLeader(), ShouldBeTrue)
+		So(second.FencingToken(), ShouldEqual, 2)
+		So(staleValid, ShouldBeFalse)
+	})
+}
+
+func Test_TryToOwnElements_MemoryStore(t *testing.T) {
+	SetDefault(NewMemoryStore())
+	defer SetDefault(NewRedisStore())
+
+	Default().ZAdd(context.Background(), "zset",
+		Z{Score: 1, Member: uint64(1)}, Z{Score: 2, Member: uint64(2)})
+
+	src := []uint64{1, 2, 3}
+	owned := []uint64{}
+	err := TryToOwnElements("zset", &src, &owned)
+
+	Convey("own the existing elements", t, func() {
+		So(err, ShouldBeNil)
+		So(owned, ShouldResemble, []uint64{1, 2})
+	})
+}
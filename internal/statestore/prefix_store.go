@@ -0,0 +1,198 @@
+package statestore
+
+import (
+	"context"
+	"time"
+)
+
+// 为所有key添加命名空间前缀的状态存储
+// 多个独立的DTF集群共用一个Redis时, 通过不同的前缀隔离
+type PrefixStore struct {
+	Prefix string
+	Store  IStateStore
+}
+
+// 创建带前缀的状态存储
+func NewPrefixStore(prefix string, store IStateStore) *PrefixStore {
+	return &PrefixStore{
+		Prefix: prefix,
+		Store:  store,
+	}
+}
+
+func (store *PrefixStore) key(key string) string {
+	return store.Prefix + key
+}
+
+func (store *PrefixStore) keys(keys []string) []string {
+	prefixed := make([]string, 0, len(keys))
+	for _, key := range keys {
+		prefixed = append(prefixed, store.key(key))
+	}
+
+	return prefixed
+}
+
+func (store *PrefixStore) Get(ctx context.Context, key string) (string, error) {
+	return store.Store.Get(ctx, store.key(key))
+}
+
+func (store *PrefixStore) Set(ctx context.Context, key string, value interface{}, expire time.Duration) error {
+	return store.Store.Set(ctx, store.key(key), value, expire)
+}
+
+func (store *PrefixStore) Del(ctx context.Context, keys ...string) (int64, error) {
+	return store.Store.Del(ctx, store.keys(keys)...)
+}
+
+func (store *PrefixStore) Expire(ctx context.Context, key string, expire time.Duration) error {
+	return store.Store.Expire(ctx, store.key(key), expire)
+}
+
+func (store *PrefixStore) HGet(ctx context.Context, key string, field string) (string, error) {
+	return store.Store.HGet(ctx, store.key(key), field)
+}
+
+func (store *PrefixStore) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	return store.Store.HGetAll(ctx, store.key(key))
+}
+
+func (store *PrefixStore) HSet(ctx context.Context, key string, field string, value interface{}) error {
+	return store.Store.HSet(ctx, store.key(key), field, value)
+}
+
+func (store *PrefixStore) HMSet(ctx context.Context, key string, values map[string]interface{}) error {
+	return store.Store.HMSet(ctx, store.key(key), values)
+}
+
+func (store *PrefixStore) HIncrBy(ctx context.Context, key string, field string, incr int64) (int64, error) {
+	return store.Store.HIncrBy(ctx, store.key(key), field, incr)
+}
+
+func (store *PrefixStore) RPush(ctx context.Context, key string, values ...interface{}) error {
+	return store.Store.RPush(ctx, store.key(key), values...)
+}
+
+func (store *PrefixStore) LPop(ctx context.Context, key string) (string, error) {
+	return store.Store.LPop(ctx, store.key(key))
+}
+
+func (store *PrefixStore) LPopN(ctx context.Context, key string, count int) ([]string, error) {
+	return store.Store.LPopN(ctx, store.key(key), count)
+}
+
+func (store *PrefixStore) LPopToZSet(
+	ctx context.Context, key string, zsetKey string, count int, score float64,
+) ([]string, error) {
+	return store.Store.LPopToZSet(ctx, store.key(key), store.key(zsetKey), count, score)
+}
+
+func (store *PrefixStore) LLen(ctx context.Context, key string) (int64, error) {
+	return store.Store.LLen(ctx, store.key(key))
+}
+
+func (store *PrefixStore) LRange(ctx context.Context, key string, start int64, stop int64) ([]string, error) {
+	return store.Store.LRange(ctx, store.key(key), start, stop)
+}
+
+func (store *PrefixStore) ZAdd(ctx context.Context, key string, members ...Z) error {
+	return store.Store.ZAdd(ctx, store.key(key), members...)
+}
+
+func (store *PrefixStore) ZRem(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	return store.Store.ZRem(ctx, store.key(key), members...)
+}
+
+func (store *PrefixStore) ZRangeByScore(ctx context.Context, key string, opt *RangeBy) ([]string, error) {
+	return store.Store.ZRangeByScore(ctx, store.key(key), opt)
+}
+
+func (store *PrefixStore) ZCard(ctx context.Context, key string) (int64, error) {
+	return store.Store.ZCard(ctx, store.key(key))
+}
+
+func (store *PrefixStore) ZCount(ctx context.Context, key string, min string, max string) (int64, error) {
+	return store.Store.ZCount(ctx, store.key(key), min, max)
+}
+
+func (store *PrefixStore) ZAddIfExists(ctx context.Context, key string, member Z) (bool, error) {
+	return store.Store.ZAddIfExists(ctx, store.key(key), member)
+}
+
+func (store *PrefixStore) ZScore(ctx context.Context, key string, member string) (float64, error) {
+	return store.Store.ZScore(ctx, store.key(key), member)
+}
+
+func (store *PrefixStore) ZRemEach(ctx context.Context, key string, members []interface{}) ([]bool, error) {
+	return store.Store.ZRemEach(ctx, store.key(key), members)
+}
+
+func (store *PrefixStore) IncrBy(ctx context.Context, key string, step int64) (int64, error) {
+	return store.Store.IncrBy(ctx, store.key(key), step)
+}
+
+func (store *PrefixStore) SetLockIfAbsent(
+	ctx context.Context, name string, token string, expire time.Duration,
+) (bool, error) {
+	return store.Store.SetLockIfAbsent(ctx, store.key(name), token, expire)
+}
+
+func (store *PrefixStore) DeleteLockIfOwned(ctx context.Context, name string, token string) (bool, error) {
+	return store.Store.DeleteLockIfOwned(ctx, store.key(name), token)
+}
+
+func (store *PrefixStore) ExpireLockIfOwned(
+	ctx context.Context, name string, token string, expire time.Duration,
+) (bool, error) {
+	return store.Store.ExpireLockIfOwned(ctx, store.key(name), token, expire)
+}
+
+func (store *PrefixStore) Batch() IBatch {
+	return &prefixBatch{store: store, batch: store.Store.Batch()}
+}
+
+// 带前缀的批量写操作
+type prefixBatch struct {
+	store *PrefixStore
+	batch IBatch
+}
+
+func (batch *prefixBatch) Set(key string, value interface{}, expire time.Duration) {
+	batch.batch.Set(batch.store.key(key), value, expire)
+}
+
+func (batch *prefixBatch) Del(keys ...string) {
+	batch.batch.Del(batch.store.keys(keys)...)
+}
+
+func (batch *prefixBatch) Expire(key string, expire time.Duration) {
+	batch.batch.Expire(batch.store.key(key), expire)
+}
+
+func (batch *prefixBatch) HSet(key string, field string, value interface{}) {
+	batch.batch.HSet(batch.store.key(key), field, value)
+}
+
+func (batch *prefixBatch) HMSet(key string, values map[string]interface{}) {
+	batch.batch.HMSet(batch.store.key(key), values)
+}
+
+func (batch *prefixBatch) HIncrBy(key string, field string, incr int64) {
+	batch.batch.HIncrBy(batch.store.key(key), field, incr)
+}
+
+func (batch *prefixBatch) RPush(key string, values ...interface{}) {
+	batch.batch.RPush(batch.store.key(key), values...)
+}
+
+func (batch *prefixBatch) ZAdd(key string, members ...Z) {
+	batch.batch.ZAdd(batch.store.key(key), members...)
+}
+
+func (batch *prefixBatch) ZRem(key string, members ...interface{}) {
+	batch.batch.ZRem(batch.store.key(key), members...)
+}
+
+func (batch *prefixBatch) Exec(ctx context.Context) error {
+	return batch.batch.Exec(ctx)
+}
@@ -0,0 +1,69 @@
+package statestore
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/danenmao/pterergate-dtf/internal/redistool"
+)
+
+func Test_PrefixStore_NoUnprefixedKey(t *testing.T) {
+	memory := NewMemoryStore()
+	store := NewPrefixStore("test:", memory)
+	ctx := context.Background()
+
+	// 执行所有的写操作
+	store.Set(ctx, "key", "value", 0)
+	store.Expire(ctx, "key", time.Minute)
+	store.HSet(ctx, "hash", "field", 1)
+	store.HMSet(ctx, "hmset", map[string]interface{}{"field": 1})
+	store.HIncrBy(ctx, "hincr", "field", 1)
+	store.RPush(ctx, "list", 1, 2)
+	store.ZAdd(ctx, "zset", Z{Score: 1, Member: 1}, Z{Score: 2, Member: 2})
+	store.ZRemEach(ctx, "zset", []interface{}{1})
+	store.IncrBy(ctx, "counter", 1)
+
+	batch := store.Batch()
+	batch.Set("batch.key", "value", 0)
+	batch.HSet("batch.hash", "field", 1)
+	batch.HMSet("batch.hmset", map[string]interface{}{"field": 1})
+	batch.HIncrBy("batch.hincr", "field", 1)
+	batch.RPush("batch.list", 1)
+	batch.ZAdd("batch.zset", Z{Score: 1, Member: 1})
+	batch.Expire("batch.zset", time.Minute)
+	batch.Exec(ctx)
+
+	lock := redistool.NewSafeLockWithBackend("lock", time.Minute, store)
+	lockErr := lock.TryLock(ctx)
+
+	Convey("all keys have the prefix", t, func() {
+		So(lockErr, ShouldBeNil)
+
+		keys := memory.Keys()
+		So(len(keys), ShouldEqual, 14)
+		for _, key := range keys {
+			So(strings.HasPrefix(key, "test:"), ShouldBeTrue)
+		}
+	})
+
+	Convey("reads and deletes use the prefix", t, func() {
+		val, err := store.Get(ctx, "key")
+		So(err, ShouldBeNil)
+		So(val, ShouldEqual, "value")
+
+		vals, err := store.ZRangeByScore(ctx, "zset", &RangeBy{Min: "-inf", Max: "+inf"})
+		So(err, ShouldBeNil)
+		So(vals, ShouldResemble, []string{"2"})
+
+		count, err := store.Del(ctx, "key", "hash")
+		So(err, ShouldBeNil)
+		So(count, ShouldEqual, 2)
+
+		So(lock.Unlock(), ShouldBeNil)
+		So(len(memory.Keys()), ShouldEqual, 11)
+	})
+}
@@ -0,0 +1,275 @@
+package statestore
+
+import (
+	"context"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/internal/redistool"
+)
+
+// 基于Redis的状态存储, 使用redistool的默认连接
+type RedisStore struct {
+	redistool.RedisLockBackend
+}
+
+// 创建基于Redis的状态存储
+func NewRedisStore() *RedisStore {
+	return &RedisStore{}
+}
+
+func (store *RedisStore) client() goredis.UniversalClient {
+	return redistool.DefaultRedis()
+}
+
+// 将redis.Nil转换为errordef.ErrNotFound
+func convertNil(err error) error {
+	if err == goredis.Nil {
+		return errordef.ErrNotFound
+	}
+
+	return err
+}
+
+func toRedisZ(members []Z) []*goredis.Z {
+	zlist := make([]*goredis.Z, 0, len(members))
+	for _, member := range members {
+		zlist = append(zlist, &goredis.Z{Score: member.Score, Member: member.Member})
+	}
+
+	return zlist
+}
+
+func (store *RedisStore) Get(ctx context.Context, key string) (string, error) {
+	val, err := store.client().Get(ctx, key).Result()
+	return val, convertNil(err)
+}
+
+func (store *RedisStore) Set(ctx context.Context, key string, value interface{}, expire time.Duration) error {
+	return store.client().Set(ctx, key, value, expire).Err()
+}
+
+func (store *RedisStore) Del(ctx context.Context, keys ...string) (int64, error) {
+	return store.client().Del(ctx, keys...).Result()
+}
+
+func (store *RedisStore) Expire(ctx context.Context, key string, expire time.Duration) error {
+	return store.client().Expire(ctx, key, expire).Err()
+}
+
+func (store *RedisStore) HGet(ctx context.Context, key string, field string) (string, error) {
+	val, err := store.client().HGet(ctx, key, field).Result()
+	return val, convertNil(err)
+}
+
+func (store *RedisStore) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	return store.client().HGetAll(ctx, key).Result()
+}
+
+func (store *RedisStore) HSet(ctx context.Context, key string, field string, value interface{}) error {
+	return store.client().HSet(ctx, key, field, value).Err()
+}
+
+func (store *RedisStore) HMSet(ctx context.Context, key string, values map[string]interface{}) error {
+	return store.client().HMSet(ctx, key, values).Err()
+}
+
+func (store *RedisStore) HIncrBy(ctx context.Context, key string, field string, incr int64) (int64, error) {
+	return store.client().HIncrBy(ctx, key, field, incr).Result()
+}
+
+func (store *RedisStore) RPush(ctx context.Context, key string, values ...interface{}) error {
+	return store.client().RPush(ctx, key, values...).Err()
+}
+
+func (store *RedisStore) LPop(ctx context.Context, key string) (string, error) {
+	val, err := store.client().LPop(ctx, key).Result()
+	return val, convertNil(err)
+}
+
+// 使用pipeline逐个弹出, 兼容不支持LPOP count参数的Redis版本
+func (store *RedisStore) LPopN(ctx context.Context, key string, count int) ([]string, error) {
+	pipeline := store.client().Pipeline()
+	for i := 0; i < count; i++ {
+		pipeline.LPop(ctx, key)
+	}
+
+	cmdList, err := pipeline.Exec(ctx)
+	if err != nil && err != goredis.Nil {
+		return nil, err
+	}
+
+	vals := []string{}
+	for _, cmd := range cmdList {
+		strCmd, ok := cmd.(*goredis.StringCmd)
+		if !ok || strCmd.Err() != nil {
+			break
+		}
+
+		vals = append(vals, strCmd.Val())
+	}
+
+	return vals, nil
+}
+
+// 逐个弹出元素并加入有序集合, 在脚本中执行保证原子性
+const lpopToZSetScript = `
+local vals = {}
+for i = 1, tonumber(ARGV[1]) do
+	local val = redis.call("LPOP", KEYS[1])
+	if not val then
+		break
+	end
+	redis.call("ZADD", KEYS[2], ARGV[2], val)
+	vals[#vals + 1] = val
+end
+return vals`
+
+func (store *RedisStore) LPopToZSet(
+	ctx context.Context, key string, zsetKey string, count int, score float64,
+) ([]string, error) {
+	vals, err := store.client().Eval(ctx, lpopToZSetScript, []string{key, zsetKey}, count, score).StringSlice()
+	if err != nil && err != goredis.Nil {
+		return nil, err
+	}
+
+	return vals, nil
+}
+
+func (store *RedisStore) LLen(ctx context.Context, key string) (int64, error) {
+	return store.client().LLen(ctx, key).Result()
+}
+
+func (store *RedisStore) LRange(ctx context.Context, key string, start int64, stop int64) ([]string, error) {
+	return store.client().LRange(ctx, key, start, stop).Result()
+}
+
+func (store *RedisStore) ZAdd(ctx context.Context, key string, members ...Z) error {
+	return store.client().ZAdd(ctx, key, toRedisZ(members)...).Err()
+}
+
+func (store *RedisStore) ZRem(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	return store.client().ZRem(ctx, key, members...).Result()
+}
+
+func (store *RedisStore) ZRangeByScore(ctx context.Context, key string, opt *RangeBy) ([]string, error) {
+	return store.client().ZRangeByScore(ctx, key, &goredis.ZRangeBy{
+		Min: opt.Min, Max: opt.Max,
+		Offset: opt.Offset, Count: opt.Count,
+	}).Result()
+}
+
+func (store *RedisStore) ZCard(ctx context.Context, key string) (int64, error) {
+	return store.client().ZCard(ctx, key).Result()
+}
+
+func (store *RedisStore) ZCount(ctx context.Context, key string, min string, max string) (int64, error) {
+	return store.client().ZCount(ctx, key, min, max).Result()
+}
+
+func (store *RedisStore) ZScore(ctx context.Context, key string, member string) (float64, error) {
+	val, err := store.client().ZScore(ctx, key, member).Result()
+	return val, convertNil(err)
+}
+
+// 检查元素存在和修改分数在脚本中执行, 分数不变时也返回存在
+const zaddIfExistsScript = `
+if redis.call("ZSCORE", KEYS[1], ARGV[2]) then
+	redis.call("ZADD", KEYS[1], ARGV[1], ARGV[2])
+	return 1
+end
+return 0`
+
+func (store *RedisStore) ZAddIfExists(ctx context.Context, key string, member Z) (bool, error) {
+	ret, err := store.client().Eval(ctx, zaddIfExistsScript, []string{key}, member.Score, member.Member).Int64()
+	if err != nil {
+		return false, err
+	}
+
+	return ret == 1, nil
+}
+
+// 在事务中逐个删除元素
+func (store *RedisStore) ZRemEach(ctx context.Context, key string, members []interface{}) ([]bool, error) {
+	pipeline := store.client().TxPipeline()
+	for _, member := range members {
+		pipeline.ZRem(ctx, key, member)
+	}
+
+	cmdList, err := pipeline.Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	removed := make([]bool, len(members))
+	for idx, cmd := range cmdList {
+		intCmd, ok := cmd.(*goredis.IntCmd)
+		if !ok || intCmd.Err() != nil {
+			continue
+		}
+
+		removed[idx] = intCmd.Val() > 0
+	}
+
+	return removed, nil
+}
+
+func (store *RedisStore) IncrBy(ctx context.Context, key string, step int64) (int64, error) {
+	return store.client().IncrBy(ctx, key, step).Result()
+}
+
+func (store *RedisStore) Batch() IBatch {
+	return &redisBatch{pipeline: store.client().Pipeline()}
+}
+
+// 基于Redis pipeline的批量写操作
+type redisBatch struct {
+	pipeline goredis.Pipeliner
+}
+
+func (batch *redisBatch) Set(key string, value interface{}, expire time.Duration) {
+	batch.pipeline.Set(context.Background(), key, value, expire)
+}
+
+func (batch *redisBatch) Del(keys ...string) {
+	batch.pipeline.Del(context.Background(), keys...)
+}
+
+func (batch *redisBatch) Expire(key string, expire time.Duration) {
+	batch.pipeline.Expire(context.Background(), key, expire)
+}
+
+func (batch *redisBatch) HSet(key string, field string, value interface{}) {
+	batch.pipeline.HSet(context.Background(), key, field, value)
+}
+
+func (batch *redisBatch) HMSet(key string, values map[string]interface{}) {
+	batch.pipeline.HMSet(context.Background(), key, values)
+}
+
+func (batch *redisBatch) HIncrBy(key string, field string, incr int64) {
+	batch.pipeline.HIncrBy(context.Background(), key, field, incr)
+}
+
+func (batch *redisBatch) RPush(key string, values ...interface{}) {
+	batch.pipeline.RPush(context.Background(), key, values...)
+}
+
+func (batch *redisBatch) ZAdd(key string, members ...Z) {
+	batch.pipeline.ZAdd(context.Background(), key, toRedisZ(members)...)
+}
+
+func (batch *redisBatch) ZRem(key string, members ...interface{}) {
+	batch.pipeline.ZRem(context.Background(), key, members...)
+}
+
+func (batch *redisBatch) Exec(ctx context.Context) error {
+	_, err := batch.pipeline.Exec(ctx)
+	if err == goredis.Nil {
+		return nil
+	}
+
+	return err
+}
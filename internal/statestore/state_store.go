@@ -0,0 +1,126 @@
+package statestore
+
+import (
+	"context"
+	"time"
+
+	"github.com/danenmao/pterergate-dtf/internal/redistool"
+)
+
+// 有序集合的元素
+type Z struct {
+	Score  float64
+	Member interface{}
+}
+
+// 按分值范围查询有序集合的参数
+// Min, Max 的格式与Redis ZRANGEBYSCORE一致, 支持 -inf, +inf 和 ( 前缀
+type RangeBy struct {
+	Min    string
+	Max    string
+	Offset int64
+	Count  int64
+}
+
+// 键值操作, 保存任务的创建参数、调度数据和生成状态
+// 键不存在时, Get返回errordef.ErrNotFound
+type IKeyValueStore interface {
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key string, value interface{}, expire time.Duration) error
+	Del(ctx context.Context, keys ...string) (int64, error)
+	Expire(ctx context.Context, key string, expire time.Duration) error
+}
+
+// 哈希表操作, 保存任务和子任务的信息
+// 键或字段不存在时, HGet返回errordef.ErrNotFound
+type IHashStore interface {
+	HGet(ctx context.Context, key string, field string) (string, error)
+	HGetAll(ctx context.Context, key string) (map[string]string, error)
+	HSet(ctx context.Context, key string, field string, value interface{}) error
+	HMSet(ctx context.Context, key string, values map[string]interface{}) error
+	HIncrBy(ctx context.Context, key string, field string, incr int64) (int64, error)
+}
+
+// 列表操作, 用于生成队列、调度队列和重试队列
+// 列表为空时, LPop返回errordef.ErrNotFound
+type IListStore interface {
+	RPush(ctx context.Context, key string, values ...interface{}) error
+	LPop(ctx context.Context, key string) (string, error)
+	LPopN(ctx context.Context, key string, count int) ([]string, error)
+	LLen(ctx context.Context, key string) (int64, error)
+
+	// 原子地弹出最多count个元素, 并以score加入有序集合zsetKey, 用于租用队列中的元素.
+	// 两个key需位于同一slot
+	LPopToZSet(ctx context.Context, key string, zsetKey string, count int, score float64) ([]string, error)
+
+	// 读取列表中[start, stop]范围内的元素, 不修改列表, 下标的格式与Redis LRANGE一致
+	LRange(ctx context.Context, key string, start int64, stop int64) ([]string, error)
+}
+
+// 有序集合操作, 用于运行中、已完成等任务和子任务集合
+// 元素不存在时, ZScore返回errordef.ErrNotFound
+type ISortedSetStore interface {
+	ZAdd(ctx context.Context, key string, members ...Z) error
+	ZRem(ctx context.Context, key string, members ...interface{}) (int64, error)
+	ZRangeByScore(ctx context.Context, key string, opt *RangeBy) ([]string, error)
+	ZCard(ctx context.Context, key string) (int64, error)
+	ZCount(ctx context.Context, key string, min string, max string) (int64, error)
+	ZScore(ctx context.Context, key string, member string) (float64, error)
+
+	// 逐个删除元素, 返回每个元素是否由本次调用删除, 用于竞争元素的所有权
+	ZRemEach(ctx context.Context, key string, members []interface{}) ([]bool, error)
+
+	// 元素存在时原子地修改其分数, 返回元素是否存在. 元素已被删除时不会重新加入
+	ZAddIfExists(ctx context.Context, key string, member Z) (bool, error)
+}
+
+// ID分配操作
+type IIdStore interface {
+	// 将计数器增加step, 返回增加后的值
+	IncrBy(ctx context.Context, key string, step int64) (int64, error)
+}
+
+// 锁操作, 带持有者令牌
+type ILockStore interface {
+	redistool.ILockBackend
+}
+
+// 批量写操作, 在Exec时一次性提交
+type IBatch interface {
+	Set(key string, value interface{}, expire time.Duration)
+	Del(keys ...string)
+	Expire(key string, expire time.Duration)
+	HSet(key string, field string, value interface{})
+	HMSet(key string, values map[string]interface{})
+	HIncrBy(key string, field string, incr int64)
+	RPush(key string, values ...interface{})
+	ZAdd(key string, members ...Z)
+	ZRem(key string, members ...interface{})
+	Exec(ctx context.Context) error
+}
+
+// 框架使用的状态存储
+type IStateStore interface {
+	IKeyValueStore
+	IHashStore
+	IListStore
+	ISortedSetStore
+	IIdStore
+	ILockStore
+
+	// 创建批量写操作
+	Batch() IBatch
+}
+
+// 默认的状态存储
+var gs_DefaultStore IStateStore = NewRedisStore()
+
+// 获取默认的状态存储
+func Default() IStateStore {
+	return gs_DefaultStore
+}
+
+// 设置默认的状态存储, 需在服务启动前调用
+func SetDefault(store IStateStore) {
+	gs_DefaultStore = store
+}
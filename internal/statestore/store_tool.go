@@ -1,52 +1,35 @@
-package redistool
+package statestore
 
 import (
 	"context"
 	"strconv"
 	"time"
 
-	"github.com/go-redis/redis/v8"
 	"github.com/golang/glog"
 )
 
 // try to get the ownership of elements
 func TryToOwnElements(keyName string, srcElements *[]uint64, ownedElements *[]uint64) error {
 	// remove elements
-	pipeline := DefaultRedis().TxPipeline()
+	members := make([]interface{}, 0, len(*srcElements))
 	for _, elem := range *srcElements {
-		pipeline.ZRem(context.Background(), keyName, elem)
+		members = append(members, elem)
 	}
 
-	// execute the pipeline
-	cmdList, err := pipeline.Exec(context.Background())
+	removed, err := Default().ZRemEach(context.Background(), keyName, members)
 	if err != nil {
-		glog.Warning("failed to exec pipeline: ", err)
+		glog.Warning("failed to remove elements: ", err)
 		return err
 	}
 
 	// check if get the ownership of elements
-	for idx, cmd := range cmdList {
-		elem := (*srcElements)[idx]
-
-		// check the result of ZRem
-		intCmd, ok := cmd.(*redis.IntCmd)
+	for idx, ok := range removed {
 		if !ok {
-			glog.Warning("failed to convert cmd: ", elem, cmd)
-			continue
-		}
-
-		err = intCmd.Err()
-		if err != nil {
-			glog.Info("failed to zrem elem from list:", elem, ",", err)
-			continue
-		}
-
-		result := intCmd.Val()
-		if result == 0 {
 			continue
 		}
 
 		// get the ownership of an element
+		elem := (*srcElements)[idx]
 		*ownedElements = append(*ownedElements, elem)
 		glog.Info("owned an element: ", elem)
 	}
@@ -62,19 +45,17 @@ func GetTimeoutElements(keyName string, count uint, retElements *[]uint64) error
 	// get timeout elements
 	now := time.Now().Unix()
 	nowStr := strconv.FormatUint(uint64(now), 10)
-	opt := redis.ZRangeBy{
+	opt := RangeBy{
 		Min: "-inf", Max: nowStr,
 		Offset: 0, Count: int64(count),
 	}
 
-	cmd := DefaultRedis().ZRangeByScore(context.Background(), keyName, &opt)
-	err := cmd.Err()
+	strList, err := Default().ZRangeByScore(context.Background(), keyName, &opt)
 	if err != nil {
-		glog.Warning("failed to get timeout element from redis: ", err)
+		glog.Warning("failed to get timeout element from store: ", err)
 		return err
 	}
 
-	strList := cmd.Val()
 	if len(strList) > 0 {
 		glog.Info("got a timeout element: ", strList)
 	}
@@ -95,7 +76,7 @@ func GetTimeoutElements(keyName string, count uint, retElements *[]uint64) error
 
 	// remove the elements that failed to covert
 	if len(wrongFormatList) > 0 {
-		DefaultRedis().ZRem(context.Background(), keyName, wrongFormatList...)
+		Default().ZRem(context.Background(), keyName, wrongFormatList...)
 	}
 
 	// no timeout elements
@@ -1,25 +1,40 @@
-package redistool
+package statestore
 
 import (
 	"errors"
+	"fmt"
+	"os"
 	"reflect"
 	"strconv"
 	"testing"
 
 	"github.com/go-redis/redis/v8"
 	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/danenmao/pterergate-dtf/internal/redistool"
 )
 
+func TestMain(m *testing.M) {
+	fmt.Println("setup...")
+	redistool.Setup()
+
+	retCode := m.Run()
+
+	fmt.Println("teardown...")
+	redistool.Teardown()
+	os.Exit(retCode)
+}
+
 func Test_TryToOwnElements_Success(t *testing.T) {
 	keyName := "testkey"
 	src := []uint64{1, 2, 3}
 	owned := []uint64{}
 
-	ClientMock.ExpectTxPipeline()
+	redistool.ClientMock.ExpectTxPipeline()
 	for _, v := range src {
-		ClientMock.ExpectZRem(keyName, v).SetVal(1)
+		redistool.ClientMock.ExpectZRem(keyName, v).SetVal(1)
 	}
-	ClientMock.ExpectTxPipelineExec()
+	redistool.ClientMock.ExpectTxPipelineExec()
 
 	err := TryToOwnElements(keyName, &src, &owned)
 
@@ -38,11 +53,11 @@ func Test_TryToOwnElements_ZRemFail(t *testing.T) {
 	src := []uint64{1, 2, 3}
 	owned := []uint64{}
 
-	ClientMock.ExpectTxPipeline()
+	redistool.ClientMock.ExpectTxPipeline()
 	for _, v := range src {
-		ClientMock.ExpectZRem(keyName, v).SetVal(0)
+		redistool.ClientMock.ExpectZRem(keyName, v).SetVal(0)
 	}
-	ClientMock.ExpectTxPipelineExec().SetErr(errors.New("exec failed"))
+	redistool.ClientMock.ExpectTxPipelineExec().SetErr(errors.New("exec failed"))
 
 	err := TryToOwnElements(keyName, &src, &owned)
 
@@ -72,7 +87,7 @@ func Test_GetTimeoutElements_Success(t *testing.T) {
 		Offset: 0, Count: int64(count),
 	}
 
-	ClientMock.Regexp().ExpectZRangeByScore(keyName, &opt).SetVal(strList)
+	redistool.ClientMock.Regexp().ExpectZRangeByScore(keyName, &opt).SetVal(strList)
 	err := GetTimeoutElements(keyName, count, &result)
 
 	Convey("get timeout elements successfully", t, func() {
@@ -95,7 +110,7 @@ func Test_GetTimeoutElements_Fail(t *testing.T) {
 		Offset: 0, Count: int64(count),
 	}
 
-	ClientMock.Regexp().ExpectZRangeByScore(keyName, &opt).SetErr(errors.New("fail"))
+	redistool.ClientMock.Regexp().ExpectZRangeByScore(keyName, &opt).SetErr(errors.New("fail"))
 	err := GetTimeoutElements(keyName, count, &result)
 
 	Convey("failed to get timeout elements", t, func() {
@@ -127,8 +142,8 @@ func Test_GetTimeoutElements_InvalidInteger(t *testing.T) {
 		Offset: 0, Count: int64(count),
 	}
 
-	ClientMock.Regexp().ExpectZRangeByScore(keyName, &opt).SetVal(strList)
-	ClientMock.ExpectZRem(keyName, "invalid").SetVal(1)
+	redistool.ClientMock.Regexp().ExpectZRangeByScore(keyName, &opt).SetVal(strList)
+	redistool.ClientMock.ExpectZRem(keyName, "invalid").SetVal(1)
 
 	err := GetTimeoutElements(keyName, count, &result)
 	Convey("get timeout elements successfully", t, func() {
@@ -151,7 +166,7 @@ func Test_GetTimeoutElements_None(t *testing.T) {
 		Offset: 0, Count: int64(count),
 	}
 
-	ClientMock.Regexp().ExpectZRangeByScore(keyName, &opt).SetVal([]string{})
+	redistool.ClientMock.Regexp().ExpectZRangeByScore(keyName, &opt).SetVal([]string{})
 	err := GetTimeoutElements(keyName, count, &result)
 
 	Convey("get timeout elements successfully", t, func() {
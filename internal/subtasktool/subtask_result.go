@@ -0,0 +1,99 @@
+package subtasktool
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/internal/blobtool"
+	"github.com/danenmao/pterergate-dtf/internal/config"
+	"github.com/danenmao/pterergate-dtf/internal/dbdef"
+	"github.com/danenmao/pterergate-dtf/internal/resultstore"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
+	"github.com/danenmao/pterergate-dtf/internal/tasktool"
+)
+
+// 将已完成子任务的结果保存到结果存储
+// 需在子任务的结果写入子任务信息key之后调用, 未配置结果存储时不做处理
+func SaveSubtaskResults(results []*taskmodel.SubtaskResult) error {
+	if len(results) == 0 || !resultstore.Enabled() {
+		return nil
+	}
+
+	records := []dbdef.DBSubtaskResult{}
+	for _, result := range results {
+		infos, err := statestore.Default().HGetAll(context.Background(), tasktool.GetSubtaskKey(uint64(result.SubtaskId)))
+		if err != nil {
+			logging.Warning("failed to read subtask info",
+				logging.SubtaskId(result.SubtaskId), logging.Err(err))
+			continue
+		}
+
+		// 超时的子任务没有执行结果, 从子任务信息中读取任务ID
+		taskId := uint64(result.TaskId)
+		if taskId == 0 {
+			taskId = parseInfoUint(infos, config.SubtaskInfo_TaskIdField)
+		}
+
+		// the blobs are deleted when the task ends, so the saved results keep the payloads
+		resultBody := resolveOrRef(result.SubtaskId, result.ResultBody)
+		typeParam := resolveOrRef(result.SubtaskId, infos[config.SubtaskInfo_Param])
+
+		records = append(records, dbdef.DBSubtaskResult{
+			SubtaskId:  uint64(result.SubtaskId),
+			TaskId:     taskId,
+			TaskType:   uint32(parseInfoUint(infos, config.SubtaskInfo_TaskTypeField)),
+			Result:     uint32(result.Result),
+			ResultCode: result.ResultCode,
+			ResultMsg:  result.ResultMsg,
+			ResultBody: resultBody,
+			TypeParam:  typeParam,
+			StartTime:  formatInfoTime(infos, config.SubtaskInfo_StartTimeField),
+			EndTime:    formatInfoTime(infos, config.SubtaskInfo_EndTimeField),
+			TimeCost:   uint32(parseInfoUint(infos, config.SubtaskInfo_TimeCostField)),
+			Attempts:   uint32(parseInfoUint(infos, config.SubtaskInfo_AttemptCountField)),
+		})
+	}
+
+	err := resultstore.Default().AddSubtaskResults(context.Background(), records)
+	if err != nil {
+		logging.Warning("failed to save subtask results", logging.Any("count", len(records)), logging.Err(err))
+		return err
+	}
+
+	return nil
+}
+
+// 读取引用的大对象, 读取失败时返回引用本身, 结果记录不因此丢失
+func resolveOrRef(subtaskId taskmodel.SubtaskIdType, val string) string {
+	resolved, err := blobtool.Resolve(context.Background(), val)
+	if err != nil {
+		logging.Warning("failed to resolve the blob, the reference is saved",
+			logging.SubtaskId(subtaskId), logging.Any("ref", val), logging.Err(err))
+		return val
+	}
+
+	return resolved
+}
+
+// 读取子任务信息中的无符号整数, 字段不存在或格式错误时返回0
+func parseInfoUint(infos map[string]string, field string) uint64 {
+	val, err := strconv.ParseUint(infos[field], 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return val
+}
+
+// 将子任务信息中的unix时间转换为数据库的时间格式
+func formatInfoTime(infos map[string]string, field string) string {
+	val := parseInfoUint(infos, field)
+	if val == 0 {
+		return dbdef.DBNullTimeStr
+	}
+
+	return time.Unix(int64(val), 0).Local().Format(dbdef.GoTimeFormatStr)
+}
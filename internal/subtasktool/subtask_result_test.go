@@ -0,0 +1,46 @@
+package subtasktool
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/danenmao/pterergate-dtf/dtf/blobstore"
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/internal/blobtool"
+	"github.com/danenmao/pterergate-dtf/internal/config"
+	"github.com/danenmao/pterergate-dtf/internal/dbdef"
+	"github.com/danenmao/pterergate-dtf/internal/resultstore"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
+	"github.com/danenmao/pterergate-dtf/internal/tasktool"
+)
+
+func Test_SaveSubtaskResults_UnresolvedBlob(t *testing.T) {
+	statestore.SetDefault(statestore.NewMemoryStore())
+	defer statestore.SetDefault(statestore.NewRedisStore())
+	resultstore.SetDefault(resultstore.NewMemoryStore())
+	defer resultstore.SetDefault(resultstore.NewDiscardStore())
+	blobtool.SetDefault(blobstore.NewFileStore(t.TempDir()), 16)
+	defer blobtool.SetDefault(nil, 0)
+
+	// 结果引用的大对象已被删除
+	ref := blobtool.RefPrefix + "1/10.result"
+	statestore.Default().HSet(context.Background(), tasktool.GetSubtaskKey(10),
+		config.SubtaskInfo_Param, "{}")
+	err := SaveSubtaskResults([]*taskmodel.SubtaskResult{
+		{TaskId: 1, SubtaskId: 10, Result: taskmodel.SubtaskResult_Success, ResultBody: ref},
+	})
+
+	records := []dbdef.DBSubtaskResult{}
+	listErr := resultstore.Default().ListSubtaskResults(context.Background(),
+		&resultstore.SubtaskResultFilter{TaskId: 1}, &records)
+
+	Convey("the result is saved with the reference if the blob can't be read", t, func() {
+		So(err, ShouldBeNil)
+		So(listErr, ShouldBeNil)
+		So(len(records), ShouldEqual, 1)
+		So(records[0].ResultBody, ShouldEqual, ref)
+		So(records[0].TypeParam, ShouldEqual, "{}")
+	})
+}
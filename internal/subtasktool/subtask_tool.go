@@ -5,12 +5,10 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/go-redis/redis/v8"
-	"github.com/golang/glog"
-
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
 	"github.com/danenmao/pterergate-dtf/internal/config"
-	"github.com/danenmao/pterergate-dtf/internal/redistool"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
 	"github.com/danenmao/pterergate-dtf/internal/tasktool"
 )
 
@@ -18,11 +16,9 @@ func SetSubtaskResult(
 	subtaskId uint64,
 	completeCode taskmodel.SubtaskResultType,
 	scanResult interface{},
-	ppipeline *redis.Pipeliner,
+	batch statestore.IBatch,
 ) error {
 
-	pipeline := *ppipeline
-
 	// get the task start time, calc the time cost of this subtask
 	var startTime uint64 = 0
 	var endTime uint64 = uint64(time.Now().Unix())
@@ -54,8 +50,7 @@ func SetSubtaskResult(
 		config.SubtaskInfo_StatusField:   subtaskStatus,
 	}
 
-	pipeline.HMSet(
-		context.Background(),
+	batch.HMSet(
 		tasktool.GetSubtaskKey(subtaskId),
 		values,
 	)
@@ -70,9 +65,10 @@ func SetSubtaskResult(
 
 		fieldName, ok := subtaskFieldMap[completeCode]
 		if !ok {
-			glog.Error("invalid complete code: ", subtaskId, taskId, completeCode)
+			logging.Error("invalid complete code",
+				logging.SubtaskId(subtaskId), logging.TaskId(taskId), logging.Any("complete_code", completeCode))
 		} else {
-			pipeline.HIncrBy(context.Background(), tasktool.GetTaskInfoKey(taskId), fieldName, 1)
+			batch.HIncrBy(tasktool.GetTaskInfoKey(taskId), fieldName, 1)
 		}
 	}
 
@@ -81,19 +77,17 @@ func SetSubtaskResult(
 
 func ReadSubtaskStatus(subtaskId taskmodel.SubtaskIdType, statusRet *uint32) error {
 
-	cmd := redistool.DefaultRedis().HGet(context.Background(), tasktool.GetSubtaskKey(uint64(subtaskId)),
+	val, err := statestore.Default().HGet(context.Background(), tasktool.GetSubtaskKey(uint64(subtaskId)),
 		config.SubtaskInfo_StatusField)
-	err := cmd.Err()
 	if err != nil {
-		glog.Warning("failed to get status of subtask: ", subtaskId, err)
+		logging.Warning("failed to get status of subtask", logging.SubtaskId(subtaskId), logging.Err(err))
 		return err
 	}
 
-	val := cmd.Val()
-
 	status, err := strconv.ParseUint(val, 10, 64)
 	if err != nil {
-		glog.Warning("failed to parse status field: ", subtaskId, val, err)
+		logging.Warning("failed to parse status field",
+			logging.SubtaskId(subtaskId), logging.Any("val", val), logging.Err(err))
 		return err
 	}
 
@@ -108,16 +102,17 @@ func GetSubtaskTaskType(subtaskId uint64, retTaskType *uint32) error {
 // 取subtask info key中记录的uint数据
 func GetSubtaskUint(subtaskId uint64, field string, retVal *uint32) error {
 
-	strCmd := redistool.DefaultRedis().HGet(context.Background(), tasktool.GetSubtaskKey(subtaskId), field)
-	err := strCmd.Err()
+	str, err := statestore.Default().HGet(context.Background(), tasktool.GetSubtaskKey(subtaskId), field)
 	if err != nil {
-		glog.Warning("failed to get field of subtask: ", subtaskId, ",", field, ",", err)
+		logging.Warning("failed to get field of subtask",
+			logging.SubtaskId(subtaskId), logging.Any("field", field), logging.Err(err))
 		return err
 	}
 
-	val, err := strCmd.Uint64()
+	val, err := strconv.ParseUint(str, 10, 64)
 	if err != nil {
-		glog.Warning("failed to convert field of subtask: ", subtaskId, ",", field, ",", strCmd.Val(), err)
+		logging.Warning("failed to convert field of subtask",
+			logging.SubtaskId(subtaskId), logging.Any("field", field), logging.Any("str", str), logging.Err(err))
 		return err
 	}
 
@@ -130,7 +125,7 @@ func IsSubtaskRunning(subtaskId taskmodel.SubtaskIdType) bool {
 	var status uint32 = 0
 	err := ReadSubtaskStatus(subtaskId, &status)
 	if err != nil {
-		glog.Warning("failed to read subtask status: ", subtaskId, err)
+		logging.Warning("failed to read subtask status", logging.SubtaskId(subtaskId), logging.Err(err))
 		return false
 	}
 
@@ -1,6 +1,7 @@
 package taskloader
 
 import (
+	"sort"
 	"sync"
 
 	"github.com/golang/glog"
@@ -35,3 +36,18 @@ func RegisterTaskType(register *taskplugin.TaskPluginRegistration) error {
 	glog.Info("succeeded to register a task type: ", elem.TaskType)
 	return nil
 }
+
+// 获取已注册的任务类型, 按任务类型排列
+func ListTaskTypes(registrations *[]taskplugin.TaskPluginRegistration) {
+
+	gs_PluginRegister.Lock.Lock()
+	defer gs_PluginRegister.Lock.Unlock()
+
+	list := []taskplugin.TaskPluginRegistration{}
+	for _, register := range gs_PluginRegister.RegistrationTable {
+		list = append(list, *register)
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].TaskType < list[j].TaskType })
+	*registrations = append(*registrations, list...)
+}
@@ -1,9 +1,10 @@
 package collectorlogic
 
 import (
-	"github.com/golang/glog"
-
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/internal/metrics"
+	"github.com/danenmao/pterergate-dtf/internal/subtasktool"
 )
 
 func OnSubtaskResult(
@@ -11,23 +12,51 @@ func OnSubtaskResult(
 	retFinished *bool,
 ) error {
 
+	// 取子任务的任务类型
+	taskType := uint32(0)
+	err := subtasktool.GetSubtaskTaskType(uint64(subtaskResult.SubtaskId), &taskType)
+	if err != nil {
+		logging.Warning("failed to get task type of subtask",
+			logging.TaskId(subtaskResult.TaskId), logging.SubtaskId(subtaskResult.SubtaskId), logging.Err(err))
+		return err
+	}
+
 	var collector taskmodel.ITaskCollectorCallback
-	err := GetSubtaskCollectorCallback(subtaskResult.SubtaskId, &collector)
+	err = GetTaskCollectorCallback(taskType, &collector)
 	if err != nil {
-		glog.Warning("failed to get subtask collector: ", subtaskResult.SubtaskId, ",", err)
+		logging.Warning("failed to get subtask collector",
+			logging.TaskId(subtaskResult.TaskId), logging.SubtaskId(subtaskResult.SubtaskId), logging.Err(err))
 		return err
 	}
 
 	support := TaskCollectorSupport{}
 	finished, err := collector.AfterExecution(subtaskResult, &support)
 	if err != nil {
-		glog.Warning("task type collector.OnScanResult return err: ", subtaskResult.SubtaskId, ",", err)
+		logging.Warning("task type collector.AfterExecution return err",
+			logging.TaskId(subtaskResult.TaskId), logging.SubtaskId(subtaskResult.SubtaskId),
+			logging.TaskType(taskType), logging.Err(err))
+	}
+
+	if finished {
+		metrics.AddSubtaskEvent(taskType, resultEvent(subtaskResult.Result), 1)
 	}
 
 	*retFinished = finished
 	return nil
 }
 
+// 子任务结果对应的指标事件
+func resultEvent(result taskmodel.SubtaskResultType) string {
+	switch result {
+	case taskmodel.SubtaskResult_Success:
+		return metrics.SubtaskEvent_Completed
+	case taskmodel.SubtaskResult_Timeout:
+		return metrics.SubtaskEvent_Timeout
+	default:
+		return metrics.SubtaskEvent_Failed
+	}
+}
+
 func OnSubtaskCompleted(
 	subtaskResult *taskmodel.SubtaskResult,
 ) error {
@@ -35,15 +64,18 @@ func OnSubtaskCompleted(
 	var collector taskmodel.ITaskCollectorCallback
 	err := GetSubtaskCollectorCallback(subtaskResult.SubtaskId, &collector)
 	if err != nil {
-		glog.Warning("failed to get subtask collector: ", subtaskResult.SubtaskId, ",", err)
+		logging.Warning("failed to get subtask collector",
+			logging.TaskId(subtaskResult.TaskId), logging.SubtaskId(subtaskResult.SubtaskId), logging.Err(err))
 		return err
 	}
 
 	_, err = collector.AfterExecution(subtaskResult, nil)
 	if err != nil {
-		glog.Warning("task type collector.OnSubtaskCompleted return err: ", subtaskResult.SubtaskId, ",", err)
+		logging.Warning("task type collector.OnSubtaskCompleted return err",
+			logging.TaskId(subtaskResult.TaskId), logging.SubtaskId(subtaskResult.SubtaskId), logging.Err(err))
 	} else {
-		glog.Info("task type collector.OnSubtaskCompleted return code: ", subtaskResult.SubtaskId)
+		logging.Debug("task type collector.OnSubtaskCompleted succeeded",
+			logging.TaskId(subtaskResult.TaskId), logging.SubtaskId(subtaskResult.SubtaskId))
 	}
 
 	return nil
@@ -54,15 +86,15 @@ func AfterTaskCompleted(taskId taskmodel.TaskIdType) error {
 	var collector taskmodel.ITaskCollectorCallback
 	err := GetCollectorCallbackByTaskId(taskId, &collector)
 	if err != nil {
-		glog.Warning("failed to get subtask collector ", taskId, ",", err)
+		logging.Warning("failed to get task collector", logging.TaskId(taskId), logging.Err(err))
 		return err
 	}
 
 	code, err := collector.AfterTaskCompleted(taskId)
 	if err != nil {
-		glog.Warning("task type collector.AfterTaskCompleted return err: ", taskId, ",", err)
+		logging.Warning("task type collector.AfterTaskCompleted return err", logging.TaskId(taskId), logging.Err(err))
 	} else {
-		glog.Info("task type collector.AfterTaskCompleted return code: ", taskId, ",", code)
+		logging.Info("task type collector.AfterTaskCompleted return code", logging.TaskId(taskId), logging.Any("code", code))
 	}
 
 	return nil
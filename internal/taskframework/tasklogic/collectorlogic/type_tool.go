@@ -1,8 +1,7 @@
 package collectorlogic
 
 import (
-	"github.com/golang/glog"
-
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
 	"github.com/danenmao/pterergate-dtf/dtf/taskplugin"
 	"github.com/danenmao/pterergate-dtf/internal/subtasktool"
@@ -15,19 +14,19 @@ func GetTaskCollectorCallback(taskType uint32, collector *taskmodel.ITaskCollect
 	var plugin taskplugin.ITaskPlugin = nil
 	err := taskloader.LookupTaskPlugin(taskType, &plugin)
 	if err != nil {
-		glog.Warning("failed to get task plugin: ", taskType)
+		logging.Warning("failed to get task plugin", logging.TaskType(taskType), logging.Err(err))
 		return err
 	}
 
 	var context taskmodel.PluginBody
 	err = plugin.GetPluginBody(&context)
 	if err != nil {
-		glog.Warning("failed to get task context: ", err.Error())
+		logging.Warning("failed to get task context", logging.TaskType(taskType), logging.Err(err))
 		return err
 	}
 
 	*collector = context.CollectorCallback
-	glog.Info("succeeded to get task collector: ", taskType)
+	logging.Debug("succeeded to get task collector", logging.TaskType(taskType))
 	return nil
 }
 
@@ -41,18 +40,18 @@ func GetSubtaskCollectorCallback(
 	taskType := uint32(0)
 	err := subtasktool.GetSubtaskTaskType(uint64(subtaskId), &taskType)
 	if err != nil {
-		glog.Warning("failed to get task type of subtask: ", subtaskId, ",", err)
+		logging.Warning("failed to get task type of subtask", logging.SubtaskId(subtaskId), logging.Err(err))
 		return err
 	}
 
 	// 获取collector
 	err = GetTaskCollectorCallback(taskType, collector)
 	if err != nil {
-		glog.Warning("failed to get task type collector: ", taskType, ",", err)
+		logging.Warning("failed to get task type collector",
+			logging.SubtaskId(subtaskId), logging.TaskType(taskType), logging.Err(err))
 		return err
 	}
 
-	glog.Info("succeeded to get task type collector: ", taskType)
 	return nil
 }
 
@@ -66,16 +65,16 @@ func GetCollectorCallbackByTaskId(
 	taskType := uint32(0)
 	err := tasktool.GetTaskType(taskId, &taskType)
 	if err != nil {
-		glog.Warning("failed to get task type: ", taskId, ",", err)
+		logging.Warning("failed to get task type", logging.TaskId(taskId), logging.Err(err))
 		return err
 	}
 
 	err = GetTaskCollectorCallback(taskType, collector)
 	if err != nil {
-		glog.Warning("failed to get task type collector: ", taskType, ",", err)
+		logging.Warning("failed to get task type collector",
+			logging.TaskId(taskId), logging.TaskType(taskType), logging.Err(err))
 		return err
 	}
 
-	glog.Info("succeeded to get task type collector: ", taskType)
 	return nil
 }
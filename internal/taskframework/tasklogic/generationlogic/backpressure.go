@@ -0,0 +1,131 @@
+package generationlogic
+
+import (
+	"time"
+
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/internal/config"
+	"github.com/danenmao/pterergate-dtf/internal/taskframework/tasklogic/generationqueue"
+)
+
+const (
+	// 检查生成队列长度的间隔
+	BackpressureCheckInterval = 100 * time.Millisecond
+
+	// 暂停生成时的检查间隔
+	BackpressurePausedInterval = 500 * time.Millisecond
+
+	// 按调度速度调整后的高水位下限
+	MinHighWatermark = 100
+
+	// 调度速度的平滑系数
+	drainRateWeight = 0.3
+)
+
+// 生成队列的背压控制
+// 队列中的子任务数达到高水位时暂停生成, 调度器消费到低水位以下时恢复.
+// 配置了缓冲时间时, 按观测到的调度速度将高水位降到缓冲时间内可调度的子任务数, 低水位按比例调整
+type Backpressure struct {
+	TaskId     taskmodel.TaskIdType
+	High       uint          // 配置的高水位
+	Low        uint          // 配置的低水位
+	BufferTime time.Duration // 队列中保持的调度时间
+
+	getDepth  func() (uint, error)
+	paused    bool
+	lastDepth uint
+	lastCheck time.Time
+	pushed    uint
+	measured  bool
+	drainRate float64 // 调度速度, 子任务/秒
+}
+
+func NewBackpressure(taskId taskmodel.TaskIdType, taskType uint32) *Backpressure {
+	high, low := config.GetGenerationWatermark(taskType)
+	return &Backpressure{
+		TaskId:     taskId,
+		High:       high,
+		Low:        low,
+		BufferTime: time.Duration(config.EnvGenerationQueueBufferTime) * time.Second,
+		getDepth: func() (uint, error) {
+			return generationqueue.GetSubtaskCount(taskId)
+		},
+	}
+}
+
+// 记录放入队列的子任务
+func (b *Backpressure) OnPush() {
+	b.pushed++
+}
+
+// 是否需要暂停生成, 每BackpressureCheckInterval读取一次队列长度
+func (b *Backpressure) ShouldPause(now time.Time) bool {
+	if !b.lastCheck.IsZero() && now.Sub(b.lastCheck) < BackpressureCheckInterval {
+		return b.paused
+	}
+
+	depth, err := b.getDepth()
+	if err != nil {
+		return b.paused
+	}
+
+	b.observe(depth, now)
+	high, low := b.Watermarks()
+	if !b.paused && depth >= high {
+		b.paused = true
+		logging.Info("the generation queue is full, pause generating", logging.TaskId(b.TaskId),
+			logging.Any("depth", depth), logging.Any("high", high), logging.Any("drain_rate", b.drainRate))
+	} else if b.paused && depth <= low {
+		b.paused = false
+		logging.Info("the generation queue is drained, resume generating", logging.TaskId(b.TaskId),
+			logging.Any("depth", depth), logging.Any("low", low), logging.Any("drain_rate", b.drainRate))
+	}
+
+	return b.paused
+}
+
+// 当前生效的高低水位
+func (b *Backpressure) Watermarks() (high uint, low uint) {
+	if b.BufferTime <= 0 || !b.measured {
+		return b.High, b.Low
+	}
+
+	high = uint(b.drainRate * b.BufferTime.Seconds())
+	if high >= b.High {
+		return b.High, b.Low
+	}
+
+	floor := uint(MinHighWatermark)
+	if floor > b.High {
+		floor = b.High
+	}
+
+	if high < floor {
+		high = floor
+	}
+
+	return high, uint(uint64(high) * uint64(b.Low) / uint64(b.High))
+}
+
+// 按两次检查之间消费的子任务数估计调度速度.
+// 队列为空时调度速度受生成速度限制, 不作为样本
+func (b *Backpressure) observe(depth uint, now time.Time) {
+	if !b.lastCheck.IsZero() && b.lastDepth > 0 && depth > 0 {
+		elapsed := now.Sub(b.lastCheck).Seconds()
+		drained := float64(b.lastDepth) + float64(b.pushed) - float64(depth)
+		if elapsed > 0 && drained >= 0 {
+			rate := drained / elapsed
+			if b.measured {
+				rate = drainRateWeight*rate + (1-drainRateWeight)*b.drainRate
+			}
+
+			b.drainRate = rate
+			b.measured = true
+		}
+	}
+
+	b.lastDepth = depth
+	b.lastCheck = now
+	b.pushed = 0
+}
@@ -0,0 +1,72 @@
+package generationlogic
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func newTestBackpressure(depth *uint, bufferTime time.Duration) *Backpressure {
+	return &Backpressure{
+		TaskId:     1,
+		High:       1000,
+		Low:        500,
+		BufferTime: bufferTime,
+		getDepth:   func() (uint, error) { return *depth, nil },
+	}
+}
+
+func Test_Backpressure_Watermark(t *testing.T) {
+	depth := uint(0)
+	b := newTestBackpressure(&depth, 0)
+	now := time.Now()
+
+	Convey("pause and resume generating by the queue depth", t, func() {
+		So(b.ShouldPause(now), ShouldBeFalse)
+
+		depth = 1000
+		So(b.ShouldPause(now.Add(10*time.Millisecond)), ShouldBeFalse) // not checked yet
+		So(b.ShouldPause(now.Add(BackpressureCheckInterval)), ShouldBeTrue)
+
+		depth = 600
+		So(b.ShouldPause(now.Add(2*BackpressureCheckInterval)), ShouldBeTrue)
+
+		depth = 500
+		So(b.ShouldPause(now.Add(3*BackpressureCheckInterval)), ShouldBeFalse)
+	})
+}
+
+func Test_Backpressure_DrainRate(t *testing.T) {
+	depth := uint(900)
+	b := newTestBackpressure(&depth, 10*time.Second)
+	now := time.Now()
+
+	Convey("adapt the watermarks to the scheduling speed", t, func() {
+		high, low := b.Watermarks()
+		So(high, ShouldEqual, 1000)
+		So(low, ShouldEqual, 500)
+
+		// 1秒内生成50个, 队列减少了0个: 调度了50个/秒, 10秒缓冲为500
+		b.ShouldPause(now)
+		for i := 0; i < 50; i++ {
+			b.OnPush()
+		}
+		So(b.ShouldPause(now.Add(time.Second)), ShouldBeTrue)
+
+		high, low = b.Watermarks()
+		So(high, ShouldEqual, 500)
+		So(low, ShouldEqual, 250)
+
+		// 调度停止时不低于下限
+		b.measured, b.drainRate = true, 0
+		high, _ = b.Watermarks()
+		So(high, ShouldEqual, MinHighWatermark)
+
+		// 调度速度超过高水位时使用配置的水位
+		b.drainRate = 1000
+		high, low = b.Watermarks()
+		So(high, ShouldEqual, 1000)
+		So(low, ShouldEqual, 500)
+	})
+}
@@ -2,20 +2,35 @@ package generationlogic
 
 import (
 	"errors"
+	"strconv"
 	"sync"
 	"time"
 
-	"github.com/golang/glog"
-
 	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/dtf/tracing"
+	"github.com/danenmao/pterergate-dtf/internal/config"
+	"github.com/danenmao/pterergate-dtf/internal/metrics"
+	"github.com/danenmao/pterergate-dtf/internal/redistool"
 	"github.com/danenmao/pterergate-dtf/internal/taskframework/tasklogic/generationqueue"
 	"github.com/danenmao/pterergate-dtf/internal/tasktool"
 )
 
 const (
 	SubtaskGenerationInterval = 10
-	SubtaskGenerationMaxTime  = 3600
+)
+
+var (
+	// the generation stops before finished, and is to be resumed from the saved status
+	ErrGenerationHandoff = errors.New("task generation handed off")
+
+	// a subtask can't be pushed into the generation queue, the generation is handed off.
+	// the status before the subtask is kept, so the subtask is generated again when resumed
+	ErrPushSubtaskFailed = errors.New("failed to push the subtask, generation handed off")
+
+	// the task is cancelled during the generation
+	errGenerationCancelled = errors.New("task generation cancelled")
 )
 
 // generation flow helpr
@@ -26,8 +41,9 @@ type FlowHelper struct {
 }
 
 type TaskGenerationImpl struct {
-	Impl     taskmodel.ITaskGenerator
-	TaskType uint32
+	Impl        taskmodel.ITaskGenerator
+	TaskType    uint32
+	TraceParent string // trace context of the task
 }
 
 func NewFlowHelper() *FlowHelper {
@@ -52,6 +68,7 @@ func (generator *FlowHelper) Begin(
 	taskType uint32,
 	taskParam *taskmodel.TaskParam,
 	taskGenerator taskmodel.ITaskGenerator,
+	traceParent string,
 ) error {
 
 	generator.Mutex.Lock()
@@ -62,8 +79,9 @@ func (generator *FlowHelper) Begin(
 
 	// save into the task generator map
 	generator.GeneratorMap[taskId] = TaskGenerationImpl{
-		Impl:     taskGenerator,
-		TaskType: taskType,
+		Impl:        taskGenerator,
+		TaskType:    taskType,
+		TraceParent: traceParent,
 	}
 
 	return nil
@@ -79,7 +97,7 @@ func (generator *FlowHelper) End(
 	// delete the subtask queue of the task
 	err := generator.GenerationQueues.RemoveTask(taskId)
 	if err != nil {
-		glog.Warning("failed to remove task: ", taskId, ", ", err.Error())
+		logging.Warning("failed to remove task", logging.TaskId(taskId), logging.Err(err))
 	}
 
 	delete(generator.GeneratorMap, taskId)
@@ -87,6 +105,10 @@ func (generator *FlowHelper) End(
 	return nil
 }
 
+// run the generation loop until the generator finishes or the task is cancelled, and return nil.
+// return ErrGenerationHandoff or ErrPushSubtaskFailed if the generation is to be resumed by another instance,
+// redistool.ErrLockNotOwned if the ownership is taken by another instance,
+// or the error of the plugin generator
 func (generator *FlowHelper) GenerationLoop(
 	taskId taskmodel.TaskIdType,
 ) error {
@@ -96,7 +118,7 @@ func (generator *FlowHelper) GenerationLoop(
 	impl, ok := generator.GeneratorMap[taskId]
 	generator.Mutex.Unlock()
 	if !ok {
-		glog.Warning("task id not found in generator map: ", taskId)
+		logging.Warning("task id not found in generator map", logging.TaskId(taskId))
 		return errors.New("task id not found")
 	}
 
@@ -109,71 +131,98 @@ func (generator *FlowHelper) pickupSubtaskLoop(
 	impl *TaskGenerationImpl,
 ) error {
 
+	logger := logging.With(logging.TaskId(taskId), logging.TaskType(impl.TaskType))
+
 	// record the start time
-	startTime := time.Now().Unix()
-	renewTime := startTime
+	loopStart := time.Now()
+	defer metrics.GenerationLoopDuration.ObserveSince(loopStart, strconv.FormatUint(uint64(impl.TaskType), 10))
+
+	renewTime := loopStart.Unix()
+
+	// hand off the generation after running for a slice, the paused time is not counted.
+	// the status is saved after the subtask of the loop is pushed, so another instance can resume from it
+	slice := time.Duration(config.EnvTaskGenerationSlice) * time.Second
+
+	// pause generating when the generation queue is deep
+	backpressure := NewBackpressure(taskId, impl.TaskType)
+	pausedTime := time.Duration(0)
 
 	// create a routine to refresh the status
 	exitChan := make(chan bool, 1)
 	go asyncRefreshGenerationStatus(taskId, exitChan)
 
 	// generation loop
+	var result error
 	for {
 
+		// wait for the scheduler to drain the generation queue
+		if backpressure.ShouldPause(time.Now()) {
+			time.Sleep(BackpressurePausedInterval)
+			pausedTime += BackpressurePausedInterval
+
+			if result = generator.checkOwnership(taskId, logger, &renewTime); result != nil {
+				break
+			}
+
+			continue
+		}
+
 		// try to create a subtask from the plugin generator
 		finished := false
 		subtaskData := taskmodel.SubtaskBody{}
+		// the span is exported only when a subtask is generated
+		span := tracing.StartSpan(impl.TraceParent, tracing.SpanName_GenerateSubtask)
 		err := CreateSubtask(taskId, impl.TaskType, impl.Impl, &subtaskData, &finished)
 		if err != nil && err != errordef.ErrNotFound {
-			glog.Warning("failed to create a subtask: ", taskId, ",", err.Error())
+			logger.Warning("failed to create a subtask", logging.Err(err))
+			span.SetError(err)
+			span.End()
+			result = err
 			break
 		}
 
-		// check if get a subtask
-		gotSubtask := (err == nil)
-
-		// periodically save the task generation status
-		taskStatus := ""
-		taskStatus, err = impl.Impl.SaveStatus(taskId)
-		if err != nil {
-			glog.Warning("failed to save task status: ", taskId, ", ", err.Error())
-		} else {
-			err = SaveStatus(taskId, taskStatus)
-			if err != nil {
-				glog.Warning("failed to save task status: ", taskId, ", ", err.Error())
-			}
-		}
-
 		// push the subtask into the subtask queue
-		if gotSubtask {
+		if err == nil {
+			span.SetAttribute("task_id", taskId)
+			span.SetAttribute("subtask_id", subtaskData.SubtaskId)
+			subtaskData.TraceParent = span.TraceParent()
+
 			err = generator.GenerationQueues.PushSubtask(taskId, &subtaskData)
 			if err != nil {
-				glog.Warning("failed to push subtask: ", taskId, ", ", err.Error())
+				logger.Warning("failed to push subtask, hand off", logging.SubtaskId(subtaskData.SubtaskId),
+					logging.Err(err))
+				span.SetError(err)
+				span.End()
+				result = ErrPushSubtaskFailed
 				break
 			}
+
+			span.End()
+			backpressure.OnPush()
+			metrics.AddSubtaskEvent(impl.TaskType, metrics.SubtaskEvent_Generated, 1)
 		}
 
+		// save the task generation status, after the subtask is pushed
+		generator.saveStatus(taskId, impl, logger)
+
 		// the task generation is over
 		if finished {
-			glog.Info("generation loop finished, break: ", taskId)
+			logger.Info("generation loop finished, break")
 			break
 		}
 
-		// control the max generation time cost
-		endTime := time.Now().Unix()
-		if endTime-startTime >= SubtaskGenerationMaxTime {
-			glog.Warning("task generation exceeds max generation time: ", taskId)
+		// hand off the generation when the slice is used up
+		if slice > 0 && time.Since(loopStart)-pausedTime >= slice {
+			logger.Info("task generation slice is used up, hand off")
+			result = ErrGenerationHandoff
 			break
 		}
 
 		// control the generation interval and speed
 		time.Sleep(time.Millisecond * SubtaskGenerationInterval)
 
-		// renew the generation ownership
-		if endTime-renewTime >= 5 {
-			tasktool.RenewTask(taskId)
-			renewTime = endTime
-			tasktool.UpdateTaskGenerationNextCheckTime(taskId)
+		if result = generator.checkOwnership(taskId, logger, &renewTime); result != nil {
+			break
 		}
 	}
 
@@ -181,6 +230,61 @@ func (generator *FlowHelper) pickupSubtaskLoop(
 	exitChan <- true
 	close(exitChan)
 
+	// the generation of the cancelled task is over
+	if result == errGenerationCancelled {
+		return nil
+	}
+
+	return result
+}
+
+// save the status of the plugin generator, the generation is resumed from it
+func (generator *FlowHelper) saveStatus(
+	taskId taskmodel.TaskIdType,
+	impl *TaskGenerationImpl,
+	logger *logging.Entry,
+) {
+	taskStatus, err := impl.Impl.SaveStatus(taskId)
+	if err != nil {
+		logger.Warning("failed to save task status", logging.Err(err))
+		return
+	}
+
+	err = SaveStatus(taskId, taskStatus)
+	if err != nil {
+		logger.Warning("failed to save task status", logging.Err(err))
+	}
+}
+
+// renew the generation ownership every 5 seconds,
+// return redistool.ErrLockNotOwned if it's taken by another instance,
+// or errGenerationCancelled if the task is cancelled
+func (generator *FlowHelper) checkOwnership(
+	taskId taskmodel.TaskIdType,
+	logger *logging.Entry,
+	renewTime *int64,
+) error {
+
+	now := time.Now().Unix()
+	if now-*renewTime < 5 {
+		return nil
+	}
+
+	if err := tasktool.RenewTask(taskId); err == redistool.ErrLockNotOwned {
+		logger.Warning("lost the generation ownership, stop generating")
+		return err
+	}
+
+	*renewTime = now
+	tasktool.UpdateTaskGenerationNextCheckTime(taskId)
+
+	// stop generating the cancelled task, and drop the subtasks not dispatched
+	if tasktool.IsTaskCancelled(taskId) {
+		logger.Info("task is cancelled, stop generating")
+		generationqueue.ClearSubtasks(taskId)
+		return errGenerationCancelled
+	}
+
 	return nil
 }
 
@@ -195,13 +299,17 @@ func asyncRefreshGenerationStatus(
 		// check if generation completed
 		select {
 		case <-exitChan:
-			glog.Info("exit task generator refresh routine: ", taskId)
+			logging.Info("exit task generator refresh routine", logging.TaskId(taskId))
 			return
 		default:
-			glog.Info("to refresh task generator status: ", taskId)
+			logging.Debug("to refresh task generator status", logging.TaskId(taskId))
+		}
+
+		if err := tasktool.RenewTask(taskId); err == redistool.ErrLockNotOwned {
+			logging.Warning("lost the generation ownership, stop refreshing", logging.TaskId(taskId))
+			return
 		}
 
-		tasktool.RenewTask(taskId)
 		tasktool.UpdateTaskGenerationNextCheckTime(taskId)
 
 		time.Sleep(time.Second * 30)
@@ -0,0 +1,141 @@
+package generationlogic
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/internal/config"
+	"github.com/danenmao/pterergate-dtf/internal/idtool"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
+	"github.com/danenmao/pterergate-dtf/internal/taskframework/tasklogic/generationqueue"
+	"github.com/danenmao/pterergate-dtf/internal/tasktool"
+)
+
+// a generator that finishes after the specified rounds, never finishes if rounds is 0.
+// it returns a subtask with the cursor as the param in every round if produce is set
+type testGenerator struct {
+	rounds  int
+	cursor  int
+	produce bool
+}
+
+func (g *testGenerator) Begin(taskId taskmodel.TaskIdType, taskType uint32, taskData *taskmodel.TaskParam,
+	oldStatus string) error {
+	g.cursor, _ = strconv.Atoi(oldStatus)
+	return nil
+}
+
+func (g *testGenerator) End(taskId taskmodel.TaskIdType) error    { return nil }
+func (g *testGenerator) Cancel(taskId taskmodel.TaskIdType) error { return nil }
+
+func (g *testGenerator) SaveStatus(taskId taskmodel.TaskIdType) (string, error) {
+	return strconv.Itoa(g.cursor), nil
+}
+
+func (g *testGenerator) QueryProgress(taskId taskmodel.TaskIdType) (float32, error) { return 0, nil }
+
+func (g *testGenerator) GetSubtask(taskId taskmodel.TaskIdType, subtaskData *taskmodel.SubtaskBody,
+	finished *bool) error {
+	g.cursor++
+	*finished = g.rounds > 0 && g.cursor >= g.rounds
+	if !g.produce {
+		return errordef.ErrNotFound
+	}
+
+	subtaskData.TypeParam = strconv.Itoa(g.cursor)
+	return nil
+}
+
+// an id generator counting from 1
+type testIdGenerator struct {
+	lastId uint64
+}
+
+func (g *testIdGenerator) Init(keyName string) error { return nil }
+
+func (g *testIdGenerator) GetId(keyName string) (uint64, error) {
+	g.lastId++
+	return g.lastId, nil
+}
+
+func (g *testIdGenerator) EnsureIdAbove(ctx context.Context, keyName string, minId uint64) error {
+	return nil
+}
+
+// a state store that fails to push into lists
+type pushFailStore struct {
+	statestore.IStateStore
+}
+
+func (store *pushFailStore) RPush(ctx context.Context, key string, values ...interface{}) error {
+	return errors.New("push failed")
+}
+
+func runTestGenerationLoop(taskId taskmodel.TaskIdType, generator *testGenerator) error {
+	oldStatus := ""
+	LoadStatus(taskId, &oldStatus)
+
+	helper := NewFlowHelper()
+	helper.Begin(taskId, 1, &taskmodel.TaskParam{}, generator, oldStatus)
+	defer helper.End(taskId)
+
+	tasktool.TryToOwnTask(taskId)
+	defer tasktool.ReleaseTask(taskId)
+
+	return helper.GenerationLoop(taskId)
+}
+
+func Test_FlowHelper_GenerationLoop(t *testing.T) {
+	statestore.SetDefault(statestore.NewMemoryStore())
+	defer statestore.SetDefault(statestore.NewRedisStore())
+
+	defer func(slice int) { config.EnvTaskGenerationSlice = slice }(config.EnvTaskGenerationSlice)
+
+	Convey("the generation finishes", t, func() {
+		config.EnvTaskGenerationSlice = 0
+		So(runTestGenerationLoop(201, &testGenerator{rounds: 3}), ShouldBeNil)
+	})
+
+	Convey("the generation is handed off when the slice is used up, and the status is saved", t, func() {
+		config.EnvTaskGenerationSlice = 1
+		generator := &testGenerator{}
+		So(runTestGenerationLoop(202, generator), ShouldEqual, ErrGenerationHandoff)
+
+		status := ""
+		So(LoadStatus(202, &status), ShouldBeNil)
+		So(status, ShouldEqual, strconv.Itoa(generator.cursor))
+		So(generator.cursor, ShouldBeGreaterThan, 0)
+	})
+
+	Convey("the subtask failed to push is generated again when resumed", t, func() {
+		config.EnvTaskGenerationSlice = 0
+		defer idtool.SetDefault(idtool.Default())
+		idtool.SetDefault(&testIdGenerator{})
+
+		store := statestore.Default()
+		statestore.SetDefault(&pushFailStore{IStateStore: store})
+		err := runTestGenerationLoop(203, &testGenerator{rounds: 3, produce: true})
+		statestore.SetDefault(store)
+		So(err, ShouldEqual, ErrPushSubtaskFailed)
+
+		So(runTestGenerationLoop(203, &testGenerator{rounds: 3, produce: true}), ShouldBeNil)
+
+		queue := generationqueue.GenerationQueue{TaskId: 203}
+		params := []string{}
+		for {
+			subtask := taskmodel.SubtaskBody{}
+			if queue.Pop(&subtask) != nil {
+				break
+			}
+
+			params = append(params, subtask.TypeParam)
+		}
+		So(params, ShouldResemble, []string{"1", "2", "3"})
+	})
+}
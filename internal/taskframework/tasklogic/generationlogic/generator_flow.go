@@ -1,11 +1,11 @@
 package generationlogic
 
 import (
-	"github.com/golang/glog"
-
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
 	"github.com/danenmao/pterergate-dtf/dtf/taskplugin"
 	"github.com/danenmao/pterergate-dtf/internal/taskframework/taskloader"
+	"github.com/danenmao/pterergate-dtf/internal/tasktool"
 )
 
 // generation flow
@@ -35,13 +35,18 @@ func (flow *GenerationFlow) InitGeneration(
 	// get the generator instance of this task type
 	err := GetTaskGenerator(taskType, &flow.Generator)
 	if err != nil {
-		glog.Warning("failed to get task generator: ", taskId, taskType, ",", err)
+		logging.Warning("failed to get task generator",
+			logging.TaskId(taskId), logging.TaskType(taskType), logging.Err(err))
 		return err
 	}
 
-	err = GetFlowHelper().Begin(taskId, taskType, taskParam, flow.Generator)
+	// the subtasks are traced as children of the task
+	traceParent, _ := tasktool.GetTaskTraceParent(taskId)
+
+	err = GetFlowHelper().Begin(taskId, taskType, taskParam, flow.Generator, traceParent)
 	if err != nil {
-		glog.Warning("failed to invoke GeneratorFlowHelper.Begin: ", taskId, ", ", taskType, ", ", err)
+		logging.Warning("failed to invoke GeneratorFlowHelper.Begin",
+			logging.TaskId(taskId), logging.TaskType(taskType), logging.Err(err))
 		return err
 	}
 
@@ -49,18 +54,18 @@ func (flow *GenerationFlow) InitGeneration(
 	lastStatus := ""
 	err = LoadStatus(taskId, &lastStatus)
 	if err != nil {
-		glog.Warning("failed to load task status: ", taskId, ", ", err.Error())
+		logging.Warning("failed to load task status", logging.TaskId(taskId), logging.Err(err))
 		return err
 	}
 
 	// begin to generate
 	err = flow.Generator.Begin(taskId, taskType, taskParam, lastStatus)
 	if err != nil {
-		glog.Warning("generator.Begin failed: ", taskId, ",", err)
+		logging.Warning("generator.Begin failed", logging.TaskId(taskId), logging.Err(err))
 		return err
 	}
 
-	glog.Info("succeeded to init task generation: ", taskId)
+	logging.Info("succeeded to init task generation", logging.TaskId(taskId), logging.TaskType(taskType))
 	return nil
 }
 
@@ -69,29 +74,34 @@ func (flow *GenerationFlow) FinishGeneration() error {
 	// invoke the generator
 	err := flow.Generator.End(flow.TaskId)
 	if err != nil {
-		glog.Warning("failed to finish the generation: ", flow.TaskId)
+		logging.Warning("failed to finish the generation", logging.TaskId(flow.TaskId), logging.Err(err))
 		return err
 	}
 
 	err = GetFlowHelper().End(flow.TaskId)
 	if err != nil {
-		glog.Warning("failed to invoke GeneratorFlowHelper.End: ", flow.TaskId, ", ", err)
+		logging.Warning("failed to invoke GeneratorFlowHelper.End", logging.TaskId(flow.TaskId), logging.Err(err))
 		return err
 	}
 
-	glog.Info("succeeded to finish task generation: ", flow.TaskId)
+	logging.Info("succeeded to finish task generation", logging.TaskId(flow.TaskId))
 	return nil
 }
 
-// generation loop
+// generation loop, return ErrGenerationHandoff or the failure if the generation is not finished
 func (flow *GenerationFlow) GenerationLoop() error {
 	err := GetFlowHelper().GenerationLoop(flow.TaskId)
+	if err == ErrGenerationHandoff {
+		logging.Info("GeneratorFlowHelper.GenerationLoop handed off", logging.TaskId(flow.TaskId))
+		return err
+	}
+
 	if err != nil {
-		glog.Warning("GeneratorFlowHelper.GenerationLoop failed: ", flow.TaskId, ", ", err)
+		logging.Warning("GeneratorFlowHelper.GenerationLoop failed", logging.TaskId(flow.TaskId), logging.Err(err))
 		return err
 	}
 
-	glog.Info("GeneratorHelper.GenerationLoop succeeded: ", flow.TaskId)
+	logging.Info("GeneratorHelper.GenerationLoop succeeded", logging.TaskId(flow.TaskId))
 	return nil
 }
 
@@ -100,18 +110,18 @@ func GetTaskGenerator(taskType uint32, generator *taskmodel.ITaskGenerator) erro
 	var plugin taskplugin.ITaskPlugin = nil
 	err := taskloader.LookupTaskPlugin(taskType, &plugin)
 	if err != nil {
-		glog.Warning("failed to get task plugin: ", taskType)
+		logging.Warning("failed to get task plugin", logging.TaskType(taskType), logging.Err(err))
 		return err
 	}
 
 	var body taskmodel.PluginBody
 	err = plugin.GetPluginBody(&body)
 	if err != nil {
-		glog.Warning("failed to get task context: ", err.Error())
+		logging.Warning("failed to get task context", logging.TaskType(taskType), logging.Err(err))
 		return err
 	}
 
 	*generator = body.Generator
-	glog.Info("succeeded to get task generator: ", taskType)
+	logging.Debug("succeeded to get task generator", logging.TaskType(taskType))
 	return nil
 }
@@ -3,10 +3,10 @@ package generationlogic
 import (
 	"time"
 
-	"github.com/golang/glog"
-
 	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/internal/blobtool"
 	"github.com/danenmao/pterergate-dtf/internal/config"
 	"github.com/danenmao/pterergate-dtf/internal/idtool"
 )
@@ -31,7 +31,7 @@ func CreateSubtask(
 
 	costTime := time.Since(subtaskStartTime)
 	if costTime > time.Second*20 {
-		glog.Warningf("GetSubtask %d costs too much time: %ds", taskId, costTime/time.Second)
+		logging.Warning("GetSubtask costs too much time", logging.TaskId(taskId), logging.Any("cost", costTime))
 	}
 
 	if err == errordef.ErrNotFound {
@@ -39,14 +39,14 @@ func CreateSubtask(
 	}
 
 	if err != nil {
-		glog.Warning("failed to invoke subtask fn: ", taskId, ",", err.Error())
+		logging.Warning("failed to invoke subtask fn", logging.TaskId(taskId), logging.Err(err))
 		return err
 	}
 
 	// get a subtask id
 	id, err := idtool.GetId(config.SubtaskIdKey)
 	if err != nil {
-		glog.Warning("failed to get a subtask id: ", err)
+		logging.Warning("failed to get a subtask id", logging.TaskId(taskId), logging.Err(err))
 		return err
 	}
 
@@ -65,6 +65,13 @@ func CreateSubtask(
 		subtaskData.Timeout = SubtaskMaxTimeout
 	}
 
-	glog.Info("succeeded to create a subtask: ", subtaskId, taskId)
+	// 较大的参数保存到大对象存储, 失败时保留在子任务中
+	err = blobtool.OffloadParam(subtaskData)
+	if err != nil {
+		logging.Warning("failed to offload the subtask param", logging.TaskId(taskId), logging.SubtaskId(subtaskId),
+			logging.Err(err))
+	}
+
+	logging.Debug("succeeded to create a subtask", logging.TaskId(taskId), logging.SubtaskId(subtaskId))
 	return nil
 }
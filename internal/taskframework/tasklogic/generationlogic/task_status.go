@@ -2,22 +2,21 @@ package generationlogic
 
 import (
 	"context"
-	"fmt"
 	"time"
 
-	"github.com/go-redis/redis/v8"
-	"github.com/golang/glog"
-
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
 	"github.com/danenmao/pterergate-dtf/internal/redistool"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
 )
 
 // 保存任务创建
-const TaskStatusKeyPrefix = "task.status."
+const TaskStatusKeyPrefix = "dtf.task.status."
 
 // 获取保存任务调度数据的Key
 func GetTaskStatusKey(taskId taskmodel.TaskIdType) string {
-	return fmt.Sprintf("%s%d", TaskStatusKeyPrefix, taskId)
+	return redistool.HashTagKey(TaskStatusKeyPrefix, taskId)
 }
 
 // 根据任务ID从Redis中加载任务的生成状态
@@ -27,21 +26,20 @@ func LoadStatus(
 ) error {
 
 	keyName := GetTaskStatusKey(taskId)
-	cmd := redistool.DefaultRedis().Get(context.Background(), keyName)
-	err := cmd.Err()
+	status, err := statestore.Default().Get(context.Background(), keyName)
 
 	// 要处理key不存在的场景。
 	// key不存在，表示任务未执行过
-	if err == redis.Nil {
+	if err == errordef.ErrNotFound {
 		return nil
 	}
 
 	if err != nil {
-		glog.Warning("failed to get task status key: ", taskId, ", ", err.Error())
+		logging.Warning("failed to get task status key", logging.TaskId(taskId), logging.Err(err))
 		return err
 	}
 
-	*retTaskStatus = cmd.Val()
+	*retTaskStatus = status
 	return nil
 }
 
@@ -51,19 +49,18 @@ func SaveStatus(
 	taskStatus string,
 ) error {
 
-	cmd := redistool.DefaultRedis().Set(
+	err := statestore.Default().Set(
 		context.Background(),
 		GetTaskStatusKey(taskId),
 		taskStatus,
 		time.Hour*48,
 	)
 
-	err := cmd.Err()
 	if err != nil {
-		glog.Warning("failed to save task status key: ", taskId, ", ", err.Error())
+		logging.Warning("failed to save task status key", logging.TaskId(taskId), logging.Err(err))
 		return err
 	}
 
-	glog.Info("succeeded to save task status: ", taskId)
+	logging.Debug("succeeded to save task status", logging.TaskId(taskId))
 	return nil
 }
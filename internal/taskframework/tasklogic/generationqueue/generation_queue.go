@@ -3,15 +3,13 @@ package generationqueue
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"time"
 
-	"github.com/go-redis/redis/v8"
-	"github.com/golang/glog"
-
 	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
 	"github.com/danenmao/pterergate-dtf/internal/redistool"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
 )
 
 // 任务的子任务队列
@@ -22,7 +20,7 @@ const (
 
 // 获取任务的子任务队列的键名
 func GetGenerationQueueOfTask(taskId taskmodel.TaskIdType) string {
-	return fmt.Sprintf("%s%d", ToScheduleSubtaskSetOfTaskPrefix, taskId)
+	return redistool.HashTagKey(ToScheduleSubtaskSetOfTaskPrefix, taskId)
 }
 
 // 任务的子任务队列
@@ -37,22 +35,24 @@ func (queue *GenerationQueue) Push(subtask *taskmodel.SubtaskBody) error {
 	// 序列化子任务
 	data, err := json.Marshal(subtask)
 	if err != nil {
-		glog.Warning("failed to marshal subtask data: ", subtask.SubtaskId, ",", err)
+		logging.Warning("failed to marshal subtask data",
+			logging.TaskId(subtask.TaskId), logging.SubtaskId(subtask.SubtaskId), logging.Err(err))
 		return err
 	}
 
 	// 将子任务放到任务的子任务队列中
 	subtaskQueueKey := GetGenerationQueueOfTask(taskmodel.TaskIdType(subtask.TaskId))
-	cmd := redistool.DefaultRedis().RPush(context.Background(), subtaskQueueKey, string(data))
-	redistool.DefaultRedis().Expire(context.Background(), subtaskQueueKey, time.Hour*8)
+	err = statestore.Default().RPush(context.Background(), subtaskQueueKey, string(data))
+	statestore.Default().Expire(context.Background(), subtaskQueueKey, time.Hour*8)
 
-	err = cmd.Err()
 	if err != nil {
-		glog.Warning("failed to rpush subtask to task queue: ", subtask.SubtaskId, ",", err)
+		logging.Warning("failed to rpush subtask to task queue",
+			logging.TaskId(subtask.TaskId), logging.SubtaskId(subtask.SubtaskId), logging.Err(err))
 		return err
 	}
 
-	glog.Info("succeeded to rpush subtask to task queue: ", subtask.SubtaskId)
+	logging.Debug("succeeded to rpush subtask to task queue",
+		logging.TaskId(subtask.TaskId), logging.SubtaskId(subtask.SubtaskId))
 	return nil
 }
 
@@ -60,29 +60,29 @@ func (queue *GenerationQueue) Push(subtask *taskmodel.SubtaskBody) error {
 func (queue *GenerationQueue) Pop(subtask *taskmodel.SubtaskBody) error {
 
 	// 从子任务队列中取子任务
-	cmd := redistool.DefaultRedis().LPop(context.Background(),
+	data, err := statestore.Default().LPop(context.Background(),
 		GetGenerationQueueOfTask(taskmodel.TaskIdType(queue.TaskId)))
-	err := cmd.Err()
 
 	// 无子任务
-	if err == redis.Nil {
-		return errordef.ErrNotFound
+	if err == errordef.ErrNotFound {
+		return err
 	}
 
 	// 其他错误
 	if err != nil {
-		glog.Warning("failed to pop subtask from queue: ", err)
+		logging.Warning("failed to pop subtask from queue", logging.TaskId(queue.TaskId), logging.Err(err))
 		return err
 	}
 
 	// 反序列化出子任务数据
-	err = json.Unmarshal([]byte(cmd.Val()), subtask)
+	err = json.Unmarshal([]byte(data), subtask)
 	if err != nil {
-		glog.Warning("failed to unmarshal subtask: ", err, ",", cmd.Val())
+		logging.Warning("failed to unmarshal subtask",
+			logging.TaskId(queue.TaskId), logging.Any("data", data), logging.Err(err))
 		return err
 	}
 
-	glog.Info("succeeded to pop subtask: ", subtask.TaskId)
+	logging.Debug("succeeded to pop subtask", logging.TaskId(subtask.TaskId), logging.SubtaskId(subtask.SubtaskId))
 	return nil
 }
 
@@ -94,18 +94,17 @@ func (queue *GenerationQueue) GetSubtaskCount(taskId taskmodel.TaskIdType) (uint
 // 判断子任务队列中子任务的数量
 func GetSubtaskCount(taskId taskmodel.TaskIdType) (uint, error) {
 
-	cmd := redistool.DefaultRedis().LLen(context.Background(), GetGenerationQueueOfTask(taskId))
-	err := cmd.Err()
+	count, err := statestore.Default().LLen(context.Background(), GetGenerationQueueOfTask(taskId))
 
 	// 如果列表 key 不存在，则 key 被解释为一个空列表，返回 0
 	// 如果 key 不是列表类型，返回一个错误。
 	if err != nil {
-		glog.Warning("failed to get subtask count from queue: ", taskId, ",", err)
+		logging.Warning("failed to get subtask count from queue", logging.TaskId(taskId), logging.Err(err))
 		return 0, err
 	}
 
-	subtaskCount := uint(cmd.Val())
-	glog.Info("get subtask count from queue: ", taskId, ", ", subtaskCount)
+	subtaskCount := uint(count)
+	logging.Debug("get subtask count from queue", logging.TaskId(taskId), logging.Any("count", subtaskCount))
 	return subtaskCount, nil
 }
 
@@ -116,33 +115,41 @@ func PushSubtaskBack(
 ) error {
 
 	// 组装命令
-	pipeline := redistool.DefaultRedis().Pipeline()
+	batch := statestore.Default().Batch()
 	for _, subtask := range *subtasks {
 		data, err := json.Marshal(subtask)
 		if err != nil {
-			glog.Warning("failed to marshal subtask data: ", subtask.SubtaskId, ",", err)
+			logging.Warning("failed to marshal subtask data",
+				logging.TaskId(subtask.TaskId), logging.SubtaskId(subtask.SubtaskId), logging.Err(err))
 			continue
 		}
 
 		// 将子任务放到任务的子任务队列中
 		subtaskKey := GetGenerationQueueOfTask(taskmodel.TaskIdType(subtask.TaskId))
-		cmd := pipeline.RPush(context.Background(), subtaskKey, string(data))
-		pipeline.Expire(context.Background(), subtaskKey, time.Hour*8)
-
-		err = cmd.Err()
-		if err != nil {
-			glog.Warning("failed to rpush subtask to task queue: ", subtask.SubtaskId, ",", err)
-			continue
-		}
+		batch.RPush(subtaskKey, string(data))
+		batch.Expire(subtaskKey, time.Hour*8)
 	} // for
 
 	// 执行命令
-	_, err := pipeline.Exec(context.Background())
+	err := batch.Exec(context.Background())
+	if err != nil {
+		logging.Warning("failed to exec batch to rpush subtasks to task queue",
+			logging.TaskId(taskId), logging.Any("count", len(*subtasks)), logging.Err(err))
+		return err
+	}
+
+	logging.Debug("succeeded to rpush subtasks to task queue",
+		logging.TaskId(taskId), logging.Any("count", len(*subtasks)))
+	return nil
+}
+
+// 清空任务的子任务生成列表, 用于取消任务
+func ClearSubtasks(taskId taskmodel.TaskIdType) error {
+	_, err := statestore.Default().Del(context.Background(), GetGenerationQueueOfTask(taskId))
 	if err != nil {
-		glog.Warning("failed to exec pipeline to rpush subtask to task queue: ", len(*subtasks), ",", err)
+		logging.Warning("failed to clear subtasks of task", logging.TaskId(taskId), logging.Err(err))
 		return err
 	}
 
-	glog.Info("succeeded to rpush subtask to task queue: ", len(*subtasks))
 	return nil
 }
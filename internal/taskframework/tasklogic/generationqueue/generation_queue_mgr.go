@@ -3,9 +3,8 @@ package generationqueue
 import (
 	"errors"
 
-	"github.com/golang/glog"
-
 	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
 )
 
@@ -18,7 +17,7 @@ type GenerationiQueueMgr struct {
 // 当创建任务、恢复任务生成时，执行添加操作
 func (mgr *GenerationiQueueMgr) AddTask(taskId taskmodel.TaskIdType) error {
 	if taskId == 0 {
-		glog.Info("invalid task id: ", taskId)
+		logging.Warning("invalid task id", logging.TaskId(taskId))
 		return errors.New("invalid task id")
 	}
 
@@ -35,13 +34,13 @@ func (mgr *GenerationiQueueMgr) RemoveTask(taskId taskmodel.TaskIdType) error {
 	// 检查任务是否存在
 	_, ok := mgr.GenerationQueueMap[taskId]
 	if !ok {
-		glog.Warning("no task id found: ", taskId)
+		logging.Warning("no task id found", logging.TaskId(taskId))
 		return nil
 	}
 
 	// 删除记录
 	delete(mgr.GenerationQueueMap, taskId)
-	glog.Info("succeeded to remove task subtask queue: ", taskId)
+	logging.Info("succeeded to remove task subtask queue", logging.TaskId(taskId))
 
 	return nil
 }
@@ -53,14 +52,12 @@ func (mgr *GenerationiQueueMgr) PushSubtask(
 ) error {
 	queue, ok := mgr.GenerationQueueMap[taskId]
 	if !ok {
-		glog.Warning("task id not found in subtask queue map: ", taskId)
+		logging.Warning("task id not found in subtask queue map", logging.TaskId(taskId))
 		return errors.New("task id not found in subtask queue map")
 	}
 
 	// 将子任务放到任务的子任务队列中
-	queue.Push(subtask)
-
-	return nil
+	return queue.Push(subtask)
 }
 
 // 从子任务队列中取子任务
@@ -70,7 +67,7 @@ func (mgr *GenerationiQueueMgr) PopSubtask(
 ) error {
 	queue, ok := mgr.GenerationQueueMap[taskId]
 	if !ok {
-		glog.Warning("task id not found in subtask queue map: ", taskId)
+		logging.Warning("task id not found in subtask queue map", logging.TaskId(taskId))
 		return errors.New("task id not found in subtask queue map")
 	}
 
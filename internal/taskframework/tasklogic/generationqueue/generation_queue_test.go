@@ -0,0 +1,40 @@
+package generationqueue
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
+)
+
+func Test_GenerationQueue_MemoryStore(t *testing.T) {
+	statestore.SetDefault(statestore.NewMemoryStore())
+	defer statestore.SetDefault(statestore.NewRedisStore())
+
+	taskId := taskmodel.TaskIdType(100)
+	queue := GenerationQueue{TaskId: taskId}
+
+	Convey("push and pop subtasks", t, func() {
+		So(queue.Push(&taskmodel.SubtaskBody{TaskId: taskId, SubtaskId: 1}), ShouldBeNil)
+		So(PushSubtaskBack(taskId, &[]taskmodel.SubtaskBody{
+			{TaskId: taskId, SubtaskId: 2},
+			{TaskId: taskId, SubtaskId: 3},
+		}), ShouldBeNil)
+
+		count, err := GetSubtaskCount(taskId)
+		So(err, ShouldBeNil)
+		So(count, ShouldEqual, 3)
+
+		for _, subtaskId := range []taskmodel.SubtaskIdType{1, 2, 3} {
+			subtask := taskmodel.SubtaskBody{}
+			So(queue.Pop(&subtask), ShouldBeNil)
+			So(subtask.SubtaskId, ShouldEqual, subtaskId)
+		}
+
+		subtask := taskmodel.SubtaskBody{}
+		So(queue.Pop(&subtask), ShouldEqual, errordef.ErrNotFound)
+	})
+}
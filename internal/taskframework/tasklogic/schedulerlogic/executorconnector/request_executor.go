@@ -3,13 +3,17 @@ package executorconnector
 import (
 	"time"
 
-	"github.com/golang/glog"
-
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/dtf/tracing"
+	"github.com/danenmao/pterergate-dtf/internal/config"
+	"github.com/danenmao/pterergate-dtf/internal/metrics"
 )
 
-// 批量推送子任务的上限
-const ExecutorMaxPushSubtaskCount uint32 = 10
+// 批量推送子任务的上限, 可在运行时调整
+func ExecutorMaxPushSubtaskCount() uint32 {
+	return config.GetSchedulingConfig().ExecutorBatchSize
+}
 
 var ExecutorService taskmodel.ExecutorInvoker
 
@@ -24,7 +28,7 @@ func ExecSubtasks(
 	failedSubtasks := []taskmodel.SubtaskBody{}
 	err := PushToExecutor(subtasks, &failedSubtasks)
 	if err != nil {
-		glog.Error("failed to push subtasks to executor: ", err.Error())
+		logging.Error("failed to push subtasks to executor", logging.TaskId(taskId), logging.Err(err))
 	}
 
 	// 如果推送失败，将子任务放到失败重试队列中, 稍后重试
@@ -43,11 +47,12 @@ func PushToExecutor(
 
 	// 将子任务发送给执行器服务
 	totalCount := len(*subtasks)
-	for i := 0; i < totalCount; i += int(ExecutorMaxPushSubtaskCount) {
+	batchSize := int(ExecutorMaxPushSubtaskCount())
+	for i := 0; i < totalCount; i += batchSize {
 
 		// 计算批量范围
 		start := i
-		end := i + int(ExecutorMaxPushSubtaskCount)
+		end := i + batchSize
 		if end > totalCount {
 			end = totalCount
 		}
@@ -58,7 +63,8 @@ func PushToExecutor(
 		err := PushBatchSubtaskToExecutor(batchList, failedSubtasks)
 		if err != nil {
 			*failedSubtasks = append(*failedSubtasks, batchList...)
-			glog.Info("added failed subtasks to retry queue: ", len(batchList))
+			logging.Warning("added failed subtasks to retry queue",
+				logging.TaskId(batchList[0].TaskId), logging.Any("count", len(batchList)), logging.Err(err))
 		}
 
 		time.Sleep(time.Millisecond)
@@ -73,16 +79,34 @@ func PushBatchSubtaskToExecutor(
 	retFailedSubtasks *[]taskmodel.SubtaskBody,
 ) error {
 
-	glog.Infof("ready to push batch subtask, subtask num: %d", len(subtasks))
+	logging.Debug("ready to push batch subtasks", logging.Any("count", len(subtasks)))
 
 	failedSubtasks := []taskmodel.SubtaskBody{}
+	span := startBatchSpan(subtasks)
+	defer span.End()
+
+	start := time.Now()
 	err := sendRequestToExecutor(subtasks)
+	metrics.ExecutorBatchDuration.ObserveSince(start)
 	if err != nil {
+		metrics.ExecutorBatchErrors.Inc()
+		span.SetError(err)
 		return err
 	}
 
+	// 按任务类型统计分发的子任务数
+	dispatched := map[uint32]int{}
+	for _, subtask := range subtasks {
+		dispatched[subtask.TaskType] += 1
+	}
+
+	for taskType, count := range dispatched {
+		metrics.AddSubtaskEvent(taskType, metrics.SubtaskEvent_Dispatched, count)
+	}
+
 	// 处理失败的子任务项
-	glog.Infof("total: %d, failed: %d", len(subtasks), len(failedSubtasks))
+	logging.Debug("pushed batch subtasks",
+		logging.Any("count", len(subtasks)), logging.Any("failed_count", len(failedSubtasks)))
 	if len(failedSubtasks) > 0 {
 		*retFailedSubtasks = append(*retFailedSubtasks, failedSubtasks...)
 	}
@@ -90,6 +114,18 @@ func PushBatchSubtaskToExecutor(
 	return nil
 }
 
+// 推送批次的span, 子任务属于同一个任务, 以第一个子任务的上下文为父span
+func startBatchSpan(subtasks []taskmodel.SubtaskBody) *tracing.Span {
+	traceParent := ""
+	if len(subtasks) > 0 {
+		traceParent = subtasks[0].TraceParent
+	}
+
+	span := tracing.StartSpan(traceParent, tracing.SpanName_PushToExecutor)
+	span.SetAttribute("subtask_count", len(subtasks))
+	return span
+}
+
 // 向执行器发送请求
 func sendRequestToExecutor(
 	req []taskmodel.SubtaskBody,
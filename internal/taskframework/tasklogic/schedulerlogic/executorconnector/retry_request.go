@@ -5,16 +5,14 @@ import (
 	"encoding/json"
 	"time"
 
-	"github.com/go-redis/redis/v8"
-	"github.com/golang/glog"
-
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
-	"github.com/danenmao/pterergate-dtf/internal/redistool"
 	"github.com/danenmao/pterergate-dtf/internal/routine"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
 )
 
 // 重试推送到执行器服务的队列的名称
-const RedisRetryToPushExecutorQueue = "retry.push.to.executor.queue"
+const RedisRetryToPushExecutorQueue = "dtf.retry.push.to.executor.queue"
 const RetryToPushInterval = 2
 
 type RetrySubtaskData struct {
@@ -43,23 +41,23 @@ func AddSubtasksToRetryQueue(
 
 		data, err := json.Marshal(&retryData)
 		if err != nil {
-			glog.Warning("failed to marshal subtask data: ", subtask.SubtaskId, subtask.TaskId)
+			logging.Warning("failed to marshal subtask data",
+				logging.TaskId(subtask.TaskId), logging.SubtaskId(subtask.SubtaskId), logging.Err(err))
 			continue
 		}
 
 		vals = append(vals, string(data))
 	}
 
-	// 批量保存到Redis重试队列中
-	cmd := redistool.DefaultRedis().RPush(context.Background(), RedisRetryToPushExecutorQueue, vals...)
-	redistool.DefaultRedis().Expire(context.Background(), RedisRetryToPushExecutorQueue, time.Hour*8)
-	err := cmd.Err()
+	// 批量保存到重试队列中
+	err := statestore.Default().RPush(context.Background(), RedisRetryToPushExecutorQueue, vals...)
+	statestore.Default().Expire(context.Background(), RedisRetryToPushExecutorQueue, time.Hour*8)
 	if err != nil {
-		glog.Warning("failed to add subtasks to retry queue")
+		logging.Warning("failed to add subtasks to retry queue", logging.Any("count", len(vals)), logging.Err(err))
 		return err
 	}
 
-	glog.Info("succeeded to add subtasks to retry queue: ", len(*subtasks))
+	logging.Info("succeeded to add subtasks to retry queue", logging.Any("count", len(vals)))
 	return nil
 }
 
@@ -68,26 +66,23 @@ func AddSubtasksToRetryQueue(
 func RetryPushToExecutorRoutine() {
 	routine.ExecRoutineWithInterval(
 		"RetryPushToExecutorRoutine",
-		retryPushToExecutor,
+		RetryPushToExecutor,
 		time.Duration(RetryToPushInterval)*time.Second,
 	)
 }
 
 // 重试将子任务推送给执行器服务
-func retryPushToExecutor() {
-
-	glog.Info("retry to push subtasks to executor")
+func RetryPushToExecutor() {
 
 	// 取子任务列表
 	subtasks := []taskmodel.SubtaskBody{}
 	err := getRetryPushSubtasks(&subtasks)
 	if err != nil {
-		glog.Warning("failed to get subtasks to retry to push: ", err)
+		logging.Warning("failed to get subtasks to retry to push", logging.Err(err))
 		return
 	}
 
 	if len(subtasks) <= 0 {
-		glog.Info("no subtask to retry")
 		return
 	}
 
@@ -96,7 +91,7 @@ func retryPushToExecutor() {
 	failedSubtasks := []taskmodel.SubtaskBody{}
 	err = PushToExecutor(&subtasks, &failedSubtasks)
 	if err != nil {
-		glog.Error("failed to push subtasks to executor: ", err.Error())
+		logging.Error("failed to push subtasks to executor", logging.Err(err))
 	}
 
 	// 如果推送失败，将子任务放到失败重试队列中, 稍后重试
@@ -110,63 +105,37 @@ func getRetryPushSubtasks(
 	subtasks *[]taskmodel.SubtaskBody,
 ) error {
 
-	// 构造命令pipeline
-	pipeline := redistool.DefaultRedis().Pipeline()
-	for i := uint32(0); i < ExecutorMaxPushSubtaskCount; i++ {
-		pipeline.LPop(context.Background(), RedisRetryToPushExecutorQueue)
-	}
-
-	// 执行
-	cmdList, err := pipeline.Exec(context.Background())
-	if err == redis.Nil {
-		glog.Info("retry key not exist")
-		return nil
-	}
-
+	// 批量弹出要重试的子任务
+	vals, err := statestore.Default().LPopN(context.Background(), RedisRetryToPushExecutorQueue,
+		int(ExecutorMaxPushSubtaskCount()))
 	if err != nil {
-		glog.Warning("failed to exec pop retry subtask list pipeline: ", err)
+		logging.Warning("failed to pop retry subtask list", logging.Err(err))
 		return err
 	}
 
-	// 从pipeline结果中读取子任务列表
+	// 读取子任务列表
 	now := time.Now()
-	for _, cmd := range cmdList {
-		err = cmd.Err()
-
-		// 无元素, 退出
-		if err == redis.Nil {
-			break
-		}
-
-		if err != nil {
-			glog.Warning("retry pipeline cmd return error: ", err)
-			break
-		}
-
-		strCmd, ok := cmd.(*redis.StringCmd)
-		if !ok {
-			glog.Error("invalid retry cmd type: ", cmd)
-			continue
-		}
+	for _, val := range vals {
 
 		// 读取保存的子任务数据
-		data := []byte(strCmd.Val())
+		data := []byte(val)
 		retryData := RetrySubtaskData{}
 		err = json.Unmarshal(data, &retryData)
 		if err != nil {
-			glog.Warning("failed to unmarshal retry subtask: ", string(data), ",", err)
+			logging.Warning("failed to unmarshal retry subtask", logging.Any("data", val), logging.Err(err))
 			continue
 		}
 
 		// 剔除已经超时的子任务，不重试
 		if retryData.ExpiredAt.Sub(now.Add(time.Second*2)) <= 0 {
-			glog.Info("remove timeout subtask in retry queue: ", retryData.TaskId, ",", retryData.SubtaskId)
+			logging.Info("remove timeout subtask in retry queue",
+				logging.TaskId(retryData.TaskId), logging.SubtaskId(retryData.SubtaskId))
 			continue
 		}
 
 		*subtasks = append(*subtasks, retryData.SubtaskBody)
 	} // for
 
-	glog.Info("succeeded to get retry push subtasks: ", len(*subtasks))
+	logging.Info("succeeded to get retry push subtasks", logging.Any("count", len(*subtasks)))
 	return nil
 }
@@ -9,6 +9,8 @@ import (
 	"github.com/golang/glog"
 
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/internal/config"
+	"github.com/danenmao/pterergate-dtf/internal/metrics"
 	"github.com/danenmao/pterergate-dtf/internal/routine"
 	"github.com/danenmao/pterergate-dtf/internal/taskframework/tasklogic/schedulerlogic/schedulingqueue"
 )
@@ -36,6 +38,7 @@ type QuotaGroupMgr struct {
 	MaxQuota      float32                // 资源组中最大的quota值
 	MaxQuotaIndex int                    // 最大quota值元素的索引
 	Mutex         sync.Mutex             // 访问锁
+	Leader        routine.ILeaderChecker // 领导者检查, 非空时共享队列的维护例程仅在领导者上执行
 }
 
 // 全局的资源组管理器对象
@@ -60,10 +63,11 @@ func (rg *QuotaGroupMgr) Init() error {
 	}
 
 	// 启动同步例程
+	// 资源组结构保存在本地内存中, 每个实例都需要同步
 	go rg.syncRecordRoutine()
 
 	// 创建调度监控例程
-	go schedulingqueue.MonitorCurrentTaskRoutine()
+	go schedulingqueue.MonitorCurrentTaskRoutine(rg.Leader)
 
 	glog.Info("succeeded to init rg mgr")
 	return nil
@@ -136,6 +140,27 @@ func (rg *QuotaGroupMgr) Select(
 	return nil
 }
 
+// 更新各调度队列的任务数指标, 在输出指标前调用
+func (rg *QuotaGroupMgr) CollectMetrics() {
+	rg.Mutex.Lock()
+	queues := []*schedulingqueue.SchedulingQueue{}
+	for _, group := range rg.GroupMap {
+		queues = append(queues, group.QueueGroup.AllQueues()...)
+	}
+	rg.Mutex.Unlock()
+
+	metrics.SchedulingQueueDepth.Reset()
+	for _, queue := range queues {
+		depth, err := queue.Length()
+		if err != nil {
+			glog.Warning("failed to get scheduling queue length: ", queue.QueueKeyName, ", ", err)
+			continue
+		}
+
+		metrics.SchedulingQueueDepth.Set(float64(depth), queue.QuotaGroupName, queue.Name())
+	}
+}
+
 // 获取调度中的任务总数
 func (rg *QuotaGroupMgr) GetTaskCount() (taskCount uint, err error) {
 
@@ -210,6 +235,11 @@ func (rg *QuotaGroupMgr) syncRecordRoutine() error {
 	return nil
 }
 
+// 立即同步资源组的记录, 用于配置更新后使新的配额生效
+func (rg *QuotaGroupMgr) Refresh() error {
+	return rg.syncRecord()
+}
+
 // 同步资源组的记录
 func (rg *QuotaGroupMgr) syncRecord() error {
 
@@ -224,8 +254,13 @@ func (rg *QuotaGroupMgr) syncRecord() error {
 	rg.Mutex.Lock()
 	defer rg.Mutex.Unlock()
 
+	// 重建quota列表
+	rg.QuotaList = []Quota{}
+	rg.MaxQuota = 0
+	rg.MaxQuotaIndex = 0
+
 	// 创建资源组结构
-	for i, record := range records {
+	for _, record := range records {
 
 		// 创建或更新资源组结构
 		group, err := rg.initOrUpdateGroup(&record)
@@ -234,23 +269,28 @@ func (rg *QuotaGroupMgr) syncRecord() error {
 			continue
 		}
 
+		// 记录最大的fit值及索引
+		if group.Quota > rg.MaxQuota {
+			rg.MaxQuotaIndex = len(rg.QuotaList)
+			rg.MaxQuota = group.Quota
+		}
+
 		// 添加到fit数组尾部
 		rg.QuotaList = append(rg.QuotaList, Quota{
 			Name:  group.Name,
 			Quota: group.Quota,
 		})
-
-		// 记录最大的fit值及索引
-		if group.Quota > rg.MaxQuota {
-			rg.MaxQuotaIndex = i
-			rg.MaxQuota = group.Quota
-		}
 	}
 
 	glog.Info("succeeded to sync rg record")
 	return nil
 }
 
+// 获取资源组的记录, 包括预定义的资源组和配置中的资源组
+func ListQuotaGroupRecords(records *[]QuotaGroupRecord) error {
+	return readQuotaGroupRecord(records)
+}
+
 // 从数据库中读取资源组的记录
 func readQuotaGroupRecord(
 	records *[]QuotaGroupRecord,
@@ -267,9 +307,35 @@ func readQuotaGroupRecord(
 
 	*records = append(*records, predefinedRG...)
 
+	// 应用配置中的资源组配额
+	applyQuotaConfig(records)
 	return nil
 }
 
+// 用配置中的配额覆盖同名资源组的配额, 配置中新增的资源组追加到列表尾部
+func applyQuotaConfig(records *[]QuotaGroupRecord) {
+	for _, quota := range config.GetSchedulingConfig().Quotas {
+		found := false
+		for i := range *records {
+			if (*records)[i].Name == quota.Name {
+				(*records)[i].Quota = quota.Quota
+				found = true
+				break
+			}
+		}
+
+		if found {
+			continue
+		}
+
+		*records = append(*records, QuotaGroupRecord{
+			ID:    uint32(len(*records) + 2),
+			Name:  quota.Name,
+			Quota: quota.Quota,
+		})
+	}
+}
+
 // 初始化或更新资源组记录
 func (rg *QuotaGroupMgr) initOrUpdateGroup(
 	record *QuotaGroupRecord,
@@ -293,7 +359,7 @@ func (rg *QuotaGroupMgr) initOrUpdateGroup(
 		Quota:       groupQuota,
 		Description: record.Description,
 		InsertTime:  uint64(time.Now().Unix()),
-		QueueGroup:  &schedulingqueue.SchedulingTeam{},
+		QueueGroup:  &schedulingqueue.SchedulingTeam{Leader: rg.Leader},
 	}
 
 	// 初始化调度队列组
@@ -3,13 +3,13 @@ package scheduler
 import (
 	"context"
 	"errors"
+	"strconv"
 
-	"github.com/go-redis/redis/v8"
 	"github.com/golang/glog"
 
 	"github.com/danenmao/pterergate-dtf/dtf/errordef"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
-	"github.com/danenmao/pterergate-dtf/internal/redistool"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
 )
 
 type IQueueScheduler interface {
@@ -52,12 +52,11 @@ func PopTaskId(
 	}
 
 	// 取队列首部的元素
-	cmd := redistool.DefaultRedis().LPop(context.Background(), keyName)
-	err := cmd.Err()
+	val, err := statestore.Default().LPop(context.Background(), keyName)
 
 	// 队列中没有元素
-	if err == redis.Nil {
-		return errordef.ErrNotFound
+	if err == errordef.ErrNotFound {
+		return err
 	}
 
 	if err != nil {
@@ -66,7 +65,7 @@ func PopTaskId(
 	}
 
 	// 转换为任务ID
-	taskId, err := cmd.Uint64()
+	taskId, err := strconv.ParseUint(val, 10, 64)
 	if err != nil {
 		glog.Warning("failed to convert lpopped task id: ", err.Error())
 		return err
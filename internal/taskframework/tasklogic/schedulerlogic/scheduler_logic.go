@@ -1,11 +1,11 @@
 package schedulerlogic
 
 import (
-	"github.com/golang/glog"
-
 	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
 	"github.com/danenmao/pterergate-dtf/dtf/taskplugin"
+	"github.com/danenmao/pterergate-dtf/dtf/tracing"
 	"github.com/danenmao/pterergate-dtf/internal/taskframework/taskloader"
 	"github.com/danenmao/pterergate-dtf/internal/taskframework/tasklogic/generationqueue"
 	"github.com/danenmao/pterergate-dtf/internal/taskframework/tasklogic/schedulerlogic/executorconnector"
@@ -29,7 +29,7 @@ func ScheduleSubtasks(
 ) error {
 	err := quotagroup.GetQuotaGroupMgr().Select(retTaskId, subtasks)
 	if err != nil {
-		glog.Warning("failed to select subtasks: ", err)
+		logging.Warning("failed to select subtasks", logging.Err(err))
 		return err
 	}
 
@@ -50,7 +50,7 @@ func ExecSubtasks(
 	var taskType uint32 = 0
 	err := tasktool.GetTaskType(taskId, &taskType)
 	if err != nil {
-		glog.Warning("failed to get task type: ", taskId, ", ", err.Error())
+		logging.Warning("failed to get task type", logging.TaskId(taskId), logging.Err(err))
 		return err
 	}
 
@@ -58,22 +58,23 @@ func ExecSubtasks(
 	var scheduler taskmodel.ITaskSchedulerCallback = nil
 	err = GetTaskSchedulerCallback(taskType, &scheduler)
 	if err != nil {
-		glog.Warning("failed to get task scheduler: ", taskType, ", ", err.Error())
+		logging.Warning("failed to get task scheduler",
+			logging.TaskId(taskId), logging.TaskType(taskType), logging.Err(err))
 		return err
 	}
 
-	glog.Info("get task type plugin scheduler: ", taskType, ", ", scheduler)
-
 	// to dispatch subtasks
 	doneSubtaskList := []taskmodel.SubtaskBody{}
-	for _, subtask := range *subtasks {
-		err = DispatchSubtask(taskType, scheduler, &subtask)
+	for i := range *subtasks {
+		// dispatch in place, so the trace context updated by the dispatch is sent to the executor
+		subtask := &(*subtasks)[i]
+		err = DispatchSubtask(taskType, scheduler, subtask)
 		if err != nil {
-			*toPushbackSubtask = append(*toPushbackSubtask, subtask)
+			*toPushbackSubtask = append(*toPushbackSubtask, *subtask)
 			continue
 		}
 
-		doneSubtaskList = append(doneSubtaskList, subtask)
+		doneSubtaskList = append(doneSubtaskList, *subtask)
 	}
 
 	// to monitor these subtasks' running statuses
@@ -82,7 +83,7 @@ func ExecSubtasks(
 	// to execute subtasks
 	err = executorconnector.ExecSubtasks(taskId, subtasks)
 	if err != nil {
-		glog.Error("failed to execute subtasks: ", taskId, ", ", err.Error())
+		logging.Error("failed to execute subtasks", logging.TaskId(taskId), logging.Err(err))
 	}
 
 	return nil
@@ -92,19 +93,19 @@ func GetTaskSchedulerCallback(taskType uint32, callback *taskmodel.ITaskSchedule
 	var plugin taskplugin.ITaskPlugin = nil
 	err := taskloader.LookupTaskPlugin(taskType, &plugin)
 	if err != nil {
-		glog.Warning("failed to get task plugin: ", taskType)
+		logging.Warning("failed to get task plugin", logging.TaskType(taskType), logging.Err(err))
 		return err
 	}
 
 	var pluginBody taskmodel.PluginBody
 	err = plugin.GetPluginBody(&pluginBody)
 	if err != nil {
-		glog.Warning("failed to get task context: ", err.Error())
+		logging.Warning("failed to get task context", logging.TaskType(taskType), logging.Err(err))
 		return err
 	}
 
 	*callback = pluginBody.SchedulerCallback
-	glog.Info("succeeded to get task scheduler callback: ", taskType)
+	logging.Debug("succeeded to get task scheduler callback", logging.TaskType(taskType))
 	return nil
 }
 
@@ -114,27 +115,40 @@ func DispatchSubtask(
 	callback taskmodel.ITaskSchedulerCallback,
 	subtask *taskmodel.SubtaskBody,
 ) error {
-	glog.Info("dipatch subtask: ", subtask)
+	logger := logging.With(logging.TaskId(subtask.TaskId), logging.SubtaskId(subtask.SubtaskId),
+		logging.TaskType(taskType))
+	logger.Debug("dispatch subtask")
+
+	span := tracing.StartSpan(subtask.TraceParent, tracing.SpanName_DispatchSubtask)
+	span.SetAttribute("task_id", subtask.TaskId)
+	span.SetAttribute("subtask_id", subtask.SubtaskId)
+	defer span.End()
 
 	// invoke the dispatch method
 	toDipatch, err := callback.BeforeDispatch(subtask.SubtaskId, subtask)
 	if err != nil {
-		glog.Info("dispatch subtask error,  pushed back: ", subtask, ", ", err.Error())
+		logger.Info("dispatch subtask error, pushed back", logging.Err(err))
+		span.SetError(err)
 		return err
 	}
 
 	// don't dispatch it now, push it back
 	if !toDipatch {
 		generationqueue.PushSubtaskBack(subtask.TaskId, &[]taskmodel.SubtaskBody{*subtask})
-		glog.Info("subtask should be pushed back: ", subtask)
+		logger.Debug("subtask should be pushed back")
+		span.SetAttribute("pushed_back", true)
 		return &errordef.DummyError{}
 	}
 
 	err = callback.AfterDispatch(subtask.SubtaskId)
 	if err != nil {
-		glog.Warning("AfterDispatch failed: ", subtask.SubtaskId, ",", err)
+		logger.Warning("AfterDispatch failed", logging.Err(err))
+		span.SetError(err)
 	}
 
-	glog.Info("succeeded to dispatch subtask: ", subtask.SubtaskId, subtask.TaskId)
+	// the execution is traced as a child of the dispatch
+	subtask.TraceParent = span.TraceParent()
+
+	logger.Debug("succeeded to dispatch subtask")
 	return nil
 }
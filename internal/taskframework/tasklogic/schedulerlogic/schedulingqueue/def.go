@@ -1,16 +1,8 @@
 package schedulingqueue
 
+// 时间片、优先级bonus系数和Priority Boost间隔可以在运行时调整,
+// 见config.GetSchedulingConfig
 const (
-	// 调度队列授予任务的基础时间片的大小, ms
-	QueueBaseTimeSlice uint32 = 1000
-	QueueTimeSliceStep uint32 = 1000
-	RRQueueTimeSlice   uint32 = 5000
-
-	// 优先级时间片bonus系数
-	PriorityBonus_Low    = 1
-	PriorityBonus_Medium = 8
-	PriorityBonus_High   = 256
-
 	// 任务调度静默期的上限
 	QuietTaskMaxInterval = 600
 
@@ -20,16 +12,12 @@ const (
 	// 任务在优先级队列中分配的基础时间片的数量
 	PriorityBaseQueueSliceCount uint32 = 40
 
-	// 对优先级调度队列执行Priority Boost策略的间隔, 秒
-	PriorityBoostInterval uint32 = 120
-	PriorityBoostStep     uint32 = 60
-
-	// 对低优先级调度队列执行Priority Boost策略的间隔, 秒
-	RRPriorityBoostInterval uint32 = 240
+	// Priority Boost策略的步长, 秒
+	PriorityBoostStep uint32 = 60
 
 	// 执行任务剩余时间加速策略的间隔, 秒
 	RemainTaskAccelerationInteral uint32 = 300
 
 	// 调度队列的Redis key前缀
-	ScheduleQueueKeyPrefix = "Schedule"
+	ScheduleQueueKeyPrefix = "dtf.schedule"
 )
@@ -5,30 +5,28 @@ import (
 	"errors"
 	"time"
 
-	"github.com/go-redis/redis/v8"
 	"github.com/golang/glog"
 
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
-	"github.com/danenmao/pterergate-dtf/internal/redistool"
 	"github.com/danenmao/pterergate-dtf/internal/routine"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
 	"github.com/danenmao/pterergate-dtf/internal/taskframework/tasklogic/tasklogicdef"
 	"github.com/danenmao/pterergate-dtf/internal/tasktool"
 )
 
 // 调度中任务队列
-const CurrentTaskZSet = "current.task.list"
+const CurrentTaskZSet = "dtf.current.task.list"
 const DefaultCurrentTaskTimeout = time.Minute
 const CheckCurrentTaskInterval = time.Duration(10) * time.Second
 
 // 添加到调度中任务队列
 func AddToCurrentTaskList(taskId taskmodel.TaskIdType) {
 	timeout := time.Now().Add(DefaultCurrentTaskTimeout).Unix()
-	cmd := redistool.DefaultRedis().ZAdd(context.Background(), CurrentTaskZSet, &redis.Z{
+	err := statestore.Default().ZAdd(context.Background(), CurrentTaskZSet, statestore.Z{
 		Score:  float64(timeout),
 		Member: taskId,
 	})
 
-	err := cmd.Err()
 	if err != nil {
 		glog.Warning("failed to add task to current task list: ", taskId, ", err:", err)
 	}
@@ -43,42 +41,42 @@ func AddListToCurrentTaskList(taskList []taskmodel.TaskIdType) {
 	}
 
 	timeout := time.Now().Add(DefaultCurrentTaskTimeout).Unix()
-	zlist := []*redis.Z{}
+	zlist := []statestore.Z{}
 	for _, taskId := range taskList {
-		zlist = append(zlist, &redis.Z{
+		zlist = append(zlist, statestore.Z{
 			Score:  float64(timeout),
 			Member: taskId,
 		})
 	}
 
-	cmd := redistool.DefaultRedis().ZAdd(context.Background(), CurrentTaskZSet, zlist...)
-	err := cmd.Err()
+	err := statestore.Default().ZAdd(context.Background(), CurrentTaskZSet, zlist...)
 	if err != nil {
 		glog.Warning("failed to add task to current task list: ", taskList, ", err:", err)
 	}
 }
 
 // 从当前任务列表中删除任务
-func RemoveFromCurrentTaskList(taskId taskmodel.TaskIdType, pipeline redis.Pipeliner) {
-	pipeline.ZRem(context.Background(), CurrentTaskZSet, taskId)
+func RemoveFromCurrentTaskList(taskId taskmodel.TaskIdType, batch statestore.IBatch) {
+	batch.ZRem(CurrentTaskZSet, taskId)
 }
 
 // 从当前任务列表中删除任务
 func RemoveFromCurrentTaskListDirectly(taskId taskmodel.TaskIdType) {
-	redistool.DefaultRedis().ZRem(context.Background(), CurrentTaskZSet, taskId)
+	statestore.Default().ZRem(context.Background(), CurrentTaskZSet, taskId)
 }
 
 // 从当前任务列表中删除任务
-func RemoveListFromCurrentTaskList(taskIdList []interface{}, pipeline redis.Pipeliner) {
-	pipeline.ZRem(context.Background(), CurrentTaskZSet, taskIdList...)
+func RemoveListFromCurrentTaskList(taskIdList []interface{}, batch statestore.IBatch) {
+	batch.ZRem(CurrentTaskZSet, taskIdList...)
 }
 
 // 协程 <<go_monitor_current_task>>
-func MonitorCurrentTaskRoutine() {
-	routine.ExecRoutineWithInterval(
+func MonitorCurrentTaskRoutine(leader routine.ILeaderChecker) {
+	routine.ExecLeaderRoutineWithInterval(
 		"MonitorCurrentTaskRoutine",
 		monitorCurrentTask,
 		CheckCurrentTaskInterval,
+		leader,
 	)
 }
 
@@ -113,7 +111,7 @@ func getLostTask(retTaskList *[]uint64) error {
 
 	// 从current_task_list中取超时的子任务
 	taskList := []uint64{}
-	err := redistool.GetTimeoutElements(CurrentTaskZSet, 100, &taskList)
+	err := statestore.GetTimeoutElements(CurrentTaskZSet, 100, &taskList)
 	if err != nil {
 		glog.Warning("failed to get lost task from redis: ", err)
 		return err
@@ -125,7 +123,7 @@ func getLostTask(retTaskList *[]uint64) error {
 	}
 
 	ownedTaskList := []uint64{}
-	err = redistool.TryToOwnElements(CurrentTaskZSet, &taskList, &ownedTaskList)
+	err = statestore.TryToOwnElements(CurrentTaskZSet, &taskList, &ownedTaskList)
 	if err != nil {
 		glog.Warning("failed to own lost task: ", taskList)
 		return err
@@ -151,15 +149,15 @@ func repairLostTaskList(taskList *[]uint64) error {
 		return nil
 	}
 
-	pipeline := redistool.DefaultRedis().Pipeline()
+	batch := statestore.Default().Batch()
 	for _, taskId := range *taskList {
-		repairLostTask(taskId, pipeline)
+		repairLostTask(taskId, batch)
 	}
 
-	// 执行pipeline
-	_, err := pipeline.Exec(context.Background())
+	// 执行batch
+	err := batch.Exec(context.Background())
 	if err != nil {
-		glog.Warning("failed to exec repiar lost task pipeline: ", taskList, ", err:", err)
+		glog.Warning("failed to exec repiar lost task batch: ", taskList, ", err:", err)
 		return err
 	}
 
@@ -167,7 +165,7 @@ func repairLostTaskList(taskList *[]uint64) error {
 }
 
 // 修改丢失的任务
-func repairLostTask(taskId uint64, pipeline redis.Pipeliner) error {
+func repairLostTask(taskId uint64, batch statestore.IBatch) error {
 
 	// 读取任务的调度数据
 	data := tasklogicdef.TaskScheduleData{}
@@ -183,13 +181,7 @@ func repairLostTask(taskId uint64, pipeline redis.Pipeliner) error {
 	}
 
 	// 将任务追加到原调度队列尾部
-	cmd := pipeline.RPush(context.Background(), data.CurrentQueueKeyName, uint64(taskId))
-	err = cmd.Err()
-	if err != nil {
-		glog.Warning("failed to append lost task to queue key: ", taskId, ", ", err)
-		return err
-	}
-
+	batch.RPush(data.CurrentQueueKeyName, uint64(taskId))
 	glog.Info("succeeded to append lost task to queue key: ", taskId, ",", data.CurrentQueueKeyName)
 	return nil
 }
@@ -2,15 +2,16 @@ package schedulingqueue
 
 import (
 	"context"
+	"fmt"
+	"strconv"
 	"time"
 
-	"github.com/go-redis/redis/v8"
-	"github.com/golang/glog"
-
 	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
 	"github.com/danenmao/pterergate-dtf/dtf/taskdef"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
-	"github.com/danenmao/pterergate-dtf/internal/redistool"
+	"github.com/danenmao/pterergate-dtf/internal/config"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
 	"github.com/danenmao/pterergate-dtf/internal/taskframework/tasklogic/generationqueue"
 	"github.com/danenmao/pterergate-dtf/internal/taskframework/tasklogic/schedulerlogic/scheduler"
 	"github.com/danenmao/pterergate-dtf/internal/taskframework/tasklogic/tasklogicdef"
@@ -25,7 +26,6 @@ type SchedulingQueue struct {
 	QuotaGroupName string                    // 队列所属的资源组的名称
 	QueueIndex     uint32                    // 队列在资源组内的索引
 	QueueKeyName   string                    // 队列的Key名
-	TimeSlice      uint32                    // 任务队列的轮转时间片，单位为ms
 	BaseQueueSlice uint32                    // 任务队列授予任务的基础时间片的数量, 单位为个
 	Scheduler      scheduler.IQueueScheduler // 调度队列的调度接口
 	NextQueue      *SchedulingQueue          // 下一个调度队列
@@ -38,19 +38,28 @@ func NewPriorityQueue(groupName string, queueName string, idx uint32) *Schedulin
 		QuotaGroupName: groupName,
 		QueueIndex:     idx,
 		QueueKeyName:   queueName,
-		TimeSlice:      QueueBaseTimeSlice + idx*QueueTimeSliceStep,
 		BaseQueueSlice: PriorityBaseQueueSliceCount,
 		Scheduler:      &scheduler.FCFSScheduler{QueueKeyName: queueName},
 	}
 }
 
+// 调度队列组的优先级队列的Key名
+// 同一团队的调度队列使用团队名作为hash tag, Cluster模式下位于同一slot
+func PriorityQueueKeyName(teamName string, idx uint32) string {
+	return fmt.Sprintf("%s.{%s}.P%d.queue", ScheduleQueueKeyPrefix, teamName, idx)
+}
+
+// 调度队列组的低优先级队列的Key名
+func RRQueueKeyName(teamName string) string {
+	return fmt.Sprintf("%s.{%s}.RR.queue", ScheduleQueueKeyPrefix, teamName)
+}
+
 // 创建一个低优先级队列
 func NewRRQueue(groupName string, queueName string) *SchedulingQueue {
 	return &SchedulingQueue{
 		QuotaGroupName: groupName,
 		QueueIndex:     RRQueueIdx,
 		QueueKeyName:   queueName,
-		TimeSlice:      RRQueueTimeSlice,
 		BaseQueueSlice: 1000000,
 		Scheduler:      &scheduler.RRScheduler{QueueKeyName: queueName},
 	}
@@ -69,7 +78,7 @@ func (queue *SchedulingQueue) Schedule(
 	noTask := false
 	err := queue.Scheduler.Schedule(&taskId, &noTask)
 	if err != nil {
-		glog.Warning("failed to schedule a task: ", err.Error())
+		logging.Warning("failed to schedule a task", logging.Err(err))
 		return false, err
 	}
 
@@ -80,6 +89,26 @@ func (queue *SchedulingQueue) Schedule(
 		return false, nil
 	}
 
+	// 暂停的任务留在队列中但不分发子任务, 取消和异常的任务从队列中移除
+	var status taskmodel.TaskStatusType = 0
+	if tasktool.ReadTaskStatus(taskId, &status) == nil {
+		switch status {
+		case taskmodel.TaskStatus_Paused:
+			queue.MoveTaskToTail(taskId, false)
+			*retTaskId = 0
+			*subtasks = []taskmodel.SubtaskBody{}
+			return false, nil
+
+		case taskmodel.TaskStatus_Cacelled, taskmodel.TaskStatus_Exceptional:
+			logging.Info("task cancelled or exceptional, remove it from queue", logging.TaskId(taskId))
+			generationqueue.ClearSubtasks(taskId)
+			queue.RemoveTask(taskId)
+			*retTaskId = 0
+			*subtasks = []taskmodel.SubtaskBody{}
+			return false, nil
+		}
+	}
+
 	// 将调度的当前任务记录到当前任务列表中
 	AddToCurrentTaskList(taskId)
 
@@ -88,13 +117,13 @@ func (queue *SchedulingQueue) Schedule(
 	quietTask := false
 	err = queue.getSubtasks(taskId, subtasks, &finished, &quietTask)
 	if err != nil {
-		glog.Warning("failed to get subtasks of task: ", taskId, ", ", err.Error())
+		logging.Warning("failed to get subtasks of task", logging.TaskId(taskId), logging.Err(err))
 		//return false, err
 	}
 
 	// 如果生成完成，将任务从调度队列中移除
 	if finished {
-		glog.Info("task generation finished: ", taskId)
+		logging.Info("task generation finished", logging.TaskId(taskId))
 		queue.RemoveTask(taskId)
 		RemoveFromCurrentTaskListDirectly(taskId)
 
@@ -105,11 +134,11 @@ func (queue *SchedulingQueue) Schedule(
 	// 获取任务在当前队列的剩余时间片数量
 	remainSliceCount, err := queue.getTaskRemainSliceCount(taskId)
 	if err != nil {
-		glog.Warning("failed to get remain slice count of task: ", taskId, err.Error())
+		logging.Warning("failed to get remain slice count of task", logging.TaskId(taskId), logging.Err(err))
 		remainSliceCount = 2
 	}
 
-	glog.Infof("task %d remain time slice: %d", taskId, remainSliceCount)
+	logging.Debug("task remain time slice", logging.TaskId(taskId), logging.Any("remain_slice_count", remainSliceCount))
 	exhausted := false
 	if remainSliceCount > 1 {
 		// 若任务还有时间片, 将任务移到调度队列尾部
@@ -134,22 +163,23 @@ func (queue *SchedulingQueue) AppendTask(
 	// 设置任务在本队列的调度数据
 	err := queue.setTaskScheduleData(taskId, priority)
 	if err != nil {
-		glog.Warning("failed to set task schedule data: ", taskId, ",", err)
+		logging.Warning("failed to set task schedule data", logging.TaskId(taskId), logging.Err(err))
 		return err
 	}
 
 	// 将任务添加到队列尾部
-	pipeline := redistool.DefaultRedis().Pipeline()
-	pipeline.RPush(context.Background(), queue.QueueKeyName, uint64(taskId))
-	RemoveFromCurrentTaskList(taskId, pipeline)
-	_, err = pipeline.Exec(context.Background())
+	batch := statestore.Default().Batch()
+	batch.RPush(queue.QueueKeyName, uint64(taskId))
+	RemoveFromCurrentTaskList(taskId, batch)
+	err = batch.Exec(context.Background())
 	if err != nil {
-		glog.Warning("failed to append task to queue key: ", taskId, ", ", err)
+		logging.Warning("failed to append task to queue key", logging.TaskId(taskId), logging.Err(err))
 		return err
 	}
 
 	queue.TaskCount += 1
-	glog.Info("succeeded to append task to queue: ", taskId, ",", queue.QueueKeyName)
+	logging.Info("succeeded to append task to queue",
+		logging.TaskId(taskId), logging.Any("queue", queue.QueueKeyName))
 	return nil
 }
 
@@ -175,34 +205,83 @@ func (queue *SchedulingQueue) setTaskScheduleData(
 	// 保存任务的调度数据
 	err := tasktool.SaveTaskScheduleData(taskId, &data)
 	if err != nil {
-		glog.Warning("failed to save task schedule data: ", taskId, ", ", err)
+		logging.Warning("failed to save task schedule data", logging.TaskId(taskId), logging.Err(err))
 		return err
 	}
 
-	glog.Info("succeeded to set task schedule data on queue: ", taskId, ",", queue.QueueKeyName)
+	logging.Debug("succeeded to set task schedule data on queue",
+		logging.TaskId(taskId), logging.Any("queue", queue.QueueKeyName))
 	return nil
 }
 
 // 根据优先级得到任务的时间片数量
 func (queue *SchedulingQueue) calcTaskSliceCount(priority uint32) uint32 {
 
+	cfg := config.GetSchedulingConfig()
 	priorityBonus := uint32(0)
 	if priority <= taskdef.TaskPriority_Low {
-		priorityBonus = PriorityBonus_Low
+		priorityBonus = cfg.PriorityBonusLow
 	} else if priority <= taskdef.TaskPriority_Medium {
-		priorityBonus = PriorityBonus_Medium
+		priorityBonus = cfg.PriorityBonusMedium
 	} else {
-		priorityBonus = PriorityBonus_High
+		priorityBonus = cfg.PriorityBonusHigh
 	}
 
 	return priorityBonus * queue.BaseQueueSlice
 }
 
+// 队列的名称, 用于指标的标签
+func (queue *SchedulingQueue) Name() string {
+	if queue.QueueIndex == RRQueueIdx {
+		return "RR"
+	}
+
+	return "P" + strconv.FormatUint(uint64(queue.QueueIndex), 10)
+}
+
+// 从存储中读取队列中的任务数
+func (queue *SchedulingQueue) Length() (int64, error) {
+	return statestore.Default().LLen(context.Background(), queue.QueueKeyName)
+}
+
+// 任务队列的轮转时间片，单位为ms
+// 每次调度时读取配置, 修改配置后对下一次调度生效
+func (queue *SchedulingQueue) TimeSlice() uint32 {
+	cfg := config.GetSchedulingConfig()
+	if queue.QueueIndex == RRQueueIdx {
+		return cfg.RRQueueTimeSlice
+	}
+
+	return cfg.QueueBaseTimeSlice + queue.QueueIndex*cfg.QueueTimeSliceStep
+}
+
+// 读取队列中前count个任务的ID, 不修改队列
+func (queue *SchedulingQueue) ListTasks(count int64, taskIdList *[]taskmodel.TaskIdType) error {
+	vals, err := statestore.Default().LRange(context.Background(), queue.QueueKeyName, 0, count-1)
+	if err != nil {
+		logging.Warning("failed to list tasks in queue", logging.Any("queue", queue.QueueKeyName), logging.Err(err))
+		return err
+	}
+
+	for _, val := range vals {
+		taskId, err := strconv.ParseUint(val, 10, 64)
+		if err != nil {
+			logging.Warning("failed to convert task id", logging.Any("val", val), logging.Err(err))
+			continue
+		}
+
+		*taskIdList = append(*taskIdList, taskmodel.TaskIdType(taskId))
+	}
+
+	return nil
+}
+
 // 从队列中移除任务
 func (queue *SchedulingQueue) RemoveTask(taskId taskmodel.TaskIdType) error {
 
 	queue.TaskCount -= 1
-	glog.Info("succeeded to remove task from queue: ", taskId, ",", queue.QueueKeyName)
+	logging.Info("succeeded to remove task from queue",
+		logging.TaskId(taskId), logging.Any("queue", queue.QueueKeyName))
 	return nil
 }
 
@@ -220,62 +299,37 @@ func (queue *SchedulingQueue) AppendBoostTaskList(taskIdList *[]taskmodel.TaskId
 		vals = append(vals, task)
 	}
 
-	pipeline := redistool.DefaultRedis().Pipeline()
-	pipeline.RPush(context.Background(), queue.QueueKeyName, vals...)
-	RemoveListFromCurrentTaskList(vals, pipeline)
-	_, err := pipeline.Exec(context.Background())
+	batch := statestore.Default().Batch()
+	batch.RPush(queue.QueueKeyName, vals...)
+	RemoveListFromCurrentTaskList(vals, batch)
+	err := batch.Exec(context.Background())
 	if err != nil {
-		glog.Warning("failed to push boost task list: ", err)
+		logging.Warning("failed to push boost task list", logging.Err(err))
 		return err
 	}
 
-	glog.Info("succeeded to append boost taks list: ", queue.QueueKeyName, *taskIdList)
+	logging.Info("succeeded to append boost task list",
+		logging.Any("queue", queue.QueueKeyName), logging.Any("task_id_list", *taskIdList))
 	return nil
 }
 
 // 从队列中取出前若干个任务
 func (queue *SchedulingQueue) PopBoostTask(taskIdList *[]taskmodel.TaskIdType) error {
 
-	// 构造命令pipeline
-	pipeline := redistool.DefaultRedis().Pipeline()
-	for i := uint32(0); i < PriorityBoostMaxTaskCount; i++ {
-		pipeline.LPop(context.Background(), queue.QueueKeyName)
-	}
-
-	cmdList, err := pipeline.Exec(context.Background())
-	if err == redis.Nil {
-		return nil
-	}
-
+	// 批量弹出队首的任务
+	vals, err := statestore.Default().LPopN(context.Background(), queue.QueueKeyName,
+		int(PriorityBoostMaxTaskCount))
 	if err != nil {
-		glog.Warning("failed to exec pop boost task list pipeline: ", queue.QueueKeyName,
-			",", err)
+		logging.Warning("failed to pop boost task list",
+			logging.Any("queue", queue.QueueKeyName), logging.Err(err))
 		return err
 	}
 
-	// 从pipeline结果中读取任务ID列表
-	for _, cmd := range cmdList {
-		err = cmd.Err()
-
-		// 无元素，退出
-		if err == redis.Nil {
-			break
-		}
-
-		if err != nil {
-			glog.Warning("pipeline cmd return error: ", err)
-			break
-		}
-
-		strCmd, ok := cmd.(*redis.StringCmd)
-		if !ok {
-			glog.Error("invalid cmd type: ", cmd)
-			continue
-		}
-
-		taskId, err := strCmd.Uint64()
+	// 读取任务ID列表
+	for _, val := range vals {
+		taskId, err := strconv.ParseUint(val, 10, 64)
 		if err != nil {
-			glog.Warning("failed to convert task id: ", strCmd.Val(), ",", err)
+			logging.Warning("failed to convert task id", logging.Any("val", val), logging.Err(err))
 			continue
 		}
 
@@ -292,21 +346,23 @@ func (queue *SchedulingQueue) MoveTaskToTail(taskId taskmodel.TaskIdType, toDecr
 	if toDecrSlice {
 		err := queue.DecreaseTaskSliceCount(taskId)
 		if err != nil {
-			glog.Warning("failed to decrease task slice count: ", taskId, ",", err)
+			logging.Warning("failed to decrease task slice count", logging.TaskId(taskId), logging.Err(err))
 		}
 	}
 
 	// 移到的尾部
-	pipeline := redistool.DefaultRedis().Pipeline()
-	pipeline.RPush(context.Background(), queue.QueueKeyName, uint64(taskId))
-	RemoveFromCurrentTaskList(taskId, pipeline)
-	_, err := pipeline.Exec(context.Background())
+	batch := statestore.Default().Batch()
+	batch.RPush(queue.QueueKeyName, uint64(taskId))
+	RemoveFromCurrentTaskList(taskId, batch)
+	err := batch.Exec(context.Background())
 	if err != nil {
-		glog.Warning("failed to exec move task to queue tail pipeline: ", taskId, ",", err)
+		logging.Warning("failed to exec move task to queue tail batch",
+			logging.TaskId(taskId), logging.Err(err))
 		return err
 	}
 
-	glog.Info("succeeded to move task to queue tail: ", taskId, ",", queue.QueueKeyName)
+	logging.Debug("succeeded to move task to queue tail",
+		logging.TaskId(taskId), logging.Any("queue", queue.QueueKeyName))
 	return nil
 }
 
@@ -319,7 +375,8 @@ func (queue *SchedulingQueue) DecreaseTaskSliceCount(
 	data := tasklogicdef.TaskScheduleData{}
 	err := tasktool.GetTaskScheduleData(taskId, &data)
 	if err != nil {
-		glog.Warning("failed to get task schedule data when move task to tail: ", taskId, ",", err)
+		logging.Warning("failed to get task schedule data when move task to tail",
+			logging.TaskId(taskId), logging.Err(err))
 		return err
 	}
 
@@ -329,11 +386,11 @@ func (queue *SchedulingQueue) DecreaseTaskSliceCount(
 	// 保存调度数据
 	err = tasktool.SaveTaskScheduleData(taskId, &data)
 	if err != nil {
-		glog.Warning("failed to update task schedule data: ", taskId, ",", err)
+		logging.Warning("failed to update task schedule data", logging.TaskId(taskId), logging.Err(err))
 		return err
 	}
 
-	glog.Info("succeeded to decrease task slice count: ", taskId)
+	logging.Debug("succeeded to decrease task slice count", logging.TaskId(taskId))
 	return nil
 }
 
@@ -346,12 +403,12 @@ func (queue *SchedulingQueue) getTaskRemainSliceCount(
 	var scheduleData = tasklogicdef.TaskScheduleData{}
 	err := tasktool.GetTaskScheduleData(taskId, &scheduleData)
 	if err != nil {
-		glog.Warning("failed to get task schedule data: ", taskId, ", ", err.Error())
+		logging.Warning("failed to get task schedule data", logging.TaskId(taskId), logging.Err(err))
 		return 0, err
 	}
 
 	// 返回任务在队列中的剩余时间片数量
-	glog.Info("task schedule data: ", scheduleData)
+	logging.Debug("task schedule data", logging.Any("schedule_data", scheduleData))
 	return scheduleData.QueueSlice, nil
 }
 
@@ -366,7 +423,7 @@ func (queue *SchedulingQueue) getSubtasks(
 	// 循环取子任务
 	err := queue.pickSubtaskLoop(taskId, subtasks, retFinished, retQuietTask)
 	if err != nil {
-		glog.Warning("failed to loop subtasks: ", taskId, ", ", err.Error())
+		logging.Warning("failed to loop subtasks", logging.TaskId(taskId), logging.Err(err))
 		return err
 	}
 
@@ -389,15 +446,16 @@ func (queue *SchedulingQueue) pickSubtaskLoop(
 	var scheduleData = tasklogicdef.TaskScheduleData{}
 	err := tasktool.GetTaskScheduleData(taskId, &scheduleData)
 	if err != nil {
-		glog.Warning("failed to get task schedule data: ", taskId, ", ", err.Error())
+		logging.Warning("failed to get task schedule data", logging.TaskId(taskId), logging.Err(err))
 	}
 
 	// 取子任务循环
+	timeSlice := queue.TimeSlice()
 	for {
 		// 检查消耗的时间片
 		now := time.Now()
-		if uint32(now.Sub(start).Milliseconds()) >= queue.TimeSlice {
-			glog.Info("task time slice is exhausted: ", taskId)
+		if uint32(now.Sub(start).Milliseconds()) >= timeSlice {
+			logging.Info("task time slice is exhausted", logging.TaskId(taskId))
 			break
 		}
 
@@ -406,7 +464,7 @@ func (queue *SchedulingQueue) pickSubtaskLoop(
 		var subtaskData = taskmodel.SubtaskBody{}
 		err := GetSubtask(taskId, &subtaskData, &finished)
 		if err != nil && err != errordef.ErrNotFound {
-			glog.Warning("failed to get subtask: ", taskId, ", ", err.Error())
+			logging.Warning("failed to get subtask", logging.TaskId(taskId), logging.Err(err))
 			continue
 		}
 
@@ -424,7 +482,7 @@ func (queue *SchedulingQueue) pickSubtaskLoop(
 		// 处理生成完成的情况
 		if finished {
 			*retFinished = true
-			glog.Info("task generation finished: ", taskId)
+			logging.Info("task generation finished", logging.TaskId(taskId))
 			break
 		}
 
@@ -439,10 +497,10 @@ func (queue *SchedulingQueue) pickSubtaskLoop(
 			// 若未超出静默期限制，任务处于静默期内
 			if uint64(now.Unix())-scheduleData.QuietStartTime < QuietTaskMaxInterval {
 				*retQuietTask = true
-				glog.Info("quiet task found: ", taskId)
+				logging.Info("quiet task found", logging.TaskId(taskId))
 				break
 			} else {
-				glog.Info("exceeds max quiet task interval: ", taskId)
+				logging.Info("exceeds max quiet task interval", logging.TaskId(taskId))
 				break
 			}
 		}
@@ -479,7 +537,7 @@ func GetSubtask(
 	}
 
 	if err != nil {
-		glog.Warning("failed to pop subtask: ", taskId, ",", err)
+		logging.Warning("failed to pop subtask", logging.TaskId(taskId), logging.Err(err))
 		return err
 	}
 
@@ -1,12 +1,11 @@
 package schedulingqueue
 
 import (
-	"fmt"
 	"time"
 
-	"github.com/golang/glog"
-
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/internal/config"
 	"github.com/danenmao/pterergate-dtf/internal/misc"
 	"github.com/danenmao/pterergate-dtf/internal/routine"
 	"github.com/danenmao/pterergate-dtf/internal/taskframework/tasklogic/tasklogicdef"
@@ -15,9 +14,10 @@ import (
 
 // 调度队列组
 type SchedulingTeam struct {
-	TeamName       string             // 调度队列组名
-	PriorityQueues []*SchedulingQueue // 优先级队列, 队列内的任务有优先级
-	RRQueue        *SchedulingQueue   // 低优先级队列, 队列内的任务使用时间片轮转策略
+	TeamName       string                 // 调度队列组名
+	PriorityQueues []*SchedulingQueue     // 优先级队列, 队列内的任务有优先级
+	RRQueue        *SchedulingQueue       // 低优先级队列, 队列内的任务使用时间片轮转策略
+	Leader         routine.ILeaderChecker // 领导者检查, 非空时Priority Boost例程仅在领导者上执行
 }
 
 // 初始化
@@ -28,7 +28,7 @@ func (queues *SchedulingTeam) Init(groupName string) error {
 	// 创建调度队列组
 	err := queues.createSchedulingQueues()
 	if err != nil {
-		glog.Warning("failed to create scheduling queues: ", err.Error())
+		logging.Warning("failed to create scheduling queues", logging.Err(err))
 		return err
 	}
 
@@ -41,7 +41,7 @@ func (queues *SchedulingTeam) Init(groupName string) error {
 	go queues.rrPriorityBoost()
 	go queues.remainAcceleration()
 
-	glog.Infof("succeeded to init scheduling queue array: %+v", queues)
+	logging.Info("succeeded to init scheduling queue array", logging.Any("queues", queues))
 	misc.DumpDataInTest("scheduling queue array", queues)
 	return nil
 }
@@ -57,6 +57,16 @@ func (queues *SchedulingTeam) GetTaskCount() (taskCount uint, err error) {
 	return
 }
 
+// 获取调度队列组中的所有队列
+func (queues *SchedulingTeam) AllQueues() []*SchedulingQueue {
+	all := append([]*SchedulingQueue{}, queues.PriorityQueues...)
+	if queues.RRQueue != nil {
+		all = append(all, queues.RRQueue)
+	}
+
+	return all
+}
+
 // 向调度队列组中添加任务
 func (queues *SchedulingTeam) AddTask(
 	taskId taskmodel.TaskIdType,
@@ -68,11 +78,12 @@ func (queues *SchedulingTeam) AddTask(
 	// 将任务添加到高优先级队列中
 	err := queues.PriorityQueues[0].AppendTask(taskId, taskType, priority)
 	if err != nil {
-		glog.Warning("failed to append task to the highest priority queue: ", taskId, err.Error())
+		logging.Warning("failed to append task to the highest priority queue",
+			logging.TaskId(taskId), logging.Err(err))
 		return err
 	}
 
-	glog.Info("succeeded to append task to the highest priority queue: ", taskId)
+	logging.Info("succeeded to append task to the highest priority queue", logging.TaskId(taskId))
 	return nil
 }
 
@@ -89,13 +100,15 @@ func (queues *SchedulingTeam) Schedule(
 		// 从当前的调度队列中选出一个任务, 进行调度
 		exhausted, err := queue.Schedule(&taskId, subtasks)
 		if err != nil {
-			glog.Warning("failed to schedule a task in: ", queue.QueueKeyName, ", ", err.Error())
+			logging.Warning("failed to schedule a task in",
+				logging.Any("queue", queue.QueueKeyName), logging.Err(err))
 			continue
 		}
 
 		// 若任务的时间片数量耗尽，移至下一个队列中
 		if exhausted {
-			glog.Info("time slice of task is over: ", taskId, ",", queue.QueueKeyName)
+			logging.Info("time slice of task is over",
+				logging.TaskId(taskId), logging.Any("queue", queue.QueueKeyName))
 			queue.RemoveTask(taskId)
 			queues.appendToNextQueue(queue, taskId)
 		}
@@ -103,7 +116,8 @@ func (queues *SchedulingTeam) Schedule(
 		// 已选出任务, 返回子任务列表
 		if len(*subtasks) > 0 {
 			*retTaskId = taskId
-			glog.Info("succeeded to schedule a task in: ", queue.QueueKeyName, ", ", taskId, len(*subtasks))
+			logging.Debug("succeeded to schedule a task in",
+				logging.Any("queue", queue.QueueKeyName), logging.TaskId(taskId), logging.Any("count", len(*subtasks)))
 			return nil
 		}
 	} // for
@@ -111,13 +125,14 @@ func (queues *SchedulingTeam) Schedule(
 	// 无法选出任务，执行低优先队列中的任务
 	_, err := queues.RRQueue.Schedule(&taskId, subtasks)
 	if err != nil {
-		glog.Warning("failed to schedule a task in RR queue: ", err.Error())
+		logging.Warning("failed to schedule a task in RR queue", logging.Err(err))
 		return err
 	}
 
 	*retTaskId = taskId
 	if taskId != 0 {
-		glog.Info("succeeded to schedule a task in RR queue, ", taskId, len(*subtasks))
+		logging.Debug("succeeded to schedule a task in RR queue",
+			logging.TaskId(taskId), logging.Any("count", len(*subtasks)))
 	}
 
 	return nil
@@ -129,7 +144,7 @@ func (queues *SchedulingTeam) appendToNextQueue(
 	taskId taskmodel.TaskIdType,
 ) error {
 	if queue.NextQueue == nil {
-		glog.Warning("null next queue pointer")
+		logging.Warning("null next queue pointer")
 		return nil
 	}
 
@@ -137,19 +152,19 @@ func (queues *SchedulingTeam) appendToNextQueue(
 	createParam := tasklogicdef.TaskCreateParam{}
 	err := tasktool.GetTaskCreateParam(taskId, &createParam)
 	if err != nil {
-		glog.Warning("failed to get task create param: ", taskId, ", ", err)
+		logging.Warning("failed to get task create param", logging.TaskId(taskId), logging.Err(err))
 		return err
 	}
 
 	// 将任务添加到下个队列的尾部
 	err = queue.NextQueue.AppendTask(taskId, createParam.TaskType, createParam.Priority)
 	if err != nil {
-		glog.Warning("failed to transfer a task to next queue: ", taskId, err.Error())
+		logging.Warning("failed to transfer a task to next queue", logging.TaskId(taskId), logging.Err(err))
 		return err
 	}
 
-	glog.Info("succeeded to append task to next queue: ", taskId, ", current:", queue.QueueKeyName,
-		",next:", queue.NextQueue.QueueKeyName)
+	logging.Debug("succeeded to append task to next queue", logging.TaskId(taskId),
+		logging.Any("queue", queue.QueueKeyName), logging.Any("next_queue", queue.NextQueue.QueueKeyName))
 	return nil
 }
 
@@ -158,13 +173,12 @@ func (queues *SchedulingTeam) createSchedulingQueues() error {
 
 	// 创建优先级调度队列
 	for i := uint32(0); i < PrioirtyQueueCount; i++ {
-		queueName := fmt.Sprintf("%s.%s.P%d.queue", ScheduleQueueKeyPrefix, queues.TeamName, i)
+		queueName := PriorityQueueKeyName(queues.TeamName, i)
 		queues.PriorityQueues = append(queues.PriorityQueues, NewPriorityQueue(queues.TeamName, queueName, i))
 	}
 
 	// 创建低优先级调度队列
-	queueName := fmt.Sprintf("%s.%s.RR.queue", ScheduleQueueKeyPrefix, queues.TeamName)
-	queues.RRQueue = NewRRQueue(queues.TeamName, queueName)
+	queues.RRQueue = NewRRQueue(queues.TeamName, RRQueueKeyName(queues.TeamName))
 
 	// 设置队列的next queue
 	for i := uint32(0); i < PrioirtyQueueCount-1; i++ {
@@ -174,18 +188,21 @@ func (queues *SchedulingTeam) createSchedulingQueues() error {
 	queues.PriorityQueues[PrioirtyQueueCount-1].NextQueue = queues.RRQueue
 	queues.RRQueue.NextQueue = nil
 
-	glog.Info("succeeded to create a scheduling group")
+	logging.Info("succeeded to create a scheduling group")
 	return nil
 }
 
 // Priority Boost策略例程
 func (queues *SchedulingTeam) priorityBoostRoutine(idx uint32) error {
-	routine.ExecRoutineWithInterval(
+	routine.ExecLeaderRoutineWithIntervalFn(
 		"priorityBoostRoutine",
 		func() {
 			queues.triggerPriorityBoost(idx)
 		},
-		time.Duration(PriorityBoostInterval)*time.Second,
+		func() time.Duration {
+			return time.Duration(config.GetSchedulingConfig().PriorityBoostInterval) * time.Second
+		},
+		queues.Leader,
 	)
 
 	return nil
@@ -193,12 +210,15 @@ func (queues *SchedulingTeam) priorityBoostRoutine(idx uint32) error {
 
 // RR队列的Priority Boost策略例程
 func (queues *SchedulingTeam) rrPriorityBoost() error {
-	routine.ExecRoutineWithInterval(
+	routine.ExecLeaderRoutineWithIntervalFn(
 		"rrPriorityBoostRoutine",
 		func() {
 			queues.triggerRRPriorityBoost()
 		},
-		time.Duration(RRPriorityBoostInterval)*time.Second,
+		func() time.Duration {
+			return time.Duration(config.GetSchedulingConfig().RRPriorityBoostInterval) * time.Second
+		},
+		queues.Leader,
 	)
 
 	return nil
@@ -206,12 +226,13 @@ func (queues *SchedulingTeam) rrPriorityBoost() error {
 
 // 任务剩余时间加速策略例程
 func (queues *SchedulingTeam) remainAcceleration() error {
-	routine.ExecRoutineWithInterval(
+	routine.ExecLeaderRoutineWithInterval(
 		"remainAccelerationRoutine",
 		func() {
 			queues.triggerRemainAcceleration()
 		},
 		time.Duration(RemainTaskAccelerationInteral)*time.Second,
+		queues.Leader,
 	)
 
 	return nil
@@ -220,7 +241,7 @@ func (queues *SchedulingTeam) remainAcceleration() error {
 // 执行Priority Boost策略
 func (queues *SchedulingTeam) triggerPriorityBoost(idx uint32) error {
 	if idx >= uint32(len(queues.PriorityQueues)) {
-		glog.Error("priority queue idx out of range: ", idx)
+		logging.Error("priority queue idx out of range", logging.Any("idx", idx))
 		return nil
 	}
 
@@ -228,11 +249,12 @@ func (queues *SchedulingTeam) triggerPriorityBoost(idx uint32) error {
 	currentQueue := queues.PriorityQueues[idx]
 	err := queues.priorityBoostOnQueue(currentQueue)
 	if err != nil {
-		glog.Warning("failed to exec priority boost on queue: ",
-			currentQueue.QueueKeyName, ",", err)
+		logging.Warning("failed to exec priority boost on queue",
+			logging.Any("queue", currentQueue.QueueKeyName), logging.Err(err))
 	}
 
-	glog.Info("succeeded to exec priority boost on queue: ", idx, ",", currentQueue.QueueKeyName)
+	logging.Info("succeeded to exec priority boost on queue",
+		logging.Any("idx", idx), logging.Any("queue", currentQueue.QueueKeyName))
 	return nil
 }
 
@@ -241,18 +263,18 @@ func (queues *SchedulingTeam) priorityBoostOnQueue(
 	currentQueue *SchedulingQueue,
 ) error {
 	queueName := currentQueue.QueueKeyName
-	glog.Info("execute priority boost for queue: ", queueName)
+	logging.Info("execute priority boost for queue", logging.Any("queue", queueName))
 
 	// 从队列中取出前若干个任务
 	taskIdList := []taskmodel.TaskIdType{}
 	err := currentQueue.PopBoostTask(&taskIdList)
 	if err != nil {
-		glog.Warning("failed to pop task list: ", queueName, ",", err)
+		logging.Warning("failed to pop task list", logging.Any("queue", queueName), logging.Err(err))
 		return err
 	}
 
 	if len(taskIdList) <= 0 {
-		glog.Info("no task to boost on queue: ", queueName)
+		logging.Info("no task to boost on queue", logging.Any("queue", queueName))
 		return nil
 	}
 
@@ -260,7 +282,8 @@ func (queues *SchedulingTeam) priorityBoostOnQueue(
 	AddListToCurrentTaskList(taskIdList)
 	err = queues.PriorityQueues[0].AppendBoostTaskList(&taskIdList)
 	if err != nil {
-		glog.Warning("failed to append boost task list: ", queueName, ",", err)
+		logging.Warning("failed to append boost task list",
+			logging.Any("queue", queueName), logging.Err(err))
 	}
 
 	return nil
@@ -271,11 +294,12 @@ func (queues *SchedulingTeam) triggerRRPriorityBoost() error {
 	currentQueue := queues.RRQueue
 	err := queues.priorityBoostOnQueue(currentQueue)
 	if err != nil {
-		glog.Warning("failed to exec priority boost on RR queue: ",
-			currentQueue.QueueKeyName, ",", err)
+		logging.Warning("failed to exec priority boost on RR queue",
+			logging.Any("queue", currentQueue.QueueKeyName), logging.Err(err))
 	}
 
-	glog.Info("succeeded to exec priority boost on RR queue: ", currentQueue.QueueKeyName)
+	logging.Info("succeeded to exec priority boost on RR queue",
+		logging.Any("queue", currentQueue.QueueKeyName))
 	return nil
 }
 
@@ -10,7 +10,7 @@ type TaskCreateParam struct {
 }
 
 // 保存任务创建
-const TaskCreateParamPrefix = "task.create.param."
+const TaskCreateParamPrefix = "dtf.task.create.param."
 
 // 任务调度数据
 type TaskScheduleData struct {
@@ -23,7 +23,7 @@ type TaskScheduleData struct {
 }
 
 // 保存任务调度数据
-const TaskScheduleDataPrefix = "task.schedule.data."
+const TaskScheduleDataPrefix = "dtf.task.schedule.data."
 
 // 执行器服务名
 const SubtaskExecutorName = ""
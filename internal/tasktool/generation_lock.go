@@ -1,24 +1,78 @@
 package tasktool
 
 import (
+	"context"
+	"sync"
 	"time"
 
+	"github.com/golang/glog"
+
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
 	"github.com/danenmao/pterergate-dtf/internal/redistool"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
+)
+
+const (
+	// 任务生成所有权的有效期
+	TaskOwnershipExpire = time.Minute
+
+	// 获取任务生成所有权的等待时间
+	TaskOwnershipWaitTime = time.Millisecond * 200
 )
 
+// 当前实例持有的任务生成锁
+var gs_OwnedTaskLocks = map[taskmodel.TaskIdType]*redistool.SafeLock{}
+var gs_OwnedTaskLocksMutex sync.Mutex
+
 // 尝试获取对任务生成的所有权
+// 任务被其他实例持有时返回redistool.ErrLockNotAcquired
 func TryToOwnTask(taskId taskmodel.TaskIdType) error {
-	err := redistool.LockWithExpire(GetTaskLockKey(taskId), 200, time.Minute*5)
-	return err
+	lock := redistool.NewSafeLockWithBackend(GetTaskLockKey(taskId), TaskOwnershipExpire,
+		statestore.Default())
+
+	ctx, cancel := context.WithTimeout(context.Background(), TaskOwnershipWaitTime)
+	defer cancel()
+
+	err := lock.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	// 由看门狗自动续期, 直到释放所有权
+	lock.StartWatchdog(TaskOwnershipExpire / 3)
+
+	gs_OwnedTaskLocksMutex.Lock()
+	gs_OwnedTaskLocks[taskId] = lock
+	gs_OwnedTaskLocksMutex.Unlock()
+
+	return nil
 }
 
 // 释放对任务生成的所有权
 func ReleaseTask(taskId taskmodel.TaskIdType) {
-	redistool.Unlock(GetTaskLockKey(taskId))
+	gs_OwnedTaskLocksMutex.Lock()
+	lock, ok := gs_OwnedTaskLocks[taskId]
+	delete(gs_OwnedTaskLocks, taskId)
+	gs_OwnedTaskLocksMutex.Unlock()
+
+	if !ok {
+		glog.Warning("task to release is not owned: ", taskId)
+		return
+	}
+
+	lock.Unlock()
 }
 
 // 对所有权续期
-func RenewTask(taskId taskmodel.TaskIdType) {
-	redistool.RenewLock(GetTaskLockKey(taskId), time.Second*60)
+// 所有权已丢失时返回redistool.ErrLockNotOwned, 调用者应停止生成
+func RenewTask(taskId taskmodel.TaskIdType) error {
+	gs_OwnedTaskLocksMutex.Lock()
+	lock, ok := gs_OwnedTaskLocks[taskId]
+	gs_OwnedTaskLocksMutex.Unlock()
+
+	if !ok {
+		return redistool.ErrLockNotOwned
+	}
+
+	return lock.Renew()
 }
@@ -0,0 +1,31 @@
+package tasktool
+
+import (
+	"fmt"
+
+	"github.com/danenmao/pterergate-dtf/internal/config"
+)
+
+// 获取元素所在的全局集合分片的key
+// 分片数不大于1时, 返回原key
+func GetShardKey(keyName string, id uint64) string {
+	if config.EnvSubtaskSetShardCount <= 1 {
+		return keyName
+	}
+
+	return fmt.Sprintf("%s.%d", keyName, id%uint64(config.EnvSubtaskSetShardCount))
+}
+
+// 获取全局集合的所有分片的key
+func GetShardKeys(keyName string) []string {
+	if config.EnvSubtaskSetShardCount <= 1 {
+		return []string{keyName}
+	}
+
+	keys := make([]string, 0, config.EnvSubtaskSetShardCount)
+	for i := uint(0); i < config.EnvSubtaskSetShardCount; i++ {
+		keys = append(keys, fmt.Sprintf("%s.%d", keyName, i))
+	}
+
+	return keys
+}
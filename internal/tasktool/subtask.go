@@ -5,15 +5,16 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/go-redis/redis/v8"
-	"github.com/golang/glog"
-
 	"github.com/danenmao/pterergate-dtf/dtf/errordef"
+	"github.com/danenmao/pterergate-dtf/dtf/logging"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
 	"github.com/danenmao/pterergate-dtf/internal/config"
-	"github.com/danenmao/pterergate-dtf/internal/redistool"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
 )
 
+// 子任务未指定超时值时的默认超时值, 秒
+const DefaultSubtaskTimeout = 720
+
 // 创建子任务信息key
 func CreateSubtaskInfoKey(
 	subtaskId uint64,
@@ -28,24 +29,24 @@ func CreateSubtaskInfoKey(
 		config.SubtaskInfo_Param:          subtaskData.TypeParam,
 		config.SubtaskInfo_StatusField:    taskmodel.SubtaskStatus_Running,
 		config.SubtaskInfo_TaskTypeField:  subtaskData.TaskType,
+		config.SubtaskInfo_TimeoutField:   subtaskData.Timeout,
 	}
 
 	// 设置子任务的运行信息
-	cmd := redistool.DefaultRedis().HMSet(
+	err := statestore.Default().HMSet(
 		context.Background(),
 		GetSubtaskKey(subtaskId),
 		valueMap,
 	)
 
-	redistool.DefaultRedis().Expire(
+	statestore.Default().Expire(
 		context.Background(),
 		GetSubtaskKey(subtaskId),
 		time.Hour*4,
 	)
 
-	err := cmd.Err()
 	if err != nil {
-		glog.Warning("failed to create subtask info key: ", subtaskId, ",", err)
+		logging.Warning("failed to create subtask info key", logging.SubtaskId(subtaskId), logging.Err(err))
 		return err
 	}
 
@@ -55,28 +56,28 @@ func CreateSubtaskInfoKey(
 // 将子任务添加到任务中
 func AddSubtaskToTask(taskId taskmodel.TaskIdType, subtaskId uint64) error {
 
-	pipeline := redistool.DefaultRedis().Pipeline()
+	batch := statestore.Default().Batch()
 
 	// 将子任务推入 redis_subtask_list.$taskid，zset，按生成时间排序
-	pipeline.ZAdd(context.Background(), GetTaskSubtaskListKey(taskId), &redis.Z{
+	batch.ZAdd(GetTaskSubtaskListKey(taskId), statestore.Z{
 		Score:  float64(time.Now().Unix()),
 		Member: subtaskId,
 	})
 
 	// 修改 redis_task_info.$taskid中的subtaskcount
-	pipeline.HIncrBy(context.Background(), GetTaskInfoKey(taskId),
+	batch.HIncrBy(GetTaskInfoKey(taskId),
 		config.TaskInfo_TotalSubtaskCountField, 1)
 
 	// 镜像任务的子任务不进入redis_subtask_to_schedule_list, 直接进入redis_subtask_scanning_zset
 
-	// 执行pipeline
-	_, err := pipeline.Exec(context.Background())
+	// 执行batch
+	err := batch.Exec(context.Background())
 	if err != nil {
-		glog.Warning("failed to exec pipeline: ", subtaskId, err)
+		logging.Warning("failed to exec pipeline", logging.SubtaskId(subtaskId), logging.Err(err))
 		return err
 	}
 
-	glog.Info("succeeded to add subtask to task: ", subtaskId, taskId)
+	logging.Debug("succeeded to add subtask to task", logging.SubtaskId(subtaskId), logging.TaskId(taskId))
 	return nil
 }
 
@@ -86,56 +87,109 @@ func AddSubtaskToRunningList(
 ) error {
 
 	// 拼装添加命令
-	const DefaultTimeout = 720
-	zlist := []*redis.Z{}
+	zmap := map[string][]statestore.Z{}
 	for _, subtask := range *subtasks {
 
-		timeout := DefaultTimeout
+		timeout := DefaultSubtaskTimeout
 		if subtask.Timeout != 0 {
 			timeout = int(subtask.Timeout)
 		}
 
-		zlist = append(zlist, &redis.Z{
+		keyName := GetShardKey(config.RunningSubtaskZset, uint64(subtask.SubtaskId))
+		zmap[keyName] = append(zmap[keyName], statestore.Z{
 			Score:  float64(time.Now().Add(time.Duration(timeout) * time.Second).Unix()),
 			Member: uint64(subtask.SubtaskId),
 		})
 	}
 
 	// 将子任务推入 redis_subtask_scanning_zset, zset, 按照超时时间排序
-	pipeline := redistool.DefaultRedis().Pipeline()
-	pipeline.ZAdd(context.Background(), config.RunningSubtaskZset, zlist...)
+	batch := statestore.Default().Batch()
+	for keyName, zlist := range zmap {
+		batch.ZAdd(keyName, zlist...)
+	}
+
+	// 记录子任务被分发执行的次数
+	for _, subtask := range *subtasks {
+		batch.HIncrBy(GetSubtaskKey(uint64(subtask.SubtaskId)), config.SubtaskInfo_AttemptCountField, 1)
+	}
+
+	err := batch.Exec(context.Background())
+	if err != nil {
+		logging.Warning("failed to add subtasks to running list", logging.Err(err))
+		return err
+	}
+
+	return nil
+}
+
+// 记录子任务被再次分发执行
+func IncrSubtaskAttemptCount(subtasks *[]taskmodel.SubtaskBody) error {
+	batch := statestore.Default().Batch()
+	for _, subtask := range *subtasks {
+		batch.HIncrBy(GetSubtaskKey(uint64(subtask.SubtaskId)), config.SubtaskInfo_AttemptCountField, 1)
+	}
 
-	// 执行pipeline
-	_, err := pipeline.Exec(context.Background())
+	err := batch.Exec(context.Background())
 	if err != nil {
-		glog.Warning("failed to exec AddSubtaskToRunningList pipeline: ", err)
+		logging.Warning("failed to increase the subtask attempt count", logging.Err(err))
 		return err
 	}
 
 	return nil
 }
 
+// 子任务是否在执行中的子任务列表中, 即已被调度器分发且尚未完成
+func IsSubtaskInRunningList(subtaskId taskmodel.SubtaskIdType) (bool, error) {
+	keyName := GetShardKey(config.RunningSubtaskZset, uint64(subtaskId))
+	_, err := statestore.Default().ZScore(context.Background(), keyName, strconv.FormatUint(uint64(subtaskId), 10))
+	if err == errordef.ErrNotFound {
+		return false, nil
+	}
+
+	if err != nil {
+		logging.Warning("failed to read the running subtask list", logging.SubtaskId(subtaskId), logging.Err(err))
+		return false, err
+	}
+
+	return true, nil
+}
+
+// 修改执行中子任务的超时时间, 返回是否修改.
+// 子任务已不在执行中的子任务列表中(如已完成)时不修改, 检查和修改是原子的
+func SetSubtaskRunningDeadline(subtaskId taskmodel.SubtaskIdType, deadline time.Time) (bool, error) {
+	keyName := GetShardKey(config.RunningSubtaskZset, uint64(subtaskId))
+	updated, err := statestore.Default().ZAddIfExists(context.Background(), keyName, statestore.Z{
+		Score:  float64(deadline.Unix()),
+		Member: uint64(subtaskId),
+	})
+
+	if err != nil {
+		logging.Warning("failed to set the subtask deadline", logging.SubtaskId(subtaskId), logging.Err(err))
+		return false, err
+	}
+
+	return updated, nil
+}
+
 func GetTaskIdOfSubtask(subtaskId uint64, taskId *taskmodel.TaskIdType) error {
 
-	cmd := redistool.DefaultRedis().HGet(
+	idStr, err := statestore.Default().HGet(
 		context.Background(), GetSubtaskKey(subtaskId), config.SubtaskInfo_TaskIdField,
 	)
 
-	err := cmd.Err()
-	if err == redis.Nil {
-		return errordef.ErrNotFound
+	if err == errordef.ErrNotFound {
+		return err
 	}
 
 	if err != nil {
-		glog.Warning("failed to read task id of subtask: ", subtaskId, ", ", err)
+		logging.Warning("failed to read task id of subtask", logging.SubtaskId(subtaskId), logging.Err(err))
 		return err
 	}
 
-	idStr := cmd.Val()
 	var intId uint64 = 0
 	intId, err = strconv.ParseUint(idStr, 10, 64)
 	if err != nil {
-		glog.Warning("failed to convert task id str: ", idStr, err)
+		logging.Warning("failed to convert task id str", logging.Any("id_str", idStr), logging.Err(err))
 		return err
 	}
 
@@ -0,0 +1,66 @@
+package tasktool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
+	"github.com/danenmao/pterergate-dtf/internal/config"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
+)
+
+// 读取或修改执行中子任务列表前, 模拟收集器完成子任务并将其移出列表
+type completingStore struct {
+	statestore.IStateStore
+	subtaskId taskmodel.SubtaskIdType
+}
+
+func (store *completingStore) complete(ctx context.Context) {
+	keyName := GetShardKey(config.RunningSubtaskZset, uint64(store.subtaskId))
+	store.IStateStore.ZRem(ctx, keyName, uint64(store.subtaskId))
+}
+
+func (store *completingStore) ZScore(ctx context.Context, key string, member string) (float64, error) {
+	score, err := store.IStateStore.ZScore(ctx, key, member)
+	store.complete(ctx)
+	return score, err
+}
+
+func (store *completingStore) ZAddIfExists(ctx context.Context, key string, member statestore.Z) (bool, error) {
+	store.complete(ctx)
+	return store.IStateStore.ZAddIfExists(ctx, key, member)
+}
+
+func Test_SetSubtaskRunningDeadline(t *testing.T) {
+	store := statestore.NewMemoryStore()
+	statestore.SetDefault(store)
+	defer statestore.SetDefault(statestore.NewRedisStore())
+
+	subtasks := []taskmodel.SubtaskBody{{SubtaskId: 21, TaskId: 2}, {SubtaskId: 22, TaskId: 2}}
+	addErr := AddSubtaskToRunningList(&subtasks)
+
+	deadline := time.Now().Add(time.Hour)
+	updated, err := SetSubtaskRunningDeadline(21, deadline)
+	keyName := GetShardKey(config.RunningSubtaskZset, 21)
+	score, _ := store.ZScore(context.Background(), keyName, "21")
+
+	// 子任务在检查和修改之间完成
+	statestore.SetDefault(&completingStore{IStateStore: store, subtaskId: 22})
+	completedUpdated, completedErr := SetSubtaskRunningDeadline(22, deadline)
+	statestore.SetDefault(store)
+	running, _ := IsSubtaskInRunningList(22)
+
+	Convey("the deadline is only set for a subtask still running", t, func() {
+		So(addErr, ShouldBeNil)
+		So(err, ShouldBeNil)
+		So(updated, ShouldBeTrue)
+		So(score, ShouldEqual, float64(deadline.Unix()))
+
+		So(completedErr, ShouldBeNil)
+		So(completedUpdated, ShouldBeFalse)
+		So(running, ShouldBeFalse)
+	})
+}
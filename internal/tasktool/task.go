@@ -1,6 +1,7 @@
 package tasktool
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/golang/glog"
@@ -8,49 +9,45 @@ import (
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
 	"github.com/danenmao/pterergate-dtf/internal/config"
 	"github.com/danenmao/pterergate-dtf/internal/dbdef"
-	"github.com/danenmao/pterergate-dtf/internal/mysqltool"
+	"github.com/danenmao/pterergate-dtf/internal/recordstore"
+	"github.com/danenmao/pterergate-dtf/internal/redistool"
 	"github.com/danenmao/pterergate-dtf/internal/taskframework/tasklogic/tasklogicdef"
 )
 
 // 添加任务记录
 func AddTaskRecord(task *dbdef.DBTaskRecord) error {
 
-	result, err := mysqltool.DefaultMySQL().NamedExec(
-		dbdef.SQL_TaskTable_InsertTask,
-		task,
-	)
-
+	err := recordstore.Default().AddTaskRecord(context.Background(), task)
 	if err != nil {
 		glog.Warning("failed to add task record: ", err.Error())
 		return err
 	}
 
-	lines, _ := result.RowsAffected()
-	glog.Info("added a task record: ", task.Id, lines)
-
 	return nil
 }
 
+// 任务相关的key使用任务ID作为hash tag, Cluster模式下位于同一slot
+
 // 获取task info key的名称
 func GetTaskInfoKey(taskId taskmodel.TaskIdType) string {
-	return fmt.Sprintf("%s%d", config.TaskInfoKeyPrefix, taskId)
+	return redistool.HashTagKey(config.TaskInfoKeyPrefix, taskId)
 }
 
 // 获取保存任务调度数据的Key
 func GetTaskCreateParamKey(taskId taskmodel.TaskIdType) string {
-	return fmt.Sprintf("%s%d", tasklogicdef.TaskCreateParamPrefix, taskId)
+	return redistool.HashTagKey(tasklogicdef.TaskCreateParamPrefix, taskId)
 }
 
 func GetTaskLockKey(taskId taskmodel.TaskIdType) string {
-	return fmt.Sprintf("%s%d", config.TaskInfoLockPrefix, taskId)
+	return redistool.HashTagKey(config.TaskInfoLockPrefix, taskId)
 }
 
 func GetTaskGenerationProgressKey(taskId taskmodel.TaskIdType) string {
-	return fmt.Sprintf("%s%d", config.TaskGenerationKeyPrefix, taskId)
+	return redistool.HashTagKey(config.TaskGenerationKeyPrefix, taskId)
 }
 
 func GetTaskSubtaskListKey(taskId taskmodel.TaskIdType) string {
-	return fmt.Sprintf("%s%d", config.TaskToSubtaskSetPrefix, taskId)
+	return redistool.HashTagKey(config.TaskToSubtaskSetPrefix, taskId)
 }
 
 func GetSubtaskKey(subtaskId uint64) string {
@@ -59,5 +56,5 @@ func GetSubtaskKey(subtaskId uint64) string {
 
 // 获取保存任务调度数据的Key
 func GetTaskScheduleDataKey(taskId taskmodel.TaskIdType) string {
-	return fmt.Sprintf("%s%d", tasklogicdef.TaskScheduleDataPrefix, taskId)
+	return redistool.HashTagKey(tasklogicdef.TaskScheduleDataPrefix, taskId)
 }
@@ -8,12 +8,13 @@ import (
 
 	"github.com/golang/glog"
 
+	"github.com/danenmao/pterergate-dtf/dtf/errordef"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
 	"github.com/danenmao/pterergate-dtf/internal/basedef"
 	"github.com/danenmao/pterergate-dtf/internal/config"
 	"github.com/danenmao/pterergate-dtf/internal/dbdef"
-	"github.com/danenmao/pterergate-dtf/internal/mysqltool"
-	"github.com/danenmao/pterergate-dtf/internal/redistool"
+	"github.com/danenmao/pterergate-dtf/internal/recordstore"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
 )
 
 // 完成任务
@@ -21,8 +22,7 @@ func CompleteTask(taskId taskmodel.TaskIdType, taskRecord *dbdef.DBTaskRecord) e
 
 	// 从task info key中读取信息
 	taskKey := GetTaskInfoKey(taskId)
-	mapCmd := redistool.DefaultRedis().HGetAll(context.Background(), taskKey)
-	infos, err := mapCmd.Result()
+	infos, err := statestore.Default().HGetAll(context.Background(), taskKey)
 	if err != nil {
 		glog.Warning("failed to get task info: ", taskId, err.Error())
 		return err
@@ -68,12 +68,20 @@ func CompleteTask(taskId taskmodel.TaskIdType, taskRecord *dbdef.DBTaskRecord) e
 		uid = 0
 	}
 
-	// 更新task info key, 写入完成状态
-	SetTaskStatus(taskId, taskmodel.TaskStatus_Completed)
+	// 更新task info key, 写入完成状态, 已取消和异常的任务保持原状态
+	status := taskmodel.TaskStatus_Completed
+	switch infos[config.TaskInfo_StatusField] {
+	case strconv.FormatUint(uint64(taskmodel.TaskStatus_Cacelled), 10):
+		status = taskmodel.TaskStatus_Cacelled
+	case strconv.FormatUint(uint64(taskmodel.TaskStatus_Exceptional), 10):
+		status = taskmodel.TaskStatus_Exceptional
+	}
+
+	SetTaskStatus(taskId, status)
 
 	// 更新 task 表的内容
 	taskRecord.Id = uint64(taskId)
-	taskRecord.TaskStatus = uint8(taskmodel.TaskStatus_Completed)
+	taskRecord.TaskStatus = uint8(status)
 	taskRecord.FinishTime = time.Now().Format(basedef.GoTimeFormatStr)
 	taskRecord.TimeCost = uint32(timeCost)
 	taskRecord.TaskType = uint32(taskType)
@@ -92,8 +100,7 @@ func CompleteTask(taskId taskmodel.TaskIdType, taskRecord *dbdef.DBTaskRecord) e
 // 设置任务的运行状态
 func SetTaskStatus(taskId taskmodel.TaskIdType, status taskmodel.TaskStatusType) error {
 
-	cmd := redistool.DefaultRedis().HSet(context.Background(), GetTaskInfoKey(taskId), config.TaskInfo_StatusField, status)
-	err := cmd.Err()
+	err := statestore.Default().HSet(context.Background(), GetTaskInfoKey(taskId), config.TaskInfo_StatusField, status)
 	if err != nil {
 		glog.Warning("failed to set status of task: ", taskId, err)
 		return err
@@ -102,19 +109,55 @@ func SetTaskStatus(taskId taskmodel.TaskIdType, status taskmodel.TaskStatusType)
 	return nil
 }
 
+// 修改任务的运行状态
+// 任务的当前状态需为fromList中的一种, 否则返回errordef.ErrInvalidStatus
+func ChangeTaskStatus(
+	taskId taskmodel.TaskIdType,
+	to taskmodel.TaskStatusType,
+	fromList ...taskmodel.TaskStatusType,
+) error {
+
+	var current taskmodel.TaskStatusType = 0
+	err := ReadTaskStatus(taskId, &current)
+	if err != nil {
+		return err
+	}
+
+	allowed := false
+	for _, from := range fromList {
+		if current == from {
+			allowed = true
+			break
+		}
+	}
+
+	if !allowed {
+		glog.Warning("task status not allowed to change: ", taskId, ", ", current, " -> ", to)
+		return errordef.ErrInvalidStatus
+	}
+
+	err = SetTaskStatus(taskId, to)
+	if err != nil {
+		return err
+	}
+
+	// 同步任务表中的状态, 失败时不影响任务的运行
+	err = recordstore.Default().UpdateTaskStatus(context.Background(), uint64(taskId), uint8(to))
+	if err != nil {
+		glog.Warning("failed to update task status in task table: ", taskId, ", ", err)
+	}
+
+	glog.Info("succeeded to change task status: ", taskId, ", ", current, " -> ", to)
+	return nil
+}
+
 // 更新任务表记录，标记任务已完成
 func WriteCompleteInfoToTaskDB(taskRecord *dbdef.DBTaskRecord) error {
-	result, err := mysqltool.DefaultMySQL().NamedExec(
-		dbdef.SQL_TaskTable_CompleteTask,
-		taskRecord,
-	)
-
+	err := recordstore.Default().CompleteTaskRecord(context.Background(), taskRecord)
 	if err != nil {
 		glog.Warning("failed to update task result: ", taskRecord.Id, err.Error())
 		return err
 	}
 
-	lines, _ := result.RowsAffected()
-	glog.Info("succeeded to write complete info to task table: ", taskRecord.Id, lines)
 	return nil
 }
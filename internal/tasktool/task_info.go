@@ -7,14 +7,13 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/go-redis/redis/v8"
 	"github.com/golang/glog"
 
 	"github.com/danenmao/pterergate-dtf/dtf/errordef"
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
 	"github.com/danenmao/pterergate-dtf/internal/config"
 	"github.com/danenmao/pterergate-dtf/internal/dbdef"
-	"github.com/danenmao/pterergate-dtf/internal/redistool"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
 	"github.com/danenmao/pterergate-dtf/internal/taskframework/tasklogic/generationqueue"
 	"github.com/danenmao/pterergate-dtf/internal/taskframework/tasklogic/tasklogicdef"
 )
@@ -40,17 +39,17 @@ func CreateTaskInfoKey(
 		config.TaskInfo_TaskTypeField:              taskParam.TaskType,
 		config.TaskInfo_Progess:                    0,
 		config.TaskInfo_StatusField:                taskmodel.TaskStatus_Running,
+		config.TaskInfo_TraceParentField:           taskParam.TraceParent,
 	}
 
 	taskKey := GetTaskInfoKey(taskId)
-	cmd := redistool.DefaultRedis().HMSet(context.Background(), taskKey, data)
-	err := cmd.Err()
+	err := statestore.Default().HMSet(context.Background(), taskKey, data)
 	if err != nil {
 		glog.Warning("failed to create task info key: ", taskId, err.Error())
-		return cmd.Err()
+		return err
 	}
 
-	redistool.DefaultRedis().Expire(context.Background(), taskKey, time.Hour*72)
+	statestore.Default().Expire(context.Background(), taskKey, time.Hour*72)
 
 	glog.Info("succeeded to create task info key: ", taskKey)
 	return nil
@@ -60,8 +59,7 @@ func CreateTaskInfoKey(
 func SetTaskRawTypeParam(taskId taskmodel.TaskIdType, paramStr string) error {
 
 	taskInfoKey := GetTaskInfoKey(taskId)
-	cmd := redistool.DefaultRedis().HSet(context.Background(), taskInfoKey, config.TaskInfo_TypeParam, paramStr)
-	err := cmd.Err()
+	err := statestore.Default().HSet(context.Background(), taskInfoKey, config.TaskInfo_TypeParam, paramStr)
 	if err != nil {
 		glog.Warning("failed to set task scan param: ", taskId, ", ", err)
 		return err
@@ -80,19 +78,13 @@ func GetTaskRawTypeParam(taskId taskmodel.TaskIdType, typeParam *string) error {
 	}
 
 	taskInfoKey := GetTaskInfoKey(taskId)
-	cmd := redistool.DefaultRedis().HGet(context.Background(), taskInfoKey, config.TaskInfo_TypeParam)
-	if cmd.Err() != nil {
-		glog.Warning("failed to get type param from task info key: ", taskId, cmd.Err())
-		return cmd.Err()
-	}
-
-	data, err := cmd.Bytes()
+	data, err := statestore.Default().HGet(context.Background(), taskInfoKey, config.TaskInfo_TypeParam)
 	if err != nil {
-		glog.Warning("failed to get data from redis cmd.Bytes: ", err)
+		glog.Warning("failed to get type param from task info key: ", taskId, err)
 		return err
 	}
 
-	*typeParam = string(data)
+	*typeParam = data
 	return nil
 }
 
@@ -103,14 +95,12 @@ func GetTaskCreateParam(
 ) error {
 
 	keyName := GetTaskCreateParamKey(taskId)
-	cmd := redistool.DefaultRedis().Get(context.Background(), keyName)
-	err := cmd.Err()
+	data, err := statestore.Default().Get(context.Background(), keyName)
 	if err != nil {
 		glog.Warning("failed to get task create param key data: ", taskId, ", ", err.Error())
 		return err
 	}
 
-	data := cmd.Val()
 	err = json.Unmarshal([]byte(data), retParam)
 	if err != nil {
 		glog.Warning("failed to unmarshal create param: ", taskId, ", ", data)
@@ -132,12 +122,11 @@ func SaveTaskCreateParam(
 		return err
 	}
 
-	cmd := redistool.DefaultRedis().Set(context.Background(),
+	err = statestore.Default().Set(context.Background(),
 		GetTaskCreateParamKey(taskId),
 		data,
 		time.Hour*48,
 	)
-	err = cmd.Err()
 	if err != nil {
 		glog.Warning("failed to set task create param key: ", taskId, ", ", err.Error())
 		return err
@@ -157,13 +146,13 @@ func GetInitTaskRecord(
 	}
 
 	taskInfoKey := GetTaskInfoKey(taskId)
-	cmd := redistool.DefaultRedis().HGet(context.Background(), taskInfoKey, config.TaskInfo_InitTaskRecord)
-	if cmd.Err() != nil {
-		glog.Warning("failed to get init task record for task: ", taskId, cmd.Err())
-		return cmd.Err()
+	data, err := statestore.Default().HGet(context.Background(), taskInfoKey, config.TaskInfo_InitTaskRecord)
+	if err != nil {
+		glog.Warning("failed to get init task record for task: ", taskId, err)
+		return err
 	}
 
-	err := json.Unmarshal([]byte(cmd.Val()), taskRecord)
+	err = json.Unmarshal([]byte(data), taskRecord)
 	if err != nil {
 		glog.Warning("failed to unmarshal init task record of task: ", taskId, err)
 		return err
@@ -179,16 +168,13 @@ func GetTaskType(
 	retTaskType *uint32,
 ) error {
 
-	cmd := redistool.DefaultRedis().HGet(context.Background(), GetTaskInfoKey(taskId),
+	val, err := statestore.Default().HGet(context.Background(), GetTaskInfoKey(taskId),
 		config.TaskInfo_TaskTypeField)
-	err := cmd.Err()
 	if err != nil {
 		glog.Warning("failed to get type of task: ", taskId, ",", err)
 		return err
 	}
 
-	val := cmd.Val()
-
 	taskType, err := strconv.ParseUint(val, 10, 64)
 	if err != nil {
 		glog.Warning("failed to parse task type field: ", taskId, ",", val, ",", err)
@@ -199,17 +185,32 @@ func GetTaskType(
 	return nil
 }
 
+// 获取任务的跟踪上下文, 未记录时返回空串
+func GetTaskTraceParent(taskId taskmodel.TaskIdType) (string, error) {
+	val, err := statestore.Default().HGet(context.Background(), GetTaskInfoKey(taskId),
+		config.TaskInfo_TraceParentField)
+	if err == errordef.ErrNotFound {
+		return "", nil
+	}
+
+	if err != nil {
+		glog.Warning("failed to get trace parent of task: ", taskId, ",", err)
+		return "", err
+	}
+
+	return val, nil
+}
+
 // update next check time
 func UpdateTaskGenerationNextCheckTime(taskId taskmodel.TaskIdType) error {
 
-	cmd := redistool.DefaultRedis().HSet(
+	err := statestore.Default().HSet(
 		context.Background(),
 		GetTaskGenerationProgressKey(taskId),
 		config.TaskGenerationKey_NextCheckTimeField,
 		time.Now().Add(time.Minute).Unix(),
 	)
 
-	err := cmd.Err()
 	if err != nil {
 		glog.Warning("failed to refresh task generation next check time value: ", taskId, err)
 		return err
@@ -223,15 +224,13 @@ func CheckIfTaskGenerationCompleted(taskId taskmodel.TaskIdType) bool {
 
 	// 检查redis_task_info.$taskid, 判断任务是否生成结束
 	taskInfoKey := GetTaskInfoKey(taskId)
-	strCmd := redistool.DefaultRedis().HGet(context.Background(),
+	completedStr, err := statestore.Default().HGet(context.Background(),
 		taskInfoKey, config.TaskInfo_GenerationCompletedField)
-	err := strCmd.Err()
 	if err != nil {
 		glog.Warning("failed to get generation completed key of task: ", taskId, err)
 		return false
 	}
 
-	completedStr := strCmd.Val()
 	generationCompleted, err := strconv.Atoi(completedStr)
 	if err != nil {
 		glog.Warning("failed to convert generation completed key of task: ", taskId, completedStr, err)
@@ -252,8 +251,7 @@ func CheckIfAllSubtaskCompleted(taskId taskmodel.TaskIdType) bool {
 
 	// redis_subtask_list.$taskid 为空
 	subtaskListKey := GetTaskSubtaskListKey(taskId)
-	cmd := redistool.DefaultRedis().ZCard(context.Background(), subtaskListKey)
-	err := cmd.Err()
+	count, err := statestore.Default().ZCard(context.Background(), subtaskListKey)
 	if err != nil {
 		glog.Warning("failed to get subtask count of task: ", taskId, err)
 		return false
@@ -261,7 +259,6 @@ func CheckIfAllSubtaskCompleted(taskId taskmodel.TaskIdType) bool {
 
 	// 当key不存在时，值为0;
 	// 当key为空时，值为0;
-	count := cmd.Val()
 	glog.Info("get subtask count of task: ", taskId, count)
 
 	return count == 0
@@ -319,9 +316,8 @@ func GetTaskScheduleData(
 ) error {
 
 	keyName := GetTaskScheduleDataKey(taskId)
-	cmd := redistool.DefaultRedis().Get(context.Background(), keyName)
-	err := cmd.Err()
-	if err == redis.Nil {
+	data, err := statestore.Default().Get(context.Background(), keyName)
+	if err == errordef.ErrNotFound {
 		return &errordef.NotFoundError{}
 	}
 
@@ -330,7 +326,6 @@ func GetTaskScheduleData(
 		return err
 	}
 
-	data := cmd.Val()
 	err = json.Unmarshal([]byte(data), retScheduleData)
 	if err != nil {
 		glog.Warning("failed to unmarshal schedule data: ", taskId, ", ", data)
@@ -352,13 +347,12 @@ func SaveTaskScheduleData(
 		return err
 	}
 
-	cmd := redistool.DefaultRedis().Set(
+	err = statestore.Default().Set(
 		context.Background(),
 		GetTaskScheduleDataKey(taskId),
 		data,
 		time.Hour*48,
 	)
-	err = cmd.Err()
 	if err != nil {
 		glog.Warning("failed to set task schedule data key: ", taskId, ", ", err.Error())
 		return err
@@ -373,15 +367,12 @@ func ReadSubtaskStartTime(
 	taskType *uint32,
 ) error {
 
-	cmd := redistool.DefaultRedis().HGetAll(context.Background(), GetSubtaskKey(subtaskId))
-	err := cmd.Err()
+	valMap, err := statestore.Default().HGetAll(context.Background(), GetSubtaskKey(subtaskId))
 	if err != nil {
 		glog.Warning("failed to get info of subtask: ", subtaskId, err)
 		return err
 	}
 
-	valMap := cmd.Val()
-
 	// start_time
 	timeStr, ok := valMap[config.SubtaskInfo_StartTimeField]
 	if !ok {
@@ -444,13 +435,12 @@ func ReadSubtaskStartTime(
 
 func GetTaskCreateTime(taskId taskmodel.TaskIdType, retCreateTime *uint64) error {
 
-	cmd := redistool.DefaultRedis().HGet(context.Background(), GetTaskInfoKey(taskId),
+	timeStr, err := statestore.Default().HGet(context.Background(), GetTaskInfoKey(taskId),
 		config.TaskInfo_CreateTimeField)
-	err := cmd.Err()
 
-	// 如果给定的字段或 key 不存在时，返回 nil
-	if err == redis.Nil {
-		return errordef.ErrNotFound
+	// 如果给定的字段或 key 不存在时，返回 ErrNotFound
+	if err == errordef.ErrNotFound {
+		return err
 	}
 
 	if err != nil {
@@ -458,7 +448,6 @@ func GetTaskCreateTime(taskId taskmodel.TaskIdType, retCreateTime *uint64) error
 		return err
 	}
 
-	timeStr := cmd.Val()
 	*retCreateTime, err = strconv.ParseUint(timeStr, 10, 64)
 	if err != nil {
 		glog.Warning("failed to parse create_time field: ", taskId, ",", timeStr, ",", err)
@@ -480,18 +469,27 @@ func IsTaskRunning(taskId taskmodel.TaskIdType) bool {
 	return status == taskmodel.TaskStatus_Running
 }
 
+// 任务是否已被取消
+func IsTaskCancelled(taskId taskmodel.TaskIdType) bool {
+
+	var status taskmodel.TaskStatusType = 0
+	err := ReadTaskStatus(taskId, &status)
+	if err != nil {
+		return false
+	}
+
+	return status == taskmodel.TaskStatus_Cacelled
+}
+
 func ReadTaskStatus(taskId taskmodel.TaskIdType, statusRet *taskmodel.TaskStatusType) error {
 
-	cmd := redistool.DefaultRedis().HGet(context.Background(), GetTaskInfoKey(taskId),
+	val, err := statestore.Default().HGet(context.Background(), GetTaskInfoKey(taskId),
 		config.TaskInfo_StatusField)
-	err := cmd.Err()
 	if err != nil {
 		glog.Warning("failed to get status of task: ", taskId, err)
 		return err
 	}
 
-	val := cmd.Val()
-
 	status, err := strconv.ParseUint(val, 10, 64)
 	if err != nil {
 		glog.Warning("failed to parse status field: ", taskId, val, err)
@@ -501,3 +499,57 @@ func ReadTaskStatus(taskId taskmodel.TaskIdType, statusRet *taskmodel.TaskStatus
 	*statusRet = taskmodel.TaskStatusType(status)
 	return nil
 }
+
+// 读取任务的执行状态数据
+// task info key不存在时, 返回errordef.ErrNotFound
+func GetTaskStatusData(taskId taskmodel.TaskIdType, statusData *taskmodel.TaskStatusData) error {
+
+	infos, err := statestore.Default().HGetAll(context.Background(), GetTaskInfoKey(taskId))
+	if err != nil {
+		glog.Warning("failed to get task info: ", taskId, ", ", err)
+		return err
+	}
+
+	if len(infos) == 0 {
+		return errordef.ErrNotFound
+	}
+
+	total := parseUintField(infos, config.TaskInfo_TotalSubtaskCountField)
+	finished := parseUintField(infos, config.TaskInfo_CompletedSubtaskCountField) +
+		parseUintField(infos, config.TaskInfo_TimeoutSubtaskCountField) +
+		parseUintField(infos, config.TaskInfo_CancelledSubtaskCountField)
+
+	statusData.TaskId = taskId
+	statusData.TaskType = uint32(parseUintField(infos, config.TaskInfo_TaskTypeField))
+	statusData.TaskStatus = taskmodel.TaskStatusType(parseUintField(infos, config.TaskInfo_StatusField))
+	statusData.SubtaskCount = uint32(total)
+	statusData.StartTime = time.Unix(int64(parseUintField(infos, config.TaskInfo_CreateTimeField)), 0)
+	statusData.TaskProgress = 0
+	if total > 0 {
+		statusData.TaskProgress = float32(finished) / float32(total)
+	}
+
+	// 优先级和资源组在创建参数中, 任务名在初始的任务记录中
+	createParam := tasklogicdef.TaskCreateParam{}
+	if GetTaskCreateParam(taskId, &createParam) == nil {
+		statusData.Priority = createParam.Priority
+		statusData.ResourceGroup = createParam.ResourceGroupName
+	}
+
+	taskRecord := dbdef.DBTaskRecord{}
+	if GetInitTaskRecord(taskId, &taskRecord) == nil {
+		statusData.TaskName = taskRecord.Name
+	}
+
+	return nil
+}
+
+// 读取字段中的无符号整数, 字段不存在或格式错误时返回0
+func parseUintField(infos map[string]string, field string) uint64 {
+	val, err := strconv.ParseUint(infos[field], 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return val
+}
@@ -4,12 +4,11 @@ import (
 	"context"
 	"time"
 
-	"github.com/go-redis/redis/v8"
 	"github.com/golang/glog"
 
 	"github.com/danenmao/pterergate-dtf/dtf/taskmodel"
 	"github.com/danenmao/pterergate-dtf/internal/config"
-	"github.com/danenmao/pterergate-dtf/internal/redistool"
+	"github.com/danenmao/pterergate-dtf/internal/statestore"
 )
 
 // 将任务添加到创建队列
@@ -17,15 +16,14 @@ import (
 func AddTaskToCreatingQueue(taskId taskmodel.TaskIdType) error {
 
 	// 将 $taskid 推入 redis_creating_task_zset
-	var z = redis.Z{
+	var z = statestore.Z{
 		Score:  float64(time.Now().Add(time.Second * time.Duration(config.EnvTaskCreatingTimeout)).Unix()),
 		Member: taskId,
 	}
 
-	cmd := redistool.DefaultRedis().ZAdd(context.Background(), config.CreatingTaskZset, &z)
-	err := cmd.Err()
+	err := statestore.Default().ZAdd(context.Background(), config.CreatingTaskZset, z)
 	if err != nil {
-		glog.Warning("failed to add task to creating task list: ", taskId, cmd.Err().Error())
+		glog.Warning("failed to add task to creating task list: ", taskId, err.Error())
 		return err
 	}
 
@@ -37,13 +35,12 @@ func AddTaskToCreatingQueue(taskId taskmodel.TaskIdType) error {
 // 将 $taskid 推入 redis_task_zset。表示任务已经存在。
 func AddTaskToExistingTaskList(taskId taskmodel.TaskIdType, timeout time.Duration) error {
 
-	var z = redis.Z{
+	var z = statestore.Z{
 		Score:  float64(time.Now().Add(timeout).Unix()),
 		Member: taskId,
 	}
 
-	cmd := redistool.DefaultRedis().ZAdd(context.Background(), config.TaskZset, &z)
-	err := cmd.Err()
+	err := statestore.Default().ZAdd(context.Background(), config.TaskZset, z)
 	if err != nil {
 		glog.Warning("failed to add task to existing task list: ", taskId, err.Error())
 		return err
@@ -56,13 +53,12 @@ func AddTaskToExistingTaskList(taskId taskmodel.TaskIdType, timeout time.Duratio
 // 将任务推送到已完成队列, 等待任务管理逻辑进行处理
 func PushTaskToCompletedList(taskId taskmodel.TaskIdType) error {
 
-	z := redis.Z{
+	z := statestore.Z{
 		Score:  float64(time.Now().Unix()),
 		Member: taskId,
 	}
 
-	cmd := redistool.DefaultRedis().ZAdd(context.Background(), config.CompletedTaskList, &z)
-	err := cmd.Err()
+	err := statestore.Default().ZAdd(context.Background(), config.CompletedTaskList, z)
 	if err != nil {
 		glog.Warning("failed to add task to completed list: ", taskId, err.Error())
 		return err
@@ -71,3 +67,21 @@ func PushTaskToCompletedList(taskId taskmodel.TaskIdType) error {
 	glog.Info("succeeded to push task to completed list: ", taskId)
 	return nil
 }
+
+// 将任务添加到待生成队列, 等待生成器执行生成
+func AddTaskToGenerateQueue(taskId taskmodel.TaskIdType) error {
+
+	z := statestore.Z{
+		Score:  float64(time.Now().Unix()),
+		Member: taskId,
+	}
+
+	err := statestore.Default().ZAdd(context.Background(), config.ToGenerateTaskZset, z)
+	if err != nil {
+		glog.Warning("failed to add task to to-generate list: ", taskId, err.Error())
+		return err
+	}
+
+	glog.Info("succeeded to add task to to-generate list: ", taskId)
+	return nil
+}